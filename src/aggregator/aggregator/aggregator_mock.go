@@ -1,5 +1,5 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/m3db/m3/src/aggregator/aggregator (interfaces: Aggregator,ElectionManager,FlushTimesManager,PlacementManager)
+// Source: github.com/m3db/m3/src/aggregator/aggregator (interfaces: Aggregator,ElectionManager,FlushTimesManager,LeadershipProvider,PlacementManager)
 
 // Copyright (c) 2020 Uber Technologies, Inc.
 //
@@ -26,7 +26,9 @@ package aggregator
 
 import (
 	"context"
+	"io"
 	"reflect"
+	"time"
 
 	"github.com/m3db/m3/src/aggregator/generated/proto/flush"
 	"github.com/m3db/m3/src/cluster/placement"
@@ -212,6 +214,37 @@ func (m *MockElectionManager) EXPECT() *MockElectionManagerMockRecorder {
 	return m.recorder
 }
 
+// BackendRevision mocks base method
+func (m *MockElectionManager) BackendRevision() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackendRevision")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BackendRevision indicates an expected call of BackendRevision
+func (mr *MockElectionManagerMockRecorder) BackendRevision() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackendRevision", reflect.TypeOf((*MockElectionManager)(nil).BackendRevision))
+}
+
+// CampaignPreview mocks base method
+func (m *MockElectionManager) CampaignPreview(arg0 context.Context) (bool, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CampaignPreview", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CampaignPreview indicates an expected call of CampaignPreview
+func (mr *MockElectionManagerMockRecorder) CampaignPreview(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CampaignPreview", reflect.TypeOf((*MockElectionManager)(nil).CampaignPreview), arg0)
+}
+
 // Close mocks base method
 func (m *MockElectionManager) Close() error {
 	m.ctrl.T.Helper()
@@ -240,6 +273,34 @@ func (mr *MockElectionManagerMockRecorder) ElectionState() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ElectionState", reflect.TypeOf((*MockElectionManager)(nil).ElectionState))
 }
 
+// Events mocks base method
+func (m *MockElectionManager) Events() <-chan ElectionEvent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Events")
+	ret0, _ := ret[0].(<-chan ElectionEvent)
+	return ret0
+}
+
+// Events indicates an expected call of Events
+func (mr *MockElectionManagerMockRecorder) Events() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Events", reflect.TypeOf((*MockElectionManager)(nil).Events))
+}
+
+// FencingToken mocks base method
+func (m *MockElectionManager) FencingToken() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FencingToken")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// FencingToken indicates an expected call of FencingToken
+func (mr *MockElectionManagerMockRecorder) FencingToken() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FencingToken", reflect.TypeOf((*MockElectionManager)(nil).FencingToken))
+}
+
 // IsCampaigning mocks base method
 func (m *MockElectionManager) IsCampaigning() bool {
 	m.ctrl.T.Helper()
@@ -254,6 +315,49 @@ func (mr *MockElectionManagerMockRecorder) IsCampaigning() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCampaigning", reflect.TypeOf((*MockElectionManager)(nil).IsCampaigning))
 }
 
+// IsLeader mocks base method
+func (m *MockElectionManager) IsLeader() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsLeader")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsLeader indicates an expected call of IsLeader
+func (mr *MockElectionManagerMockRecorder) IsLeader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLeader", reflect.TypeOf((*MockElectionManager)(nil).IsLeader))
+}
+
+// LeaderSince mocks base method
+func (m *MockElectionManager) LeaderSince() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaderSince")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// LeaderSince indicates an expected call of LeaderSince
+func (mr *MockElectionManagerMockRecorder) LeaderSince() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaderSince", reflect.TypeOf((*MockElectionManager)(nil).LeaderSince))
+}
+
+// LiveInstances mocks base method
+func (m *MockElectionManager) LiveInstances() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LiveInstances")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LiveInstances indicates an expected call of LiveInstances
+func (mr *MockElectionManagerMockRecorder) LiveInstances() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LiveInstances", reflect.TypeOf((*MockElectionManager)(nil).LiveInstances))
+}
+
 // Open mocks base method
 func (m *MockElectionManager) Open(arg0 uint32) error {
 	m.ctrl.T.Helper()
@@ -268,6 +372,46 @@ func (mr *MockElectionManagerMockRecorder) Open(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockElectionManager)(nil).Open), arg0)
 }
 
+// OpenShards mocks base method
+func (m *MockElectionManager) OpenShards(arg0 []uint32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenShards", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OpenShards indicates an expected call of OpenShards
+func (mr *MockElectionManagerMockRecorder) OpenShards(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenShards", reflect.TypeOf((*MockElectionManager)(nil).OpenShards), arg0)
+}
+
+// Reconfigure mocks base method
+func (m *MockElectionManager) Reconfigure(arg0 ElectionManagerOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconfigure", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reconfigure indicates an expected call of Reconfigure
+func (mr *MockElectionManagerMockRecorder) Reconfigure(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconfigure", reflect.TypeOf((*MockElectionManager)(nil).Reconfigure), arg0)
+}
+
+// RegisterPreResignHook mocks base method
+func (m *MockElectionManager) RegisterPreResignHook(arg0 int, arg1 PreResignHook) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RegisterPreResignHook", arg0, arg1)
+}
+
+// RegisterPreResignHook indicates an expected call of RegisterPreResignHook
+func (mr *MockElectionManagerMockRecorder) RegisterPreResignHook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterPreResignHook", reflect.TypeOf((*MockElectionManager)(nil).RegisterPreResignHook), arg0, arg1)
+}
+
 // Reset mocks base method
 func (m *MockElectionManager) Reset() error {
 	m.ctrl.T.Helper()
@@ -296,6 +440,36 @@ func (mr *MockElectionManagerMockRecorder) Resign(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resign", reflect.TypeOf((*MockElectionManager)(nil).Resign), arg0)
 }
 
+// ShardElectionState mocks base method
+func (m *MockElectionManager) ShardElectionState(arg0 uint32) (ElectionState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardElectionState", arg0)
+	ret0, _ := ret[0].(ElectionState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardElectionState indicates an expected call of ShardElectionState
+func (mr *MockElectionManagerMockRecorder) ShardElectionState(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardElectionState", reflect.TypeOf((*MockElectionManager)(nil).ShardElectionState), arg0)
+}
+
+// Subscribe mocks base method
+func (m *MockElectionManager) Subscribe() (watch.Watch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe")
+	ret0, _ := ret[0].(watch.Watch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockElectionManagerMockRecorder) Subscribe() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockElectionManager)(nil).Subscribe))
+}
+
 // MockFlushTimesManager is a mock of FlushTimesManager interface
 type MockFlushTimesManager struct {
 	ctrl     *gomock.Controller
@@ -319,6 +493,34 @@ func (m *MockFlushTimesManager) EXPECT() *MockFlushTimesManagerMockRecorder {
 	return m.recorder
 }
 
+// AdoptFrom mocks base method
+func (m *MockFlushTimesManager) AdoptFrom(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdoptFrom", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AdoptFrom indicates an expected call of AdoptFrom
+func (mr *MockFlushTimesManagerMockRecorder) AdoptFrom(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdoptFrom", reflect.TypeOf((*MockFlushTimesManager)(nil).AdoptFrom), arg0)
+}
+
+// Backfill mocks base method
+func (m *MockFlushTimesManager) Backfill(arg0 map[uint32]map[time.Duration]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Backfill indicates an expected call of Backfill
+func (mr *MockFlushTimesManagerMockRecorder) Backfill(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockFlushTimesManager)(nil).Backfill), arg0)
+}
+
 // Close mocks base method
 func (m *MockFlushTimesManager) Close() error {
 	m.ctrl.T.Helper()
@@ -333,6 +535,35 @@ func (mr *MockFlushTimesManagerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockFlushTimesManager)(nil).Close))
 }
 
+// DryRunStore mocks base method
+func (m *MockFlushTimesManager) DryRunStore(arg0 *flush.ShardSetFlushTimes) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DryRunStore", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DryRunStore indicates an expected call of DryRunStore
+func (mr *MockFlushTimesManagerMockRecorder) DryRunStore(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DryRunStore", reflect.TypeOf((*MockFlushTimesManager)(nil).DryRunStore), arg0)
+}
+
+// DumpOpenMetrics mocks base method
+func (m *MockFlushTimesManager) DumpOpenMetrics(arg0 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DumpOpenMetrics", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DumpOpenMetrics indicates an expected call of DumpOpenMetrics
+func (mr *MockFlushTimesManagerMockRecorder) DumpOpenMetrics(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DumpOpenMetrics", reflect.TypeOf((*MockFlushTimesManager)(nil).DumpOpenMetrics), arg0)
+}
+
 // Get mocks base method
 func (m *MockFlushTimesManager) Get() (*flush.ShardSetFlushTimes, error) {
 	m.ctrl.T.Helper()
@@ -390,6 +621,65 @@ func (mr *MockFlushTimesManagerMockRecorder) StoreAsync(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreAsync", reflect.TypeOf((*MockFlushTimesManager)(nil).StoreAsync), arg0)
 }
 
+// StoreIf mocks base method
+func (m *MockFlushTimesManager) StoreIf(arg0 func(*flush.ShardSetFlushTimes) bool, arg1 *flush.ShardSetFlushTimes) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreIf", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreIf indicates an expected call of StoreIf
+func (mr *MockFlushTimesManagerMockRecorder) StoreIf(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreIf", reflect.TypeOf((*MockFlushTimesManager)(nil).StoreIf), arg0, arg1)
+}
+
+// Summary mocks base method
+func (m *MockFlushTimesManager) Summary() (FlushTimesSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Summary")
+	ret0, _ := ret[0].(FlushTimesSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Summary indicates an expected call of Summary
+func (mr *MockFlushTimesManagerMockRecorder) Summary() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Summary", reflect.TypeOf((*MockFlushTimesManager)(nil).Summary))
+}
+
+// VerifyConsistency mocks base method
+func (m *MockFlushTimesManager) VerifyConsistency() (ConsistencyReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyConsistency")
+	ret0, _ := ret[0].(ConsistencyReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyConsistency indicates an expected call of VerifyConsistency
+func (mr *MockFlushTimesManagerMockRecorder) VerifyConsistency() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyConsistency", reflect.TypeOf((*MockFlushTimesManager)(nil).VerifyConsistency))
+}
+
+// WaitUntilAdvanced mocks base method
+func (m *MockFlushTimesManager) WaitUntilAdvanced(arg0 context.Context, arg1 uint32, arg2 time.Duration, arg3 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitUntilAdvanced", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitUntilAdvanced indicates an expected call of WaitUntilAdvanced
+func (mr *MockFlushTimesManagerMockRecorder) WaitUntilAdvanced(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitUntilAdvanced", reflect.TypeOf((*MockFlushTimesManager)(nil).WaitUntilAdvanced), arg0, arg1, arg2, arg3)
+}
+
 // Watch mocks base method
 func (m *MockFlushTimesManager) Watch() (watch.Watch, error) {
 	m.ctrl.T.Helper()
@@ -405,6 +695,102 @@ func (mr *MockFlushTimesManagerMockRecorder) Watch() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockFlushTimesManager)(nil).Watch))
 }
 
+// WatchShard mocks base method
+func (m *MockFlushTimesManager) WatchShard(arg0 uint32) (<-chan int64, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchShard", arg0)
+	ret0, _ := ret[0].(<-chan int64)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WatchShard indicates an expected call of WatchShard
+func (mr *MockFlushTimesManagerMockRecorder) WatchShard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchShard", reflect.TypeOf((*MockFlushTimesManager)(nil).WatchShard), arg0)
+}
+
+// MockLeadershipProvider is a mock of LeadershipProvider interface
+type MockLeadershipProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockLeadershipProviderMockRecorder
+}
+
+// MockLeadershipProviderMockRecorder is the mock recorder for MockLeadershipProvider
+type MockLeadershipProviderMockRecorder struct {
+	mock *MockLeadershipProvider
+}
+
+// NewMockLeadershipProvider creates a new mock instance
+func NewMockLeadershipProvider(ctrl *gomock.Controller) *MockLeadershipProvider {
+	mock := &MockLeadershipProvider{ctrl: ctrl}
+	mock.recorder = &MockLeadershipProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockLeadershipProvider) EXPECT() *MockLeadershipProviderMockRecorder {
+	return m.recorder
+}
+
+// IsCampaigning mocks base method
+func (m *MockLeadershipProvider) IsCampaigning() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsCampaigning")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsCampaigning indicates an expected call of IsCampaigning
+func (mr *MockLeadershipProviderMockRecorder) IsCampaigning() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsCampaigning", reflect.TypeOf((*MockLeadershipProvider)(nil).IsCampaigning))
+}
+
+// IsLeader mocks base method
+func (m *MockLeadershipProvider) IsLeader() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsLeader")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsLeader indicates an expected call of IsLeader
+func (mr *MockLeadershipProviderMockRecorder) IsLeader() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsLeader", reflect.TypeOf((*MockLeadershipProvider)(nil).IsLeader))
+}
+
+// LeaderSince mocks base method
+func (m *MockLeadershipProvider) LeaderSince() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LeaderSince")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// LeaderSince indicates an expected call of LeaderSince
+func (mr *MockLeadershipProviderMockRecorder) LeaderSince() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LeaderSince", reflect.TypeOf((*MockLeadershipProvider)(nil).LeaderSince))
+}
+
+// Subscribe mocks base method
+func (m *MockLeadershipProvider) Subscribe() (watch.Watch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe")
+	ret0, _ := ret[0].(watch.Watch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockLeadershipProviderMockRecorder) Subscribe() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockLeadershipProvider)(nil).Subscribe))
+}
+
 // MockPlacementManager is a mock of PlacementManager interface
 type MockPlacementManager struct {
 	ctrl     *gomock.Controller
@@ -442,6 +828,36 @@ func (mr *MockPlacementManagerMockRecorder) Close() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockPlacementManager)(nil).Close))
 }
 
+// DetectIsolationViolations mocks base method
+func (m *MockPlacementManager) DetectIsolationViolations() ([]ShardViolation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectIsolationViolations")
+	ret0, _ := ret[0].([]ShardViolation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectIsolationViolations indicates an expected call of DetectIsolationViolations
+func (mr *MockPlacementManagerMockRecorder) DetectIsolationViolations() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectIsolationViolations", reflect.TypeOf((*MockPlacementManager)(nil).DetectIsolationViolations))
+}
+
+// ExportJSON mocks base method
+func (m *MockPlacementManager) ExportJSON() ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportJSON")
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportJSON indicates an expected call of ExportJSON
+func (mr *MockPlacementManagerMockRecorder) ExportJSON() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportJSON", reflect.TypeOf((*MockPlacementManager)(nil).ExportJSON))
+}
+
 // HasReplacementInstance mocks base method
 func (m *MockPlacementManager) HasReplacementInstance() (bool, error) {
 	m.ctrl.T.Helper()
@@ -501,6 +917,37 @@ func (mr *MockPlacementManagerMockRecorder) InstanceID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceID", reflect.TypeOf((*MockPlacementManager)(nil).InstanceID))
 }
 
+// IsMarkedForRemoval mocks base method
+func (m *MockPlacementManager) IsMarkedForRemoval() (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsMarkedForRemoval")
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsMarkedForRemoval indicates an expected call of IsMarkedForRemoval
+func (mr *MockPlacementManagerMockRecorder) IsMarkedForRemoval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMarkedForRemoval", reflect.TypeOf((*MockPlacementManager)(nil).IsMarkedForRemoval))
+}
+
+// MovementCost mocks base method
+func (m *MockPlacementManager) MovementCost(arg0 placement.Placement) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MovementCost", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// MovementCost indicates an expected call of MovementCost
+func (mr *MockPlacementManagerMockRecorder) MovementCost(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MovementCost", reflect.TypeOf((*MockPlacementManager)(nil).MovementCost), arg0)
+}
+
 // Open mocks base method
 func (m *MockPlacementManager) Open() error {
 	m.ctrl.T.Helper()
@@ -515,6 +962,21 @@ func (mr *MockPlacementManagerMockRecorder) Open() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockPlacementManager)(nil).Open))
 }
 
+// PendingStages mocks base method
+func (m *MockPlacementManager) PendingStages() ([]StagedPlacementInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingStages")
+	ret0, _ := ret[0].([]StagedPlacementInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingStages indicates an expected call of PendingStages
+func (mr *MockPlacementManagerMockRecorder) PendingStages() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingStages", reflect.TypeOf((*MockPlacementManager)(nil).PendingStages))
+}
+
 // Placement mocks base method
 func (m *MockPlacementManager) Placement() (placement.ActiveStagedPlacement, placement.Placement, error) {
 	m.ctrl.T.Helper()
@@ -531,6 +993,96 @@ func (mr *MockPlacementManagerMockRecorder) Placement() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Placement", reflect.TypeOf((*MockPlacementManager)(nil).Placement))
 }
 
+// PreferredFailoverTarget mocks base method
+func (m *MockPlacementManager) PreferredFailoverTarget() (placement.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreferredFailoverTarget")
+	ret0, _ := ret[0].(placement.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreferredFailoverTarget indicates an expected call of PreferredFailoverTarget
+func (mr *MockPlacementManagerMockRecorder) PreferredFailoverTarget() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreferredFailoverTarget", reflect.TypeOf((*MockPlacementManager)(nil).PreferredFailoverTarget))
+}
+
+// PreferredOwner mocks base method
+func (m *MockPlacementManager) PreferredOwner(arg0 uint32, arg1 RoutingPreference) (placement.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreferredOwner", arg0, arg1)
+	ret0, _ := ret[0].(placement.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreferredOwner indicates an expected call of PreferredOwner
+func (mr *MockPlacementManagerMockRecorder) PreferredOwner(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreferredOwner", reflect.TypeOf((*MockPlacementManager)(nil).PreferredOwner), arg0, arg1)
+}
+
+// QuorumForShard mocks base method
+func (m *MockPlacementManager) QuorumForShard(arg0 uint32) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuorumForShard", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QuorumForShard indicates an expected call of QuorumForShard
+func (mr *MockPlacementManagerMockRecorder) QuorumForShard(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuorumForShard", reflect.TypeOf((*MockPlacementManager)(nil).QuorumForShard), arg0)
+}
+
+// RebalanceRecommendation mocks base method
+func (m *MockPlacementManager) RebalanceRecommendation() (*RebalancePlan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebalanceRecommendation")
+	ret0, _ := ret[0].(*RebalancePlan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RebalanceRecommendation indicates an expected call of RebalanceRecommendation
+func (mr *MockPlacementManagerMockRecorder) RebalanceRecommendation() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebalanceRecommendation", reflect.TypeOf((*MockPlacementManager)(nil).RebalanceRecommendation))
+}
+
+// RoutingTable mocks base method
+func (m *MockPlacementManager) RoutingTable() (map[uint32][]placement.Instance, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RoutingTable")
+	ret0, _ := ret[0].(map[uint32][]placement.Instance)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RoutingTable indicates an expected call of RoutingTable
+func (mr *MockPlacementManagerMockRecorder) RoutingTable() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RoutingTable", reflect.TypeOf((*MockPlacementManager)(nil).RoutingTable))
+}
+
+// ShardDistribution mocks base method
+func (m *MockPlacementManager) ShardDistribution() (map[string]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardDistribution")
+	ret0, _ := ret[0].(map[string]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardDistribution indicates an expected call of ShardDistribution
+func (mr *MockPlacementManagerMockRecorder) ShardDistribution() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardDistribution", reflect.TypeOf((*MockPlacementManager)(nil).ShardDistribution))
+}
+
 // Shards mocks base method
 func (m *MockPlacementManager) Shards() (shard.Shards, error) {
 	m.ctrl.T.Helper()
@@ -545,3 +1097,108 @@ func (mr *MockPlacementManagerMockRecorder) Shards() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*MockPlacementManager)(nil).Shards))
 }
+
+// UnassignedShards mocks base method
+func (m *MockPlacementManager) UnassignedShards() ([]uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnassignedShards")
+	ret0, _ := ret[0].([]uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnassignedShards indicates an expected call of UnassignedShards
+func (mr *MockPlacementManagerMockRecorder) UnassignedShards() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnassignedShards", reflect.TypeOf((*MockPlacementManager)(nil).UnassignedShards))
+}
+
+// VerifyRedistribution mocks base method
+func (m *MockPlacementManager) VerifyRedistribution(arg0 string, arg1, arg2 placement.Placement) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyRedistribution", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyRedistribution indicates an expected call of VerifyRedistribution
+func (mr *MockPlacementManagerMockRecorder) VerifyRedistribution(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyRedistribution", reflect.TypeOf((*MockPlacementManager)(nil).VerifyRedistribution), arg0, arg1, arg2)
+}
+
+// WaitForCutover mocks base method
+func (m *MockPlacementManager) WaitForCutover(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForCutover", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForCutover indicates an expected call of WaitForCutover
+func (mr *MockPlacementManagerMockRecorder) WaitForCutover(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForCutover", reflect.TypeOf((*MockPlacementManager)(nil).WaitForCutover), arg0)
+}
+
+// WaitForShardState mocks base method
+func (m *MockPlacementManager) WaitForShardState(arg0 context.Context, arg1 shard.State) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WaitForShardState", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WaitForShardState indicates an expected call of WaitForShardState
+func (mr *MockPlacementManagerMockRecorder) WaitForShardState(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForShardState", reflect.TypeOf((*MockPlacementManager)(nil).WaitForShardState), arg0, arg1)
+}
+
+// WatchInstanceWeight mocks base method
+func (m *MockPlacementManager) WatchInstanceWeight() (<-chan uint32, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchInstanceWeight")
+	ret0, _ := ret[0].(<-chan uint32)
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WatchInstanceWeight indicates an expected call of WatchInstanceWeight
+func (mr *MockPlacementManagerMockRecorder) WatchInstanceWeight() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchInstanceWeight", reflect.TypeOf((*MockPlacementManager)(nil).WatchInstanceWeight))
+}
+
+// WatchMarkedForRemoval mocks base method
+func (m *MockPlacementManager) WatchMarkedForRemoval() (<-chan struct{}, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchMarkedForRemoval")
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WatchMarkedForRemoval indicates an expected call of WatchMarkedForRemoval
+func (mr *MockPlacementManagerMockRecorder) WatchMarkedForRemoval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchMarkedForRemoval", reflect.TypeOf((*MockPlacementManager)(nil).WatchMarkedForRemoval))
+}
+
+// WatchReplacementComplete mocks base method
+func (m *MockPlacementManager) WatchReplacementComplete() (<-chan struct{}, func(), error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchReplacementComplete")
+	ret0, _ := ret[0].(<-chan struct{})
+	ret1, _ := ret[1].(func())
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// WatchReplacementComplete indicates an expected call of WatchReplacementComplete
+func (mr *MockPlacementManagerMockRecorder) WatchReplacementComplete() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchReplacementComplete", reflect.TypeOf((*MockPlacementManager)(nil).WatchReplacementComplete))
+}
@@ -1059,7 +1059,7 @@ func testAggregatorWithCustomPlacements(
 
 // nolint: unparam
 func testPlacementWatcherWithPlacementProto(
-	t *testing.T,
+	t testing.TB,
 	placementKey string,
 	proto *placementpb.PlacementSnapshots,
 ) (placement.StagedPlacementWatcher, kv.Store) {
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// ElectionAuditRecord records a change in leadership of an instance.
+type ElectionAuditRecord struct {
+	// InstanceID is the leader value the instance campaigns with, which is its
+	// instance ID by default.
+	InstanceID string
+	// ShardSetID is the shard set the election is held for.
+	ShardSetID uint32
+	// Shards are the shards of the subset the election is held for, or nil if
+	// the election is held for the whole shard set.
+	Shards []uint32
+	// Term is the id of the latest leadership term of the election.
+	Term int64
+	// Event is the leadership change, one of LeaderAcquiredEvent,
+	// LeaderLostEvent or ResignedEvent.
+	Event ElectionEventType
+	// Timestamp is when the leadership changed.
+	Timestamp time.Time
+	// Reason describes why the leadership changed.
+	Reason string
+}
+
+// ElectionAuditSink receives a record of every leadership change of the
+// election manager, e.g. to keep a durable audit trail of leadership.
+type ElectionAuditSink interface {
+	// Record records the given leadership change.
+	Record(record ElectionAuditRecord) error
+}
+
+type noopElectionAuditSink struct{}
+
+// NewNoopElectionAuditSink creates a new sink discarding audit records, which is
+// the default.
+func NewNoopElectionAuditSink() ElectionAuditSink {
+	return noopElectionAuditSink{}
+}
+
+func (noopElectionAuditSink) Record(ElectionAuditRecord) error {
+	return nil
+}
+
+type fileElectionAuditEntry struct {
+	InstanceID string    `json:"instanceID"`
+	ShardSetID uint32    `json:"shardSetID"`
+	Shards     []uint32  `json:"shards,omitempty"`
+	Term       int64     `json:"term"`
+	Event      string    `json:"event"`
+	Timestamp  time.Time `json:"timestamp"`
+	Reason     string    `json:"reason"`
+}
+
+type fileElectionAuditSink struct {
+	sync.Mutex
+
+	path string
+}
+
+// NewFileElectionAuditSink creates a new sink appending audit records as JSON
+// lines to the file at the given path, which is created if it does not exist.
+// Each record is synced to disk before Record returns.
+func NewFileElectionAuditSink(path string) ElectionAuditSink {
+	return &fileElectionAuditSink{path: path}
+}
+
+func (s *fileElectionAuditSink) Record(record ElectionAuditRecord) error {
+	data, err := json.Marshal(fileElectionAuditEntry{
+		InstanceID: record.InstanceID,
+		ShardSetID: record.ShardSetID,
+		Shards:     record.Shards,
+		Term:       record.Term,
+		Event:      record.Event.String(),
+		Timestamp:  record.Timestamp,
+		Reason:     record.Reason,
+	})
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+
+	// NB: the file is reopened for every record as leadership changes are rare,
+	// so that the file can be rotated without coordinating with the sink.
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
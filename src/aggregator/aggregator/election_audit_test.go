@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileElectionAuditSinkRecord(t *testing.T) {
+	dir, err := ioutil.TempDir("", "election-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		path = filepath.Join(dir, "audit.log")
+		sink = NewFileElectionAuditSink(path)
+		now  = time.Unix(1600000000, 0).UTC()
+	)
+	require.NoError(t, sink.Record(ElectionAuditRecord{
+		InstanceID: "instance1",
+		ShardSetID: 3,
+		Term:       7,
+		Event:      LeaderAcquiredEvent,
+		Timestamp:  now,
+		Reason:     "election state changed from follower to leader",
+	}))
+	require.NoError(t, sink.Record(ElectionAuditRecord{
+		InstanceID: "instance1",
+		ShardSetID: 3,
+		Shards:     []uint32{1, 2},
+		Term:       7,
+		Event:      ResignedEvent,
+		Timestamp:  now.Add(time.Second),
+		Reason:     "resign",
+	}))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`{"instanceID":"instance1","shardSetID":3,"term":7,"event":"leader-acquired",` +
+			`"timestamp":"2020-09-13T12:26:40Z","reason":"election state changed from follower to leader"}`,
+		`{"instanceID":"instance1","shardSetID":3,"shards":[1,2],"term":7,"event":"resigned",` +
+			`"timestamp":"2020-09-13T12:26:41Z","reason":"resign"}`,
+		``,
+	}, strings.Split(string(data), "\n"))
+}
+
+func TestFileElectionAuditSinkRecordError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "election-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink := NewFileElectionAuditSink(filepath.Join(dir, "missing", "audit.log"))
+	require.Error(t, sink.Record(ElectionAuditRecord{Event: LeaderLostEvent}))
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/cluster/services/leader"
+)
+
+var (
+	// ErrNoElectionLeader is returned by an election backend when there is
+	// no leader for an election.
+	ErrNoElectionLeader = errors.New("election has no leader")
+
+	// ErrElectionRevisionUnsupported is returned by an election backend which
+	// cannot determine the revision of an election.
+	ErrElectionRevisionUnsupported = errors.New("election backend does not support revisions")
+
+	// ErrElectionLiveInstancesUnsupported is returned by an election backend which
+	// cannot determine the instances holding sessions in an election.
+	ErrElectionLiveInstancesUnsupported = errors.New("election backend does not support live instances")
+)
+
+// ElectionBackend is the backend leadership elections are held against.
+type ElectionBackend interface {
+	// Leader returns the leader value of the given election, or ErrNoElectionLeader
+	// if the election has no leader.
+	Leader(ctx context.Context, electionID string) (string, error)
+
+	// Revision returns the revision of the leader of the given election, e.g. the
+	// etcd mod revision of the leader key, which increases whenever leadership
+	// changes hands. ErrNoElectionLeader is returned if the election has no leader.
+	Revision(ctx context.Context, electionID string) (int64, error)
+
+	// LiveInstances returns the IDs of the instances holding an active session in
+	// the given election in ascending order, regardless of whether they are
+	// contending for leadership.
+	LiveInstances(ctx context.Context, electionID string) ([]string, error)
+}
+
+// revisionedLeaderService is implemented by leader services able to return the
+// revision of the leader of an election, such as the etcd leader service.
+type revisionedLeaderService interface {
+	LeaderRevision(electionID string) (int64, error)
+}
+
+type leaderServiceElectionBackend struct {
+	leaderService services.LeaderService
+}
+
+// NewLeaderServiceElectionBackend creates an election backend backed by the
+// given leader service.
+func NewLeaderServiceElectionBackend(leaderService services.LeaderService) ElectionBackend {
+	return leaderServiceElectionBackend{leaderService: leaderService}
+}
+
+func (b leaderServiceElectionBackend) Leader(
+	ctx context.Context,
+	electionID string,
+) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	value, err := b.leaderService.Leader(electionID)
+	if err == leader.ErrNoLeader {
+		return "", ErrNoElectionLeader
+	}
+	return value, err
+}
+
+func (b leaderServiceElectionBackend) Revision(
+	ctx context.Context,
+	electionID string,
+) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	leaderService, ok := b.leaderService.(revisionedLeaderService)
+	if !ok {
+		return 0, ErrElectionRevisionUnsupported
+	}
+	revision, err := leaderService.LeaderRevision(electionID)
+	if err == leader.ErrNoLeader {
+		return 0, ErrNoElectionLeader
+	}
+	return revision, err
+}
+
+// LiveInstances is unsupported as the leader service does not expose the
+// sessions of other instances.
+func (b leaderServiceElectionBackend) LiveInstances(
+	ctx context.Context,
+	electionID string,
+) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrElectionLiveInstancesUnsupported
+}
+
+// electionBackendSupportsLiveInstances returns whether the given election backend
+// supports LiveInstances. A nil backend defaults to the leader service backend,
+// which does not.
+func electionBackendSupportsLiveInstances(backend ElectionBackend) bool {
+	switch backend.(type) {
+	case nil, leaderServiceElectionBackend:
+		return false
+	default:
+		return true
+	}
+}
+
+// VerifyLeader returns true if the given instance is the current leader of the
+// election of the given shard set, and false otherwise. The election is resolved
+// with the default election key format, see VerifyLeaderWithOptions for election
+// managers with a custom election key format or prefix. Unlike the election
+// manager, it only reads the leader from the backend and neither campaigns nor
+// mutates any election state.
+func VerifyLeader(
+	ctx context.Context,
+	backend ElectionBackend,
+	shardSetID uint32,
+	instanceID string,
+) (bool, error) {
+	electionID := newElectionID("", defaultElectionKeyFormat, shardSetID)
+	return verifyElectionLeader(ctx, backend, electionID, instanceID)
+}
+
+// VerifyLeaderWithOptions is like VerifyLeader, with the election of the shard
+// set resolved with the election key format and prefix of the given options.
+func VerifyLeaderWithOptions(
+	ctx context.Context,
+	backend ElectionBackend,
+	opts ElectionManagerOptions,
+	shardSetID uint32,
+	instanceID string,
+) (bool, error) {
+	return verifyElectionLeader(ctx, backend, ElectionID(opts, shardSetID), instanceID)
+}
+
+func verifyElectionLeader(
+	ctx context.Context,
+	backend ElectionBackend,
+	electionID string,
+	instanceID string,
+) (bool, error) {
+	leader, err := backend.Leader(ctx, electionID)
+	if err == ErrNoElectionLeader {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return leader == instanceID, nil
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/cluster/services/leader"
+	"github.com/m3db/m3/src/cluster/services/leader/campaign"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// memElectionBackend is an in-memory election backend for testing.
+type memElectionBackend struct {
+	sync.Mutex
+
+	leaders   map[string]string
+	revisions map[string]int64
+	revision  int64
+	sessions  map[string]map[string]struct{}
+}
+
+func newMemElectionBackend() *memElectionBackend {
+	return &memElectionBackend{
+		leaders:   make(map[string]string),
+		revisions: make(map[string]int64),
+		sessions:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (b *memElectionBackend) addSession(electionID, instanceID string) {
+	b.Lock()
+	b.addSessionWithLock(electionID, instanceID)
+	b.Unlock()
+}
+
+func (b *memElectionBackend) addSessionWithLock(electionID, instanceID string) {
+	sessions, exists := b.sessions[electionID]
+	if !exists {
+		sessions = make(map[string]struct{})
+		b.sessions[electionID] = sessions
+	}
+	sessions[instanceID] = struct{}{}
+}
+
+func (b *memElectionBackend) removeSessionWithLock(electionID, instanceID string) {
+	delete(b.sessions[electionID], instanceID)
+}
+
+func (b *memElectionBackend) setLeader(electionID, leader string) {
+	b.Lock()
+	b.setLeaderWithLock(electionID, leader)
+	b.Unlock()
+}
+
+func (b *memElectionBackend) setLeaderWithLock(electionID, leader string) {
+	b.revision++
+	b.leaders[electionID] = leader
+	b.revisions[electionID] = b.revision
+}
+
+func (b *memElectionBackend) deleteLeaderWithLock(electionID string) {
+	b.revision++
+	delete(b.leaders, electionID)
+	delete(b.revisions, electionID)
+}
+
+func (b *memElectionBackend) Leader(
+	ctx context.Context,
+	electionID string,
+) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	b.Lock()
+	defer b.Unlock()
+	leader, exists := b.leaders[electionID]
+	if !exists {
+		return "", ErrNoElectionLeader
+	}
+	return leader, nil
+}
+
+func (b *memElectionBackend) Revision(
+	ctx context.Context,
+	electionID string,
+) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	revision, exists := b.revisions[electionID]
+	if !exists {
+		return 0, ErrNoElectionLeader
+	}
+	return revision, nil
+}
+
+func (b *memElectionBackend) LiveInstances(
+	ctx context.Context,
+	electionID string,
+) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	b.Lock()
+	defer b.Unlock()
+	instances := make([]string, 0, len(b.sessions[electionID]))
+	for instanceID := range b.sessions[electionID] {
+		instances = append(instances, instanceID)
+	}
+	sort.Strings(instances)
+	return instances, nil
+}
+
+// memLeaderService is a leader service holding elections in an in-memory
+// backend, granting leadership to the first campaigner of each election.
+// Resigning from an election demotes the leader to follower and ends its
+// campaign, as is the case with etcd.
+type memLeaderService struct {
+	backend   *memElectionBackend
+	value     string
+	statusChs map[string]chan campaign.Status
+}
+
+func newMemLeaderService(backend *memElectionBackend, value string) *memLeaderService {
+	return &memLeaderService{
+		backend:   backend,
+		value:     value,
+		statusChs: make(map[string]chan campaign.Status),
+	}
+}
+
+func (s *memLeaderService) Campaign(
+	electionID string,
+	opts services.CampaignOptions,
+) (<-chan campaign.Status, error) {
+	s.backend.Lock()
+	defer s.backend.Unlock()
+	statusCh := make(chan campaign.Status, 2)
+	s.statusChs[electionID] = statusCh
+	s.backend.addSessionWithLock(electionID, s.value)
+	if _, exists := s.backend.leaders[electionID]; !exists {
+		s.backend.setLeaderWithLock(electionID, s.value)
+		statusCh <- campaign.NewStatus(campaign.Leader)
+	} else {
+		statusCh <- campaign.NewStatus(campaign.Follower)
+	}
+	return statusCh, nil
+}
+
+func (s *memLeaderService) Resign(electionID string) error {
+	s.backend.Lock()
+	defer s.backend.Unlock()
+	if s.backend.leaders[electionID] == s.value {
+		s.backend.deleteLeaderWithLock(electionID)
+	}
+	s.backend.removeSessionWithLock(electionID, s.value)
+	if statusCh, exists := s.statusChs[electionID]; exists {
+		statusCh <- campaign.NewStatus(campaign.Follower)
+		close(statusCh)
+		delete(s.statusChs, electionID)
+	}
+	return nil
+}
+
+func (s *memLeaderService) Leader(electionID string) (string, error) {
+	value, err := s.backend.Leader(context.Background(), electionID)
+	if err == ErrNoElectionLeader {
+		return "", leader.ErrNoLeader
+	}
+	return value, err
+}
+
+func (s *memLeaderService) LeaderRevision(electionID string) (int64, error) {
+	revision, err := s.backend.Revision(context.Background(), electionID)
+	if err == ErrNoElectionLeader {
+		return 0, leader.ErrNoLeader
+	}
+	return revision, err
+}
+
+func (s *memLeaderService) Observe(electionID string) (<-chan string, error) {
+	return make(chan string), nil
+}
+
+func (s *memLeaderService) Close() error { return nil }
+
+func TestVerifyLeader(t *testing.T) {
+	backend := newMemElectionBackend()
+	backend.setLeader(fmt.Sprintf(defaultElectionKeyFormat, testShardSetID), testInstanceID1)
+
+	isLeader, err := VerifyLeader(context.Background(), backend, testShardSetID, testInstanceID1)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	isLeader, err = VerifyLeader(context.Background(), backend, testShardSetID, testInstanceID2)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+}
+
+func TestVerifyLeaderWithOptionsElectionKeyPrefix(t *testing.T) {
+	backend := newMemElectionBackend()
+	opts := NewElectionManagerOptions().SetElectionKeyPrefix("/cluster-a")
+	electionID := ElectionID(opts, testShardSetID)
+	require.Equal(t, "/cluster-a"+fmt.Sprintf(defaultElectionKeyFormat, testShardSetID), electionID)
+
+	// NB: the unprefixed election of the shard set has a different leader.
+	backend.setLeader(fmt.Sprintf(defaultElectionKeyFormat, testShardSetID), testInstanceID2)
+	backend.setLeader(electionID, testInstanceID1)
+
+	isLeader, err := VerifyLeaderWithOptions(context.Background(), backend, opts, testShardSetID, testInstanceID1)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+
+	isLeader, err = VerifyLeaderWithOptions(context.Background(), backend, opts, testShardSetID, testInstanceID2)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+
+	isLeader, err = VerifyLeader(context.Background(), backend, testShardSetID, testInstanceID2)
+	require.NoError(t, err)
+	require.True(t, isLeader)
+}
+
+func TestVerifyLeaderNoLeader(t *testing.T) {
+	backend := newMemElectionBackend()
+	isLeader, err := VerifyLeader(context.Background(), backend, testShardSetID, testInstanceID1)
+	require.NoError(t, err)
+	require.False(t, isLeader)
+}
+
+func TestVerifyLeaderContextDone(t *testing.T) {
+	backend := newMemElectionBackend()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := VerifyLeader(ctx, backend, testShardSetID, testInstanceID1)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestLeaderServiceElectionBackendLeader(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		electionKey = fmt.Sprintf(defaultElectionKeyFormat, testShardSetID)
+		errLeader   = errors.New("leader error")
+	)
+	leaderService := services.NewMockLeaderService(ctrl)
+	gomock.InOrder(
+		leaderService.EXPECT().Leader(electionKey).Return(testInstanceID1, nil),
+		leaderService.EXPECT().Leader(electionKey).Return("", leader.ErrNoLeader),
+		leaderService.EXPECT().Leader(electionKey).Return("", errLeader),
+	)
+
+	backend := NewLeaderServiceElectionBackend(leaderService)
+	value, err := backend.Leader(context.Background(), electionKey)
+	require.NoError(t, err)
+	require.Equal(t, testInstanceID1, value)
+
+	_, err = backend.Leader(context.Background(), electionKey)
+	require.Equal(t, ErrNoElectionLeader, err)
+
+	_, err = backend.Leader(context.Background(), electionKey)
+	require.Equal(t, errLeader, err)
+}
+
+func TestLeaderServiceElectionBackendRevision(t *testing.T) {
+	var (
+		backend     = newMemElectionBackend()
+		electionKey = fmt.Sprintf(defaultElectionKeyFormat, testShardSetID)
+		service1    = NewLeaderServiceElectionBackend(newMemLeaderService(backend, testInstanceID1))
+	)
+	_, err := service1.Revision(context.Background(), electionKey)
+	require.Equal(t, ErrNoElectionLeader, err)
+
+	backend.setLeader(electionKey, testInstanceID1)
+	revision, err := service1.Revision(context.Background(), electionKey)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revision)
+
+	// A leadership change must be observed as a newer revision.
+	backend.setLeader(electionKey, testInstanceID2)
+	revision, err = service1.Revision(context.Background(), electionKey)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revision)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service1.Revision(ctx, electionKey)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestLeaderServiceElectionBackendRevisionUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewLeaderServiceElectionBackend(services.NewMockLeaderService(ctrl))
+	_, err := backend.Revision(context.Background(), "election")
+	require.Equal(t, ErrElectionRevisionUnsupported, err)
+}
+
+func TestLeaderServiceElectionBackendLiveInstancesUnsupported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := NewLeaderServiceElectionBackend(services.NewMockLeaderService(ctrl))
+	_, err := backend.LiveInstances(context.Background(), "election")
+	require.Equal(t, ErrElectionLiveInstancesUnsupported, err)
+}
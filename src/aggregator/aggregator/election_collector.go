@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	electionStateDesc = prometheus.NewDesc(
+		"m3aggregator_election_state",
+		"Whether the instance is in the given election state.",
+		[]string{"state"}, nil,
+	)
+	electionLeaderSinceDesc = prometheus.NewDesc(
+		"m3aggregator_election_leader_since_seconds",
+		"Unix time the instance became the leader, or zero if it is not the leader.",
+		nil, nil,
+	)
+	electionFencingTokenDesc = prometheus.NewDesc(
+		"m3aggregator_election_fencing_token",
+		"Id of the latest leadership term of the instance.",
+		nil, nil,
+	)
+	electionCampaigningDesc = prometheus.NewDesc(
+		"m3aggregator_election_campaigning",
+		"Whether the instance is actively campaigning.",
+		nil, nil,
+	)
+
+	collectedElectionStates = []ElectionState{
+		FollowerState,
+		PendingFollowerState,
+		LeaderState,
+	}
+)
+
+type electionManagerCollector struct {
+	mgr ElectionManager
+}
+
+// NewElectionManagerCollector returns a Prometheus collector reporting the
+// election state, leadership start time, fencing token and campaign status of
+// the given election manager, read on each scrape.
+func NewElectionManagerCollector(mgr ElectionManager) prometheus.Collector {
+	return &electionManagerCollector{mgr: mgr}
+}
+
+func (c *electionManagerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- electionStateDesc
+	ch <- electionLeaderSinceDesc
+	ch <- electionFencingTokenDesc
+	ch <- electionCampaigningDesc
+}
+
+func (c *electionManagerCollector) Collect(ch chan<- prometheus.Metric) {
+	state := c.mgr.ElectionState()
+	for _, s := range collectedElectionStates {
+		ch <- prometheus.MustNewConstMetric(electionStateDesc, prometheus.GaugeValue,
+			boolToFloat64(s == state), s.String())
+	}
+
+	var leaderSince float64
+	if t := c.mgr.LeaderSince(); !t.IsZero() {
+		leaderSince = float64(t.UnixNano()) / 1e9
+	}
+	ch <- prometheus.MustNewConstMetric(electionLeaderSinceDesc, prometheus.GaugeValue,
+		leaderSince)
+	ch <- prometheus.MustNewConstMetric(electionFencingTokenDesc, prometheus.GaugeValue,
+		float64(c.mgr.FencingToken()))
+	ch <- prometheus.MustNewConstMetric(electionCampaigningDesc, prometheus.GaugeValue,
+		boolToFloat64(c.mgr.IsCampaigning()))
+}
+
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
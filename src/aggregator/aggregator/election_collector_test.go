@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestElectionManagerCollector(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testElectionManagerOptions(t, ctrl)
+	mgr := NewElectionManager(opts).(*electionManager)
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(NewElectionManagerCollector(mgr)))
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP m3aggregator_election_campaigning Whether the instance is actively campaigning.
+# TYPE m3aggregator_election_campaigning gauge
+m3aggregator_election_campaigning 0
+# HELP m3aggregator_election_fencing_token Id of the latest leadership term of the instance.
+# TYPE m3aggregator_election_fencing_token gauge
+m3aggregator_election_fencing_token 0
+# HELP m3aggregator_election_leader_since_seconds Unix time the instance became the leader, or zero if it is not the leader.
+# TYPE m3aggregator_election_leader_since_seconds gauge
+m3aggregator_election_leader_since_seconds 0
+# HELP m3aggregator_election_state Whether the instance is in the given election state.
+# TYPE m3aggregator_election_state gauge
+m3aggregator_election_state{state="follower"} 1
+m3aggregator_election_state{state="leader"} 0
+m3aggregator_election_state{state="pendingFollower"} 0
+`)))
+
+	// The collector should reflect the live state on each scrape.
+	mgr.electionStateWatchable.Update(LeaderState)
+	mgr.campaignStateWatchable.Update(campaignEnabled)
+	mgr.leaderSinceNanos = time.Unix(1600000000, 0).UnixNano()
+	mgr.term = 3
+	require.Equal(t, time.Unix(1600000000, 0), mgr.LeaderSince())
+	require.Equal(t, int64(3), mgr.FencingToken())
+
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP m3aggregator_election_campaigning Whether the instance is actively campaigning.
+# TYPE m3aggregator_election_campaigning gauge
+m3aggregator_election_campaigning 1
+# HELP m3aggregator_election_fencing_token Id of the latest leadership term of the instance.
+# TYPE m3aggregator_election_fencing_token gauge
+m3aggregator_election_fencing_token 3
+# HELP m3aggregator_election_leader_since_seconds Unix time the instance became the leader, or zero if it is not the leader.
+# TYPE m3aggregator_election_leader_since_seconds gauge
+m3aggregator_election_leader_since_seconds 1.6e+09
+# HELP m3aggregator_election_state Whether the instance is in the given election state.
+# TYPE m3aggregator_election_state gauge
+m3aggregator_election_state{state="follower"} 0
+m3aggregator_election_state{state="leader"} 1
+m3aggregator_election_state{state="pendingFollower"} 0
+`)))
+}
@@ -0,0 +1,275 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// ElectionEventType is the type of an election event.
+type ElectionEventType int
+
+// A list of supported election event types.
+const (
+	// UnknownElectionEvent is an unknown election event.
+	UnknownElectionEvent ElectionEventType = iota
+
+	// CampaignStartedEvent is emitted when a campaign has been started.
+	CampaignStartedEvent
+
+	// LeaderAcquiredEvent is emitted when the instance has become the leader.
+	LeaderAcquiredEvent
+
+	// LeaderLostEvent is emitted when the instance is no longer the leader.
+	LeaderLostEvent
+
+	// ResignedEvent is emitted when the instance has resigned from the campaign.
+	ResignedEvent
+
+	// KeepaliveFailedEvent is emitted when the campaign failed to be kept alive.
+	KeepaliveFailedEvent
+)
+
+func (t ElectionEventType) String() string {
+	switch t {
+	case CampaignStartedEvent:
+		return "campaign-started"
+	case LeaderAcquiredEvent:
+		return "leader-acquired"
+	case LeaderLostEvent:
+		return "leader-lost"
+	case ResignedEvent:
+		return "resigned"
+	case KeepaliveFailedEvent:
+		return "keepalive-failed"
+	default:
+		return "unknown"
+	}
+}
+
+// coalescable returns true if consecutive events of this type can be merged
+// without losing information about state transitions.
+func (t ElectionEventType) coalescable() bool {
+	return t == CampaignStartedEvent || t == KeepaliveFailedEvent
+}
+
+// terminal returns true if events of this type end leadership, which are never
+// dropped while the election manager is open.
+func (t ElectionEventType) terminal() bool {
+	return t == LeaderLostEvent || t == ResignedEvent
+}
+
+// ElectionEvent is an event in the lifecycle of an election.
+type ElectionEvent struct {
+	Type      ElectionEventType
+	Timestamp time.Time
+	Reason    string
+}
+
+// maxPendingElectionEvents is the maximum number of undelivered election events
+// buffered, and then queued, for each subscriber of the election manager.
+const maxPendingElectionEvents = 1024
+
+// electionEventStream fans out election events to its subscribers, each of
+// which receives the events emitted after it subscribed.
+type electionEventStream struct {
+	sync.Mutex
+
+	maxPending  int
+	drops       tally.Counter
+	closed      bool
+	subscribers []*electionEventSubscriber
+}
+
+func newElectionEventStream(maxPending int, drops tally.Counter) *electionEventStream {
+	return &electionEventStream{
+		maxPending: maxPending,
+		drops:      drops,
+	}
+}
+
+// Subscribe returns a new channel events are delivered on, which is closed once
+// the stream is closed.
+func (s *electionEventStream) Subscribe() <-chan ElectionEvent {
+	s.Lock()
+	defer s.Unlock()
+
+	sub := newElectionEventSubscriber(s.maxPending, s.drops)
+	if s.closed {
+		close(sub.eventsCh)
+		return sub.eventsCh
+	}
+	s.subscribers = append(s.subscribers, sub)
+	go sub.deliver()
+	return sub.eventsCh
+}
+
+func (s *electionEventStream) Emit(event ElectionEvent) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+	for _, sub := range s.subscribers {
+		sub.emit(event)
+	}
+}
+
+func (s *electionEventStream) Close() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, sub := range s.subscribers {
+		sub.close()
+	}
+}
+
+// electionEventSubscriber delivers election events in order to a single
+// subscriber. Up to maxPending undelivered events are buffered in the channel
+// events are delivered on, beyond which further events are queued. Consecutive
+// queued events of a coalescable type are merged, keeping only the latest.
+// Otherwise up to maxPending events are queued, beyond which the oldest queued
+// event which is not terminal is dropped, so that a subscriber falling behind
+// still observes every time leadership ends.
+//
+// Once closed, the queued events are moved to the buffer as long as it has room
+// and the channel is closed, dropping the remaining events, so that delivery
+// stops even if the subscriber has stopped receiving.
+type electionEventSubscriber struct {
+	sync.Mutex
+
+	maxPending int
+	drops      tally.Counter
+	closed     bool
+	pending    []ElectionEvent
+	notifyCh   chan struct{}
+	doneCh     chan struct{}
+	eventsCh   chan ElectionEvent
+}
+
+func newElectionEventSubscriber(maxPending int, drops tally.Counter) *electionEventSubscriber {
+	return &electionEventSubscriber{
+		maxPending: maxPending,
+		drops:      drops,
+		notifyCh:   make(chan struct{}, 1),
+		doneCh:     make(chan struct{}),
+		eventsCh:   make(chan ElectionEvent, maxPending),
+	}
+}
+
+func (s *electionEventSubscriber) emit(event ElectionEvent) {
+	s.Lock()
+	if n := len(s.pending); n > 0 && s.pending[n-1].Type == event.Type && event.Type.coalescable() {
+		s.pending[n-1] = event
+	} else {
+		if len(s.pending) >= s.maxPending {
+			s.dropOldestNonTerminalWithLock()
+		}
+		s.pending = append(s.pending, event)
+	}
+	s.Unlock()
+	s.notify()
+}
+
+// dropOldestNonTerminalWithLock drops the oldest queued event which is not
+// terminal, if any, in which case the queue grows beyond its bound.
+func (s *electionEventSubscriber) dropOldestNonTerminalWithLock() {
+	for i, event := range s.pending {
+		if event.Type.terminal() {
+			continue
+		}
+		s.pending = append(s.pending[:i], s.pending[i+1:]...)
+		s.drops.Inc(1)
+		return
+	}
+}
+
+func (s *electionEventSubscriber) close() {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+	close(s.doneCh)
+}
+
+func (s *electionEventSubscriber) notify() {
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *electionEventSubscriber) deliver() {
+	for {
+		s.Lock()
+		pending := s.pending
+		if s.closed {
+			s.pending = nil
+			s.Unlock()
+			s.flush(pending)
+			return
+		}
+		if len(pending) == 0 {
+			s.Unlock()
+			select {
+			case <-s.notifyCh:
+			case <-s.doneCh:
+			}
+			continue
+		}
+		event := pending[0]
+		s.pending = pending[1:]
+		s.Unlock()
+
+		select {
+		case s.eventsCh <- event:
+		case <-s.doneCh:
+			s.Lock()
+			pending = append([]ElectionEvent{event}, s.pending...)
+			s.pending = nil
+			s.Unlock()
+			s.flush(pending)
+			return
+		}
+	}
+}
+
+// flush moves the given events to the buffer as long as it has room, dropping
+// the remaining events, and closes the channel events are delivered on.
+func (s *electionEventSubscriber) flush(events []ElectionEvent) {
+	for i, event := range events {
+		select {
+		case s.eventsCh <- event:
+		default:
+			s.drops.Inc(int64(len(events) - i))
+			close(s.eventsCh)
+			return
+		}
+	}
+	close(s.eventsCh)
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestElectionEventStreamNoSubscriber(t *testing.T) {
+	stream := newElectionEventStream(maxPendingElectionEvents, tally.NoopScope.Counter("drops"))
+	stream.Emit(ElectionEvent{Type: CampaignStartedEvent})
+	require.Equal(t, 0, len(stream.subscribers))
+
+	stream.Close()
+	_, ok := <-stream.Subscribe()
+	require.False(t, ok)
+}
+
+func TestElectionEventStreamMultipleSubscribers(t *testing.T) {
+	var (
+		stream = newElectionEventStream(maxPendingElectionEvents, tally.NoopScope.Counter("drops"))
+		now    = time.Unix(0, 0)
+		events = []ElectionEvent{
+			{Type: CampaignStartedEvent, Timestamp: now},
+			{Type: LeaderAcquiredEvent, Timestamp: now.Add(time.Second)},
+			{Type: ResignedEvent, Timestamp: now.Add(2 * time.Second)},
+		}
+	)
+
+	// Every subscriber receives the events emitted after it subscribed.
+	first := stream.Subscribe()
+	stream.Emit(events[0])
+	second := stream.Subscribe()
+	for _, event := range events[1:] {
+		stream.Emit(event)
+	}
+	stream.Close()
+
+	receive := func(eventsCh <-chan ElectionEvent) []ElectionEvent {
+		var received []ElectionEvent
+		for event := range eventsCh {
+			received = append(received, event)
+		}
+		return received
+	}
+	require.Equal(t, events, receive(first))
+	require.Equal(t, events[1:], receive(second))
+}
+
+func TestElectionEventSubscriberCoalesce(t *testing.T) {
+	var (
+		sub    = newElectionEventSubscriber(maxPendingElectionEvents, tally.NoopScope.Counter("drops"))
+		now    = time.Unix(0, 0)
+		events = []ElectionEvent{
+			{Type: CampaignStartedEvent, Timestamp: now},
+			{Type: CampaignStartedEvent, Timestamp: now.Add(time.Second)},
+			{Type: LeaderAcquiredEvent, Timestamp: now.Add(2 * time.Second)},
+			{Type: KeepaliveFailedEvent, Timestamp: now.Add(3 * time.Second), Reason: "first"},
+			{Type: KeepaliveFailedEvent, Timestamp: now.Add(4 * time.Second), Reason: "second"},
+			{Type: LeaderLostEvent, Timestamp: now.Add(5 * time.Second)},
+			{Type: LeaderLostEvent, Timestamp: now.Add(6 * time.Second)},
+			{Type: ResignedEvent, Timestamp: now.Add(7 * time.Second)},
+		}
+	)
+
+	// Queue all events before starting delivery so none of them is delivered
+	// before the others are queued.
+	for _, event := range events {
+		sub.emit(event)
+	}
+	sub.close()
+	go sub.deliver()
+
+	var received []ElectionEvent
+	for event := range sub.eventsCh {
+		received = append(received, event)
+	}
+	expected := []ElectionEvent{events[1], events[2], events[4], events[5], events[6], events[7]}
+	require.Equal(t, expected, received)
+}
+
+func TestElectionEventSubscriberKeepsTerminalEvents(t *testing.T) {
+	var (
+		scope  = tally.NewTestScope("", nil)
+		sub    = newElectionEventSubscriber(2, scope.Counter("drops"))
+		now    = time.Unix(0, 0)
+		events = []ElectionEvent{
+			{Type: LeaderAcquiredEvent, Timestamp: now},
+			{Type: LeaderLostEvent, Timestamp: now.Add(time.Second)},
+			{Type: ResignedEvent, Timestamp: now.Add(2 * time.Second)},
+			{Type: LeaderAcquiredEvent, Timestamp: now.Add(3 * time.Second)},
+			{Type: LeaderLostEvent, Timestamp: now.Add(4 * time.Second)},
+		}
+	)
+
+	// The oldest queued events which are not terminal are dropped once the queue
+	// is full, while terminal events are queued beyond the bound.
+	for _, event := range events {
+		sub.emit(event)
+	}
+	go sub.deliver()
+
+	var received []ElectionEvent
+	for i := 0; i < 3; i++ {
+		received = append(received, <-sub.eventsCh)
+	}
+	sub.close()
+	_, ok := <-sub.eventsCh
+	require.False(t, ok)
+	require.Equal(t, []ElectionEvent{events[1], events[2], events[4]}, received)
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["drops+"].Value())
+}
+
+func TestElectionEventStreamCloseWithoutReceiving(t *testing.T) {
+	var (
+		scope  = tally.NewTestScope("", nil)
+		stream = newElectionEventStream(1, scope.Counter("drops"))
+		now    = time.Unix(0, 0)
+		events = []ElectionEvent{
+			{Type: ResignedEvent, Timestamp: now},
+			{Type: LeaderLostEvent, Timestamp: now.Add(time.Second)},
+			{Type: ResignedEvent, Timestamp: now.Add(2 * time.Second)},
+		}
+	)
+
+	// Closing the stream stops delivering to a subscriber which has stopped
+	// receiving, keeping the buffered events and dropping the others, even if
+	// they are terminal.
+	eventsCh := stream.Subscribe()
+	for _, event := range events {
+		stream.Emit(event)
+	}
+	stream.Close()
+	for {
+		if counter, ok := scope.Snapshot().Counters()["drops+"]; ok && counter.Value() == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	var received []ElectionEvent
+	for event := range eventsCh {
+		received = append(received, event)
+	}
+	require.Equal(t, events[:1], received)
+}
+
+func TestElectionEventTypeString(t *testing.T) {
+	require.Equal(t, "campaign-started", CampaignStartedEvent.String())
+	require.Equal(t, "leader-acquired", LeaderAcquiredEvent.String())
+	require.Equal(t, "leader-lost", LeaderLostEvent.String())
+	require.Equal(t, "resigned", ResignedEvent.String())
+	require.Equal(t, "keepalive-failed", KeepaliveFailedEvent.String())
+	require.Equal(t, "unknown", UnknownElectionEvent.String())
+}
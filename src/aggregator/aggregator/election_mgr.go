@@ -25,6 +25,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -40,20 +43,47 @@ import (
 	"go.uber.org/zap"
 )
 
+// LeadershipProvider provides the leadership and campaigning state of the
+// instance, which is all the flush subsystem needs to know about elections.
+type LeadershipProvider interface {
+	// IsLeader returns true if the instance holds leadership, including while it
+	// is pending to step down to follower.
+	IsLeader() bool
+
+	// LeaderSince returns the time the instance became the leader, or the zero
+	// time if it is not the leader.
+	LeaderSince() time.Time
+
+	// IsCampaigning returns true if the election manager is actively campaigning,
+	// and false otherwise.
+	IsCampaigning() bool
+
+	// Subscribe watches for changes to the election state of the instance, so
+	// that leadership changes can be reacted to rather than polled for. The
+	// watch only holds the latest election state and should be closed once it
+	// is no longer needed.
+	Subscribe() (watch.Watch, error)
+}
+
 // ElectionManager manages leadership elections.
 type ElectionManager interface {
+	LeadershipProvider
+
 	// Reset resets the election manager.
 	Reset() error
 
-	// Open opens the election manager for a given shard set.
+	// Open opens the election manager for a given shard set. Opening an open
+	// election manager for another shard set, e.g. after the instance has been
+	// reassigned a shard set, releases leadership of the previous shard set
+	// before campaigning for the given one.
 	Open(shardSetID uint32) error
 
 	// ElectionState returns the election state.
 	ElectionState() ElectionState
 
-	// IsCampaigning returns true if the election manager is actively campaigning,
-	// and false otherwise.
-	IsCampaigning() bool
+	// FencingToken returns the id of the latest leadership term, which increases
+	// monotonically with each term, or zero if the instance has never led.
+	FencingToken() int64
 
 	// Resign stops the election and resigns from the ongoing campaign if any, thereby
 	// forcing the current instance to become a follower. If the provided context
@@ -61,6 +91,56 @@ type ElectionManager interface {
 	// election is restarted if necessary.
 	Resign(ctx context.Context) error
 
+	// Reconfigure applies the runtime-adjustable options, namely the campaign, change
+	// and resign retry options, the campaign state check interval, the shard cutoff
+	// check offset, the minimum leadership hold and the quorum guard, to the election
+	// manager. Changes to any other options, including the election options as the
+	// lease is owned by the leader service, are rejected.
+	Reconfigure(opts ElectionManagerOptions) error
+
+	// BackendRevision returns the revision of the leader of the election in the
+	// election backend, e.g. the etcd mod revision of the leader key, which is
+	// useful to correlate elections across instances.
+	BackendRevision() (int64, error)
+
+	// LiveInstances returns the IDs of the instances holding an active session in
+	// the election of the shard set in the election backend, which unlike the
+	// campaigners includes instances not contending for leadership.
+	LiveInstances() ([]string, error)
+
+	// CampaignPreview reports whether campaigning would acquire leadership along
+	// with the current leader, if any, without campaigning or otherwise mutating
+	// any election state. Leadership would only be acquired if the election has
+	// no leader or the instance already leads it.
+	CampaignPreview(ctx context.Context) (wouldAcquire bool, currentLeader string, err error)
+
+	// OpenShards opens an election for the given subset of the shards of the
+	// shard set, led independently of the shard set and of other subsets. This
+	// supports leadership models finer grained than the shard set, and requires
+	// the election manager to be open. A shard may only belong to one subset.
+	OpenShards(shardIDs []uint32) error
+
+	// ShardElectionState returns the election state of the subset the given
+	// shard belongs to.
+	ShardElectionState(shardID uint32) (ElectionState, error)
+
+	// RegisterPreResignHook registers a hook run before resigning leadership on
+	// Resign and Close, e.g. to drain in-flight work. Hooks run in ascending
+	// priority order so lower priorities run first, and hooks with the same
+	// priority run in registration order. Hook errors are logged and do not
+	// prevent resignation.
+	RegisterPreResignHook(priority int, hook PreResignHook)
+
+	// Events subscribes to the stream of election events, returning a channel
+	// receiving the events emitted from then on. Each call returns a separate
+	// subscription. Events are delivered in order and the channel is closed once
+	// the election manager is closed, so callers must keep draining it until
+	// then. If the caller falls behind by more than a bounded number of events,
+	// the oldest undelivered ones are dropped, except for leader lost and
+	// resigned events. Undelivered events beyond the bound are also dropped once
+	// the election manager is closed.
+	Events() <-chan ElectionEvent
+
 	// Close the election manager.
 	Close() error
 }
@@ -73,16 +153,27 @@ var (
 	errElectionManagerAlreadyOpenOrClosed = errors.New("election manager is already open or closed")
 	errElectionManagerNotOpenOrClosed     = errors.New("election manager is not open or closed")
 	errElectionManagerOpen                = errors.New("election manager is open")
+	errElectionManagerChangingShardSet    = errors.New("election manager is changing shard set")
 	errLeaderNotChanged                   = errors.New("leader has not changed")
 	errUnexpectedShardCutoverCutoffTimes  = errors.New("unexpected shard cutover and/or cutoff times")
+	errStepDownCancelled                  = errors.New("step-down cancelled during min leadership hold")
+	errNoShardsInSubset                   = errors.New("no shards in subset")
 )
 
+func newReconfigureError(option string) error {
+	return fmt.Errorf("%s cannot be changed at runtime", option)
+}
+
 type electionManagerState int
 
 const (
 	electionManagerNotOpen electionManagerState = iota
 	electionManagerOpen
 	electionManagerClosed
+	// electionManagerChangingShardSet is the state while the election manager
+	// moves over to the election of another shard set, during which it can
+	// neither be opened nor closed.
+	electionManagerChangingShardSet
 )
 
 // ElectionState is the election state.
@@ -175,11 +266,13 @@ type electionManagerMetrics struct {
 	campaignUnknownState                   tally.Counter
 	campaignCheckErrors                    tally.Counter
 	campaignCheckHasActiveShards           tally.Counter
+	campaignCheckNoShards                  tally.Counter
 	campaignCheckNoCutoverShards           tally.Counter
 	campaignCheckFlushTimesErrors          tally.Counter
 	campaignCheckReplacementInstanceErrors tally.Counter
 	campaignCheckHasReplacementInstance    tally.Counter
 	campaignCheckUnexpectedShardTimes      tally.Counter
+	campaignCheckQuorumLoss                tally.Counter
 	verifyLeaderErrors                     tally.Counter
 	verifyLeaderNotChanged                 tally.Counter
 	verifyCampaignDisabled                 tally.Counter
@@ -187,18 +280,32 @@ type electionManagerMetrics struct {
 	verifyPlacementErrors                  tally.Counter
 	verifyInstanceErrors                   tally.Counter
 	verifyLeaderNotInPlacement             tally.Counter
+	deferredStepDowns                      tally.Counter
+	quorumLossStepDowns                    tally.Counter
 	followerResign                         tally.Counter
 	resignTimeout                          tally.Counter
 	resignErrors                           tally.Counter
 	resignOnCloseSuccess                   tally.Counter
 	resignOnCloseErrors                    tally.Counter
+	shardSetChangeResigns                  tally.Counter
+	shardSetChangeResignErrors             tally.Counter
 	resignOnClose                          tally.Gauge
+	preResignHookErrors                    tally.Counter
 	followerToPendingFollower              tally.Counter
 	electionState                          tally.Gauge
 	campaignState                          tally.Gauge
 	campaigning                            tally.Gauge
 	leadersWithActiveShards                tally.Gauge
 	followersWithActiveShards              tally.Gauge
+	termsStarted                           tally.Counter
+	termDuration                           tally.Timer
+	termRenewals                           tally.Gauge
+	termLongestRenewalGap                  tally.Timer
+	termRenewalCheckErrors                 tally.Counter
+	term                                   tally.Gauge
+	auditDrops                             tally.Counter
+	auditRecordErrors                      tally.Counter
+	eventDrops                             tally.Counter
 }
 
 func newElectionManagerMetrics(scope tally.Scope) electionManagerMetrics {
@@ -206,17 +313,21 @@ func newElectionManagerMetrics(scope tally.Scope) electionManagerMetrics {
 	campaignCheckScope := scope.SubScope("campaign-check")
 	verifyScope := scope.SubScope("verify")
 	resignScope := scope.SubScope("resign")
+	termScope := scope.SubScope("term")
+	auditScope := scope.SubScope("audit")
 	return electionManagerMetrics{
 		campaignCreateErrors:                   campaignScope.Counter("create-errors"),
 		campaignErrors:                         campaignScope.Counter("errors"),
 		campaignUnknownState:                   campaignScope.Counter("unknown-state"),
 		campaignCheckErrors:                    campaignCheckScope.Counter("errors"),
 		campaignCheckHasActiveShards:           campaignCheckScope.Counter("has-active-shards"),
+		campaignCheckNoShards:                  campaignCheckScope.Counter("no-shards"),
 		campaignCheckNoCutoverShards:           campaignCheckScope.Counter("no-cutover-shards"),
 		campaignCheckFlushTimesErrors:          campaignCheckScope.Counter("flush-times-errors"),
 		campaignCheckReplacementInstanceErrors: campaignCheckScope.Counter("repl-instance-errors"),
 		campaignCheckHasReplacementInstance:    campaignCheckScope.Counter("has-repl-instance"),
 		campaignCheckUnexpectedShardTimes:      campaignCheckScope.Counter("unexpected-shard-times"),
+		campaignCheckQuorumLoss:                campaignCheckScope.Counter("quorum-loss"),
 		verifyLeaderErrors:                     verifyScope.Counter("leader-errors"),
 		verifyLeaderNotChanged:                 verifyScope.Counter("leader-not-changed"),
 		verifyCampaignDisabled:                 verifyScope.Counter("campaign-disabled"),
@@ -224,18 +335,32 @@ func newElectionManagerMetrics(scope tally.Scope) electionManagerMetrics {
 		verifyPlacementErrors:                  verifyScope.Counter("placement-errors"),
 		verifyInstanceErrors:                   verifyScope.Counter("instance-errors"),
 		verifyLeaderNotInPlacement:             verifyScope.Counter("leader-not-in-placement"),
+		deferredStepDowns:                      resignScope.Counter("deferred-step-downs"),
+		quorumLossStepDowns:                    resignScope.Counter("quorum-loss-step-downs"),
 		followerResign:                         resignScope.Counter("follower-resign"),
 		resignTimeout:                          resignScope.Counter("timeout"),
 		resignErrors:                           resignScope.Counter("errors"),
 		resignOnCloseSuccess:                   resignScope.Counter("on-close-success"),
 		resignOnCloseErrors:                    resignScope.Counter("on-close-errors"),
+		shardSetChangeResigns:                  resignScope.Counter("on-shard-set-change"),
+		shardSetChangeResignErrors:             resignScope.Counter("on-shard-set-change-errors"),
 		resignOnClose:                          resignScope.Gauge("on-close"),
+		preResignHookErrors:                    resignScope.Counter("pre-resign-hook-errors"),
 		followerToPendingFollower:              scope.Counter("follower-to-pending-follower"),
 		electionState:                          scope.Gauge("election-state"),
 		campaignState:                          scope.Gauge("campaign-state"),
 		campaigning:                            scope.Gauge("campaigning"),
 		leadersWithActiveShards:                scope.Gauge("leaders-with-active-shards"),
 		followersWithActiveShards:              scope.Gauge("follower-with-active-shards"),
+		termsStarted:                           termScope.Counter("started"),
+		termDuration:                           termScope.Timer("duration"),
+		termRenewals:                           termScope.Gauge("renewals"),
+		termLongestRenewalGap:                  termScope.Timer("longest-renewal-gap"),
+		termRenewalCheckErrors:                 termScope.Counter("renewal-check-errors"),
+		term:                                   termScope.Gauge("id"),
+		auditDrops:                             auditScope.Counter("drops"),
+		auditRecordErrors:                      auditScope.Counter("record-errors"),
+		eventDrops:                             scope.SubScope("events").Counter("drops"),
 	}
 }
 
@@ -246,72 +371,156 @@ type goalState struct {
 
 type campaignIsEnabledFn func() (bool, error)
 
+// PreResignHook is run before resigning leadership.
+type PreResignHook func() error
+
+type prioritizedPreResignHook struct {
+	priority int
+	hook     PreResignHook
+}
+
+// leaderTerm tracks lease renewals over a single leadership term, i.e. the
+// span between acquiring and losing leadership. Terms are numbered with a
+// monotonically increasing id that serves as a local fencing token.
+//
+// NB: the leader client keeps the lease alive internally, so a renewal is
+// observed whenever the election still has the instance as its leader when
+// checked, which fails once the lease is no longer kept alive.
+type leaderTerm struct {
+	id               int64
+	startNanos       int64
+	lastRenewalNanos int64
+	renewals         int
+	longestGap       time.Duration
+}
+
+func (t *leaderTerm) renew(nowNanos int64) {
+	t.observeGap(nowNanos)
+	t.lastRenewalNanos = nowNanos
+	t.renewals++
+}
+
+func (t *leaderTerm) observeGap(nowNanos int64) {
+	if gap := time.Duration(nowNanos - t.lastRenewalNanos); gap > t.longestGap {
+		t.longestGap = gap
+	}
+}
+
 // nolint: maligned
 type electionManager struct {
 	sync.RWMutex
 	sync.WaitGroup
 
-	nowFn                      clock.NowFn
-	logger                     *zap.Logger
-	reportInterval             time.Duration
-	campaignOpts               services.CampaignOptions
-	electionOpts               services.ElectionOptions
+	opts              ElectionManagerOptions
+	nowFn             clock.NowFn
+	afterFn           AfterFn
+	logger            *zap.Logger
+	reportInterval    time.Duration
+	campaignOpts      services.CampaignOptions
+	electionOpts      services.ElectionOptions
+	electionKeyFmt    string
+	electionKeyPrefix string
+	leaderService     services.LeaderService
+	electionBackend   ElectionBackend
+	electionStrategy  ElectionStrategy
+	leaderValue       string
+	placementManager  PlacementManager
+	flushTimesManager FlushTimesManager
+	flushTimesChecker flushTimesChecker
+	auditSink         ElectionAuditSink
+	auditCh           chan ElectionAuditRecord
+
+	// NB: the following can be changed at runtime and are guarded by
+	// reconfigureLock once the election manager is open.
+	reconfigureLock            sync.RWMutex
+	reconfiguredCh             chan struct{}
 	campaignRetrier            retry.Retrier
 	changeRetrier              retry.Retrier
 	resignRetrier              retry.Retrier
-	electionKeyFmt             string
-	leaderService              services.LeaderService
-	leaderValue                string
-	placementManager           PlacementManager
-	flushTimesManager          FlushTimesManager
-	flushTimesChecker          flushTimesChecker
 	campaignStateCheckInterval time.Duration
 	shardCutoffCheckOffset     time.Duration
+	minLeadershipHold          time.Duration
+	quorumGuardEnabled         bool
 
 	state                  electionManagerState
 	doneCh                 chan struct{}
 	campaigning            int32
 	campaignStateWatchable watch.Watchable
 	electionKey            string
+	shardSetID             uint32
+	shards                 []uint32
 	electionStateWatchable watch.Watchable
 	nextGoalStateID        int64
 	goalStateLock          *sync.RWMutex
 	goalStateWatchable     watch.Watchable
 	campaignIsEnabledFn    campaignIsEnabledFn
 	resignOnClose          int32
+	changingShardSet       int32
+	resignRequested        int32
+	quorumLost             int32
+	leaderSinceNanos       int64
+	term                   int64
+	currentTerm            *leaderTerm
+	events                 *electionEventStream
 	sleepFn                sleepFn
+	shardElections         map[uint32]*electionManager
+	preResignHooksLock     sync.RWMutex
+	preResignHooks         []prioritizedPreResignHook
 	metrics                electionManagerMetrics
 }
 
+// ElectionID returns the ID of the election of the given shard set held by the
+// election managers created with the given options.
+func ElectionID(opts ElectionManagerOptions, shardSetID uint32) string {
+	return newElectionID(opts.ElectionKeyPrefix(), opts.ElectionKeyFmt(), shardSetID)
+}
+
+func newElectionID(prefix, keyFmt string, shardSetID uint32) string {
+	return prefix + fmt.Sprintf(keyFmt, shardSetID)
+}
+
 // NewElectionManager creates a new election manager.
 func NewElectionManager(opts ElectionManagerOptions) ElectionManager {
 	instrumentOpts := opts.InstrumentOptions()
 	scope := instrumentOpts.MetricsScope()
 	campaignOpts := opts.CampaignOptions()
-	campaignRetrier := retry.NewRetrier(opts.CampaignRetryOptions().SetForever(true))
-	changeRetrier := retry.NewRetrier(opts.ChangeRetryOptions().SetForever(true))
-	resignRetrier := retry.NewRetrier(opts.ResignRetryOptions().SetForever(true))
 	mgr := &electionManager{
+		opts:                       opts,
 		nowFn:                      opts.ClockOptions().NowFn(),
+		afterFn:                    opts.AfterFn(),
 		logger:                     instrumentOpts.Logger(),
 		reportInterval:             instrumentOpts.ReportInterval(),
 		campaignOpts:               campaignOpts,
 		electionOpts:               opts.ElectionOptions(),
-		campaignRetrier:            campaignRetrier,
-		changeRetrier:              changeRetrier,
-		resignRetrier:              resignRetrier,
 		electionKeyFmt:             opts.ElectionKeyFmt(),
+		electionKeyPrefix:          opts.ElectionKeyPrefix(),
 		leaderService:              opts.LeaderService(),
+		electionBackend:            opts.ElectionBackend(),
+		electionStrategy:           opts.ElectionStrategy(),
 		leaderValue:                campaignOpts.LeaderValue(),
 		placementManager:           opts.PlacementManager(),
 		flushTimesManager:          opts.FlushTimesManager(),
 		flushTimesChecker:          newFlushTimesChecker(scope.SubScope("campaign-check")),
+		auditSink:                  opts.AuditSink(),
+		auditCh:                    make(chan ElectionAuditRecord, opts.AuditBufferSize()),
 		campaignStateCheckInterval: opts.CampaignStateCheckInterval(),
 		shardCutoffCheckOffset:     opts.ShardCutoffCheckOffset(),
-		sleepFn:                    time.Sleep,
+		minLeadershipHold:          opts.MinLeadershipHold(),
+		quorumGuardEnabled:         opts.QuorumGuardEnabled(),
+		reconfiguredCh:             make(chan struct{}, 1),
 		metrics:                    newElectionManagerMetrics(scope),
 	}
+	if mgr.electionBackend == nil {
+		mgr.electionBackend = NewLeaderServiceElectionBackend(mgr.leaderService)
+	}
+	if mgr.electionStrategy == nil {
+		mgr.electionStrategy = NewLeaseElectionStrategy(mgr.leaderService)
+	}
+	mgr.campaignRetrier = mgr.newRetrier(opts.CampaignRetryOptions())
+	mgr.changeRetrier = mgr.newRetrier(opts.ChangeRetryOptions())
+	mgr.resignRetrier = mgr.newRetrier(opts.ResignRetryOptions())
 	mgr.campaignIsEnabledFn = mgr.campaignIsEnabled
+	mgr.sleepFn = mgr.sleep
 	mgr.Lock()
 	mgr.resetWithLock()
 	mgr.Unlock()
@@ -325,7 +534,7 @@ func (mgr *electionManager) Reset() error {
 	switch mgr.state {
 	case electionManagerNotOpen:
 		return nil
-	case electionManagerOpen:
+	case electionManagerOpen, electionManagerChangingShardSet:
 		return errElectionManagerOpen
 	default:
 		mgr.resetWithLock()
@@ -335,12 +544,105 @@ func (mgr *electionManager) Reset() error {
 
 func (mgr *electionManager) Open(shardSetID uint32) error {
 	mgr.Lock()
+	if mgr.state == electionManagerOpen && mgr.shardSetID != shardSetID {
+		mgr.Unlock()
+		return mgr.changeShardSet(shardSetID)
+	}
 	defer mgr.Unlock()
 
+	if mgr.state == electionManagerChangingShardSet {
+		return errElectionManagerChangingShardSet
+	}
 	if mgr.state != electionManagerNotOpen {
 		return errElectionManagerAlreadyOpenOrClosed
 	}
-	mgr.electionKey = fmt.Sprintf(mgr.electionKeyFmt, shardSetID)
+	mgr.shardSetID = shardSetID
+	return mgr.openWithLock(newElectionID(mgr.electionKeyPrefix, mgr.electionKeyFmt, shardSetID))
+}
+
+// changeShardSet moves the election manager over to the election of the given
+// shard set. Campaigning for the current shard set is stopped and its leadership
+// released before campaigning for the given shard set, so that leadership of a
+// shard set no longer owned is not held until its lease expires. The election
+// manager can neither be opened nor closed until the change completes.
+func (mgr *electionManager) changeShardSet(shardSetID uint32) error {
+	wasLeader := mgr.ElectionState() != FollowerState
+	if wasLeader {
+		mgr.runPreResignHooks("shard set changed")
+	}
+
+	mgr.Lock()
+	if mgr.state == electionManagerChangingShardSet {
+		mgr.Unlock()
+		return errElectionManagerChangingShardSet
+	}
+	if mgr.state != electionManagerOpen {
+		mgr.Unlock()
+		return errElectionManagerNotOpenOrClosed
+	}
+	prevShardSetID, prevElectionKey := mgr.shardSetID, mgr.electionKey
+	// NB: the campaign loop skips resigning asynchronously on exit as the
+	// previous shard set is resigned from below before campaigning again.
+	atomic.StoreInt32(&mgr.changingShardSet, 1)
+	mgr.state = electionManagerChangingShardSet
+	close(mgr.doneCh)
+	subsetMgrs := mgr.uniqueShardElectionsWithLock()
+	mgr.Unlock()
+
+	for _, subsetMgr := range subsetMgrs {
+		if err := subsetMgr.Close(); err != nil {
+			mgr.logError("shard subset election manager close error", err)
+		}
+	}
+	mgr.Wait()
+	atomic.StoreInt32(&mgr.changingShardSet, 0)
+
+	reason := fmt.Sprintf("shard set changed from %d to %d", prevShardSetID, shardSetID)
+	ctx, cancel := context.WithTimeout(context.Background(), mgr.electionOpts.ResignTimeout())
+	defer cancel()
+	mgr.reconfigureLock.RLock()
+	resignRetrier := mgr.resignRetrier
+	mgr.reconfigureLock.RUnlock()
+	// NB: the previous lease expires on its own if resigning keeps failing, so
+	// campaigning for the new shard set goes ahead regardless.
+	if err := resignRetrier.AttemptWhile(func(int) bool {
+		return ctx.Err() == nil
+	}, func() error {
+		return mgr.electionStrategy.Resign(prevElectionKey)
+	}); err != nil {
+		mgr.metrics.shardSetChangeResignErrors.Inc(1)
+		mgr.logError("shard set change resign error", err)
+	} else {
+		mgr.metrics.shardSetChangeResigns.Inc(1)
+	}
+
+	mgr.Lock()
+	defer mgr.Unlock()
+
+	if wasLeader {
+		mgr.emitEvent(ResignedEvent, reason)
+		mgr.emitEvent(LeaderLostEvent, reason)
+	}
+	mgr.logger.Info(reason)
+	mgr.doneCh = make(chan struct{})
+	atomic.StoreInt32(&mgr.campaigning, 0)
+	atomic.StoreInt64(&mgr.leaderSinceNanos, 0)
+	mgr.electionStateWatchable.Update(FollowerState)
+	// NB: the goroutines watching the previous goal states have exited already.
+	mgr.goalStateWatchable.Close()
+	mgr.goalStateWatchable = watch.NewWatchable()
+	mgr.shardElections = make(map[uint32]*electionManager)
+	mgr.shardSetID = shardSetID
+	electionKey := newElectionID(mgr.electionKeyPrefix, mgr.electionKeyFmt, shardSetID)
+	if err := mgr.openWithLock(electionKey); err != nil {
+		mgr.state = electionManagerClosed
+		return err
+	}
+	return nil
+}
+
+func (mgr *electionManager) openWithLock(electionKey string) error {
+	mgr.electionKey = electionKey
 	_, stateChangeWatch, err := mgr.goalStateWatchable.Watch()
 	if err != nil {
 		return err
@@ -355,12 +657,16 @@ func (mgr *electionManager) Open(shardSetID uint32) error {
 	}
 	mgr.state = electionManagerOpen
 
-	mgr.Add(5)
-	go mgr.watchGoalStateChanges(stateChangeWatch)
-	go mgr.verifyPendingFollower(verifyWatch)
-	go mgr.checkCampaignStateLoop()
-	go mgr.campaignLoop(campaignStateWatch)
-	go mgr.reportMetrics()
+	// NB: the goroutines are handed the done channel as it is replaced when the
+	// shard set changes.
+	doneCh := mgr.doneCh
+	mgr.Add(6)
+	go mgr.watchGoalStateChanges(stateChangeWatch, doneCh)
+	go mgr.verifyPendingFollower(verifyWatch, doneCh)
+	go mgr.checkCampaignStateLoop(doneCh)
+	go mgr.campaignLoop(campaignStateWatch, doneCh)
+	go mgr.reportMetrics(doneCh)
+	go mgr.recordAudits(doneCh)
 
 	mgr.logger.Info("election manager opened successfully")
 	return nil
@@ -374,6 +680,27 @@ func (mgr *electionManager) IsCampaigning() bool {
 	return mgr.campaignState() == campaignEnabled
 }
 
+func (mgr *electionManager) IsLeader() bool {
+	return mgr.ElectionState() != FollowerState
+}
+
+func (mgr *electionManager) Subscribe() (watch.Watch, error) {
+	_, w, err := mgr.electionStateWatchable.Watch()
+	return w, err
+}
+
+func (mgr *electionManager) LeaderSince() time.Time {
+	leaderSinceNanos := atomic.LoadInt64(&mgr.leaderSinceNanos)
+	if leaderSinceNanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, leaderSinceNanos)
+}
+
+func (mgr *electionManager) FencingToken() int64 {
+	return atomic.LoadInt64(&mgr.term)
+}
+
 func (mgr *electionManager) Resign(ctx context.Context) error {
 	mgr.RLock()
 	state := mgr.state
@@ -391,6 +718,17 @@ func (mgr *electionManager) Resign(ctx context.Context) error {
 		mgr.metrics.followerResign.Inc(1)
 		return nil
 	}
+	// NB: only voluntary step-downs are deferred by the min leadership hold,
+	// whereas step-downs forced by the shard cutoff or quorum loss are not.
+	if !mgr.deferStepDown(ctx.Done()) {
+		if err := ctx.Err(); err != nil {
+			mgr.metrics.resignTimeout.Inc(1)
+			mgr.logError("resign error", err)
+			return err
+		}
+		return errStepDownCancelled
+	}
+	mgr.runPreResignHooks("resign requested")
 
 	ctxNotDone := func(int) bool {
 		select {
@@ -401,7 +739,7 @@ func (mgr *electionManager) Resign(ctx context.Context) error {
 		}
 	}
 	// Log the context error because the error returned from the retrier is not helpful.
-	if err := mgr.resignWhile(ctxNotDone); err != nil {
+	if err := mgr.resignWhile(ctxNotDone, "resign requested"); err != nil {
 		mgr.metrics.resignTimeout.Inc(1)
 		mgr.logError("resign error", ctx.Err())
 		return ctx.Err()
@@ -421,24 +759,242 @@ func (mgr *electionManager) Resign(ctx context.Context) error {
 	}
 }
 
+func (mgr *electionManager) Reconfigure(opts ElectionManagerOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.LeaderService() != mgr.leaderService {
+		return newReconfigureError("leader service")
+	}
+	if backend := opts.ElectionBackend(); backend != nil && backend != mgr.electionBackend {
+		return newReconfigureError("election backend")
+	}
+	if strategy := opts.ElectionStrategy(); strategy != nil && strategy != mgr.electionStrategy {
+		return newReconfigureError("election strategy")
+	}
+	if opts.ElectionKeyFmt() != mgr.electionKeyFmt {
+		return newReconfigureError("election key format")
+	}
+	if opts.ElectionKeyPrefix() != mgr.electionKeyPrefix {
+		return newReconfigureError("election key prefix")
+	}
+	if opts.CampaignOptions().LeaderValue() != mgr.leaderValue {
+		return newReconfigureError("leader value")
+	}
+	if opts.PlacementManager() != mgr.placementManager {
+		return newReconfigureError("placement manager")
+	}
+	if opts.FlushTimesManager() != mgr.flushTimesManager {
+		return newReconfigureError("flush times manager")
+	}
+	if opts.AuditSink() != mgr.auditSink {
+		return newReconfigureError("audit sink")
+	}
+	if opts.AuditBufferSize() != cap(mgr.auditCh) {
+		return newReconfigureError("audit buffer size")
+	}
+	if electionOpts := opts.ElectionOptions(); electionOpts.TTLSecs() != mgr.electionOpts.TTLSecs() ||
+		electionOpts.LeaderTimeout() != mgr.electionOpts.LeaderTimeout() ||
+		electionOpts.ResignTimeout() != mgr.electionOpts.ResignTimeout() {
+		return newReconfigureError("election options")
+	}
+
+	mgr.reconfigureLock.Lock()
+	mgr.campaignRetrier = mgr.newRetrier(opts.CampaignRetryOptions())
+	mgr.changeRetrier = mgr.newRetrier(opts.ChangeRetryOptions())
+	mgr.resignRetrier = mgr.newRetrier(opts.ResignRetryOptions())
+	mgr.campaignStateCheckInterval = opts.CampaignStateCheckInterval()
+	mgr.shardCutoffCheckOffset = opts.ShardCutoffCheckOffset()
+	mgr.minLeadershipHold = opts.MinLeadershipHold()
+	mgr.quorumGuardEnabled = opts.QuorumGuardEnabled()
+	mgr.reconfigureLock.Unlock()
+
+	// NB: retriers and the shard cutoff check offset are picked up on their next
+	// use, while the campaign state check loop needs to be notified to reset its
+	// ticker. Retries already in progress continue with their previous options.
+	select {
+	case mgr.reconfiguredCh <- struct{}{}:
+	default:
+	}
+	mgr.RLock()
+	subsetMgrs := mgr.uniqueShardElectionsWithLock()
+	mgr.RUnlock()
+	for _, subsetMgr := range subsetMgrs {
+		if err := subsetMgr.Reconfigure(opts); err != nil {
+			return err
+		}
+	}
+	mgr.logger.Info("election manager reconfigured")
+	return nil
+}
+
+func (mgr *electionManager) BackendRevision() (int64, error) {
+	mgr.RLock()
+	state := mgr.state
+	electionKey := mgr.electionKey
+	mgr.RUnlock()
+	if state != electionManagerOpen {
+		return 0, errElectionManagerNotOpenOrClosed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mgr.electionOpts.LeaderTimeout())
+	defer cancel()
+	return mgr.electionBackend.Revision(ctx, electionKey)
+}
+
+func (mgr *electionManager) LiveInstances() ([]string, error) {
+	mgr.RLock()
+	state := mgr.state
+	electionKey := mgr.electionKey
+	mgr.RUnlock()
+	if state != electionManagerOpen {
+		return nil, errElectionManagerNotOpenOrClosed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mgr.electionOpts.LeaderTimeout())
+	defer cancel()
+	return mgr.electionBackend.LiveInstances(ctx, electionKey)
+}
+
+func (mgr *electionManager) CampaignPreview(ctx context.Context) (bool, string, error) {
+	mgr.RLock()
+	state := mgr.state
+	electionKey := mgr.electionKey
+	mgr.RUnlock()
+	if state != electionManagerOpen {
+		return false, "", errElectionManagerNotOpenOrClosed
+	}
+
+	leader, err := mgr.electionBackend.Leader(ctx, electionKey)
+	if err == ErrNoElectionLeader {
+		return true, "", nil
+	}
+	if err != nil {
+		return false, "", err
+	}
+	return leader == mgr.leaderValue, leader, nil
+}
+
+func (mgr *electionManager) OpenShards(shardIDs []uint32) error {
+	if len(shardIDs) == 0 {
+		return errNoShardsInSubset
+	}
+	sorted := make([]uint32, len(shardIDs))
+	copy(sorted, shardIDs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mgr.Lock()
+	defer mgr.Unlock()
+
+	if mgr.state != electionManagerOpen {
+		return errElectionManagerNotOpenOrClosed
+	}
+	ids := make([]string, 0, len(sorted))
+	for i, shardID := range sorted {
+		if _, exists := mgr.shardElections[shardID]; exists || (i > 0 && sorted[i-1] == shardID) {
+			return fmt.Errorf("shard %d already belongs to a subset", shardID)
+		}
+		ids = append(ids, strconv.FormatUint(uint64(shardID), 10))
+	}
+
+	// NB: the subset election key is a sibling rather than a child of the shard
+	// set election key, since elections are held against all keys under it.
+	subset := strings.Join(ids, "_")
+	instrumentOpts := mgr.opts.InstrumentOptions()
+	subsetOpts := mgr.opts.SetInstrumentOptions(instrumentOpts.
+		SetLogger(instrumentOpts.Logger().With(zap.String("shards", subset))).
+		SetMetricsScope(instrumentOpts.MetricsScope().Tagged(map[string]string{
+			"shards": subset,
+		})))
+	subsetMgr := NewElectionManager(subsetOpts).(*electionManager)
+	// NB: subsets campaign whenever the shard set would.
+	subsetMgr.campaignIsEnabledFn = mgr.campaignIsEnabledFn
+	subsetMgr.shardSetID = mgr.shardSetID
+	subsetMgr.shards = sorted
+	subsetMgr.Lock()
+	err := subsetMgr.openWithLock(mgr.electionKey + "-shards-" + subset)
+	subsetMgr.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, shardID := range sorted {
+		mgr.shardElections[shardID] = subsetMgr
+	}
+	return nil
+}
+
+func (mgr *electionManager) ShardElectionState(shardID uint32) (ElectionState, error) {
+	mgr.RLock()
+	state := mgr.state
+	subsetMgr, exists := mgr.shardElections[shardID]
+	mgr.RUnlock()
+	if state != electionManagerOpen {
+		return UnknownState, errElectionManagerNotOpenOrClosed
+	}
+	if !exists {
+		return UnknownState, fmt.Errorf("shard %d does not belong to a subset", shardID)
+	}
+	return subsetMgr.ElectionState(), nil
+}
+
+func (mgr *electionManager) Events() <-chan ElectionEvent {
+	mgr.RLock()
+	events := mgr.events
+	mgr.RUnlock()
+	return events.Subscribe()
+}
+
+func (mgr *electionManager) RegisterPreResignHook(priority int, hook PreResignHook) {
+	mgr.preResignHooksLock.Lock()
+	defer mgr.preResignHooksLock.Unlock()
+
+	// NB: copy on write as hooks being run iterate over the previous slice.
+	hooks := make([]prioritizedPreResignHook, 0, len(mgr.preResignHooks)+1)
+	hooks = append(hooks, mgr.preResignHooks...)
+	hooks = append(hooks, prioritizedPreResignHook{priority: priority, hook: hook})
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].priority < hooks[j].priority
+	})
+	mgr.preResignHooks = hooks
+}
+
 func (mgr *electionManager) Close() error {
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	// NB: run the hooks before closing as closing resigns from the campaign.
+	if state == electionManagerOpen && mgr.ElectionState() != FollowerState {
+		mgr.runPreResignHooks("election manager closed")
+	}
+
 	mgr.Lock()
+	if mgr.state == electionManagerChangingShardSet {
+		mgr.Unlock()
+		return errElectionManagerChangingShardSet
+	}
 	if mgr.state != electionManagerOpen {
 		mgr.Unlock()
 		return errElectionManagerNotOpenOrClosed
 	}
 	close(mgr.doneCh)
 	mgr.state = electionManagerClosed
+	subsetMgrs := mgr.uniqueShardElectionsWithLock()
 	mgr.Unlock()
 
+	for _, subsetMgr := range subsetMgrs {
+		if err := subsetMgr.Close(); err != nil {
+			mgr.logError("shard subset election manager close error", err)
+		}
+	}
 	mgr.Wait()
+	mgr.events.Close()
 	mgr.campaignStateWatchable.Close()
 	mgr.electionStateWatchable.Close()
 	mgr.goalStateWatchable.Close()
 	return nil
 }
 
-func (mgr *electionManager) watchGoalStateChanges(watch watch.Watch) {
+func (mgr *electionManager) watchGoalStateChanges(watch watch.Watch, doneCh <-chan struct{}) {
 	defer func() {
 		watch.Close()
 		mgr.Done()
@@ -446,7 +1002,7 @@ func (mgr *electionManager) watchGoalStateChanges(watch watch.Watch) {
 
 	for {
 		select {
-		case <-mgr.doneCh:
+		case <-doneCh:
 			return
 		case <-watch.C():
 			mgr.processGoalState(watch.Get().(goalState))
@@ -469,11 +1025,22 @@ func (mgr *electionManager) processGoalState(goalState goalState) {
 		mgr.metrics.followerToPendingFollower.Inc(1)
 		return
 	}
+	if newState == LeaderState {
+		atomic.StoreInt64(&mgr.leaderSinceNanos, mgr.nowFn().UnixNano())
+	} else if currState == LeaderState {
+		atomic.StoreInt64(&mgr.leaderSinceNanos, 0)
+	}
 	mgr.electionStateWatchable.Update(newState)
-	mgr.logger.Info(fmt.Sprintf("election state changed from %v to %v", currState, newState))
+	reason := fmt.Sprintf("election state changed from %v to %v", currState, newState)
+	mgr.logger.Info(reason)
+	if newState == LeaderState {
+		mgr.emitEvent(LeaderAcquiredEvent, reason)
+	} else if currState == LeaderState {
+		mgr.emitEvent(LeaderLostEvent, reason)
+	}
 }
 
-func (mgr *electionManager) verifyPendingFollower(watch watch.Watch) {
+func (mgr *electionManager) verifyPendingFollower(watch watch.Watch, doneCh <-chan struct{}) {
 	defer func() {
 		watch.Close()
 		mgr.Done()
@@ -481,7 +1048,7 @@ func (mgr *electionManager) verifyPendingFollower(watch watch.Watch) {
 
 	for {
 		select {
-		case <-mgr.doneCh:
+		case <-doneCh:
 			return
 		case <-watch.C():
 		}
@@ -504,8 +1071,11 @@ func (mgr *electionManager) verifyPendingFollower(watch watch.Watch) {
 		}
 
 		// Do not change state if the follower state cannot be verified.
-		if verifyErr := mgr.changeRetrier.AttemptWhile(continueFn, func() error {
-			leader, err := mgr.leaderService.Leader(mgr.electionKey)
+		mgr.reconfigureLock.RLock()
+		changeRetrier := mgr.changeRetrier
+		mgr.reconfigureLock.RUnlock()
+		if verifyErr := changeRetrier.AttemptWhile(continueFn, func() error {
+			leader, err := mgr.electionStrategy.Leader(mgr.electionKey)
 			if err != nil {
 				mgr.metrics.verifyLeaderErrors.Inc(1)
 				mgr.logError("error determining the leader", err)
@@ -586,17 +1156,28 @@ func (mgr *electionManager) checkCampaignState() {
 	mgr.processCampaignStateChange(newState)
 }
 
-func (mgr *electionManager) checkCampaignStateLoop() {
+func (mgr *electionManager) checkCampaignStateLoop(doneCh <-chan struct{}) {
 	defer mgr.Done()
 
-	ticker := time.NewTicker(mgr.campaignStateCheckInterval)
-	defer ticker.Stop()
+	mgr.reconfigureLock.RLock()
+	checkInterval := mgr.campaignStateCheckInterval
+	mgr.reconfigureLock.RUnlock()
+	checkCh := mgr.afterFn(checkInterval)
 
 	for {
 		mgr.checkCampaignState()
 		select {
-		case <-ticker.C:
-		case <-mgr.doneCh:
+		case <-checkCh:
+			checkCh = mgr.afterFn(checkInterval)
+		case <-mgr.reconfiguredCh:
+			mgr.reconfigureLock.RLock()
+			newCheckInterval := mgr.campaignStateCheckInterval
+			mgr.reconfigureLock.RUnlock()
+			if newCheckInterval != checkInterval {
+				checkInterval = newCheckInterval
+				checkCh = mgr.afterFn(checkInterval)
+			}
+		case <-doneCh:
 			return
 		}
 	}
@@ -608,6 +1189,7 @@ func (mgr *electionManager) processCampaignStateChange(newState campaignState) {
 		mgr.campaignStateWatchable.Update(campaignEnabled)
 	case campaignDisabled:
 		mgr.campaignStateWatchable.Update(campaignPendingDisabled)
+		wasLeader := mgr.ElectionState() == LeaderState
 		// NB(xichen): if campaign should be disabled, we need to resign from
 		// any ongoing campaign and retry on errors until either we succeed or
 		// the campaign becomes enabled.
@@ -623,8 +1205,11 @@ func (mgr *electionManager) processCampaignStateChange(newState campaignState) {
 			}
 			return !enabled
 		}
-		if err := mgr.resignWhile(shouldResignFn); err == nil {
+		if err := mgr.resignWhile(shouldResignFn, "campaign disabled"); err == nil {
 			mgr.campaignStateWatchable.Update(campaignDisabled)
+			if wasLeader && atomic.LoadInt32(&mgr.quorumLost) == 1 {
+				mgr.metrics.quorumLossStepDowns.Inc(1)
+			}
 		} else if enabled {
 			mgr.campaignStateWatchable.Update(campaignEnabled)
 		}
@@ -632,6 +1217,69 @@ func (mgr *electionManager) processCampaignStateChange(newState campaignState) {
 }
 
 func (mgr *electionManager) campaignIsEnabled() (bool, error) {
+	enabled, err := mgr.campaignIsEnabledForShards()
+	if err != nil || !enabled {
+		return enabled, err
+	}
+	return mgr.hasVisibleQuorum()
+}
+
+// hasVisibleQuorum returns true if the quorum guard is disabled, or if a quorum
+// of the instances in the shard set of the current instance, including itself,
+// hold live sessions in the election.
+func (mgr *electionManager) hasVisibleQuorum() (bool, error) {
+	mgr.reconfigureLock.RLock()
+	quorumGuardEnabled := mgr.quorumGuardEnabled
+	mgr.reconfigureLock.RUnlock()
+	if !quorumGuardEnabled {
+		atomic.StoreInt32(&mgr.quorumLost, 0)
+		return true, nil
+	}
+
+	instance, err := mgr.placementManager.Instance()
+	if err != nil {
+		return false, err
+	}
+	_, p, err := mgr.placementManager.Placement()
+	if err != nil {
+		return false, err
+	}
+	peers := make(map[string]struct{})
+	for _, peer := range p.Instances() {
+		if peer.ShardSetID() == instance.ShardSetID() {
+			peers[peer.ID()] = struct{}{}
+		}
+	}
+	liveInstances, err := mgr.LiveInstances()
+	if err != nil {
+		return false, err
+	}
+	visible := 0
+	for _, id := range liveInstances {
+		if _, exists := peers[id]; exists {
+			visible++
+		}
+	}
+	quorum := len(peers)/2 + 1
+	if visible >= quorum {
+		atomic.StoreInt32(&mgr.quorumLost, 0)
+		return true, nil
+	}
+
+	// NB: step-downs are only counted once leadership is given up as a result of
+	// the campaign being disabled, rather than on every check losing the quorum.
+	atomic.StoreInt32(&mgr.quorumLost, 1)
+	mgr.metrics.campaignCheckQuorumLoss.Inc(1)
+	mgr.logger.Warn("campaign is not enabled, quorum of shard set not visible",
+		zap.Int("visible", visible),
+		zap.Int("quorum", quorum),
+		zap.Int("shardSetInstances", len(peers)))
+	return false, nil
+}
+
+// campaignIsEnabledForShards returns whether campaigning is enabled based on the
+// shards owned by the current instance.
+func (mgr *electionManager) campaignIsEnabledForShards() (bool, error) {
 	// If the current instance is not found in the placement, campaigning is disabled.
 	shards, err := mgr.placementManager.Shards()
 	if err == ErrInstanceNotFoundInPlacement {
@@ -643,11 +1291,23 @@ func (mgr *electionManager) campaignIsEnabled() (bool, error) {
 		return false, err
 	}
 
+	// If the current instance owns no shards, it has no data to flush and as such
+	// campaigning is disabled until it is assigned shards, which is re-evaluated
+	// on every campaign state check.
+	if shards.NumShards() == 0 {
+		mgr.metrics.campaignCheckNoShards.Inc(1)
+		mgr.logger.Warn("campaign is not enabled, no shards owned")
+		return false, nil
+	}
+
 	// NB(xichen): We apply an offset when checking if a shard has been cutoff in order
 	// to detect if the campaign should be stopped before all the shards are cut off.
 	// This is to avoid the situation where the campaign is stopped after the shards
 	// are cut off, and the instance gets promoted to leader before the campaign is stopped,
 	// causing incomplete data to be flushed.
+	mgr.reconfigureLock.RLock()
+	shardCutoffCheckOffset := mgr.shardCutoffCheckOffset
+	mgr.reconfigureLock.RUnlock()
 	var (
 		nowNanos        = mgr.nowFn().UnixNano()
 		noCutoverShards = true
@@ -656,7 +1316,7 @@ func (mgr *electionManager) campaignIsEnabled() (bool, error) {
 	)
 	for _, shard := range allShards {
 		hasCutover := nowNanos >= shard.CutoverNanos()
-		hasNotCutoff := nowNanos < shard.CutoffNanos()-int64(mgr.shardCutoffCheckOffset)
+		hasNotCutoff := nowNanos < shard.CutoffNanos()-int64(shardCutoffCheckOffset)
 		if hasCutover && hasNotCutoff {
 			mgr.metrics.campaignCheckHasActiveShards.Inc(1)
 			if mgr.ElectionState() == LeaderState {
@@ -736,13 +1396,21 @@ func (mgr *electionManager) campaignIsEnabled() (bool, error) {
 	return false, errUnexpectedShardCutoverCutoffTimes
 }
 
-func (mgr *electionManager) campaignLoop(campaignStateWatch watch.Watch) {
+func (mgr *electionManager) campaignLoop(campaignStateWatch watch.Watch, doneCh <-chan struct{}) {
 	defer mgr.Done()
 
-	var campaignStatusCh <-chan campaign.Status
+	var (
+		campaignStatusCh <-chan campaign.Status
+		// errorReported is whether an error status has been received on the
+		// current campaign status channel.
+		errorReported bool
+		// renewalCheckCh fires when the lease renewal of the current leadership
+		// term is due to be checked, and is nil while not leading.
+		renewalCheckCh <-chan time.Time
+	)
 	shouldCampaignFn := func(int) bool {
 		select {
-		case <-mgr.doneCh:
+		case <-doneCh:
 			return false
 		default:
 			campaignState := campaignStateWatch.Get().(campaignState)
@@ -752,9 +1420,12 @@ func (mgr *electionManager) campaignLoop(campaignStateWatch watch.Watch) {
 
 	for {
 		if campaignStatusCh == nil {
-			if err := mgr.campaignRetrier.AttemptWhile(shouldCampaignFn, func() error {
+			mgr.reconfigureLock.RLock()
+			campaignRetrier := mgr.campaignRetrier
+			mgr.reconfigureLock.RUnlock()
+			if err := campaignRetrier.AttemptWhile(shouldCampaignFn, func() error {
 				var err error
-				campaignStatusCh, err = mgr.leaderService.Campaign(mgr.electionKey, mgr.campaignOpts)
+				campaignStatusCh, err = mgr.electionStrategy.Campaign(mgr.electionKey, mgr.campaignOpts)
 				if err == nil {
 					return nil
 				}
@@ -762,14 +1433,16 @@ func (mgr *electionManager) campaignLoop(campaignStateWatch watch.Watch) {
 				mgr.logError("error creating campaign", err)
 				return err
 			}); err == nil {
+				atomic.StoreInt32(&mgr.resignRequested, 0)
 				atomic.StoreInt32(&mgr.campaigning, 1)
+				mgr.emitEvent(CampaignStartedEvent, "campaign enabled")
 			} else {
 				// If we get here, the campaign failed and either the manager is closed or
 				// the campaign is disabled. If the manager is closed, we return immediately.
 				// Otherwise we wait for a change in the campaign enabled status before continuing
 				// campaigning.
 				select {
-				case <-mgr.doneCh:
+				case <-doneCh:
 					return
 				case <-campaignStateWatch.C():
 					continue
@@ -777,26 +1450,53 @@ func (mgr *electionManager) campaignLoop(campaignStateWatch watch.Watch) {
 			}
 		}
 
+		if mgr.currentTerm == nil {
+			renewalCheckCh = nil
+		} else if renewalCheckCh == nil {
+			mgr.reconfigureLock.RLock()
+			checkInterval := mgr.campaignStateCheckInterval
+			mgr.reconfigureLock.RUnlock()
+			renewalCheckCh = mgr.afterFn(checkInterval)
+		}
+
 		select {
+		case <-renewalCheckCh:
+			renewalCheckCh = nil
+			mgr.checkTermRenewal()
 		case campaignStatus, ok := <-campaignStatusCh:
 			// If the campaign status channel is closed, this is either because session has expired,
 			// or we have resigned from the campaign, or there are issues with the underlying etcd
 			// cluster, in which case we back off a little and restart the campaign.
 			if !ok {
+				mgr.endTerm("campaign status channel closed")
 				campaignStatusCh = nil
 				atomic.StoreInt32(&mgr.campaigning, 0)
+				// NB: the channel is also closed after resigning, and after an error
+				// status which has already been reported as a keepalive failure.
+				resigned := atomic.SwapInt32(&mgr.resignRequested, 0) == 1
+				if !resigned && !errorReported {
+					mgr.emitEvent(KeepaliveFailedEvent, "campaign status channel closed")
+				}
+				errorReported = false
 				mgr.sleepFn(backOffOnResignOrElectionError)
 				continue
 			}
+			if campaignStatus.State == campaign.Error {
+				errorReported = true
+			}
 			mgr.processCampaignUpdate(campaignStatus)
-		case <-mgr.doneCh:
+		case <-doneCh:
+			mgr.endTerm("election manager closed")
+			if atomic.LoadInt32(&mgr.changingShardSet) == 1 {
+				return
+			}
 			electionKey := mgr.electionKey
 			// Asynchronously resign from ongoing campaign on close to avoid blocking the close
 			// call while still ensuring there are no lingering campaigns that are kept alive
 			// after the campaign manager is closed.
 			go func() {
 				atomic.AddInt32(&mgr.resignOnClose, 1)
-				if err := mgr.leaderService.Resign(electionKey); err != nil {
+				if err := mgr.electionStrategy.Resign(electionKey); err != nil {
 					mgr.metrics.resignOnCloseErrors.Inc(1)
 				} else {
 					mgr.metrics.resignOnCloseSuccess.Inc(1)
@@ -812,6 +1512,7 @@ func (mgr *electionManager) processCampaignUpdate(campaignStatus campaign.Status
 	if campaignStatus.State == campaign.Error {
 		mgr.metrics.campaignErrors.Inc(1)
 		mgr.logError("error campaigning", campaignStatus.Err)
+		mgr.emitEvent(KeepaliveFailedEvent, fmt.Sprintf("error campaigning: %v", campaignStatus.Err))
 		return
 	}
 
@@ -822,6 +1523,12 @@ func (mgr *electionManager) processCampaignUpdate(campaignStatus campaign.Status
 		return
 	}
 
+	if newState == LeaderState {
+		mgr.startTerm()
+	} else {
+		mgr.endTerm(fmt.Sprintf("campaign state changed to %v", newState))
+	}
+
 	mgr.goalStateLock.Lock()
 	mgr.setGoalStateWithLock(newState)
 	mgr.goalStateLock.Unlock()
@@ -834,6 +1541,69 @@ func (mgr *electionManager) setGoalStateWithLock(newState ElectionState) {
 	mgr.goalStateWatchable.Update(newGoalState)
 }
 
+// startTerm starts a new leadership term if there is none. It is only called
+// from the campaign loop.
+func (mgr *electionManager) startTerm() {
+	if mgr.currentTerm != nil {
+		return
+	}
+
+	nowNanos := mgr.nowFn().UnixNano()
+	id := atomic.AddInt64(&mgr.term, 1)
+	mgr.currentTerm = &leaderTerm{
+		id:               id,
+		startNanos:       nowNanos,
+		lastRenewalNanos: nowNanos,
+	}
+	mgr.metrics.termsStarted.Inc(1)
+	mgr.metrics.term.Update(float64(id))
+}
+
+// checkTermRenewal records a lease renewal for the current leadership term if
+// the election still has the instance as its leader. It is only called from the
+// campaign loop.
+func (mgr *electionManager) checkTermRenewal() {
+	if mgr.currentTerm == nil {
+		return
+	}
+	leader, err := mgr.electionStrategy.Leader(mgr.electionKey)
+	if err != nil {
+		mgr.metrics.termRenewalCheckErrors.Inc(1)
+		mgr.logError("error checking lease renewal", err)
+		return
+	}
+	if leader != mgr.leaderValue {
+		return
+	}
+	mgr.currentTerm.renew(mgr.nowFn().UnixNano())
+}
+
+// endTerm ends the current leadership term if any and emits its duration and
+// renewal statistics. The time between the last renewal and the end of the term
+// counts towards the longest renewal gap, as a leader that fails to renew before
+// losing its lease is the degradation being tracked.
+func (mgr *electionManager) endTerm(reason string) {
+	term := mgr.currentTerm
+	if term == nil {
+		return
+	}
+	mgr.currentTerm = nil
+
+	nowNanos := mgr.nowFn().UnixNano()
+	term.observeGap(nowNanos)
+	duration := time.Duration(nowNanos - term.startNanos)
+	mgr.metrics.termDuration.Record(duration)
+	mgr.metrics.termRenewals.Update(float64(term.renewals))
+	mgr.metrics.termLongestRenewalGap.Record(term.longestGap)
+	mgr.logger.Info("leadership term ended",
+		zap.String("reason", reason),
+		zap.Int64("term", term.id),
+		zap.Duration("duration", duration),
+		zap.Int("renewals", term.renewals),
+		zap.Duration("longestRenewalGap", term.longestGap),
+	)
+}
+
 func (mgr *electionManager) resetWithLock() {
 	mgr.state = electionManagerNotOpen
 	mgr.doneCh = make(chan struct{})
@@ -843,28 +1613,167 @@ func (mgr *electionManager) resetWithLock() {
 	mgr.electionStateWatchable = watch.NewWatchable()
 	mgr.electionStateWatchable.Update(FollowerState)
 	mgr.nextGoalStateID = 0
+	mgr.leaderSinceNanos = 0
+	mgr.currentTerm = nil
 	mgr.goalStateLock = &sync.RWMutex{}
 	mgr.goalStateWatchable = watch.NewWatchable()
+	mgr.events = newElectionEventStream(maxPendingElectionEvents, mgr.metrics.eventDrops)
+	mgr.shardElections = make(map[uint32]*electionManager)
+}
+
+func (mgr *electionManager) uniqueShardElectionsWithLock() []*electionManager {
+	var (
+		seen       = make(map[*electionManager]struct{}, len(mgr.shardElections))
+		subsetMgrs []*electionManager
+	)
+	for _, subsetMgr := range mgr.shardElections {
+		if _, exists := seen[subsetMgr]; exists {
+			continue
+		}
+		seen[subsetMgr] = struct{}{}
+		subsetMgrs = append(subsetMgrs, subsetMgr)
+	}
+	return subsetMgrs
 }
 
-func (mgr *electionManager) resignWhile(continueFn retry.ContinueFn) error {
-	return mgr.resignRetrier.AttemptWhile(continueFn, func() error {
-		if err := mgr.leaderService.Resign(mgr.electionKey); err != nil {
+// resignWhile resigns from the campaign, retrying on errors while the given
+// function returns true.
+func (mgr *electionManager) resignWhile(continueFn retry.ContinueFn, reason string) error {
+	mgr.RLock()
+	electionKey := mgr.electionKey
+	mgr.RUnlock()
+	mgr.reconfigureLock.RLock()
+	resignRetrier := mgr.resignRetrier
+	mgr.reconfigureLock.RUnlock()
+	return resignRetrier.AttemptWhile(continueFn, func() error {
+		atomic.StoreInt32(&mgr.resignRequested, 1)
+		if err := mgr.electionStrategy.Resign(electionKey); err != nil {
 			mgr.metrics.resignErrors.Inc(1)
 			mgr.logError("resign error", err)
 			return err
 		}
+		mgr.emitEvent(ResignedEvent, reason)
 		return nil
 	})
 }
 
-func (mgr *electionManager) reportMetrics() {
+// deferStepDown blocks until leadership has been held for the minimum leadership
+// hold, returning false if the given channel is closed or the election manager is
+// closed in the meantime.
+func (mgr *electionManager) deferStepDown(cancelCh <-chan struct{}) bool {
+	leaderSinceNanos := atomic.LoadInt64(&mgr.leaderSinceNanos)
+	if leaderSinceNanos == 0 {
+		return true
+	}
+	mgr.reconfigureLock.RLock()
+	minLeadershipHold := mgr.minLeadershipHold
+	mgr.reconfigureLock.RUnlock()
+	remaining := time.Duration(leaderSinceNanos + int64(minLeadershipHold) - mgr.nowFn().UnixNano())
+	if remaining <= 0 {
+		return true
+	}
+
+	mgr.metrics.deferredStepDowns.Inc(1)
+	mgr.logger.Info("deferring step-down until min leadership hold elapses",
+		zap.Duration("minLeadershipHold", minLeadershipHold),
+		zap.Duration("remaining", remaining))
+	select {
+	case <-mgr.afterFn(remaining):
+		return true
+	case <-cancelCh:
+		return false
+	case <-mgr.done():
+		return false
+	}
+}
+
+// runPreResignHooks runs the registered pre-resign hooks in priority order.
+func (mgr *electionManager) runPreResignHooks(reason string) {
+	mgr.preResignHooksLock.RLock()
+	hooks := mgr.preResignHooks
+	mgr.preResignHooksLock.RUnlock()
+
+	for _, h := range hooks {
+		if err := h.hook(); err != nil {
+			mgr.metrics.preResignHookErrors.Inc(1)
+			mgr.logger.Error("pre-resign hook error",
+				zap.String("reason", reason),
+				zap.Int("priority", h.priority),
+				zap.Error(err))
+		}
+	}
+}
+
+func (mgr *electionManager) emitEvent(eventType ElectionEventType, reason string) {
+	now := mgr.nowFn()
+	mgr.events.Emit(ElectionEvent{
+		Type:      eventType,
+		Timestamp: now,
+		Reason:    reason,
+	})
+	switch eventType {
+	case LeaderAcquiredEvent, LeaderLostEvent, ResignedEvent:
+		mgr.enqueueAudit(ElectionAuditRecord{
+			InstanceID: mgr.leaderValue,
+			ShardSetID: mgr.shardSetID,
+			Shards:     mgr.shards,
+			Term:       atomic.LoadInt64(&mgr.term),
+			Event:      eventType,
+			Timestamp:  now,
+			Reason:     reason,
+		})
+	}
+}
+
+// enqueueAudit queues the given record for the audit sink, dropping it if the
+// buffer is full so that leadership changes never block on the sink.
+func (mgr *electionManager) enqueueAudit(record ElectionAuditRecord) {
+	select {
+	case mgr.auditCh <- record:
+	default:
+		mgr.metrics.auditDrops.Inc(1)
+	}
+}
+
+// recordAudits records the queued audit records to the audit sink until the
+// election manager is closed, at which point the records still queued are
+// recorded before returning.
+func (mgr *electionManager) recordAudits(doneCh <-chan struct{}) {
+	defer mgr.Done()
+
+	for {
+		select {
+		case <-doneCh:
+			for {
+				select {
+				case record := <-mgr.auditCh:
+					mgr.recordAudit(record)
+				default:
+					return
+				}
+			}
+		case record := <-mgr.auditCh:
+			mgr.recordAudit(record)
+		}
+	}
+}
+
+func (mgr *electionManager) recordAudit(record ElectionAuditRecord) {
+	if err := mgr.auditSink.Record(record); err != nil {
+		mgr.metrics.auditRecordErrors.Inc(1)
+		mgr.logger.Error("election audit record error",
+			zap.String("event", record.Event.String()),
+			zap.Int64("term", record.Term),
+			zap.Error(err))
+	}
+}
+
+func (mgr *electionManager) reportMetrics(doneCh <-chan struct{}) {
 	defer mgr.Done()
 
-	ticker := time.NewTicker(mgr.reportInterval)
 	for {
 		select {
-		case <-ticker.C:
+		case <-mgr.afterFn(mgr.reportInterval):
 			electionState := mgr.ElectionState()
 			campaignState := mgr.campaignState()
 			campaigning := atomic.LoadInt32(&mgr.campaigning)
@@ -873,16 +1782,50 @@ func (mgr *electionManager) reportMetrics() {
 			mgr.metrics.campaignState.Update(float64(campaignState))
 			mgr.metrics.campaigning.Update(float64(campaigning))
 			mgr.metrics.resignOnClose.Update(float64(resignOnClose))
-		case <-mgr.doneCh:
-			ticker.Stop()
+		case <-doneCh:
 			return
 		}
 	}
 }
 
+// done returns the channel which is closed once the current election is closed.
+// The channel is replaced when the shard set changes, so it is read under the
+// lock outside of the goroutines started on open.
+func (mgr *electionManager) done() <-chan struct{} {
+	mgr.RLock()
+	doneCh := mgr.doneCh
+	mgr.RUnlock()
+	return doneCh
+}
+
+// newRetrier creates a retrier retrying forever that backs off on the election
+// manager clock.
+func (mgr *electionManager) newRetrier(opts retry.Options) retry.Retrier {
+	return retry.NewRetrier(opts.SetForever(true).SetSleepFn(mgr.backoff))
+}
+
+// backoff blocks until the given retry backoff elapses. Unlike sleep it does not
+// return early on close, since retries such as resigning on shard set changes
+// continue after the election manager is closed.
+func (mgr *electionManager) backoff(d time.Duration) {
+	<-mgr.afterFn(d)
+}
+
+// sleep blocks until the given duration elapses or the election manager is
+// closed.
+func (mgr *electionManager) sleep(d time.Duration) {
+	select {
+	case <-mgr.afterFn(d):
+	case <-mgr.done():
+	}
+}
+
 func (mgr *electionManager) logError(desc string, err error) {
+	mgr.RLock()
+	electionKey := mgr.electionKey
+	mgr.RUnlock()
 	mgr.logger.Error(desc,
-		zap.String("electionKey", mgr.electionKey),
+		zap.String("electionKey", electionKey),
 		zap.Duration("electionTTL", time.Duration(mgr.electionOpts.TTLSecs())*time.Second),
 		zap.String("leaderValue", mgr.campaignOpts.LeaderValue()),
 		zap.Error(err),
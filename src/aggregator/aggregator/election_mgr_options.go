@@ -21,6 +21,9 @@
 package aggregator
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/services"
@@ -33,6 +36,21 @@ const (
 	defaultElectionKeyFormat          = "/shardset/%d/lock"
 	defaultCampaignStateCheckInterval = time.Second
 	defaultShardCutoffCheckOffset     = 30 * time.Second
+	defaultAuditBufferSize            = 64
+)
+
+// AfterFn returns a channel receiving the current time once the given duration
+// has elapsed, e.g. time.After.
+type AfterFn func(d time.Duration) <-chan time.Time
+
+var (
+	errNoAfterFn   = errors.New("no after function")
+	errNoAuditSink = errors.New("no audit sink")
+
+	errQuorumGuardLiveInstancesUnsupported = errors.New(
+		"quorum guard requires an election backend supporting live instances")
+
+	electionKeyPrefixRegexp = regexp.MustCompile(`^(/[A-Za-z0-9_.\-]+)+$`)
 )
 
 // ElectionManagerOptions provide a set of options for the election manager.
@@ -43,6 +61,14 @@ type ElectionManagerOptions interface {
 	// ClockOptions returns the clock options.
 	ClockOptions() clock.Options
 
+	// SetAfterFn sets the function used to wait for durations to elapse, which
+	// together with the clock options drives all timing of the election manager,
+	// such as campaign state checks, backoffs and the minimum leadership hold.
+	SetAfterFn(value AfterFn) ElectionManagerOptions
+
+	// AfterFn returns the function used to wait for durations to elapse.
+	AfterFn() AfterFn
+
 	// SetInstrumentOptions sets the instrument options.
 	SetInstrumentOptions(value instrument.Options) ElectionManagerOptions
 
@@ -85,12 +111,35 @@ type ElectionManagerOptions interface {
 	// ElectionKeyFmt returns the election key format.
 	ElectionKeyFmt() string
 
+	// SetElectionKeyPrefix sets the prefix prepended to election keys, allowing
+	// environments sharing the same backend to campaign under isolated key spaces.
+	// The prefix must be a sequence of slash-separated path segments (e.g. "/prod"),
+	// and an empty prefix campaigns under the default key space.
+	SetElectionKeyPrefix(value string) ElectionManagerOptions
+
+	// ElectionKeyPrefix returns the prefix prepended to election keys.
+	ElectionKeyPrefix() string
+
 	// SetLeaderService sets the leader service.
 	SetLeaderService(value services.LeaderService) ElectionManagerOptions
 
 	// LeaderService returns the leader service.
 	LeaderService() services.LeaderService
 
+	// SetElectionBackend sets the election backend used to inspect elections,
+	// which defaults to a backend reading from the leader service.
+	SetElectionBackend(value ElectionBackend) ElectionManagerOptions
+
+	// ElectionBackend returns the election backend used to inspect elections.
+	ElectionBackend() ElectionBackend
+
+	// SetElectionStrategy sets the strategy leadership is acquired and held with,
+	// which defaults to a lease based strategy campaigning with the leader service.
+	SetElectionStrategy(value ElectionStrategy) ElectionManagerOptions
+
+	// ElectionStrategy returns the strategy leadership is acquired and held with.
+	ElectionStrategy() ElectionStrategy
+
 	// SetPlacementManager sets the placement manager.
 	SetPlacementManager(value PlacementManager) ElectionManagerOptions
 
@@ -118,10 +167,49 @@ type ElectionManagerOptions interface {
 	// The cutoff time is applied in order to stop campaignining when necessary before all
 	// shards are cut off avoiding incomplete data to be flushed.
 	ShardCutoffCheckOffset() time.Duration
+
+	// SetMinLeadershipHold sets the minimum duration leadership is held for once
+	// acquired. Resigning within this duration is deferred until it has elapsed,
+	// while step-downs forced by the shard cutoff, the quorum guard or losing the
+	// lease are not. A zero duration disables deferring step-downs.
+	SetMinLeadershipHold(value time.Duration) ElectionManagerOptions
+
+	// MinLeadershipHold returns the minimum duration leadership is held for once
+	// acquired.
+	MinLeadershipHold() time.Duration
+
+	// SetQuorumGuardEnabled sets whether campaigning requires the instance to see
+	// a quorum of the instances in its shard set holding live sessions in the
+	// election, so that it neither acquires nor retains leadership while it is
+	// partitioned from most of its peers. This requires an election backend
+	// supporting live instances and leader values to be instance IDs.
+	SetQuorumGuardEnabled(value bool) ElectionManagerOptions
+
+	// QuorumGuardEnabled returns whether campaigning requires the instance to
+	// see a quorum of the instances in its shard set.
+	QuorumGuardEnabled() bool
+
+	// SetAuditSink sets the sink leadership changes are recorded to.
+	SetAuditSink(value ElectionAuditSink) ElectionManagerOptions
+
+	// AuditSink returns the sink leadership changes are recorded to.
+	AuditSink() ElectionAuditSink
+
+	// SetAuditBufferSize sets the number of audit records buffered for the audit
+	// sink, beyond which records are dropped rather than blocking elections.
+	SetAuditBufferSize(value int) ElectionManagerOptions
+
+	// AuditBufferSize returns the number of audit records buffered for the audit
+	// sink.
+	AuditBufferSize() int
+
+	// Validate validates the options.
+	Validate() error
 }
 
 type electionManagerOptions struct {
 	clockOpts                  clock.Options
+	afterFn                    AfterFn
 	instrumentOpts             instrument.Options
 	electionOpts               services.ElectionOptions
 	campaignOpts               services.CampaignOptions
@@ -129,17 +217,25 @@ type electionManagerOptions struct {
 	changeRetryOpts            retry.Options
 	resignRetryOpts            retry.Options
 	electionKeyFmt             string
+	electionKeyPrefix          string
 	leaderService              services.LeaderService
+	electionBackend            ElectionBackend
+	electionStrategy           ElectionStrategy
 	placementManager           PlacementManager
 	flushTimesManager          FlushTimesManager
 	campaignStateCheckInterval time.Duration
 	shardCutoffCheckOffset     time.Duration
+	minLeadershipHold          time.Duration
+	quorumGuardEnabled         bool
+	auditSink                  ElectionAuditSink
+	auditBufferSize            int
 }
 
 // NewElectionManagerOptions create a new set of options for the election manager.
 func NewElectionManagerOptions() ElectionManagerOptions {
 	return &electionManagerOptions{
 		clockOpts:                  clock.NewOptions(),
+		afterFn:                    time.After,
 		instrumentOpts:             instrument.NewOptions(),
 		electionOpts:               services.NewElectionOptions(),
 		campaignRetryOpts:          retry.NewOptions(),
@@ -148,6 +244,8 @@ func NewElectionManagerOptions() ElectionManagerOptions {
 		electionKeyFmt:             defaultElectionKeyFormat,
 		campaignStateCheckInterval: defaultCampaignStateCheckInterval,
 		shardCutoffCheckOffset:     defaultShardCutoffCheckOffset,
+		auditSink:                  NewNoopElectionAuditSink(),
+		auditBufferSize:            defaultAuditBufferSize,
 	}
 }
 
@@ -161,6 +259,16 @@ func (o *electionManagerOptions) ClockOptions() clock.Options {
 	return o.clockOpts
 }
 
+func (o *electionManagerOptions) SetAfterFn(value AfterFn) ElectionManagerOptions {
+	opts := *o
+	opts.afterFn = value
+	return &opts
+}
+
+func (o *electionManagerOptions) AfterFn() AfterFn {
+	return o.afterFn
+}
+
 func (o *electionManagerOptions) SetInstrumentOptions(value instrument.Options) ElectionManagerOptions {
 	opts := *o
 	opts.instrumentOpts = value
@@ -231,6 +339,16 @@ func (o *electionManagerOptions) ElectionKeyFmt() string {
 	return o.electionKeyFmt
 }
 
+func (o *electionManagerOptions) SetElectionKeyPrefix(value string) ElectionManagerOptions {
+	opts := *o
+	opts.electionKeyPrefix = value
+	return &opts
+}
+
+func (o *electionManagerOptions) ElectionKeyPrefix() string {
+	return o.electionKeyPrefix
+}
+
 func (o *electionManagerOptions) SetLeaderService(value services.LeaderService) ElectionManagerOptions {
 	opts := *o
 	opts.leaderService = value
@@ -241,6 +359,26 @@ func (o *electionManagerOptions) LeaderService() services.LeaderService {
 	return o.leaderService
 }
 
+func (o *electionManagerOptions) SetElectionBackend(value ElectionBackend) ElectionManagerOptions {
+	opts := *o
+	opts.electionBackend = value
+	return &opts
+}
+
+func (o *electionManagerOptions) ElectionBackend() ElectionBackend {
+	return o.electionBackend
+}
+
+func (o *electionManagerOptions) SetElectionStrategy(value ElectionStrategy) ElectionManagerOptions {
+	opts := *o
+	opts.electionStrategy = value
+	return &opts
+}
+
+func (o *electionManagerOptions) ElectionStrategy() ElectionStrategy {
+	return o.electionStrategy
+}
+
 func (o *electionManagerOptions) SetPlacementManager(value PlacementManager) ElectionManagerOptions {
 	opts := *o
 	opts.placementManager = value
@@ -280,3 +418,65 @@ func (o *electionManagerOptions) SetShardCutoffCheckOffset(value time.Duration)
 func (o *electionManagerOptions) ShardCutoffCheckOffset() time.Duration {
 	return o.shardCutoffCheckOffset
 }
+
+func (o *electionManagerOptions) SetMinLeadershipHold(value time.Duration) ElectionManagerOptions {
+	opts := *o
+	opts.minLeadershipHold = value
+	return &opts
+}
+
+func (o *electionManagerOptions) MinLeadershipHold() time.Duration {
+	return o.minLeadershipHold
+}
+
+func (o *electionManagerOptions) SetQuorumGuardEnabled(value bool) ElectionManagerOptions {
+	opts := *o
+	opts.quorumGuardEnabled = value
+	return &opts
+}
+
+func (o *electionManagerOptions) QuorumGuardEnabled() bool {
+	return o.quorumGuardEnabled
+}
+
+func (o *electionManagerOptions) SetAuditSink(value ElectionAuditSink) ElectionManagerOptions {
+	opts := *o
+	opts.auditSink = value
+	return &opts
+}
+
+func (o *electionManagerOptions) AuditSink() ElectionAuditSink {
+	return o.auditSink
+}
+
+func (o *electionManagerOptions) SetAuditBufferSize(value int) ElectionManagerOptions {
+	opts := *o
+	opts.auditBufferSize = value
+	return &opts
+}
+
+func (o *electionManagerOptions) AuditBufferSize() int {
+	return o.auditBufferSize
+}
+
+func (o *electionManagerOptions) Validate() error {
+	if o.afterFn == nil {
+		return errNoAfterFn
+	}
+	if o.auditSink == nil {
+		return errNoAuditSink
+	}
+	if o.auditBufferSize < 0 {
+		return fmt.Errorf("negative audit buffer size: %d", o.auditBufferSize)
+	}
+	if o.minLeadershipHold < 0 {
+		return fmt.Errorf("negative min leadership hold: %v", o.minLeadershipHold)
+	}
+	if o.electionKeyPrefix != "" && !electionKeyPrefixRegexp.MatchString(o.electionKeyPrefix) {
+		return fmt.Errorf("invalid election key prefix: %q", o.electionKeyPrefix)
+	}
+	if o.quorumGuardEnabled && !electionBackendSupportsLiveInstances(o.electionBackend) {
+		return errQuorumGuardLiveInstancesUnsupported
+	}
+	return nil
+}
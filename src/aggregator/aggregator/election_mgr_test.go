@@ -32,12 +32,16 @@ import (
 	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/cluster/services/leader"
 	"github.com/m3db/m3/src/cluster/services/leader/campaign"
 	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/retry"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 func TestElectionStateJSONMarshal(t *testing.T) {
@@ -119,9 +123,220 @@ func TestElectionManagerOpenAlreadyOpen(t *testing.T) {
 	opts := testElectionManagerOptions(t, ctrl)
 	mgr := NewElectionManager(opts).(*electionManager)
 	mgr.state = electionManagerOpen
+	mgr.shardSetID = testShardSetID
 	require.Equal(t, errElectionManagerAlreadyOpenOrClosed, mgr.Open(testShardSetID))
 }
 
+// recordingElectionStrategy records the campaigns and resignations of the
+// election strategy it wraps in the order they are made.
+type recordingElectionStrategy struct {
+	ElectionStrategy
+
+	sync.Mutex
+	calls []string
+}
+
+func (s *recordingElectionStrategy) Campaign(
+	electionID string,
+	opts services.CampaignOptions,
+) (<-chan campaign.Status, error) {
+	s.record("campaign " + electionID)
+	return s.ElectionStrategy.Campaign(electionID, opts)
+}
+
+func (s *recordingElectionStrategy) Resign(electionID string) error {
+	s.record("resign " + electionID)
+	return s.ElectionStrategy.Resign(electionID)
+}
+
+func (s *recordingElectionStrategy) record(call string) {
+	s.Lock()
+	s.calls = append(s.calls, call)
+	s.Unlock()
+}
+
+func (s *recordingElectionStrategy) recorded() []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+func TestElectionManagerOpenShardSetChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		lock     = &electionLock{holders: make(map[string]string)}
+		strategy = &recordingElectionStrategy{
+			ElectionStrategy: newLockElectionStrategy(lock),
+		}
+		instance = placement.NewInstance().SetID(testInstanceID1)
+		p        = placement.NewPlacement().SetInstances([]placement.Instance{instance})
+		scope    = tally.NewTestScope("", nil)
+	)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts.SetLeaderValue(testInstanceID1)).
+		SetElectionStrategy(strategy).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	placementManager := opts.PlacementManager().(*MockPlacementManager)
+	placementManager.EXPECT().Instance().Return(instance, nil).AnyTimes()
+	placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+	waitForState := func(state ElectionState) {
+		for mgr.ElectionState() != state {
+			require.NoError(t, ctx.Err(), "timed out waiting for %v", state)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	var hookCalls int
+	mgr.RegisterPreResignHook(0, func() error {
+		hookCalls++
+		return nil
+	})
+
+	const otherShardSetID = testShardSetID + 1
+	require.NoError(t, mgr.Open(testShardSetID))
+	keyA := mgr.electionKey
+	waitForState(LeaderState)
+
+	require.NoError(t, mgr.Open(otherShardSetID))
+	keyB := mgr.electionKey
+	require.NotEqual(t, keyA, keyB)
+	waitForState(LeaderState)
+	leaderValue, err := strategy.Leader(keyB)
+	require.NoError(t, err)
+	require.Equal(t, testInstanceID1, leaderValue)
+
+	// Leadership of the previous shard set is released before campaigning for
+	// the new shard set.
+	require.Equal(t, []string{
+		"campaign " + keyA,
+		"resign " + keyA,
+		"campaign " + keyB,
+	}, strategy.recorded())
+	_, err = strategy.Leader(keyA)
+	require.Equal(t, leader.ErrNoLeader, err)
+	require.Equal(t, 1, hookCalls)
+	require.Equal(t, int64(1),
+		scope.Snapshot().Counters()["resign.on-shard-set-change+"].Value())
+
+	// Reopening for the current shard set is still an error.
+	require.Equal(t, errElectionManagerAlreadyOpenOrClosed, mgr.Open(otherShardSetID))
+	require.NoError(t, mgr.Close())
+}
+
+func TestElectionManagerConcurrentShardSetChangeOpenClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		lock     = &electionLock{holders: make(map[string]string)}
+		instance = placement.NewInstance().SetID(testInstanceID1)
+		p        = placement.NewPlacement().SetInstances([]placement.Instance{instance})
+	)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts.SetLeaderValue(testInstanceID1)).
+		SetElectionStrategy(newLockElectionStrategy(lock)).
+		SetMinLeadershipHold(time.Hour)
+	placementManager := opts.PlacementManager().(*MockPlacementManager)
+	placementManager.EXPECT().Instance().Return(instance, nil).AnyTimes()
+	placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	// NB: resigning is deferred by the min leadership hold until the election
+	// is closed, which races with the shard set changes.
+	var (
+		wg     sync.WaitGroup
+		closed int32
+	)
+	for i := 0; i < 10; i++ {
+		shardSetID := testShardSetID + uint32(i) + 1
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			mgr.Open(shardSetID) // nolint: errcheck
+		}()
+		go func() {
+			defer wg.Done()
+			if mgr.Close() == nil {
+				atomic.AddInt32(&closed, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			mgr.Resign(ctx) // nolint: errcheck
+		}()
+	}
+	wg.Wait()
+
+	require.True(t, atomic.LoadInt32(&closed) <= 1)
+	if atomic.LoadInt32(&closed) == 0 {
+		require.NoError(t, mgr.Close())
+	}
+	require.Equal(t, errElectionManagerNotOpenOrClosed, mgr.Close())
+}
+
+// blockingResignElectionStrategy blocks resigning from the elections of the
+// election strategy it wraps until released.
+type blockingResignElectionStrategy struct {
+	ElectionStrategy
+
+	resigningCh chan struct{}
+	releaseCh   chan struct{}
+}
+
+func (s *blockingResignElectionStrategy) Resign(electionID string) error {
+	select {
+	case s.resigningCh <- struct{}{}:
+	default:
+	}
+	<-s.releaseCh
+	return s.ElectionStrategy.Resign(electionID)
+}
+
+func TestElectionManagerShardSetChangeRejectsOpenClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	strategy := &blockingResignElectionStrategy{
+		ElectionStrategy: newLockElectionStrategy(&electionLock{holders: make(map[string]string)}),
+		resigningCh:      make(chan struct{}, 1),
+		releaseCh:        make(chan struct{}),
+	}
+	opts := testElectionManagerOptions(t, ctrl).SetElectionStrategy(strategy)
+	mgr := NewElectionManager(opts).(*electionManager)
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	changeErrCh := make(chan error, 1)
+	go func() {
+		changeErrCh <- mgr.Open(testShardSetID + 1)
+	}()
+
+	// The election manager can neither be opened, closed nor reset while the
+	// previous shard set is resigned from.
+	<-strategy.resigningCh
+	require.Equal(t, errElectionManagerChangingShardSet, mgr.Open(testShardSetID+2))
+	require.Equal(t, errElectionManagerChangingShardSet, mgr.Close())
+	require.Equal(t, errElectionManagerOpen, mgr.Reset())
+
+	close(strategy.releaseCh)
+	require.NoError(t, <-changeErrCh)
+	require.Equal(t, testShardSetID+1, mgr.shardSetID)
+	require.NoError(t, mgr.Close())
+}
+
 func TestElectionManagerOpenSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -144,6 +359,260 @@ func TestElectionManagerOpenSuccess(t *testing.T) {
 	require.NoError(t, mgr.Close())
 }
 
+func TestElectionManagerElectionKeyPrefixIsolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		backend = newMemElectionBackend()
+		mgrs    []*electionManager
+	)
+	for _, prefix := range []string{"/env1", "/env2"} {
+		campaignOpts, err := services.NewCampaignOptions()
+		require.NoError(t, err)
+		campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+		opts := testElectionManagerOptions(t, ctrl).
+			SetCampaignOptions(campaignOpts).
+			SetElectionKeyPrefix(prefix).
+			SetLeaderService(newMemLeaderService(backend, testInstanceID1))
+		require.NoError(t, opts.Validate())
+		mgr := NewElectionManager(opts).(*electionManager)
+		mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+		require.NoError(t, mgr.Open(testShardSetID))
+		mgrs = append(mgrs, mgr)
+	}
+
+	// Both managers campaign for the same shard set but in different environments,
+	// and as such should both become leaders.
+	for _, mgr := range mgrs {
+		for mgr.ElectionState() != LeaderState {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	require.Equal(t, "/env1/shardset/1/lock", mgrs[0].electionKey)
+	require.Equal(t, "/env2/shardset/1/lock", mgrs[1].electionKey)
+
+	for _, mgr := range mgrs {
+		require.NoError(t, mgr.Close())
+	}
+}
+
+func TestElectionManagerBackendRevision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := newMemElectionBackend()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(newMemLeaderService(backend, testInstanceID1)).
+		SetElectionBackend(backend)
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+
+	_, err = mgr.BackendRevision()
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+
+	require.NoError(t, mgr.Open(testShardSetID))
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(10 * time.Millisecond)
+	}
+	revision, err := mgr.BackendRevision()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revision)
+
+	// Another instance taking over leadership bumps the revision.
+	backend.setLeader(mgr.electionKey, testInstanceID2)
+	revision, err = mgr.BackendRevision()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), revision)
+
+	// Changing the election backend requires a restart.
+	require.Error(t, mgr.Reconfigure(opts.SetElectionBackend(newMemElectionBackend())))
+	require.NoError(t, mgr.Close())
+
+	_, err = mgr.BackendRevision()
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+}
+
+func TestElectionManagerLiveInstances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := newMemElectionBackend()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(newMemLeaderService(backend, testInstanceID1)).
+		SetElectionBackend(backend)
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+
+	_, err = mgr.LiveInstances()
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+
+	require.NoError(t, mgr.Open(testShardSetID))
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(10 * time.Millisecond)
+	}
+	instances, err := mgr.LiveInstances()
+	require.NoError(t, err)
+	require.Equal(t, []string{testInstanceID1}, instances)
+
+	// Instances holding sessions are live whether or not they campaign.
+	backend.addSession(mgr.electionKey, testInstanceID3)
+	backend.addSession(mgr.electionKey, testInstanceID2)
+	instances, err = mgr.LiveInstances()
+	require.NoError(t, err)
+	require.Equal(t, []string{testInstanceID1, testInstanceID2, testInstanceID3}, instances)
+	require.NoError(t, mgr.Close())
+
+	_, err = mgr.LiveInstances()
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+}
+
+func TestElectionManagerCampaignPreview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := newMemElectionBackend()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(newMemLeaderService(backend, testInstanceID1)).
+		SetElectionBackend(backend)
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return false, nil }
+
+	_, _, err = mgr.CampaignPreview(context.Background())
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// No leader.
+	wouldAcquire, leader, err := mgr.CampaignPreview(context.Background())
+	require.NoError(t, err)
+	require.True(t, wouldAcquire)
+	require.Equal(t, "", leader)
+
+	// Another instance leads the election.
+	backend.setLeader(mgr.electionKey, testInstanceID2)
+	wouldAcquire, leader, err = mgr.CampaignPreview(context.Background())
+	require.NoError(t, err)
+	require.False(t, wouldAcquire)
+	require.Equal(t, testInstanceID2, leader)
+
+	// The preview neither campaigns nor changes the leader.
+	require.Equal(t, FollowerState, mgr.ElectionState())
+	revision, err := backend.Revision(context.Background(), mgr.electionKey)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revision)
+
+	// The instance already leads the election.
+	backend.setLeader(mgr.electionKey, testInstanceID1)
+	wouldAcquire, leader, err = mgr.CampaignPreview(context.Background())
+	require.NoError(t, err)
+	require.True(t, wouldAcquire)
+	require.Equal(t, testInstanceID1, leader)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err = mgr.CampaignPreview(ctx)
+	require.Equal(t, context.Canceled, err)
+}
+
+func TestElectionManagerOpenShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	backend := newMemElectionBackend()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(newMemLeaderService(backend, testInstanceID1)).
+		SetElectionBackend(backend)
+	// NB: following the leader of a subset requires it to be in the placement.
+	instance1 := placement.NewInstance().SetID(testInstanceID1).SetShardSetID(testShardSetID)
+	instance2 := placement.NewInstance().SetID(testInstanceID2).SetShardSetID(testShardSetID)
+	placementManager := opts.PlacementManager().(*MockPlacementManager)
+	placementManager.EXPECT().
+		Placement().
+		Return(nil, placement.NewPlacement().SetInstances([]placement.Instance{instance1, instance2}), nil).
+		AnyTimes()
+	placementManager.EXPECT().Instance().Return(instance1, nil).AnyTimes()
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+
+	require.Equal(t, errElectionManagerNotOpenOrClosed, mgr.OpenShards([]uint32{0}))
+	require.NoError(t, mgr.Open(testShardSetID))
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Another instance already leads the election of the second subset.
+	backend.setLeader(mgr.electionKey+"-shards-2_3", testInstanceID2)
+	require.NoError(t, mgr.OpenShards([]uint32{1, 0}))
+	require.NoError(t, mgr.OpenShards([]uint32{3, 2}))
+	require.Equal(t, errNoShardsInSubset, mgr.OpenShards(nil))
+	require.Error(t, mgr.OpenShards([]uint32{1, 4}))
+	require.Error(t, mgr.OpenShards([]uint32{4, 4}))
+
+	// Each subset is led independently.
+	for {
+		state, err := mgr.ShardElectionState(0)
+		require.NoError(t, err)
+		if state == LeaderState {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for _, shardID := range []uint32{0, 1} {
+		state, err := mgr.ShardElectionState(shardID)
+		require.NoError(t, err)
+		require.Equal(t, LeaderState, state)
+	}
+	for _, shardID := range []uint32{2, 3} {
+		state, err := mgr.ShardElectionState(shardID)
+		require.NoError(t, err)
+		require.Equal(t, FollowerState, state)
+	}
+	_, err = mgr.ShardElectionState(4)
+	require.Error(t, err)
+	require.Equal(t, LeaderState, mgr.ElectionState())
+
+	leader, err := backend.Leader(context.Background(), mgr.electionKey+"-shards-0_1")
+	require.NoError(t, err)
+	require.Equal(t, testInstanceID1, leader)
+	leader, err = backend.Leader(context.Background(), mgr.electionKey+"-shards-2_3")
+	require.NoError(t, err)
+	require.Equal(t, testInstanceID2, leader)
+
+	subsetMgr := mgr.shardElections[0]
+	require.NoError(t, mgr.Close())
+	require.Equal(t, electionManagerClosed, subsetMgr.state)
+	_, err = mgr.ShardElectionState(0)
+	require.Equal(t, errElectionManagerNotOpenOrClosed, err)
+}
+
+func TestElectionManagerOptionsValidateElectionKeyPrefix(t *testing.T) {
+	opts := NewElectionManagerOptions()
+	require.NoError(t, opts.Validate())
+	for _, prefix := range []string{"/prod", "/prod/us-east_1", "/a.b"} {
+		require.NoError(t, opts.SetElectionKeyPrefix(prefix).Validate(), prefix)
+	}
+	for _, prefix := range []string{"/", " ", "prod", "/prod/", "/prod//a", "/pr od", "/%d"} {
+		require.Error(t, opts.SetElectionKeyPrefix(prefix).Validate(), prefix)
+	}
+}
+
 func TestElectionManagerElectionState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -175,6 +644,34 @@ func TestElectionManagerIsCampaigning(t *testing.T) {
 	}
 }
 
+func TestElectionManagerLeadershipProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var leadership LeadershipProvider = NewElectionManager(testElectionManagerOptions(t, ctrl))
+	mgr := leadership.(*electionManager)
+	w, err := leadership.Subscribe()
+	require.NoError(t, err)
+	defer w.Close()
+	<-w.C()
+	require.Equal(t, FollowerState, w.Get())
+
+	inputs := []struct {
+		state    ElectionState
+		expected bool
+	}{
+		{state: LeaderState, expected: true},
+		{state: PendingFollowerState, expected: true},
+		{state: FollowerState, expected: false},
+	}
+	for _, input := range inputs {
+		mgr.electionStateWatchable.Update(input.state)
+		require.Equal(t, input.expected, leadership.IsLeader())
+		<-w.C()
+		require.Equal(t, input.state, w.Get())
+	}
+}
+
 func TestElectionManagerResignAlreadyClosed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -230,7 +727,7 @@ func TestElectionManagerResignLeaderServiceResignError(t *testing.T) {
 			return nil
 		}).
 		AnyTimes()
-	mgr.leaderService = leaderService
+	mgr.electionStrategy = NewLeaseElectionStrategy(leaderService)
 
 	retryOpts := retry.NewOptions().
 		SetInitialBackoff(10 * time.Millisecond).
@@ -247,6 +744,50 @@ func TestElectionManagerResignLeaderServiceResignError(t *testing.T) {
 	require.NoError(t, mgr.Close())
 }
 
+func TestElectionManagerResignRunsPreResignHooksInPriorityOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testElectionManagerOptions(t, ctrl)
+	mgr := NewElectionManager(opts).(*electionManager)
+
+	leaderService := services.NewMockLeaderService(ctrl)
+	leaderService.EXPECT().
+		Campaign(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(string, services.CampaignOptions,
+		) (<-chan campaign.Status, error) {
+			return make(chan campaign.Status), nil
+		}).
+		AnyTimes()
+
+	var order []string
+	leaderService.EXPECT().
+		Resign(gomock.Any()).
+		DoAndReturn(func(string) error {
+			order = append(order, "resign")
+			mgr.electionStateWatchable.Update(FollowerState)
+			return nil
+		}).
+		AnyTimes()
+	mgr.electionStrategy = NewLeaseElectionStrategy(leaderService)
+
+	register := func(name string, priority int, err error) {
+		mgr.RegisterPreResignHook(priority, func() error {
+			order = append(order, name)
+			return err
+		})
+	}
+	register("close files", 10, nil)
+	register("flush", 0, errors.New("flush error"))
+	register("stop writes", -5, nil)
+
+	mgr.electionStateWatchable.Update(LeaderState)
+	require.NoError(t, mgr.Open(testShardSetID))
+	require.NoError(t, mgr.Resign(context.Background()))
+	require.Equal(t, []string{"stop writes", "flush", "close files", "resign"}, order)
+	require.NoError(t, mgr.Close())
+}
+
 func TestElectionManagerResignTimeout(t *testing.T) {
 	t.Parallel()
 	ctrl := gomock.NewController(t)
@@ -275,77 +816,549 @@ func TestElectionManagerResignTimeout(t *testing.T) {
 	require.NoError(t, mgr.Close())
 }
 
-func TestElectionManagerResignSuccess(t *testing.T) {
-	t.Parallel()
-
+func TestElectionManagerResignSuccess(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		statusCh = make(chan campaign.Status, 1)
+		mgr      *electionManager
+	)
+
+	leaderValue := "myself"
+	leaderService := services.NewMockLeaderService(ctrl)
+	leaderService.EXPECT().Leader(gomock.Any()).Return("someone else", nil).AnyTimes()
+	leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil).AnyTimes()
+	leaderService.EXPECT().
+		Resign(gomock.Any()).
+		DoAndReturn(func(string) error {
+			select {
+			case statusCh <- campaign.Status{State: campaign.Follower}:
+			default:
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(leaderValue)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		// SetCampaignStateCheckInterval(1 * time.Second).
+		SetLeaderService(leaderService)
+	i := placement.NewInstance().SetID("myself")
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Instance().
+		Return(i, nil)
+	p := placement.NewPlacement().SetInstances([]placement.Instance{
+		i, placement.NewInstance().SetID("someone else"),
+	})
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Placement().
+		Return(nil, p, nil)
+	mgr = NewElectionManager(opts).(*electionManager)
+	mgr.sleepFn = func(time.Duration) {}
+	mgr.electionStateWatchable.Update(LeaderState)
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	require.NoError(t, mgr.Resign(ctx))
+
+	var mgrState electionManagerState
+	for i := 0; i < 10; i++ {
+		mgr.RLock()
+		mgrState = mgr.state
+		mgr.RUnlock()
+		if mgr.ElectionState() == FollowerState && mgrState == electionManagerOpen {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.Equal(t, FollowerState, mgr.ElectionState())
+	require.Equal(t, electionManagerOpen, mgrState)
+	require.NoError(t, mgr.Close())
+}
+
+func TestElectionManagerEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		statusCh    = make(chan campaign.Status, 1)
+		leaderValue = "myself"
+		errCampaign = errors.New("keepalive error")
+	)
+	leaderService := services.NewMockLeaderService(ctrl)
+	leaderService.EXPECT().Leader(gomock.Any()).Return("someone else", nil).AnyTimes()
+	leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil).Times(1)
+	leaderService.EXPECT().
+		Resign(gomock.Any()).
+		DoAndReturn(func(string) error {
+			select {
+			case statusCh <- campaign.Status{State: campaign.Follower}:
+			default:
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(leaderValue)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(leaderService)
+	i := placement.NewInstance().SetID(leaderValue)
+	p := placement.NewPlacement().SetInstances([]placement.Instance{
+		i, placement.NewInstance().SetID("someone else"),
+	})
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Instance().
+		Return(i, nil).
+		AnyTimes()
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Placement().
+		Return(nil, p, nil).
+		AnyTimes()
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+	events := mgr.Events()
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	nextEvent := func() ElectionEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for election event")
+			return ElectionEvent{}
+		}
+	}
+
+	require.Equal(t, CampaignStartedEvent, nextEvent().Type)
+	statusCh <- campaign.Status{State: campaign.Leader}
+	require.Equal(t, LeaderAcquiredEvent, nextEvent().Type)
+	statusCh <- campaign.Status{State: campaign.Error, Err: errCampaign}
+	event := nextEvent()
+	require.Equal(t, KeepaliveFailedEvent, event.Type)
+	require.Contains(t, event.Reason, errCampaign.Error())
+
+	// NB: resigning and losing leadership happen concurrently so the order in
+	// which they are observed is not deterministic.
+	require.NoError(t, mgr.Resign(ctx))
+	types := map[ElectionEventType]bool{nextEvent().Type: true, nextEvent().Type: true}
+	require.Equal(t, map[ElectionEventType]bool{ResignedEvent: true, LeaderLostEvent: true}, types)
+
+	require.NoError(t, mgr.Close())
+	for range events {
+	}
+}
+
+func TestElectionManagerKeepaliveFailedEventOnStatusChannelClose(t *testing.T) {
+	errCampaign := errors.New("session expired")
+	tests := []struct {
+		name          string
+		closeCampaign func(
+			t *testing.T,
+			mgr *electionManager,
+			statusCh chan campaign.Status,
+			nextEvent func() ElectionEvent,
+		)
+		expected []ElectionEventType
+	}{
+		{
+			name: "resigned",
+			closeCampaign: func(
+				t *testing.T,
+				mgr *electionManager,
+				_ chan campaign.Status,
+				_ func() ElectionEvent,
+			) {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				require.NoError(t, mgr.Resign(ctx))
+			},
+			expected: []ElectionEventType{ResignedEvent, LeaderLostEvent},
+		},
+		{
+			name: "error",
+			closeCampaign: func(
+				t *testing.T,
+				_ *electionManager,
+				statusCh chan campaign.Status,
+				nextEvent func() ElectionEvent,
+			) {
+				// NB: the error is delivered before the channel is closed so that
+				// a duplicate keepalive failure would not be coalesced with it.
+				statusCh <- campaign.NewErrorStatus(errCampaign)
+				require.Equal(t, KeepaliveFailedEvent, nextEvent().Type)
+				close(statusCh)
+			},
+			expected: nil,
+		},
+		{
+			name: "closed",
+			closeCampaign: func(
+				t *testing.T,
+				_ *electionManager,
+				statusCh chan campaign.Status,
+				_ func() ElectionEvent,
+			) {
+				close(statusCh)
+			},
+			expected: []ElectionEventType{KeepaliveFailedEvent},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// NB: as with the leader service, resigning sends a follower status
+			// and then closes the campaign status channel.
+			var (
+				statusChLock sync.Mutex
+				statusChs    = make(chan chan campaign.Status, 2)
+				statusCh     chan campaign.Status
+			)
+			leaderService := services.NewMockLeaderService(ctrl)
+			leaderService.EXPECT().
+				Campaign(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(string, services.CampaignOptions) (<-chan campaign.Status, error) {
+					statusChLock.Lock()
+					defer statusChLock.Unlock()
+					statusCh = make(chan campaign.Status, 2)
+					statusChs <- statusCh
+					return statusCh, nil
+				}).
+				Times(2)
+			leaderService.EXPECT().
+				Resign(gomock.Any()).
+				DoAndReturn(func(string) error {
+					statusChLock.Lock()
+					defer statusChLock.Unlock()
+					statusCh <- campaign.NewStatus(campaign.Follower)
+					close(statusCh)
+					return nil
+				}).
+				AnyTimes()
+
+			leaderService.EXPECT().Leader(gomock.Any()).Return("someone else", nil).AnyTimes()
+
+			campaignOpts, err := services.NewCampaignOptions()
+			require.NoError(t, err)
+			campaignOpts = campaignOpts.SetLeaderValue("myself")
+			opts := testElectionManagerOptions(t, ctrl).
+				SetCampaignOptions(campaignOpts).
+				SetLeaderService(leaderService)
+			i := placement.NewInstance().SetID("myself")
+			p := placement.NewPlacement().SetInstances([]placement.Instance{
+				i, placement.NewInstance().SetID("someone else"),
+			})
+			placementManager := opts.PlacementManager().(*MockPlacementManager)
+			placementManager.EXPECT().Instance().Return(i, nil).AnyTimes()
+			placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+			mgr := NewElectionManager(opts).(*electionManager)
+			mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+			mgr.sleepFn = func(time.Duration) {}
+			events := mgr.Events()
+			require.NoError(t, mgr.Open(testShardSetID))
+
+			nextEvent := func() ElectionEvent {
+				select {
+				case event := <-events:
+					return event
+				case <-ctx.Done():
+					require.FailNow(t, "timed out waiting for election event")
+					return ElectionEvent{}
+				}
+			}
+
+			require.Equal(t, CampaignStartedEvent, nextEvent().Type)
+			firstStatusCh := <-statusChs
+			firstStatusCh <- campaign.NewStatus(campaign.Leader)
+			require.Equal(t, LeaderAcquiredEvent, nextEvent().Type)
+			test.closeCampaign(t, mgr, firstStatusCh, nextEvent)
+
+			// NB: any keepalive failure is emitted before the campaign is restarted
+			// once the status channel is closed, while leadership is lost
+			// concurrently, so the order in which events are observed is not
+			// deterministic.
+			var (
+				received  []ElectionEventType
+				restarted bool
+			)
+			for !restarted || len(received) < len(test.expected) {
+				if event := nextEvent(); event.Type == CampaignStartedEvent {
+					restarted = true
+				} else {
+					received = append(received, event.Type)
+				}
+			}
+			require.Equal(t, len(test.expected), len(received), "%v", received)
+			for _, eventType := range test.expected {
+				require.Contains(t, received, eventType)
+			}
+			<-statusChs
+
+			require.NoError(t, mgr.Close())
+			for range events {
+			}
+		})
+	}
+}
+
+type recordingElectionAuditSink struct {
+	records chan ElectionAuditRecord
+}
+
+func (s recordingElectionAuditSink) Record(record ElectionAuditRecord) error {
+	s.records <- record
+	return nil
+}
+
+func TestElectionManagerAuditSink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		statusCh    = make(chan campaign.Status, 1)
+		leaderValue = "myself"
+		sink        = recordingElectionAuditSink{records: make(chan ElectionAuditRecord, 10)}
+	)
+	leaderService := services.NewMockLeaderService(ctrl)
+	leaderService.EXPECT().Leader(gomock.Any()).Return("someone else", nil).AnyTimes()
+	leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil).Times(1)
+	leaderService.EXPECT().
+		Resign(gomock.Any()).
+		DoAndReturn(func(string) error {
+			select {
+			case statusCh <- campaign.Status{State: campaign.Follower}:
+			default:
+			}
+			return nil
+		}).
+		AnyTimes()
+
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(leaderValue)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts).
+		SetLeaderService(leaderService).
+		SetAuditSink(sink)
+	i := placement.NewInstance().SetID(leaderValue)
+	p := placement.NewPlacement().SetInstances([]placement.Instance{
+		i, placement.NewInstance().SetID("someone else"),
+	})
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Instance().
+		Return(i, nil).
+		AnyTimes()
+	opts.PlacementManager().(*MockPlacementManager).
+		EXPECT().
+		Placement().
+		Return(nil, p, nil).
+		AnyTimes()
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	nextRecord := func() ElectionAuditRecord {
+		select {
+		case record := <-sink.records:
+			return record
+		case <-ctx.Done():
+			require.FailNow(t, "timed out waiting for audit record")
+			return ElectionAuditRecord{}
+		}
+	}
+
+	statusCh <- campaign.Status{State: campaign.Leader}
+	record := nextRecord()
+	require.Equal(t, LeaderAcquiredEvent, record.Event)
+	require.Equal(t, leaderValue, record.InstanceID)
+	require.Equal(t, testShardSetID, record.ShardSetID)
+	require.Equal(t, int64(1), record.Term)
+	require.False(t, record.Timestamp.IsZero())
+	require.Contains(t, record.Reason, "election state changed")
+
+	// NB: resigning and losing leadership happen concurrently so the order in
+	// which they are recorded is not deterministic.
+	require.NoError(t, mgr.Resign(ctx))
+	records := map[ElectionEventType]ElectionAuditRecord{}
+	for j := 0; j < 2; j++ {
+		record := nextRecord()
+		records[record.Event] = record
+	}
+	require.Equal(t, 2, len(records))
+	for _, eventType := range []ElectionEventType{ResignedEvent, LeaderLostEvent} {
+		record, ok := records[eventType]
+		require.True(t, ok)
+		require.Equal(t, leaderValue, record.InstanceID)
+		require.Equal(t, int64(1), record.Term)
+	}
+
+	require.NoError(t, mgr.Close())
+	select {
+	case record := <-sink.records:
+		require.FailNow(t, "unexpected audit record", "%+v", record)
+	default:
+	}
+}
+
+func TestElectionManagerMinLeadershipHoldDefersStepDown(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
 	var (
-		statusCh = make(chan campaign.Status, 1)
-		mgr      *electionManager
+		mgr        *electionManager
+		resignedAt []time.Time
 	)
-
-	leaderValue := "myself"
 	leaderService := services.NewMockLeaderService(ctrl)
-	leaderService.EXPECT().Leader(gomock.Any()).Return("someone else", nil).AnyTimes()
-	leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil).AnyTimes()
 	leaderService.EXPECT().
 		Resign(gomock.Any()).
 		DoAndReturn(func(string) error {
-			select {
-			case statusCh <- campaign.Status{State: campaign.Follower}:
-			default:
-			}
+			resignedAt = append(resignedAt, time.Now())
+			mgr.processGoalState(goalState{state: FollowerState})
 			return nil
 		}).
-		AnyTimes()
+		Times(2)
 
-	campaignOpts, err := services.NewCampaignOptions()
-	require.NoError(t, err)
-	campaignOpts = campaignOpts.SetLeaderValue(leaderValue)
+	minLeadershipHold := 300 * time.Millisecond
+	scope := tally.NewTestScope("", nil)
 	opts := testElectionManagerOptions(t, ctrl).
-		SetCampaignOptions(campaignOpts).
-		// SetCampaignStateCheckInterval(1 * time.Second).
-		SetLeaderService(leaderService)
-	i := placement.NewInstance().SetID("myself")
-	opts.PlacementManager().(*MockPlacementManager).
-		EXPECT().
-		Instance().
-		Return(i, nil)
-	p := placement.NewPlacement().SetInstances([]placement.Instance{
-		i, placement.NewInstance().SetID("someone else"),
-	})
-	opts.PlacementManager().(*MockPlacementManager).
-		EXPECT().
-		Placement().
-		Return(nil, p, nil)
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetLeaderService(leaderService).
+		SetMinLeadershipHold(minLeadershipHold)
 	mgr = NewElectionManager(opts).(*electionManager)
-	mgr.sleepFn = func(time.Duration) {}
-	mgr.electionStateWatchable.Update(LeaderState)
-	require.NoError(t, mgr.Open(testShardSetID))
+	mgr.state = electionManagerOpen
 
+	// A resign cancelled within the hold window does not resign.
+	leaderSince := time.Now()
+	mgr.processGoalState(goalState{state: LeaderState})
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, mgr.Resign(cancelledCtx))
+	require.Equal(t, 0, len(resignedAt))
+
+	// A resign requested within the hold window is deferred until it expires.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 	require.NoError(t, mgr.Resign(ctx))
+	require.Equal(t, 1, len(resignedAt))
+	require.True(t, resignedAt[0].Sub(leaderSince) >= minLeadershipHold)
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["resign.deferred-step-downs+"].Value())
+
+	// A step-down forced by the shard cutoff within the hold window resigns
+	// without waiting for the window to expire.
+	mgr.processGoalState(goalState{state: LeaderState})
+	mgr.campaignIsEnabledFn = func() (bool, error) { return false, nil }
+	start := time.Now()
+	mgr.processCampaignStateChange(campaignDisabled)
+	require.Equal(t, 2, len(resignedAt))
+	require.True(t, resignedAt[1].Sub(start) < minLeadershipHold)
+	require.Equal(t, campaignDisabled, mgr.campaignState())
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["resign.deferred-step-downs+"].Value())
+}
 
-	var mgrState electionManagerState
-	for i := 0; i < 10; i++ {
-		mgr.RLock()
-		mgrState = mgr.state
-		mgr.RUnlock()
-		if mgr.ElectionState() == FollowerState && mgrState == electionManagerOpen {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
+func TestElectionManagerOptionsValidateMinLeadershipHold(t *testing.T) {
+	opts := NewElectionManagerOptions()
+	require.NoError(t, opts.SetMinLeadershipHold(time.Second).Validate())
+	require.Error(t, opts.SetMinLeadershipHold(-time.Second).Validate())
+}
+
+func TestElectionManagerReconfigureWhileLeading(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	statusCh := make(chan campaign.Status, 1)
+	leaderService := services.NewMockLeaderService(ctrl)
+	leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil).Times(1)
+	leaderService.EXPECT().Resign(gomock.Any()).Return(nil).AnyTimes()
+
+	opts := testElectionManagerOptions(t, ctrl).
+		SetLeaderService(leaderService).
+		SetCampaignStateCheckInterval(time.Hour)
+	mgr := NewElectionManager(opts).(*electionManager)
+	var checks int32
+	mgr.campaignIsEnabledFn = func() (bool, error) {
+		atomic.AddInt32(&checks, 1)
+		return true, nil
 	}
+	require.NoError(t, mgr.Open(testShardSetID))
+	statusCh <- campaign.Status{State: campaign.Leader}
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&checks))
+
+	// Shortening the check interval should take effect without waiting for the
+	// previous interval to elapse, and without affecting leadership.
+	retryOpts := retry.NewOptions().SetInitialBackoff(10 * time.Millisecond)
+	require.NoError(t, mgr.Reconfigure(opts.
+		SetCampaignStateCheckInterval(10*time.Millisecond).
+		SetCampaignRetryOptions(retryOpts)))
+	for atomic.LoadInt32(&checks) < 5 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, LeaderState, mgr.ElectionState())
+	require.True(t, mgr.IsCampaigning())
 
-	require.Equal(t, FollowerState, mgr.ElectionState())
-	require.Equal(t, electionManagerOpen, mgrState)
+	mgr.reconfigureLock.RLock()
+	require.Equal(t, 10*time.Millisecond, mgr.campaignStateCheckInterval)
+	mgr.reconfigureLock.RUnlock()
 	require.NoError(t, mgr.Close())
 }
 
+func TestElectionManagerReconfigureIncompatibleChanges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := testElectionManagerOptions(t, ctrl)
+	mgr := NewElectionManager(opts).(*electionManager)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+
+	inputs := []ElectionManagerOptions{
+		opts.SetLeaderService(services.NewMockLeaderService(ctrl)),
+		opts.SetElectionKeyFmt("/other/%d/lock"),
+		opts.SetElectionKeyPrefix("/env"),
+		opts.SetElectionKeyPrefix("env"),
+		opts.SetCampaignOptions(campaignOpts.SetLeaderValue("other")),
+		opts.SetPlacementManager(NewMockPlacementManager(ctrl)),
+		opts.SetFlushTimesManager(NewMockFlushTimesManager(ctrl)),
+		opts.SetElectionOptions(services.NewElectionOptions().SetTTLSecs(30)),
+	}
+	for _, input := range inputs {
+		require.Error(t, mgr.Reconfigure(input))
+	}
+	require.Equal(t, defaultCampaignStateCheckInterval, mgr.campaignStateCheckInterval)
+	require.NoError(t, mgr.Reconfigure(opts.SetShardCutoffCheckOffset(time.Minute)))
+	require.Equal(t, time.Minute, mgr.shardCutoffCheckOffset)
+}
+
 func TestElectionManagerCloseNotOpenOrResigned(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -502,6 +1515,140 @@ func TestElectionManagerCampaignLoop(t *testing.T) {
 	}
 }
 
+func TestElectionManagerTermMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	lock := &electionLock{holders: make(map[string]string)}
+	scope := tally.NewTestScope("", nil)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts.SetLeaderValue(testInstanceID1)).
+		SetElectionStrategy(newLockElectionStrategy(lock)).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.electionKey = "election"
+	now := time.Unix(0, 0)
+	mgr.nowFn = func() time.Time { return now }
+	setLeader := func(value string) {
+		lock.Lock()
+		defer lock.Unlock()
+		if value == "" {
+			delete(lock.holders, mgr.electionKey)
+			return
+		}
+		lock.holders[mgr.electionKey] = value
+	}
+
+	// Repeated leader statuses do not start a new term, and renewals are only
+	// recorded while the instance is still the leader of the election.
+	mgr.processCampaignUpdate(campaign.NewStatus(campaign.Leader))
+	now = now.Add(time.Second)
+	mgr.processCampaignUpdate(campaign.NewStatus(campaign.Leader))
+	require.Equal(t, int64(1), mgr.currentTerm.id)
+	setLeader(testInstanceID1)
+	mgr.checkTermRenewal()
+	for _, gap := range []time.Duration{10 * time.Second, time.Second} {
+		now = now.Add(gap)
+		mgr.checkTermRenewal()
+	}
+	now = now.Add(time.Second)
+	setLeader(testInstanceID2)
+	mgr.checkTermRenewal()
+	setLeader("")
+	mgr.checkTermRenewal()
+	now = now.Add(time.Second)
+	mgr.processCampaignUpdate(campaign.NewStatus(campaign.Follower))
+	require.Nil(t, mgr.currentTerm)
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(1), snapshot.Counters()["term.started+"].Value())
+	require.Equal(t, int64(1), snapshot.Counters()["term.renewal-check-errors+"].Value())
+	require.Equal(t, float64(1), snapshot.Gauges()["term.id+"].Value())
+	require.Equal(t, float64(3), snapshot.Gauges()["term.renewals+"].Value())
+	require.Equal(t, []time.Duration{14 * time.Second},
+		snapshot.Timers()["term.duration+"].Values())
+	require.Equal(t, []time.Duration{10 * time.Second},
+		snapshot.Timers()["term.longest-renewal-gap+"].Values())
+
+	// Errors and repeated follower statuses do not start a new term.
+	mgr.processCampaignUpdate(campaign.NewErrorStatus(errors.New("foo")))
+	mgr.processCampaignUpdate(campaign.NewStatus(campaign.Follower))
+	mgr.checkTermRenewal()
+	require.Nil(t, mgr.currentTerm)
+
+	// A second term lost without renewals when the campaign status channel
+	// closes records the time until the term ended as its longest gap.
+	mgr.processCampaignUpdate(campaign.NewStatus(campaign.Leader))
+	require.Equal(t, int64(2), mgr.currentTerm.id)
+	now = now.Add(30 * time.Second)
+	mgr.endTerm("campaign status channel closed")
+
+	snapshot = scope.Snapshot()
+	require.Equal(t, int64(2), snapshot.Counters()["term.started+"].Value())
+	require.Equal(t, float64(2), snapshot.Gauges()["term.id+"].Value())
+	require.Equal(t, float64(0), snapshot.Gauges()["term.renewals+"].Value())
+	require.Equal(t, []time.Duration{14 * time.Second, 30 * time.Second},
+		snapshot.Timers()["term.duration+"].Values())
+	require.Equal(t, []time.Duration{10 * time.Second, 30 * time.Second},
+		snapshot.Timers()["term.longest-renewal-gap+"].Values())
+}
+
+func TestElectionManagerCampaignLoopChecksTermRenewals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		lock     = &electionLock{holders: make(map[string]string)}
+		scope    = tally.NewTestScope("", nil)
+		nowNanos int64
+		armedCh  = make(chan time.Duration)
+		checkCh  = make(chan time.Time)
+	)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetCampaignOptions(campaignOpts.SetLeaderValue(testInstanceID1)).
+		SetElectionStrategy(newLockElectionStrategy(lock)).
+		SetCampaignStateCheckInterval(5 * time.Second).
+		SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time {
+			return time.Unix(0, atomic.LoadInt64(&nowNanos))
+		})).
+		SetAfterFn(func(d time.Duration) <-chan time.Time {
+			armedCh <- d
+			return checkCh
+		}).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.electionKey = "election"
+	mgr.campaignStateWatchable.Update(campaignEnabled)
+	_, campaignStateWatch, err := mgr.campaignStateWatchable.Watch()
+	require.NoError(t, err)
+	doneCh := make(chan struct{})
+	mgr.Add(1)
+	go mgr.campaignLoop(campaignStateWatch, doneCh)
+
+	// A renewal check is armed once the instance leads, and rearmed after
+	// every check.
+	require.Equal(t, 5*time.Second, <-armedCh)
+	for _, gap := range []time.Duration{5 * time.Second, 10 * time.Second} {
+		atomic.AddInt64(&nowNanos, int64(gap))
+		checkCh <- time.Unix(0, atomic.LoadInt64(&nowNanos))
+		require.Equal(t, 5*time.Second, <-armedCh)
+	}
+	atomic.AddInt64(&nowNanos, int64(3*time.Second))
+	close(doneCh)
+	mgr.Wait()
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, float64(2), snapshot.Gauges()["term.renewals+"].Value())
+	require.Equal(t, []time.Duration{18 * time.Second},
+		snapshot.Timers()["term.duration+"].Values())
+	require.Equal(t, []time.Duration{10 * time.Second},
+		snapshot.Timers()["term.longest-renewal-gap+"].Values())
+}
+
 func TestElectionManagerVerifyLeaderDelayWithValidLeader(t *testing.T) {
 	t.Parallel()
 
@@ -556,7 +1703,7 @@ func TestElectionManagerVerifyLeaderDelayWithValidLeader(t *testing.T) {
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 
 	for {
@@ -618,7 +1765,7 @@ func TestElectionManagerVerifyLeaderDelayWithLeaderNotInPlacement(t *testing.T)
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 	for {
 		if atomic.LoadInt32(&iter) > 10 {
@@ -684,7 +1831,7 @@ func TestElectionManagerVerifyLeaderDelayWithLeaderOwningDifferentShardSet(t *te
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 	for {
 		if atomic.LoadInt32(&iter) > 10 {
@@ -748,7 +1895,7 @@ func TestElectionManagerVerifyWithLeaderErrors(t *testing.T) {
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 
 	for {
@@ -790,7 +1937,7 @@ func TestElectionManagerVerifyPendingFollowerStale(t *testing.T) {
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 
 	for {
@@ -828,7 +1975,7 @@ func TestElectionManagerVerifyCampaignDisabled(t *testing.T) {
 	require.NoError(t, err)
 
 	mgr.Add(1)
-	go mgr.verifyPendingFollower(watch)
+	go mgr.verifyPendingFollower(watch, mgr.doneCh)
 	mgr.goalStateWatchable.Update(goalState{state: PendingFollowerState})
 
 	// Sleep a little and check nothing changes.
@@ -961,6 +2108,64 @@ func TestElectionManagerCampaignIsEnabledWithActiveShards(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestElectionManagerCampaignIsEnabledNoShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		start         = time.Unix(1000, 0)
+		clk           = newFakeElectionClock(start)
+		checkInterval = time.Second
+		scope         = tally.NewTestScope("", nil)
+		hasShards     int32
+	)
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().
+		Shards().
+		DoAndReturn(func() (shard.Shards, error) {
+			if atomic.LoadInt32(&hasShards) == 0 {
+				return shard.NewShards(nil), nil
+			}
+			return shard.NewShards([]shard.Shard{shard.NewShard(0)}), nil
+		}).
+		AnyTimes()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetClockOptions(clock.NewOptions().SetNowFn(clk.Now)).
+		SetAfterFn(clk.After).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetCampaignOptions(campaignOpts).
+		SetPlacementManager(placementManager).
+		SetLeaderService(newMemLeaderService(newMemElectionBackend(), testInstanceID1)).
+		SetCampaignStateCheckInterval(checkInterval)
+	mgr := NewElectionManager(opts).(*electionManager)
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	// The instance never campaigns while it owns no shards.
+	for i := 1; i <= 3; i++ {
+		clk.waitForWaiter(start.Add(time.Duration(i) * checkInterval))
+		clk.Advance(checkInterval)
+	}
+	clk.waitForWaiter(start.Add(4 * checkInterval))
+	require.Equal(t, int32(0), atomic.LoadInt32(&mgr.campaigning))
+	require.NotEqual(t, LeaderState, mgr.ElectionState())
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(4), counters["campaign-check.no-shards+"].Value())
+	require.Equal(t, int64(0), counters["campaign-check.no-cutover-shards+"].Value())
+
+	// Once assigned shards, the next check enables the campaign.
+	atomic.StoreInt32(&hasShards, 1)
+	clk.Advance(checkInterval)
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, int64(4), scope.Snapshot().Counters()["campaign-check.no-shards+"].Value())
+
+	require.NoError(t, mgr.Close())
+}
+
 func TestElectionManagerCampaignIsEnabledNoCutoverShards(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1157,6 +2362,292 @@ func TestElectionManagerCampaignIsEnabledUnexpectedShardCutoverCutoffTimes(t *te
 	require.Equal(t, errUnexpectedShardCutoverCutoffTimes, err)
 }
 
+func TestElectionManagerOptionsValidateQuorumGuard(t *testing.T) {
+	opts := NewElectionManagerOptions().SetQuorumGuardEnabled(true)
+	require.Equal(t, errQuorumGuardLiveInstancesUnsupported, opts.Validate())
+
+	leaderService := newMemLeaderService(newMemElectionBackend(), testInstanceID1)
+	backend := NewLeaderServiceElectionBackend(leaderService)
+	require.Equal(t, errQuorumGuardLiveInstancesUnsupported,
+		opts.SetElectionBackend(backend).Validate())
+	require.NoError(t, opts.SetElectionBackend(newMemElectionBackend()).Validate())
+	require.NoError(t, opts.SetQuorumGuardEnabled(false).Validate())
+}
+
+func TestElectionManagerCampaignIsEnabledQuorumGuard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	instance1 := placement.NewInstance().SetID(testInstanceID1).SetShardSetID(testShardSetID)
+	instance2 := placement.NewInstance().SetID(testInstanceID2).SetShardSetID(testShardSetID)
+	instance3 := placement.NewInstance().SetID(testInstanceID3).SetShardSetID(testShardSetID)
+	otherShardSet := placement.NewInstance().SetID("other").SetShardSetID(testShardSetID + 1)
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().
+		Shards().
+		Return(shard.NewShards([]shard.Shard{shard.NewShard(0)}), nil).
+		AnyTimes()
+	placementManager.EXPECT().Instance().Return(instance1, nil).AnyTimes()
+	placementManager.EXPECT().
+		Placement().
+		Return(nil, placement.NewPlacement().SetInstances([]placement.Instance{
+			instance1, instance2, instance3, otherShardSet,
+		}), nil).
+		AnyTimes()
+
+	backend := newMemElectionBackend()
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	scope := tally.NewTestScope("", nil)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetCampaignOptions(campaignOpts).
+		SetPlacementManager(placementManager).
+		SetLeaderService(newMemLeaderService(backend, testInstanceID1)).
+		SetElectionBackend(backend).
+		SetCampaignStateCheckInterval(10 * time.Millisecond).
+		SetQuorumGuardEnabled(true)
+	require.NoError(t, opts.Validate())
+	mgr := NewElectionManager(opts).(*electionManager)
+	// NB: the guard is only applied by the campaign state check loop once the
+	// instance has become the leader and the test has set up the sessions.
+	var guardActive int32
+	mgr.campaignIsEnabledFn = func() (bool, error) {
+		if atomic.LoadInt32(&guardActive) == 0 {
+			return true, nil
+		}
+		return mgr.campaignIsEnabled()
+	}
+
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A quorum of the shard set is visible, instances in other shard sets aside.
+	backend.addSession(mgr.electionKey, testInstanceID2)
+	backend.addSession(mgr.electionKey, "other")
+	enabled, err := mgr.campaignIsEnabled()
+	require.NoError(t, err)
+	require.True(t, enabled)
+
+	// Losing sight of a quorum disables campaigning, while the leader is only
+	// stepped down by the campaign state check loop.
+	backend.Lock()
+	backend.removeSessionWithLock(mgr.electionKey, testInstanceID2)
+	backend.Unlock()
+	for i := 0; i < 2; i++ {
+		enabled, err = mgr.campaignIsEnabled()
+		require.NoError(t, err)
+		require.False(t, enabled)
+	}
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["campaign-check.quorum-loss+"].Value())
+	require.Equal(t, int64(0), counters["resign.quorum-loss-step-downs+"].Value())
+
+	// Step-downs are counted once leadership is given up, not on every check.
+	atomic.StoreInt32(&guardActive, 1)
+	for mgr.ElectionState() != FollowerState || mgr.campaignState() != campaignDisabled {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	counters = scope.Snapshot().Counters()
+	require.True(t, counters["campaign-check.quorum-loss+"].Value() > 2)
+	require.Equal(t, int64(1), counters["resign.quorum-loss-step-downs+"].Value())
+
+	// The guard is a no-op once disabled.
+	require.NoError(t, mgr.Reconfigure(opts.SetQuorumGuardEnabled(false)))
+	enabled, err = mgr.campaignIsEnabled()
+	require.NoError(t, err)
+	require.True(t, enabled)
+}
+
+// fakeElectionClock is a manually advanced clock for driving the timing of
+// the election manager deterministically.
+type fakeElectionClock struct {
+	sync.Mutex
+
+	now     time.Time
+	waiters []fakeElectionClockWaiter
+}
+
+type fakeElectionClockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+func newFakeElectionClock(now time.Time) *fakeElectionClock {
+	return &fakeElectionClock{now: now}
+}
+
+func (c *fakeElectionClock) Now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.now
+}
+
+func (c *fakeElectionClock) After(d time.Duration) <-chan time.Time {
+	c.Lock()
+	defer c.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeElectionClockWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing all waiters that are due.
+func (c *fakeElectionClock) Advance(d time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// waitForWaiter blocks until something waits for the given deadline.
+func (c *fakeElectionClock) waitForWaiter(deadline time.Time) {
+	for {
+		c.Lock()
+		for _, w := range c.waiters {
+			if w.deadline.Equal(deadline) {
+				c.Unlock()
+				return
+			}
+		}
+		c.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestElectionManagerFakeClockLifecycle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		start         = time.Unix(1000, 0)
+		clk           = newFakeElectionClock(start)
+		hold          = time.Hour
+		checkInterval = time.Second
+		scope         = tally.NewTestScope("", nil)
+		enabled       = int32(1)
+	)
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	campaignOpts = campaignOpts.SetLeaderValue(testInstanceID1)
+	opts := testElectionManagerOptions(t, ctrl).
+		SetClockOptions(clock.NewOptions().SetNowFn(clk.Now)).
+		SetAfterFn(clk.After).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetCampaignOptions(campaignOpts).
+		SetChangeRetryOptions(retry.NewOptions().SetInitialBackoff(time.Millisecond)).
+		SetLeaderService(newMemLeaderService(newMemElectionBackend(), testInstanceID1)).
+		SetCampaignStateCheckInterval(checkInterval).
+		SetMinLeadershipHold(hold)
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) {
+		return atomic.LoadInt32(&enabled) == 1, nil
+	}
+	require.NoError(t, mgr.Open(testShardSetID))
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, start.UnixNano(), atomic.LoadInt64(&mgr.leaderSinceNanos))
+
+	// A resign requested within the min leadership hold is deferred until the
+	// hold elapses on the clock.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resignErrCh := make(chan error, 1)
+	go func() {
+		resignErrCh <- mgr.Resign(ctx)
+	}()
+	clk.waitForWaiter(start.Add(hold))
+	require.Equal(t, LeaderState, mgr.ElectionState())
+
+	// Once the hold elapses the instance resigns, which ends the campaign. The
+	// resign then waits for a new leader, of which there is none.
+	clk.Advance(hold)
+	backOffEnd := start.Add(hold).Add(backOffOnResignOrElectionError)
+	clk.waitForWaiter(backOffEnd)
+	cancel()
+	require.Equal(t, context.Canceled, <-resignErrCh)
+
+	// The instance is elected again after backing off.
+	clk.Advance(backOffOnResignOrElectionError)
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(time.Millisecond)
+	}
+	require.Equal(t, backOffEnd.UnixNano(), atomic.LoadInt64(&mgr.leaderSinceNanos))
+
+	// Disabling the campaign within the hold is noticed on a later check, and
+	// steps down without waiting for the hold to elapse.
+	atomic.StoreInt32(&enabled, 0)
+	for mgr.ElectionState() != FollowerState || mgr.campaignState() != campaignDisabled {
+		clk.Advance(checkInterval)
+		time.Sleep(time.Millisecond)
+	}
+	require.True(t, clk.Now().Sub(backOffEnd) < hold)
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["term.started+"].Value())
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["resign.deferred-step-downs+"].Value())
+
+	require.NoError(t, mgr.Close())
+}
+
+func TestElectionManagerRetriersUseClock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		start    = time.Unix(1000, 0)
+		clk      = newFakeElectionClock(start)
+		backoff  = time.Hour
+		statusCh = make(chan campaign.Status, 1)
+	)
+	leaderService := services.NewMockLeaderService(ctrl)
+	gomock.InOrder(
+		leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(nil, errors.New("campaign error")),
+		leaderService.EXPECT().Campaign(gomock.Any(), gomock.Any()).Return(statusCh, nil),
+	)
+	leaderService.EXPECT().Resign(gomock.Any()).Return(nil).AnyTimes()
+
+	opts := testElectionManagerOptions(t, ctrl).
+		SetClockOptions(clock.NewOptions().SetNowFn(clk.Now)).
+		SetAfterFn(clk.After).
+		SetLeaderService(leaderService).
+		SetCampaignStateCheckInterval(time.Minute).
+		SetCampaignRetryOptions(retry.NewOptions().SetInitialBackoff(backoff).SetJitter(false))
+	mgr := NewElectionManager(opts).(*electionManager)
+	mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	// The failed campaign is retried once the backoff elapses on the clock
+	// rather than in real time.
+	clk.waitForWaiter(start.Add(backoff))
+	require.Equal(t, FollowerState, mgr.ElectionState())
+	clk.Advance(backoff)
+	statusCh <- campaign.Status{State: campaign.Leader}
+	for mgr.ElectionState() != LeaderState {
+		time.Sleep(time.Millisecond)
+	}
+	require.NoError(t, mgr.Close())
+}
+
+func TestElectionManagerOptionsValidateAfterFn(t *testing.T) {
+	require.Equal(t, errNoAfterFn, NewElectionManagerOptions().SetAfterFn(nil).Validate())
+}
+
 func testElectionManagerOptions(t *testing.T, ctrl *gomock.Controller) ElectionManagerOptions {
 	campaignOpts, err := services.NewCampaignOptions()
 	require.NoError(t, err)
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/cluster/services/leader/campaign"
+)
+
+// ElectionStrategy is how leadership of an election is acquired and held, e.g.
+// by holding a lease which is kept alive while leading, or by holding a
+// distributed lock until it is released.
+type ElectionStrategy interface {
+	// Campaign campaigns for leadership of the given election, returning a
+	// channel receiving the status of the campaign, which is closed once the
+	// campaign ends, e.g. when resigning. The channel must be consumed until it
+	// is closed.
+	Campaign(electionID string, opts services.CampaignOptions) (<-chan campaign.Status, error)
+
+	// Resign gives up leadership of the given election, ending the campaign.
+	Resign(electionID string) error
+
+	// Leader returns the leader value of the given election.
+	Leader(electionID string) (string, error)
+}
+
+type leaseElectionStrategy struct {
+	leaderService services.LeaderService
+}
+
+// NewLeaseElectionStrategy creates an election strategy campaigning with the
+// given leader service, where leadership is held by keeping alive a lease,
+// e.g. an etcd session lease, and lost if the lease expires. This is the
+// default.
+func NewLeaseElectionStrategy(leaderService services.LeaderService) ElectionStrategy {
+	return leaseElectionStrategy{leaderService: leaderService}
+}
+
+func (s leaseElectionStrategy) Campaign(
+	electionID string,
+	opts services.CampaignOptions,
+) (<-chan campaign.Status, error) {
+	return s.leaderService.Campaign(electionID, opts)
+}
+
+func (s leaseElectionStrategy) Resign(electionID string) error {
+	return s.leaderService.Resign(electionID)
+}
+
+func (s leaseElectionStrategy) Leader(electionID string) (string, error) {
+	return s.leaderService.Leader(electionID)
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/services"
+	"github.com/m3db/m3/src/cluster/services/leader"
+	"github.com/m3db/m3/src/cluster/services/leader/campaign"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// electionLock is a distributed lock held by at most one campaign of each
+// election until it resigns, with no lease to keep alive.
+type electionLock struct {
+	sync.Mutex
+
+	holders map[string]string
+}
+
+type lockElectionStrategy struct {
+	sync.Mutex
+
+	lock      *electionLock
+	campaigns map[string]chan struct{}
+}
+
+func newLockElectionStrategy(lock *electionLock) *lockElectionStrategy {
+	return &lockElectionStrategy{lock: lock, campaigns: make(map[string]chan struct{})}
+}
+
+func (s *lockElectionStrategy) Campaign(
+	electionID string,
+	opts services.CampaignOptions,
+) (<-chan campaign.Status, error) {
+	resignCh := make(chan struct{})
+	s.Lock()
+	s.campaigns[electionID] = resignCh
+	s.Unlock()
+
+	// NB: a campaign sends at most three statuses so sends never block.
+	statusCh := make(chan campaign.Status, 3)
+	go func() {
+		defer close(statusCh)
+
+		statusCh <- campaign.NewStatus(campaign.Follower)
+		for !s.tryLock(electionID, opts.LeaderValue()) {
+			select {
+			case <-resignCh:
+				statusCh <- campaign.NewStatus(campaign.Follower)
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+
+		statusCh <- campaign.NewStatus(campaign.Leader)
+		<-resignCh
+		s.unlock(electionID, opts.LeaderValue())
+		statusCh <- campaign.NewStatus(campaign.Follower)
+	}()
+	return statusCh, nil
+}
+
+func (s *lockElectionStrategy) tryLock(electionID, value string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, held := s.lock.holders[electionID]; held {
+		return false
+	}
+	s.lock.holders[electionID] = value
+	return true
+}
+
+func (s *lockElectionStrategy) unlock(electionID, value string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.lock.holders[electionID] == value {
+		delete(s.lock.holders, electionID)
+	}
+}
+
+func (s *lockElectionStrategy) Resign(electionID string) error {
+	s.Lock()
+	defer s.Unlock()
+	if resignCh, ok := s.campaigns[electionID]; ok {
+		close(resignCh)
+		delete(s.campaigns, electionID)
+	}
+	return nil
+}
+
+func (s *lockElectionStrategy) Leader(electionID string) (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	value, ok := s.lock.holders[electionID]
+	if !ok {
+		return "", leader.ErrNoLeader
+	}
+	return value, nil
+}
+
+func TestLeaseElectionStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	campaignOpts, err := services.NewCampaignOptions()
+	require.NoError(t, err)
+	statusCh := make(chan campaign.Status)
+	leaderService := services.NewMockLeaderService(ctrl)
+	gomock.InOrder(
+		leaderService.EXPECT().Campaign("election", campaignOpts).Return(statusCh, nil),
+		leaderService.EXPECT().Leader("election").Return("myself", nil),
+		leaderService.EXPECT().Resign("election").Return(nil),
+	)
+
+	strategy := NewLeaseElectionStrategy(leaderService)
+	ch, err := strategy.Campaign("election", campaignOpts)
+	require.NoError(t, err)
+	require.Equal(t, (<-chan campaign.Status)(statusCh), ch)
+	value, err := strategy.Leader("election")
+	require.NoError(t, err)
+	require.Equal(t, "myself", value)
+	require.NoError(t, strategy.Resign("election"))
+}
+
+func TestElectionManagerLockElectionStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var (
+		lock      = &electionLock{holders: make(map[string]string)}
+		instances = []placement.Instance{
+			placement.NewInstance().SetID(testInstanceID1),
+			placement.NewInstance().SetID(testInstanceID2),
+		}
+		p = placement.NewPlacement().SetInstances(instances)
+	)
+	newElectionManager := func(instance placement.Instance) *electionManager {
+		campaignOpts, err := services.NewCampaignOptions()
+		require.NoError(t, err)
+		opts := testElectionManagerOptions(t, ctrl).
+			SetCampaignOptions(campaignOpts.SetLeaderValue(instance.ID())).
+			SetElectionStrategy(newLockElectionStrategy(lock))
+		placementManager := opts.PlacementManager().(*MockPlacementManager)
+		placementManager.EXPECT().Instance().Return(instance, nil).AnyTimes()
+		placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+		mgr := NewElectionManager(opts).(*electionManager)
+		mgr.campaignIsEnabledFn = func() (bool, error) { return true, nil }
+		return mgr
+	}
+	waitForState := func(mgr *electionManager, state ElectionState) {
+		for mgr.ElectionState() != state {
+			require.NoError(t, ctx.Err(), "timed out waiting for %v", state)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// The first instance to campaign acquires the lock and leads.
+	mgr1 := newElectionManager(instances[0])
+	require.NoError(t, mgr1.Open(testShardSetID))
+	waitForState(mgr1, LeaderState)
+	mgr2 := newElectionManager(instances[1])
+	require.NoError(t, mgr2.Open(testShardSetID))
+	waitForState(mgr2, FollowerState)
+	require.True(t, mgr1.IsLeader())
+	require.False(t, mgr2.IsLeader())
+
+	// Resigning releases the lock for the other instance to acquire, while the
+	// resigned instance backs off before campaigning again and waiting for the
+	// lock.
+	require.NoError(t, mgr1.Resign(ctx))
+	waitForState(mgr2, LeaderState)
+	waitForState(mgr1, FollowerState)
+	require.Equal(t, int64(1), mgr2.FencingToken())
+
+	require.NoError(t, mgr2.Close())
+	require.NoError(t, mgr1.Close())
+}
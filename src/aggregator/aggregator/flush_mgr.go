@@ -117,7 +117,7 @@ type flushManager struct {
 	checkEvery    time.Duration
 	jitterEnabled bool
 	maxJitterFn   FlushJitterFn
-	electionMgr   ElectionManager
+	leadership    LeadershipProvider
 	leaderOpts    FlushManagerOptions
 	followerOpts  FlushManagerOptions
 
@@ -156,7 +156,7 @@ func NewFlushManager(opts FlushManagerOptions) FlushManager {
 		checkEvery:    opts.CheckEvery(),
 		jitterEnabled: opts.JitterEnabled(),
 		maxJitterFn:   opts.MaxJitterFn(),
-		electionMgr:   opts.ElectionManager(),
+		leadership:    opts.ElectionManager(),
 		leaderOpts:    leaderOpts,
 		followerOpts:  followerOpts,
 		rand:          rand,
@@ -350,12 +350,10 @@ func (mgr *flushManager) flush() {
 }
 
 func (mgr *flushManager) checkElectionState() ElectionState {
-	switch mgr.electionMgr.ElectionState() {
-	case FollowerState:
-		return FollowerState
-	default:
+	if mgr.leadership.IsLeader() {
 		return LeaderState
 	}
+	return FollowerState
 }
 
 func (mgr *flushManager) flushManagerWithLock() roleBasedFlushManager {
@@ -89,11 +89,12 @@ type FlushManagerOptions interface {
 	// PlacementManager returns the placement manager.
 	PlacementManager() PlacementManager
 
-	// SetElectionManager sets the election manager.
-	SetElectionManager(value ElectionManager) FlushManagerOptions
+	// SetElectionManager sets the leadership provider, which is usually the
+	// election manager.
+	SetElectionManager(value LeadershipProvider) FlushManagerOptions
 
-	// ElectionManager returns the election manager.
-	ElectionManager() ElectionManager
+	// ElectionManager returns the leadership provider.
+	ElectionManager() LeadershipProvider
 
 	// SetFlushTimesManager sets the flush times manager.
 	SetFlushTimesManager(value FlushTimesManager) FlushManagerOptions
@@ -132,7 +133,7 @@ type flushManagerOptions struct {
 	maxJitterFn            FlushJitterFn
 	workerPool             sync.WorkerPool
 	placementManager       PlacementManager
-	electionManager        ElectionManager
+	electionManager        LeadershipProvider
 	flushTimesManager      FlushTimesManager
 	flushTimesPersistEvery time.Duration
 	maxBufferSize          time.Duration
@@ -225,13 +226,13 @@ func (o *flushManagerOptions) PlacementManager() PlacementManager {
 	return o.placementManager
 }
 
-func (o *flushManagerOptions) SetElectionManager(value ElectionManager) FlushManagerOptions {
+func (o *flushManagerOptions) SetElectionManager(value LeadershipProvider) FlushManagerOptions {
 	opts := *o
 	opts.electionManager = value
 	return &opts
 }
 
-func (o *flushManagerOptions) ElectionManager() ElectionManager {
+func (o *flushManagerOptions) ElectionManager() LeadershipProvider {
 	return o.electionManager
 }
 
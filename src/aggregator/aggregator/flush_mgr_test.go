@@ -449,15 +449,15 @@ func TestFlushManagerFlush(t *testing.T) {
 	defer ctrl.Finish()
 
 	var (
-		slept             int32
-		followerFlushes   int
-		followerInits     int
-		leaderFlushes     int
-		leaderInits       int
-		electionStateLock sync.Mutex
-		electionState     = FollowerState
-		signalCh          = make(chan struct{})
-		captured          []*flushBucket
+		slept           int32
+		followerFlushes int
+		followerInits   int
+		leaderFlushes   int
+		leaderInits     int
+		leadershipLock  sync.Mutex
+		isLeader        bool
+		signalCh        = make(chan struct{})
+		captured        []*flushBucket
 	)
 	followerFlushTask := NewMockflushTask(ctrl)
 	followerFlushTask.EXPECT().
@@ -482,13 +482,13 @@ func TestFlushManagerFlush(t *testing.T) {
 			time.Sleep(50 * time.Millisecond)
 		}
 	}
-	electionManager := NewMockElectionManager(ctrl)
-	electionManager.EXPECT().
-		ElectionState().
-		DoAndReturn(func() ElectionState {
-			electionStateLock.Lock()
-			defer electionStateLock.Unlock()
-			return electionState
+	leadership := NewMockLeadershipProvider(ctrl)
+	leadership.EXPECT().
+		IsLeader().
+		DoAndReturn(func() bool {
+			leadershipLock.Lock()
+			defer leadershipLock.Unlock()
+			return isLeader
 		}).
 		AnyTimes()
 
@@ -498,7 +498,7 @@ func TestFlushManagerFlush(t *testing.T) {
 		SetJitterEnabled(false)
 	mgr := NewFlushManager(opts).(*flushManager)
 	mgr.sleepFn = sleepFn
-	mgr.electionMgr = electionManager
+	mgr.leadership = leadership
 
 	leaderMgr := NewMockroleBasedFlushManager(ctrl)
 	leaderMgr.EXPECT().Open().AnyTimes()
@@ -556,9 +556,9 @@ func TestFlushManagerFlush(t *testing.T) {
 	require.Equal(t, 0, leaderInits)
 
 	// Transition to leader.
-	electionStateLock.Lock()
-	electionState = LeaderState
-	electionStateLock.Unlock()
+	leadershipLock.Lock()
+	isLeader = true
+	leadershipLock.Unlock()
 	signalCh <- struct{}{}
 	waitUntilSlept(2)
 	require.Equal(t, 1, followerFlushes)
@@ -567,9 +567,9 @@ func TestFlushManagerFlush(t *testing.T) {
 	require.Equal(t, 1, leaderInits)
 
 	// Transition to follower.
-	electionStateLock.Lock()
-	electionState = FollowerState
-	electionStateLock.Unlock()
+	leadershipLock.Lock()
+	isLeader = false
+	leadershipLock.Unlock()
 	signalCh <- struct{}{}
 	waitUntilSlept(3)
 	require.Equal(t, 2, followerFlushes)
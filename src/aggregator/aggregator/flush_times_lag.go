@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// FlushLagReport is the flush lag of a shard set reported to a central
+// registry, giving a cluster-wide view of the worst flush lag of each instance.
+type FlushLagReport struct {
+	// ShardSetID is the shard set the flush times belong to.
+	ShardSetID uint32
+
+	// NumShards is the number of shards with flush times.
+	NumShards int
+
+	// OldestShardID is the shard owning the earliest flush time.
+	OldestShardID uint32
+
+	// MaxLag is the time since the earliest flush time across all shards.
+	MaxLag time.Duration
+
+	// Timestamp is when the lag was computed.
+	Timestamp time.Time
+}
+
+// FlushLagReporter reports the flush lag of the flush times manager to a
+// central registry.
+type FlushLagReporter interface {
+	// Report reports the given flush lag.
+	Report(report FlushLagReport) error
+}
+
+type noopFlushLagReporter struct{}
+
+// NewNoopFlushLagReporter creates a new reporter discarding flush lag, which
+// is the default.
+func NewNoopFlushLagReporter() FlushLagReporter {
+	return noopFlushLagReporter{}
+}
+
+func (noopFlushLagReporter) Report(FlushLagReport) error {
+	return nil
+}
+
+type httpFlushLagReport struct {
+	InstanceID     string `json:"instanceID"`
+	ShardSetID     uint32 `json:"shardSetID"`
+	NumShards      int    `json:"numShards"`
+	OldestShardID  uint32 `json:"oldestShardID"`
+	MaxLagNanos    int64  `json:"maxLagNanos"`
+	TimestampNanos int64  `json:"timestampNanos"`
+}
+
+type httpFlushLagReporter struct {
+	url        string
+	instanceID string
+	client     *http.Client
+}
+
+// NewHTTPFlushLagReporter creates a new reporter POSTing the flush lag of the
+// given instance as JSON to the registry at the given URL with the given client.
+func NewHTTPFlushLagReporter(url, instanceID string, client *http.Client) FlushLagReporter {
+	return httpFlushLagReporter{url: url, instanceID: instanceID, client: client}
+}
+
+func (r httpFlushLagReporter) Report(report FlushLagReport) error {
+	data, err := json.Marshal(httpFlushLagReport{
+		InstanceID:     r.instanceID,
+		ShardSetID:     report.ShardSetID,
+		NumShards:      report.NumShards,
+		OldestShardID:  report.OldestShardID,
+		MaxLagNanos:    int64(report.MaxLag),
+		TimestampNanos: report.Timestamp.UnixNano(),
+	})
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Post(r.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// NB: drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("reporting flush lag to %s failed with status code %d",
+			r.url, resp.StatusCode)
+	}
+	return nil
+}
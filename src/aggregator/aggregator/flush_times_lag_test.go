@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFlushLagReporterReport(t *testing.T) {
+	var received []httpFlushLagReport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var report httpFlushLagReport
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&report))
+		received = append(received, report)
+	}))
+	defer server.Close()
+
+	reporter := NewHTTPFlushLagReporter(server.URL, "instance1", server.Client())
+	require.NoError(t, reporter.Report(FlushLagReport{
+		ShardSetID:    3,
+		NumShards:     2,
+		OldestShardID: 1,
+		MaxLag:        time.Minute,
+		Timestamp:     time.Unix(0, 1000),
+	}))
+	require.Equal(t, []httpFlushLagReport{
+		{
+			InstanceID:     "instance1",
+			ShardSetID:     3,
+			NumShards:      2,
+			OldestShardID:  1,
+			MaxLagNanos:    int64(time.Minute),
+			TimestampNanos: 1000,
+		},
+	}, received)
+}
+
+func TestHTTPFlushLagReporterReportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewHTTPFlushLagReporter(server.URL, "instance1", server.Client()).
+		Report(FlushLagReport{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "status code 503")
+}
@@ -21,9 +21,15 @@
 package aggregator
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
 	"github.com/m3db/m3/src/cluster/kv"
@@ -32,10 +38,18 @@ import (
 	"github.com/m3db/m3/src/x/retry"
 	"github.com/m3db/m3/src/x/watch"
 
+	"github.com/gogo/protobuf/proto"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
+const (
+	flushAgeMetricName = "aggregator_flush_age_seconds"
+	flushAgeMetricHelp = "Time since the last flush by shard, resolution and flush type."
+)
+
 type getFlushTimesByResolutionFn func(*schema.ShardFlushTimes) map[int64]int64
 
 var (
@@ -65,13 +79,105 @@ type FlushTimesManager interface {
 	// Watch watches for updates to flush times.
 	Watch() (watch.Watch, error)
 
+	// WatchShard watches for changes to the flush time of the given shard, which
+	// is the earliest of its flush times across all resolutions. The returned
+	// channel only holds the latest flush time if the subscriber falls behind,
+	// and is closed once the returned function is called or the manager is closed.
+	WatchShard(shardID uint32) (<-chan int64, func(), error)
+
+	// WaitUntilAdvanced blocks until the standard flush time of the given shard
+	// and resolution is later than the given time in nanoseconds, returning the
+	// context error if the context is done first.
+	WaitUntilAdvanced(
+		ctx context.Context,
+		shardID uint32,
+		resolution time.Duration,
+		past int64,
+	) error
+
+	// Summary returns a summary of the latest flush times across all shards.
+	Summary() (FlushTimesSummary, error)
+
 	// StoreAsync stores the flush times asynchronously.
 	StoreAsync(value *schema.ShardSetFlushTimes) error
 
+	// StoreIf stores the flush times synchronously if the given predicate holds
+	// for the flush times currently persisted, which are nil if none are. The
+	// predicate is re-evaluated against the latest flush times each time the
+	// store conflicts with a concurrent store, so it never clobbers flush times
+	// it has not seen. Returns whether the flush times were stored.
+	StoreIf(
+		predicate func(current *schema.ShardSetFlushTimes) bool,
+		value *schema.ShardSetFlushTimes,
+	) (bool, error)
+
+	// DryRunStore returns the serialized payload that would be persisted for
+	// the given flush times without writing it to kv.
+	DryRunStore(value *schema.ShardSetFlushTimes) ([]byte, error)
+
+	// VerifyConsistency cross-checks the latest flush times against the shards
+	// currently owned by the instance, reporting owned shards without flush times
+	// and shards with flush times that are no longer owned.
+	VerifyConsistency() (ConsistencyReport, error)
+
+	// AdoptFrom replaces the local flush times with the flush times of the shard
+	// set owned by the given instance, e.g. during a leadership handoff. The local
+	// flush times are left untouched unless the remote flush times are read and
+	// decoded in full. The flush times are stored synchronously with a
+	// check-and-set, and kv.ErrVersionMismatch is returned if the local flush
+	// times are stored concurrently.
+	AdoptFrom(instanceID string) error
+
+	// Backfill moves the standard flush times of the given shards and resolutions
+	// forward in a single store, e.g. after restoring from a backup, to avoid
+	// re-aggregating data already flushed. Flush times only ever move forward, so
+	// the backfill is rejected as a whole if it would move any of them backward.
+	// The backfill is applied to the flush times in kv with a check-and-set, and
+	// is rejected if no flush times have been loaded yet.
+	Backfill(times map[uint32]map[time.Duration]int64) error
+
+	// DumpOpenMetrics writes the age of the latest flush times of each shard and
+	// resolution to the given writer in the OpenMetrics text format, as a point
+	// in time snapshot for offline analysis. Forwarded flush ages are those of the
+	// earliest flush across the number of times forwarded.
+	DumpOpenMetrics(w io.Writer) error
+
 	// Close closes the flush times manager.
 	Close() error
 }
 
+// FlushTimesSummary summarizes the flush times across all tracked shards.
+type FlushTimesSummary struct {
+	// NumShards is the number of shards with flush times.
+	NumShards int
+
+	// MinFlushedNanos is the earliest flush time across all shards.
+	MinFlushedNanos int64
+
+	// MaxFlushedNanos is the latest flush time across all shards.
+	MaxFlushedNanos int64
+
+	// OldestShardID is the shard owning the earliest flush time.
+	OldestShardID uint32
+}
+
+// ConsistencyReport reports the inconsistencies between the flush times and
+// the shards owned by the instance, with shard IDs in ascending order.
+type ConsistencyReport struct {
+	// UntrackedShards are the shards owned by the instance without flush times,
+	// which potentially have never been flushed.
+	UntrackedShards []uint32
+
+	// StaleShards are the shards with flush times which are no longer owned by
+	// the instance.
+	StaleShards []uint32
+}
+
+// Consistent returns true if there are no inconsistencies.
+func (r ConsistencyReport) Consistent() bool {
+	return len(r.UntrackedShards) == 0 && len(r.StaleShards) == 0
+}
+
 type flushTimesManagerState int
 
 const (
@@ -84,20 +190,58 @@ var (
 	errFlushTimesManagerNotOpenOrClosed     = errors.New("flush times manager not open or closed")
 	errFlushTimesManagerOpen                = errors.New("flush times manager open")
 	errFlushTimesManagerAlreadyOpenOrClosed = errors.New("flush times manager already open or closed")
+	errNoFlushTimes                         = errors.New("no flush times")
+	errNoPlacementManager                   = errors.New("no placement manager")
+	errAdoptInMetricsOnlyMode               = errors.New("flush times can not be adopted in metrics only mode")
+	errStoreIfInMetricsOnlyMode             = errors.New("flush times can not be conditionally stored in metrics only mode")
+	errFlushTimesPayloadTooLarge            = errors.New("flush times payload exceeds the payload limit")
 )
 
 type flushTimesManagerMetrics struct {
 	flushTimesUnmarshalErrors tally.Counter
 	flushTimesPersist         instrument.MethodMetrics
+	flushAgeByResolution      map[int64]tally.Gauge
+	flushTimesStores          tally.Counter
+	timeSinceLastStore        tally.Gauge
+	storeDeltaBytes           tally.Gauge
+	coalescedStores           tally.Counter
+	flushTimeRegressions      tally.Counter
+	storeIfConflicts          tally.Counter
+	payloadSizeWarnings       tally.Counter
+	oversizedPayloads         tally.Counter
+	sinkPublishErrors         tally.Counter
+	sinkDrops                 tally.Counter
+	flushLag                  tally.Gauge
+	lagReportErrors           tally.Counter
 }
 
 func newFlushTimesManagerMetrics(
 	scope tally.Scope,
 	opts instrument.TimerOptions,
+	flushAgeResolutions []time.Duration,
 ) flushTimesManagerMetrics {
+	flushAgeByResolution := make(map[int64]tally.Gauge, len(flushAgeResolutions))
+	for _, resolution := range flushAgeResolutions {
+		flushAgeByResolution[int64(resolution)] = scope.Tagged(map[string]string{
+			"resolution": resolution.String(),
+		}).Gauge("flush-age")
+	}
 	return flushTimesManagerMetrics{
 		flushTimesUnmarshalErrors: scope.Counter("flush-times-unmarshal-errors"),
 		flushTimesPersist:         instrument.NewMethodMetrics(scope, "flush-times-persist", opts),
+		flushAgeByResolution:      flushAgeByResolution,
+		flushTimesStores:          scope.Counter("flush-times-stores"),
+		timeSinceLastStore:        scope.Gauge("time-since-last-store"),
+		storeDeltaBytes:           scope.Gauge("store-delta-bytes"),
+		coalescedStores:           scope.Counter("flush-times-coalesced-stores"),
+		flushTimeRegressions:      scope.Counter("flush-time-regressions"),
+		storeIfConflicts:          scope.Counter("flush-times-store-if-conflicts"),
+		payloadSizeWarnings:       scope.Counter("flush-times-payload-size-warnings"),
+		oversizedPayloads:         scope.Counter("flush-times-oversized-payloads"),
+		sinkPublishErrors:         scope.Counter("flush-times-sink-publish-errors"),
+		sinkDrops:                 scope.Counter("flush-times-sink-drops"),
+		flushLag:                  scope.Gauge("flush-lag"),
+		lagReportErrors:           scope.Counter("flush-lag-report-errors"),
 	}
 }
 
@@ -107,12 +251,28 @@ type flushTimesManager struct {
 
 	nowFn                    clock.NowFn
 	logger                   *zap.Logger
+	reportInterval           time.Duration
 	flushTimesKeyFmt         string
 	flushTimesStore          kv.Store
 	flushTimesPersistRetrier retry.Retrier
+	flushTimesSerializer     FlushTimesSerializer
+	placementManager         PlacementManager
+	metricsOnly              bool
+	storeCoalesceWindow      time.Duration
+	payloadLimitBytes        int
+	payloadWarnBytes         int
+	rejectOversizedPayloads  bool
+	flushTimesSink           FlushTimesSink
+	sinkCh                   chan *schema.ShardSetFlushTimes
+	flushLagReporter         FlushLagReporter
+	flushLagReportInterval   time.Duration
 
 	state               flushTimesManagerState
 	doneCh              chan struct{}
+	shardSetID          uint32
+	lastStoreNanos      int64
+	lastStoreBytes      int64
+	pendingStores       int64
 	flushTimesKey       string
 	proto               *schema.ShardSetFlushTimes
 	flushTimesWatchable watch.Watchable
@@ -126,11 +286,23 @@ func NewFlushTimesManager(opts FlushTimesManagerOptions) FlushTimesManager {
 	mgr := &flushTimesManager{
 		nowFn:                    opts.ClockOptions().NowFn(),
 		logger:                   instrumentOpts.Logger(),
+		reportInterval:           instrumentOpts.ReportInterval(),
 		flushTimesKeyFmt:         opts.FlushTimesKeyFmt(),
 		flushTimesStore:          opts.FlushTimesStore(),
 		flushTimesPersistRetrier: opts.FlushTimesPersistRetrier(),
+		flushTimesSerializer:     opts.FlushTimesSerializer(),
+		placementManager:         opts.PlacementManager(),
+		metricsOnly:              opts.MetricsOnly(),
+		storeCoalesceWindow:      opts.StoreCoalesceWindow(),
+		payloadLimitBytes:        opts.PayloadLimitBytes(),
+		payloadWarnBytes:         int(float64(opts.PayloadLimitBytes()) * opts.PayloadWarnRatio()),
+		rejectOversizedPayloads:  opts.RejectOversizedPayloads(),
+		flushTimesSink:           opts.FlushTimesSink(),
+		sinkCh:                   make(chan *schema.ShardSetFlushTimes, opts.FlushTimesSinkBufferSize()),
+		flushLagReporter:         opts.FlushLagReporter(),
+		flushLagReportInterval:   opts.FlushLagReportInterval(),
 		metrics: newFlushTimesManagerMetrics(instrumentOpts.MetricsScope(),
-			instrumentOpts.TimerOptions()),
+			instrumentOpts.TimerOptions(), opts.FlushAgeResolutions()),
 	}
 	mgr.Lock()
 	mgr.resetWithLock()
@@ -160,7 +332,17 @@ func (mgr *flushTimesManager) Open(shardSetID uint32) error {
 	if mgr.state != flushTimesManagerNotOpen {
 		return errFlushTimesManagerAlreadyOpenOrClosed
 	}
+	mgr.shardSetID = shardSetID
 	mgr.flushTimesKey = fmt.Sprintf(mgr.flushTimesKeyFmt, shardSetID)
+	if mgr.metricsOnly {
+		// NB: flush times are neither read from nor persisted to kv.
+		mgr.state = flushTimesManagerOpen
+		mgr.Add(2)
+		go mgr.reportMetrics()
+		go mgr.publishFlushTimes()
+		mgr.startReportingLag()
+		return nil
+	}
 	flushTimesWatch, err := mgr.flushTimesStore.Watch(mgr.flushTimesKey)
 	if err != nil {
 		return err
@@ -171,9 +353,12 @@ func (mgr *flushTimesManager) Open(shardSetID uint32) error {
 	}
 	mgr.state = flushTimesManagerOpen
 
-	mgr.Add(2)
+	mgr.Add(4)
 	go mgr.watchFlushTimes(flushTimesWatch)
 	go mgr.persistFlushTimes(persistWatch)
+	go mgr.reportMetrics()
+	go mgr.publishFlushTimes()
+	mgr.startReportingLag()
 
 	return nil
 }
@@ -199,17 +384,427 @@ func (mgr *flushTimesManager) Watch() (watch.Watch, error) {
 	return watch, err
 }
 
+func (mgr *flushTimesManager) WatchShard(shardID uint32) (<-chan int64, func(), error) {
+	flushTimesWatch, err := mgr.Watch()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		shardCh  = make(chan int64, 1)
+		doneCh   = make(chan struct{})
+		doneOnce sync.Once
+		closeFn  = func() { doneOnce.Do(func() { close(doneCh) }) }
+	)
+	go func() {
+		defer func() {
+			flushTimesWatch.Close()
+			close(shardCh)
+		}()
+
+		var (
+			lastFlushedNanos int64
+			notified         bool
+		)
+		for {
+			select {
+			case _, ok := <-flushTimesWatch.C():
+				if !ok {
+					return
+				}
+			case <-doneCh:
+				return
+			}
+
+			flushTimes, _ := flushTimesWatch.Get().(*schema.ShardSetFlushTimes)
+			flushedNanos, found := shardFlushedNanos(flushTimes, shardID)
+			if !found || (notified && flushedNanos == lastFlushedNanos) {
+				continue
+			}
+			lastFlushedNanos, notified = flushedNanos, true
+
+			// NB: this is the only sender so after draining any stale flush time
+			// the send below never blocks.
+			select {
+			case <-shardCh:
+			default:
+			}
+			shardCh <- flushedNanos
+		}
+	}()
+	return shardCh, closeFn, nil
+}
+
+func (mgr *flushTimesManager) WaitUntilAdvanced(
+	ctx context.Context,
+	shardID uint32,
+	resolution time.Duration,
+	past int64,
+) error {
+	// NB: the shard watch tracks the earliest flush time across resolutions, so
+	// the flush times are watched directly for the flush time of the resolution.
+	flushTimesWatch, err := mgr.Watch()
+	if err != nil {
+		return err
+	}
+	defer flushTimesWatch.Close()
+
+	for {
+		select {
+		case _, ok := <-flushTimesWatch.C():
+			if !ok {
+				return errFlushTimesManagerNotOpenOrClosed
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		flushTimes, _ := flushTimesWatch.Get().(*schema.ShardSetFlushTimes)
+		byResolution := flushTimes.GetByShard()[shardID].GetStandardByResolution()
+		if flushedNanos, ok := byResolution[int64(resolution)]; ok && flushedNanos > past {
+			return nil
+		}
+	}
+}
+
+func (mgr *flushTimesManager) Summary() (FlushTimesSummary, error) {
+	flushTimes, err := mgr.Get()
+	if err != nil {
+		return FlushTimesSummary{}, err
+	}
+	return summarizeFlushTimes(flushTimes)
+}
+
 func (mgr *flushTimesManager) StoreAsync(value *schema.ShardSetFlushTimes) error {
+	if mgr.metricsOnly {
+		return mgr.storeInMemory(value)
+	}
+
 	mgr.RLock()
 	defer mgr.RUnlock()
 
 	if mgr.state != flushTimesManagerOpen {
 		return errFlushTimesManagerNotOpenOrClosed
 	}
+	atomic.AddInt64(&mgr.pendingStores, 1)
 	mgr.persistWatchable.Update(value)
+	atomic.StoreInt64(&mgr.lastStoreNanos, mgr.nowFn().UnixNano())
+	mgr.metrics.flushTimesStores.Inc(1)
+	mgr.reportFlushAges(value)
+	return nil
+}
+
+// storeInMemory replaces the in-memory flush times with the given flush times
+// and reports metrics as if they were stored, without persisting them to kv.
+func (mgr *flushTimesManager) storeInMemory(value *schema.ShardSetFlushTimes) error {
+	mgr.Lock()
+	if mgr.state != flushTimesManagerOpen {
+		mgr.Unlock()
+		return errFlushTimesManagerNotOpenOrClosed
+	}
+	mgr.proto = value
+	mgr.Unlock()
+
+	mgr.onStoredInMemory(value)
+	return nil
+}
+
+// backfillInMemory backfills the in-memory flush times, merging and replacing
+// them under the lock so concurrent stores are never lost.
+func (mgr *flushTimesManager) backfillInMemory(times map[uint32]map[time.Duration]int64) error {
+	mgr.Lock()
+	if mgr.state != flushTimesManagerOpen {
+		mgr.Unlock()
+		return errFlushTimesManagerNotOpenOrClosed
+	}
+	if mgr.proto == nil {
+		mgr.Unlock()
+		return errNoFlushTimes
+	}
+	backfilled, err := backfillFlushTimes(mgr.proto, times)
+	if err != nil {
+		mgr.Unlock()
+		return err
+	}
+	mgr.proto = backfilled
+	mgr.Unlock()
+
+	mgr.onStoredInMemory(backfilled)
 	return nil
 }
 
+// onStoredInMemory reports metrics and notifies watchers as if the given flush
+// times were stored.
+func (mgr *flushTimesManager) onStoredInMemory(value *schema.ShardSetFlushTimes) {
+	if data, err := mgr.flushTimesSerializer.Marshal(value); err == nil {
+		mgr.reportStoreDelta(data)
+	}
+	mgr.flushTimesWatchable.Update(value)
+	atomic.StoreInt64(&mgr.lastStoreNanos, mgr.nowFn().UnixNano())
+	mgr.metrics.flushTimesStores.Inc(1)
+	mgr.reportFlushAges(value)
+	mgr.enqueuePublish(value)
+}
+
+func (mgr *flushTimesManager) StoreIf(
+	predicate func(current *schema.ShardSetFlushTimes) bool,
+	value *schema.ShardSetFlushTimes,
+) (bool, error) {
+	if mgr.metricsOnly {
+		return false, errStoreIfInMetricsOnlyMode
+	}
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	if state != flushTimesManagerOpen {
+		return false, errFlushTimesManagerNotOpenOrClosed
+	}
+
+	return mgr.update(func(
+		current *schema.ShardSetFlushTimes,
+	) (*schema.ShardSetFlushTimes, bool, error) {
+		return value, predicate(current), nil
+	})
+}
+
+// update stores the flush times returned by the given function for the flush
+// times in kv, or none if they are not persisted yet, with a check-and-set. The
+// function is re-evaluated against the newer flush times on conflicting writes,
+// and nothing is stored if it returns false.
+func (mgr *flushTimesManager) update(
+	fn func(current *schema.ShardSetFlushTimes) (*schema.ShardSetFlushTimes, bool, error),
+) (bool, error) {
+	for {
+		var (
+			current *schema.ShardSetFlushTimes
+			version int
+		)
+		kvValue, err := mgr.flushTimesStore.Get(mgr.flushTimesKey)
+		switch err {
+		case nil:
+			if current, err = decodeFlushTimes(kvValue); err != nil {
+				mgr.metrics.flushTimesUnmarshalErrors.Inc(1)
+				return false, err
+			}
+			version = kvValue.Version()
+		case kv.ErrNotFound:
+		default:
+			return false, err
+		}
+		value, ok, err := fn(current)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		data, err := mgr.flushTimesSerializer.Marshal(value)
+		if err != nil {
+			return false, err
+		}
+		if err := mgr.checkPayloadSize(data); err != nil {
+			return false, err
+		}
+		payload := &serializedFlushTimes{data: data}
+		if version == 0 {
+			_, err = mgr.flushTimesStore.SetIfNotExists(mgr.flushTimesKey, payload)
+		} else {
+			_, err = mgr.flushTimesStore.CheckAndSet(mgr.flushTimesKey, version, payload)
+		}
+		switch err {
+		case nil:
+			mgr.reportStoreDelta(data)
+			atomic.StoreInt64(&mgr.lastStoreNanos, mgr.nowFn().UnixNano())
+			mgr.metrics.flushTimesStores.Inc(1)
+			mgr.reportFlushAges(value)
+			mgr.enqueuePublish(value)
+			return true, nil
+		case kv.ErrVersionMismatch, kv.ErrAlreadyExists:
+			mgr.metrics.storeIfConflicts.Inc(1)
+		default:
+			return false, err
+		}
+	}
+}
+
+func (mgr *flushTimesManager) DryRunStore(value *schema.ShardSetFlushTimes) ([]byte, error) {
+	if value == nil {
+		return nil, errNoFlushTimes
+	}
+	return mgr.flushTimesSerializer.Marshal(value)
+}
+
+func (mgr *flushTimesManager) VerifyConsistency() (ConsistencyReport, error) {
+	if mgr.placementManager == nil {
+		return ConsistencyReport{}, errNoPlacementManager
+	}
+	flushTimes, err := mgr.Get()
+	if err != nil {
+		return ConsistencyReport{}, err
+	}
+	shards, err := mgr.placementManager.Shards()
+	if err != nil {
+		return ConsistencyReport{}, err
+	}
+
+	// NB: leaving shards are still owned until they are cut off and as such
+	// are expected to be tracked.
+	var (
+		report ConsistencyReport
+		owned  = make(map[uint32]struct{}, shards.NumShards())
+	)
+	for _, shardID := range shards.AllIDs() {
+		owned[shardID] = struct{}{}
+		if flushTimes == nil || flushTimes.ByShard[shardID] == nil {
+			report.UntrackedShards = append(report.UntrackedShards, shardID)
+		}
+	}
+	if flushTimes != nil {
+		for shardID := range flushTimes.ByShard {
+			if _, exists := owned[shardID]; !exists {
+				report.StaleShards = append(report.StaleShards, shardID)
+			}
+		}
+	}
+	sort.Slice(report.UntrackedShards, func(i, j int) bool {
+		return report.UntrackedShards[i] < report.UntrackedShards[j]
+	})
+	sort.Slice(report.StaleShards, func(i, j int) bool {
+		return report.StaleShards[i] < report.StaleShards[j]
+	})
+	return report, nil
+}
+
+func (mgr *flushTimesManager) AdoptFrom(instanceID string) error {
+	if mgr.metricsOnly {
+		return errAdoptInMetricsOnlyMode
+	}
+	if mgr.placementManager == nil {
+		return errNoPlacementManager
+	}
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	if state != flushTimesManagerOpen {
+		return errFlushTimesManagerNotOpenOrClosed
+	}
+	_, p, err := mgr.placementManager.Placement()
+	if err != nil {
+		return err
+	}
+	instance, exists := p.Instance(instanceID)
+	if !exists {
+		return fmt.Errorf("instance %s not found in placement", instanceID)
+	}
+
+	// NB: the remote flush times are fully decoded before anything is stored
+	// so a partial or failed read never overwrites the local flush times.
+	key := fmt.Sprintf(mgr.flushTimesKeyFmt, instance.ShardSetID())
+	value, err := mgr.flushTimesStore.Get(key)
+	if err != nil {
+		return fmt.Errorf("unable to read flush times from %s: %v", key, err)
+	}
+	proto, err := decodeFlushTimes(value)
+	if err != nil {
+		mgr.metrics.flushTimesUnmarshalErrors.Inc(1)
+		return fmt.Errorf("unable to decode flush times from %s: %v", key, err)
+	}
+
+	// NB: the adopted flush times replace the local flush times as a whole, so
+	// a conflicting store is surfaced rather than retried to avoid clobbering
+	// flush times stored since the adoption started.
+	var attempts int
+	_, err = mgr.update(func(
+		*schema.ShardSetFlushTimes,
+	) (*schema.ShardSetFlushTimes, bool, error) {
+		if attempts++; attempts > 1 {
+			return nil, false, kv.ErrVersionMismatch
+		}
+		return proto, true, nil
+	})
+	return err
+}
+
+func (mgr *flushTimesManager) Backfill(times map[uint32]map[time.Duration]int64) error {
+	if mgr.metricsOnly {
+		return mgr.backfillInMemory(times)
+	}
+	// NB: the flush times are only backfilled once they have been loaded, since
+	// backfilling before would otherwise persist the backfilled shards alone.
+	current, err := mgr.Get()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return errNoFlushTimes
+	}
+	_, err = mgr.update(func(
+		current *schema.ShardSetFlushTimes,
+	) (*schema.ShardSetFlushTimes, bool, error) {
+		if current == nil {
+			return nil, false, errNoFlushTimes
+		}
+		backfilled, err := backfillFlushTimes(current, times)
+		return backfilled, err == nil, err
+	})
+	return err
+}
+
+// backfillFlushTimes returns a copy of the given flush times with the standard
+// flush times of the given shards and resolutions moved forward, or an error if
+// any of them would move backward.
+func backfillFlushTimes(
+	current *schema.ShardSetFlushTimes,
+	times map[uint32]map[time.Duration]int64,
+) (*schema.ShardSetFlushTimes, error) {
+	// NB: the latest flush times are shared with readers and are therefore
+	// copied rather than updated in place.
+	backfilled := &schema.ShardSetFlushTimes{
+		ByShard: make(map[uint32]*schema.ShardFlushTimes, len(current.ByShard)+len(times)),
+	}
+	for shardID, shardFlushTimes := range current.ByShard {
+		backfilled.ByShard[shardID] = shardFlushTimes
+	}
+	for shardID, byResolution := range times {
+		var (
+			existing        = backfilled.ByShard[shardID]
+			shardFlushTimes = &schema.ShardFlushTimes{}
+		)
+		if existing != nil {
+			shardFlushTimes.Tombstoned = existing.Tombstoned
+			shardFlushTimes.ForwardedByResolution = existing.ForwardedByResolution
+			shardFlushTimes.TimedByResolution = existing.TimedByResolution
+		}
+		shardFlushTimes.StandardByResolution = make(map[int64]int64,
+			len(existing.GetStandardByResolution())+len(byResolution))
+		for resolution, flushedNanos := range existing.GetStandardByResolution() {
+			shardFlushTimes.StandardByResolution[resolution] = flushedNanos
+		}
+		for resolution, flushedNanos := range byResolution {
+			prev, exists := shardFlushTimes.StandardByResolution[int64(resolution)]
+			if exists && flushedNanos < prev {
+				return nil, fmt.Errorf(
+					"backfill would move flush time of shard %d at resolution %v backward from %d to %d",
+					shardID, resolution, prev, flushedNanos)
+			}
+			shardFlushTimes.StandardByResolution[int64(resolution)] = flushedNanos
+		}
+		backfilled.ByShard[shardID] = shardFlushTimes
+	}
+	return backfilled, nil
+}
+
+func (mgr *flushTimesManager) DumpOpenMetrics(w io.Writer) error {
+	flushTimes, err := mgr.Get()
+	if err != nil {
+		return err
+	}
+	family := newFlushAgeMetricFamily(flushTimes, mgr.nowFn().UnixNano())
+	if _, err := expfmt.MetricFamilyToOpenMetrics(w, family); err != nil {
+		return err
+	}
+	_, err = expfmt.FinalizeOpenMetrics(w)
+	return err
+}
+
 func (mgr *flushTimesManager) Close() error {
 	mgr.Lock()
 	if mgr.state != flushTimesManagerOpen {
@@ -229,6 +824,10 @@ func (mgr *flushTimesManager) Close() error {
 func (mgr *flushTimesManager) resetWithLock() {
 	mgr.state = flushTimesManagerNotOpen
 	mgr.doneCh = make(chan struct{})
+	mgr.lastStoreNanos = 0
+	mgr.lastStoreBytes = 0
+	mgr.pendingStores = 0
+	mgr.shardSetID = 0
 	mgr.flushTimesKey = ""
 	mgr.proto = nil
 	mgr.flushTimesWatchable = watch.NewWatchable()
@@ -245,11 +844,8 @@ func (mgr *flushTimesManager) watchFlushTimes(flushTimesWatch kv.ValueWatch) {
 			return
 		}
 
-		var (
-			value = flushTimesWatch.Get()
-			proto schema.ShardSetFlushTimes
-		)
-		if err := value.Unmarshal(&proto); err != nil {
+		proto, err := decodeFlushTimes(flushTimesWatch.Get())
+		if err != nil {
 			mgr.metrics.flushTimesUnmarshalErrors.Inc(1)
 			mgr.logger.Error("flush times unmarshal error",
 				zap.String("flushTimesKey", mgr.flushTimesKey),
@@ -258,9 +854,119 @@ func (mgr *flushTimesManager) watchFlushTimes(flushTimesWatch kv.ValueWatch) {
 			continue
 		}
 		mgr.Lock()
-		mgr.proto = &proto
+		mgr.detectRegressions(mgr.proto, proto)
+		mgr.proto = proto
 		mgr.Unlock()
-		mgr.flushTimesWatchable.Update(&proto)
+		mgr.flushTimesWatchable.Update(proto)
+		mgr.reportFlushAges(proto)
+	}
+}
+
+// detectRegressions reports the flush times read from kv which are earlier than
+// the flush times last seen for the same shard and resolution. Flush times only
+// ever move forward, so this usually means a stale leader persisted outdated
+// flush times.
+func (mgr *flushTimesManager) detectRegressions(prev, curr *schema.ShardSetFlushTimes) {
+	if prev == nil {
+		return
+	}
+	for shardID, currShard := range curr.ByShard {
+		prevShard, exists := prev.ByShard[shardID]
+		if !exists {
+			continue
+		}
+		for resolution, currNanos := range currShard.StandardByResolution {
+			prevNanos, exists := prevShard.StandardByResolution[resolution]
+			if !exists || currNanos >= prevNanos {
+				continue
+			}
+			mgr.metrics.flushTimeRegressions.Inc(1)
+			mgr.logger.Error("flush time regressed",
+				zap.String("flushTimesKey", mgr.flushTimesKey),
+				zap.Uint32("shard", shardID),
+				zap.Duration("resolution", time.Duration(resolution)),
+				zap.Time("prevFlushTime", time.Unix(0, prevNanos)),
+				zap.Time("flushTime", time.Unix(0, currNanos)),
+			)
+		}
+	}
+}
+
+func decodeFlushTimes(value kv.Value) (*schema.ShardSetFlushTimes, error) {
+	var (
+		payload serializedFlushTimes
+		proto   schema.ShardSetFlushTimes
+	)
+	if err := value.Unmarshal(&payload); err != nil {
+		return nil, err
+	}
+	if err := unmarshalFlushTimes(payload.data, &proto); err != nil {
+		return nil, err
+	}
+	return &proto, nil
+}
+
+// reportFlushAges reports the age of the oldest flush time across all shards
+// for each of the configured resolutions.
+func (mgr *flushTimesManager) reportFlushAges(flushTimes *schema.ShardSetFlushTimes) {
+	if len(mgr.metrics.flushAgeByResolution) == 0 || flushTimes == nil {
+		return
+	}
+	oldestByResolution := make(map[int64]int64, len(mgr.metrics.flushAgeByResolution))
+	update := func(resolution int64, lastFlushedNanos int64) {
+		if _, tracked := mgr.metrics.flushAgeByResolution[resolution]; !tracked {
+			return
+		}
+		if oldest, exists := oldestByResolution[resolution]; !exists || lastFlushedNanos < oldest {
+			oldestByResolution[resolution] = lastFlushedNanos
+		}
+	}
+	for _, shardFlushTimes := range flushTimes.ByShard {
+		if shardFlushTimes == nil {
+			continue
+		}
+		for resolution, lastFlushedNanos := range shardFlushTimes.StandardByResolution {
+			update(resolution, lastFlushedNanos)
+		}
+		for resolution, lastFlushedNanos := range shardFlushTimes.TimedByResolution {
+			update(resolution, lastFlushedNanos)
+		}
+		for resolution, fbr := range shardFlushTimes.ForwardedByResolution {
+			if fbr == nil {
+				continue
+			}
+			for _, lastFlushedNanos := range fbr.ByNumForwardedTimes {
+				update(resolution, lastFlushedNanos)
+			}
+		}
+	}
+	nowNanos := mgr.nowFn().UnixNano()
+	for resolution, oldest := range oldestByResolution {
+		age := time.Duration(nowNanos - oldest)
+		mgr.metrics.flushAgeByResolution[resolution].Update(age.Seconds())
+	}
+}
+
+// reportMetrics periodically reports the time since flush times were last
+// stored, which is not reported until flush times are first stored.
+func (mgr *flushTimesManager) reportMetrics() {
+	defer mgr.Done()
+
+	ticker := time.NewTicker(mgr.reportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			lastStoreNanos := atomic.LoadInt64(&mgr.lastStoreNanos)
+			if lastStoreNanos == 0 {
+				continue
+			}
+			sinceLastStore := time.Duration(mgr.nowFn().UnixNano() - lastStoreNanos)
+			mgr.metrics.timeSinceLastStore.Update(sinceLastStore.Seconds())
+		case <-mgr.doneCh:
+			return
+		}
 	}
 }
 
@@ -270,28 +976,280 @@ func (mgr *flushTimesManager) persistFlushTimes(persistWatch watch.Watch) {
 	for {
 		select {
 		case <-mgr.doneCh:
+			mgr.persistOnClose(persistWatch)
 			return
 		case <-persistWatch.C():
-			flushTimes := persistWatch.Get().(*schema.ShardSetFlushTimes)
-			persistStart := mgr.nowFn()
-			persistErr := mgr.flushTimesPersistRetrier.Attempt(func() error {
-				_, err := mgr.flushTimesStore.Set(mgr.flushTimesKey, flushTimes)
-				return err
-			})
-			duration := mgr.nowFn().Sub(persistStart)
-			if persistErr == nil {
-				mgr.metrics.flushTimesPersist.ReportSuccess(duration)
-			} else {
-				mgr.metrics.flushTimesPersist.ReportError(duration)
-				mgr.logger.Error("flush times persist error",
+		}
+
+		if mgr.storeCoalesceWindow > 0 {
+			timer := time.NewTimer(mgr.storeCoalesceWindow)
+			select {
+			case <-timer.C:
+			case <-mgr.doneCh:
+				timer.Stop()
+				mgr.persistOnClose(persistWatch)
+				return
+			}
+		}
+		mgr.persistLatest(persistWatch)
+	}
+}
+
+// persistOnClose persists the flush times stored but not yet persisted when
+// stores are coalesced, so the final flush times are persisted even if the
+// manager is closed before the window elapses.
+func (mgr *flushTimesManager) persistOnClose(persistWatch watch.Watch) {
+	if mgr.storeCoalesceWindow > 0 && atomic.LoadInt64(&mgr.pendingStores) > 0 {
+		mgr.persistLatest(persistWatch)
+	}
+}
+
+// persistLatest persists the latest stored flush times, coalescing all stores
+// since flush times were last persisted.
+func (mgr *flushTimesManager) persistLatest(persistWatch watch.Watch) {
+	// NB: drain the pending notification, if any, since the latest flush times
+	// are about to be persisted.
+	select {
+	case <-persistWatch.C():
+	default:
+	}
+	if pending := atomic.SwapInt64(&mgr.pendingStores, 0); pending > 1 {
+		mgr.metrics.coalescedStores.Inc(pending - 1)
+	}
+
+	flushTimes := persistWatch.Get().(*schema.ShardSetFlushTimes)
+	persistStart := mgr.nowFn()
+	data, persistErr := mgr.flushTimesSerializer.Marshal(flushTimes)
+	if persistErr == nil {
+		persistErr = mgr.checkPayloadSize(data)
+	}
+	if persistErr == nil {
+		mgr.reportStoreDelta(data)
+		payload := &serializedFlushTimes{data: data}
+		persistErr = mgr.flushTimesPersistRetrier.Attempt(func() error {
+			_, err := mgr.flushTimesStore.Set(mgr.flushTimesKey, payload)
+			return err
+		})
+	}
+	duration := mgr.nowFn().Sub(persistStart)
+	if persistErr == nil {
+		mgr.metrics.flushTimesPersist.ReportSuccess(duration)
+		mgr.enqueuePublish(flushTimes)
+	} else {
+		mgr.metrics.flushTimesPersist.ReportError(duration)
+		mgr.logger.Error("flush times persist error",
+			zap.String("flushTimesKey", mgr.flushTimesKey),
+			zap.Error(persistErr),
+		)
+	}
+}
+
+// enqueuePublish queues the given stored flush times for publishing to the sink,
+// dropping them if the buffer is full so that stores never block on the sink.
+func (mgr *flushTimesManager) enqueuePublish(value *schema.ShardSetFlushTimes) {
+	select {
+	case mgr.sinkCh <- value:
+	default:
+		mgr.metrics.sinkDrops.Inc(1)
+	}
+}
+
+// publishFlushTimes publishes the queued flush times to the sink until the
+// manager is closed.
+func (mgr *flushTimesManager) publishFlushTimes() {
+	defer mgr.Done()
+
+	for {
+		select {
+		case <-mgr.doneCh:
+			return
+		case value := <-mgr.sinkCh:
+			if err := mgr.flushTimesSink.Publish(value); err != nil {
+				mgr.metrics.sinkPublishErrors.Inc(1)
+				mgr.logger.Error("flush times sink publish error",
 					zap.String("flushTimesKey", mgr.flushTimesKey),
-					zap.Error(persistErr),
+					zap.Error(err),
 				)
 			}
 		}
 	}
 }
 
+// startReportingLag starts reporting the flush lag periodically until the
+// manager is closed, unless reporting is disabled or nothing is reported to.
+func (mgr *flushTimesManager) startReportingLag() {
+	if mgr.flushLagReportInterval <= 0 {
+		return
+	}
+	if _, noop := mgr.flushLagReporter.(noopFlushLagReporter); noop {
+		return
+	}
+	mgr.Add(1)
+	go mgr.reportLag()
+}
+
+func (mgr *flushTimesManager) reportLag() {
+	defer mgr.Done()
+
+	ticker := time.NewTicker(mgr.flushLagReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-mgr.doneCh:
+			return
+		}
+
+		// NB: there is no lag to report until flush times are available.
+		summary, err := mgr.Summary()
+		if err != nil {
+			continue
+		}
+		now := mgr.nowFn()
+		report := FlushLagReport{
+			ShardSetID:    mgr.shardSetID,
+			NumShards:     summary.NumShards,
+			OldestShardID: summary.OldestShardID,
+			MaxLag:        now.Sub(time.Unix(0, summary.MinFlushedNanos)),
+			Timestamp:     now,
+		}
+		mgr.metrics.flushLag.Update(report.MaxLag.Seconds())
+		if err := mgr.flushLagReporter.Report(report); err != nil {
+			mgr.metrics.lagReportErrors.Inc(1)
+			mgr.logger.Error("flush lag report error",
+				zap.String("flushTimesKey", mgr.flushTimesKey),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// checkPayloadSize warns if the given serialized flush times approach the
+// payload limit, so that stores failing at scale do not come as a surprise,
+// and returns errFlushTimesPayloadTooLarge if they exceed it and oversized
+// payloads are rejected.
+func (mgr *flushTimesManager) checkPayloadSize(data []byte) error {
+	if mgr.payloadLimitBytes <= 0 || len(data) <= mgr.payloadWarnBytes {
+		return nil
+	}
+	if len(data) <= mgr.payloadLimitBytes {
+		mgr.metrics.payloadSizeWarnings.Inc(1)
+		mgr.logger.Warn("flush times payload approaching payload limit",
+			zap.String("flushTimesKey", mgr.flushTimesKey),
+			zap.Int("payloadBytes", len(data)),
+			zap.Int("limitBytes", mgr.payloadLimitBytes))
+		return nil
+	}
+	mgr.metrics.oversizedPayloads.Inc(1)
+	if mgr.rejectOversizedPayloads {
+		return errFlushTimesPayloadTooLarge
+	}
+	mgr.logger.Warn("flush times payload exceeds payload limit",
+		zap.String("flushTimesKey", mgr.flushTimesKey),
+		zap.Int("payloadBytes", len(data)),
+		zap.Int("limitBytes", mgr.payloadLimitBytes))
+	return nil
+}
+
+// reportStoreDelta reports the difference in size between the given serialized
+// flush times and those previously stored. A delta that keeps growing across
+// stores means flush times accumulate, e.g. for shards no longer owned.
+func (mgr *flushTimesManager) reportStoreDelta(data []byte) {
+	size := int64(len(data))
+	prevSize := atomic.SwapInt64(&mgr.lastStoreBytes, size)
+	mgr.metrics.storeDeltaBytes.Update(float64(size - prevSize))
+}
+
+// shardFlushedNanos returns the earliest flush time of the given shard across
+// its standard, timed and forwarded flush times.
+func shardFlushedNanos(
+	flushTimes *schema.ShardSetFlushTimes,
+	shardID uint32,
+) (int64, bool) {
+	if flushTimes == nil {
+		return 0, false
+	}
+	shardFlushTimes, exists := flushTimes.ByShard[shardID]
+	if !exists || shardFlushTimes == nil {
+		return 0, false
+	}
+	var (
+		minFlushedNanos int64
+		found           bool
+	)
+	update := func(lastFlushedNanos int64) {
+		if !found || lastFlushedNanos < minFlushedNanos {
+			minFlushedNanos = lastFlushedNanos
+		}
+		found = true
+	}
+	for _, lastFlushedNanos := range shardFlushTimes.StandardByResolution {
+		update(lastFlushedNanos)
+	}
+	for _, lastFlushedNanos := range shardFlushTimes.TimedByResolution {
+		update(lastFlushedNanos)
+	}
+	for _, fbr := range shardFlushTimes.ForwardedByResolution {
+		if fbr == nil {
+			continue
+		}
+		for _, lastFlushedNanos := range fbr.ByNumForwardedTimes {
+			update(lastFlushedNanos)
+		}
+	}
+	return minFlushedNanos, found
+}
+
+// summarizeFlushTimes computes the summary of the given flush times in a single
+// pass over the standard, timed and forwarded flush times of every shard. Ties
+// for the oldest shard are broken in favor of the lower shard ID.
+func summarizeFlushTimes(flushTimes *schema.ShardSetFlushTimes) (FlushTimesSummary, error) {
+	if flushTimes == nil {
+		return FlushTimesSummary{}, errNoFlushTimes
+	}
+
+	var (
+		summary FlushTimesSummary
+		found   bool
+	)
+	update := func(shardID uint32, lastFlushedNanos int64) {
+		if !found || lastFlushedNanos < summary.MinFlushedNanos ||
+			(lastFlushedNanos == summary.MinFlushedNanos && shardID < summary.OldestShardID) {
+			summary.MinFlushedNanos = lastFlushedNanos
+			summary.OldestShardID = shardID
+		}
+		if !found || lastFlushedNanos > summary.MaxFlushedNanos {
+			summary.MaxFlushedNanos = lastFlushedNanos
+		}
+		found = true
+	}
+	for shardID, shardFlushTimes := range flushTimes.ByShard {
+		if shardFlushTimes == nil {
+			continue
+		}
+		summary.NumShards++
+		for _, lastFlushedNanos := range shardFlushTimes.StandardByResolution {
+			update(shardID, lastFlushedNanos)
+		}
+		for _, lastFlushedNanos := range shardFlushTimes.TimedByResolution {
+			update(shardID, lastFlushedNanos)
+		}
+		for _, fbr := range shardFlushTimes.ForwardedByResolution {
+			if fbr == nil {
+				continue
+			}
+			for _, lastFlushedNanos := range fbr.ByNumForwardedTimes {
+				update(shardID, lastFlushedNanos)
+			}
+		}
+	}
+	if !found {
+		return FlushTimesSummary{}, errNoFlushTimes
+	}
+	return summary, nil
+}
+
 type flushTimesCheckerMetrics struct {
 	noFlushTimes             tally.Counter
 	shardNotFound            tally.Counter
@@ -386,3 +1344,64 @@ func fullyFlushed(flushtimes map[int64]int64, targetNanos int64) bool {
 	}
 	return true
 }
+
+// newFlushAgeMetricFamily returns a gauge of the age of the given flush times
+// at the given time, ordered by shard, flush type and resolution.
+func newFlushAgeMetricFamily(
+	flushTimes *schema.ShardSetFlushTimes,
+	nowNanos int64,
+) *dto.MetricFamily {
+	family := &dto.MetricFamily{
+		Name: proto.String(flushAgeMetricName),
+		Help: proto.String(flushAgeMetricHelp),
+		Type: dto.MetricType_GAUGE.Enum(),
+	}
+	if flushTimes == nil {
+		return family
+	}
+	shardIDs := make([]uint32, 0, len(flushTimes.ByShard))
+	for shardID := range flushTimes.ByShard {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+
+	addMetrics := func(shardID uint32, flushType string, byResolution map[int64]int64) {
+		resolutions := make([]int64, 0, len(byResolution))
+		for resolution := range byResolution {
+			resolutions = append(resolutions, resolution)
+		}
+		sort.Slice(resolutions, func(i, j int) bool { return resolutions[i] < resolutions[j] })
+		for _, resolution := range resolutions {
+			age := time.Duration(nowNanos - byResolution[resolution])
+			family.Metric = append(family.Metric, &dto.Metric{
+				Label: []*dto.LabelPair{
+					{Name: proto.String("shard"), Value: proto.String(strconv.Itoa(int(shardID)))},
+					{Name: proto.String("type"), Value: proto.String(flushType)},
+					{Name: proto.String("resolution"), Value: proto.String(time.Duration(resolution).String())},
+				},
+				Gauge: &dto.Gauge{Value: proto.Float64(age.Seconds())},
+			})
+		}
+	}
+	for _, shardID := range shardIDs {
+		shardFlushTimes := flushTimes.ByShard[shardID]
+		if shardFlushTimes == nil {
+			continue
+		}
+		addMetrics(shardID, "standard", shardFlushTimes.StandardByResolution)
+		addMetrics(shardID, "timed", shardFlushTimes.TimedByResolution)
+		forwarded := make(map[int64]int64, len(shardFlushTimes.ForwardedByResolution))
+		for resolution, fbr := range shardFlushTimes.ForwardedByResolution {
+			if fbr == nil {
+				continue
+			}
+			for _, lastFlushedNanos := range fbr.ByNumForwardedTimes {
+				if earliest, exists := forwarded[resolution]; !exists || lastFlushedNanos < earliest {
+					forwarded[resolution] = lastFlushedNanos
+				}
+			}
+		}
+		addMetrics(shardID, "forwarded", forwarded)
+	}
+	return family
+}
@@ -21,6 +21,8 @@
 package aggregator
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/instrument"
@@ -29,6 +31,12 @@ import (
 
 const (
 	defaultFlushTimesKeyFormat = "/shardset/%d/flush"
+
+	// NB: this matches the default maximum request size of etcd.
+	defaultFlushTimesPayloadLimitBytes = 1536 * 1024
+	defaultFlushTimesPayloadWarnRatio  = 0.8
+	defaultFlushTimesSinkBufferSize    = 64
+	defaultFlushLagReportInterval      = 10 * time.Second
 )
 
 // FlushTimesManagerOptions provide a set of options for flush times manager.
@@ -62,6 +70,102 @@ type FlushTimesManagerOptions interface {
 
 	// FlushTimesPersistRetrier returns the retrier for persisting flush times.
 	FlushTimesPersistRetrier() retry.Retrier
+
+	// SetFlushAgeResolutions sets the resolutions to report flush age gauges for.
+	// Gauges are only reported for the configured resolutions to bound cardinality.
+	SetFlushAgeResolutions(value []time.Duration) FlushTimesManagerOptions
+
+	// FlushAgeResolutions returns the resolutions to report flush age gauges for.
+	FlushAgeResolutions() []time.Duration
+
+	// SetFlushTimesSerializer sets the serializer for persisting flush times.
+	// Persisted flush times are read back regardless of their format.
+	SetFlushTimesSerializer(value FlushTimesSerializer) FlushTimesManagerOptions
+
+	// FlushTimesSerializer returns the serializer for persisting flush times.
+	FlushTimesSerializer() FlushTimesSerializer
+
+	// SetPlacementManager sets the placement manager used to verify the flush
+	// times are consistent with the shards owned by the instance.
+	SetPlacementManager(value PlacementManager) FlushTimesManagerOptions
+
+	// PlacementManager returns the placement manager used to verify the flush
+	// times are consistent with the shards owned by the instance.
+	PlacementManager() PlacementManager
+
+	// SetMetricsOnly sets whether flush times are only kept in memory, in which
+	// case stored flush times update the in-memory flush times and metrics but
+	// are never persisted to nor read from kv. This isolates aggregation from kv
+	// writes and is only meant for tests and benchmarks: flush times are lost on
+	// restart and are not shared with other instances.
+	SetMetricsOnly(value bool) FlushTimesManagerOptions
+
+	// MetricsOnly returns whether flush times are only kept in memory.
+	MetricsOnly() bool
+
+	// SetStoreCoalesceWindow sets the window stores are coalesced within, in
+	// which case only the latest flush times stored within the window are
+	// persisted once it elapses, reducing kv writes under heavy flush activity.
+	// Stores are persisted as they happen if the window is zero.
+	SetStoreCoalesceWindow(value time.Duration) FlushTimesManagerOptions
+
+	// StoreCoalesceWindow returns the window stores are coalesced within.
+	StoreCoalesceWindow() time.Duration
+
+	// SetPayloadLimitBytes sets the size limit of kv values, which persisting
+	// flush times fails beyond. Payload size checks are disabled if the limit is
+	// not positive.
+	SetPayloadLimitBytes(value int) FlushTimesManagerOptions
+
+	// PayloadLimitBytes returns the size limit of kv values.
+	PayloadLimitBytes() int
+
+	// SetPayloadWarnRatio sets the fraction of the payload limit beyond which
+	// persisting flush times warns that the payload is approaching the limit.
+	SetPayloadWarnRatio(value float64) FlushTimesManagerOptions
+
+	// PayloadWarnRatio returns the fraction of the payload limit beyond which
+	// persisting flush times warns that the payload is approaching the limit.
+	PayloadWarnRatio() float64
+
+	// SetRejectOversizedPayloads sets whether flush times whose payload exceeds
+	// the payload limit are rejected rather than sent to kv.
+	SetRejectOversizedPayloads(value bool) FlushTimesManagerOptions
+
+	// RejectOversizedPayloads returns whether flush times whose payload exceeds
+	// the payload limit are rejected rather than sent to kv.
+	RejectOversizedPayloads() bool
+
+	// SetFlushTimesSink sets the sink flush times are published to once stored.
+	SetFlushTimesSink(value FlushTimesSink) FlushTimesManagerOptions
+
+	// FlushTimesSink returns the sink flush times are published to once stored.
+	FlushTimesSink() FlushTimesSink
+
+	// SetFlushTimesSinkBufferSize sets the number of stored flush times buffered
+	// for publishing, beyond which they are dropped so that stores never block
+	// on a slow sink.
+	SetFlushTimesSinkBufferSize(value int) FlushTimesManagerOptions
+
+	// FlushTimesSinkBufferSize returns the number of stored flush times buffered
+	// for publishing.
+	FlushTimesSinkBufferSize() int
+
+	// SetFlushLagReporter sets the reporter the flush lag is periodically
+	// reported to. The flush lag is not reported with the default no-op reporter.
+	SetFlushLagReporter(value FlushLagReporter) FlushTimesManagerOptions
+
+	// FlushLagReporter returns the reporter the flush lag is periodically
+	// reported to.
+	FlushLagReporter() FlushLagReporter
+
+	// SetFlushLagReportInterval sets the interval at which the flush lag is
+	// reported, where a non-positive interval disables reporting.
+	SetFlushLagReportInterval(value time.Duration) FlushTimesManagerOptions
+
+	// FlushLagReportInterval returns the interval at which the flush lag is
+	// reported.
+	FlushLagReportInterval() time.Duration
 }
 
 type flushTimesManagerOptions struct {
@@ -70,6 +174,18 @@ type flushTimesManagerOptions struct {
 	flushTimesKeyFmt         string
 	flushTimesStore          kv.Store
 	flushTimesPersistRetrier retry.Retrier
+	flushAgeResolutions      []time.Duration
+	flushTimesSerializer     FlushTimesSerializer
+	placementManager         PlacementManager
+	metricsOnly              bool
+	storeCoalesceWindow      time.Duration
+	payloadLimitBytes        int
+	payloadWarnRatio         float64
+	rejectOversizedPayloads  bool
+	flushTimesSink           FlushTimesSink
+	flushTimesSinkBufferSize int
+	flushLagReporter         FlushLagReporter
+	flushLagReportInterval   time.Duration
 }
 
 // NewFlushTimesManagerOptions create a new set of flush times manager options.
@@ -79,6 +195,13 @@ func NewFlushTimesManagerOptions() FlushTimesManagerOptions {
 		instrumentOpts:           instrument.NewOptions(),
 		flushTimesKeyFmt:         defaultFlushTimesKeyFormat,
 		flushTimesPersistRetrier: retry.NewRetrier(retry.NewOptions()),
+		flushTimesSerializer:     NewProtoFlushTimesSerializer(),
+		payloadLimitBytes:        defaultFlushTimesPayloadLimitBytes,
+		payloadWarnRatio:         defaultFlushTimesPayloadWarnRatio,
+		flushTimesSink:           NewNoopFlushTimesSink(),
+		flushTimesSinkBufferSize: defaultFlushTimesSinkBufferSize,
+		flushLagReporter:         NewNoopFlushLagReporter(),
+		flushLagReportInterval:   defaultFlushLagReportInterval,
 	}
 }
 
@@ -131,3 +254,123 @@ func (o *flushTimesManagerOptions) SetFlushTimesPersistRetrier(value retry.Retri
 func (o *flushTimesManagerOptions) FlushTimesPersistRetrier() retry.Retrier {
 	return o.flushTimesPersistRetrier
 }
+
+func (o *flushTimesManagerOptions) SetFlushAgeResolutions(value []time.Duration) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushAgeResolutions = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushAgeResolutions() []time.Duration {
+	return o.flushAgeResolutions
+}
+
+func (o *flushTimesManagerOptions) SetFlushTimesSerializer(value FlushTimesSerializer) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushTimesSerializer = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushTimesSerializer() FlushTimesSerializer {
+	return o.flushTimesSerializer
+}
+
+func (o *flushTimesManagerOptions) SetPlacementManager(value PlacementManager) FlushTimesManagerOptions {
+	opts := *o
+	opts.placementManager = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) PlacementManager() PlacementManager {
+	return o.placementManager
+}
+
+func (o *flushTimesManagerOptions) SetMetricsOnly(value bool) FlushTimesManagerOptions {
+	opts := *o
+	opts.metricsOnly = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) MetricsOnly() bool {
+	return o.metricsOnly
+}
+
+func (o *flushTimesManagerOptions) SetStoreCoalesceWindow(value time.Duration) FlushTimesManagerOptions {
+	opts := *o
+	opts.storeCoalesceWindow = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) StoreCoalesceWindow() time.Duration {
+	return o.storeCoalesceWindow
+}
+
+func (o *flushTimesManagerOptions) SetPayloadLimitBytes(value int) FlushTimesManagerOptions {
+	opts := *o
+	opts.payloadLimitBytes = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) PayloadLimitBytes() int {
+	return o.payloadLimitBytes
+}
+
+func (o *flushTimesManagerOptions) SetPayloadWarnRatio(value float64) FlushTimesManagerOptions {
+	opts := *o
+	opts.payloadWarnRatio = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) PayloadWarnRatio() float64 {
+	return o.payloadWarnRatio
+}
+
+func (o *flushTimesManagerOptions) SetRejectOversizedPayloads(value bool) FlushTimesManagerOptions {
+	opts := *o
+	opts.rejectOversizedPayloads = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) RejectOversizedPayloads() bool {
+	return o.rejectOversizedPayloads
+}
+
+func (o *flushTimesManagerOptions) SetFlushTimesSink(value FlushTimesSink) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushTimesSink = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushTimesSink() FlushTimesSink {
+	return o.flushTimesSink
+}
+
+func (o *flushTimesManagerOptions) SetFlushTimesSinkBufferSize(value int) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushTimesSinkBufferSize = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushTimesSinkBufferSize() int {
+	return o.flushTimesSinkBufferSize
+}
+
+func (o *flushTimesManagerOptions) SetFlushLagReporter(value FlushLagReporter) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushLagReporter = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushLagReporter() FlushLagReporter {
+	return o.flushLagReporter
+}
+
+func (o *flushTimesManagerOptions) SetFlushLagReportInterval(value time.Duration) FlushTimesManagerOptions {
+	opts := *o
+	opts.flushLagReportInterval = value
+	return &opts
+}
+
+func (o *flushTimesManagerOptions) FlushLagReportInterval() time.Duration {
+	return o.flushLagReportInterval
+}
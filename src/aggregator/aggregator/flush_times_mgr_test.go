@@ -21,14 +21,27 @@
 package aggregator
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
 	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/instrument"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/mock/gomock"
+	golangproto "github.com/golang/protobuf/proto"
+	"github.com/prometheus/prometheus/pkg/textparse"
 	"github.com/stretchr/testify/require"
 	"github.com/uber-go/tally"
 )
@@ -157,6 +170,191 @@ func TestFlushTimesManagerWatchSuccess(t *testing.T) {
 	require.Equal(t, 12345, watch.Get().(int))
 }
 
+func TestFlushTimesManagerWatchShardClosed(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	_, _, err := mgr.WatchShard(0)
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, err)
+}
+
+func TestFlushTimesManagerWatchShard(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	shardCh, closeFn, err := mgr.WatchShard(0)
+	require.NoError(t, err)
+
+	flushTimesFn := func(shard0, shard1 int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Second): shard0},
+					TimedByResolution:    map[int64]int64{int64(time.Second): shard0 + 100},
+				},
+				1: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Second): shard1},
+				},
+			},
+		}
+	}
+
+	mgr.flushTimesWatchable.Update(flushTimesFn(1000, 2000))
+	require.Equal(t, int64(1000), <-shardCh)
+
+	// Updates to other shards should not fire the subscription.
+	mgr.flushTimesWatchable.Update(flushTimesFn(1000, 3000))
+	mgr.flushTimesWatchable.Update(flushTimesFn(1000, 4000))
+	select {
+	case flushedNanos := <-shardCh:
+		require.Fail(t, "unexpected shard notification", "flushed nanos %d", flushedNanos)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mgr.flushTimesWatchable.Update(flushTimesFn(5000, 4000))
+	require.Equal(t, int64(5000), <-shardCh)
+
+	closeFn()
+	for range shardCh {
+	}
+}
+
+func TestFlushTimesManagerWaitUntilAdvancedClosed(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	err := mgr.WaitUntilAdvanced(context.Background(), 0, time.Second, 1000)
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, err)
+}
+
+func TestFlushTimesManagerWaitUntilAdvanced(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	flushTimesFn := func(secondNanos, minuteNanos int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{
+						int64(time.Second): secondNanos,
+						int64(time.Minute): minuteNanos,
+					},
+				},
+			},
+		}
+	}
+	mgr.flushTimesWatchable.Update(flushTimesFn(1000, 1000))
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- mgr.WaitUntilAdvanced(context.Background(), 0, time.Minute, 1000)
+	}()
+
+	// Flush times of other resolutions or not past the given time do not unblock.
+	mgr.flushTimesWatchable.Update(flushTimesFn(5000, 1000))
+	select {
+	case err := <-doneCh:
+		require.Fail(t, "unexpected wait return", "error %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	mgr.flushTimesWatchable.Update(flushTimesFn(5000, 2000))
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "timed out waiting for the flush time to advance")
+	}
+
+	// Flush times already past the given time return immediately.
+	require.NoError(t, mgr.WaitUntilAdvanced(context.Background(), 0, time.Second, 1000))
+}
+
+func TestFlushTimesManagerWaitUntilAdvancedTimeout(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	mgr.flushTimesWatchable.Update(&schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			0: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{int64(time.Second): 1000},
+			},
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := mgr.WaitUntilAdvanced(ctx, 1, time.Second, 0)
+	require.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestFlushTimesManagerSummaryClosed(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	_, err := mgr.Summary()
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, err)
+}
+
+func TestFlushTimesManagerSummaryNoFlushTimes(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	_, err := mgr.Summary()
+	require.Equal(t, errNoFlushTimes, err)
+}
+
+func TestFlushTimesManagerSummarySuccess(t *testing.T) {
+	proto := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			0: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): 5000,
+					int64(time.Minute): 4800,
+				},
+			},
+			1: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): 5100,
+				},
+				TimedByResolution: map[int64]int64{
+					int64(time.Second): 1200,
+				},
+			},
+			2: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): 5200,
+				},
+				ForwardedByResolution: map[int64]*schema.ForwardedFlushTimesForResolution{
+					int64(time.Second): &schema.ForwardedFlushTimesForResolution{
+						ByNumForwardedTimes: map[int32]int64{
+							1: 5300,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	mgr, store := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	// Update the flush times and wait for the change to propagate.
+	_, err := store.Set(testFlushTimesKey, proto)
+	require.NoError(t, err)
+	for {
+		if mgr.flushTimesWatchable.Get() != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	summary, err := mgr.Summary()
+	require.NoError(t, err)
+	require.Equal(t, FlushTimesSummary{
+		NumShards:       3,
+		MinFlushedNanos: 1200,
+		MaxFlushedNanos: 5300,
+		OldestShardID:   1,
+	}, summary)
+}
+
 func TestFlushTimesManagerStoreAsyncClosed(t *testing.T) {
 	mgr, _ := testFlushTimesManager()
 	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, mgr.StoreAsync(testFlushTimesProto))
@@ -180,6 +378,307 @@ func TestFlushTimesManagerStoreAsyncSuccess(t *testing.T) {
 	}
 }
 
+func TestFlushTimesManagerStoreAsyncSerializers(t *testing.T) {
+	for _, serializer := range []FlushTimesSerializer{
+		NewProtoFlushTimesSerializer(),
+		NewJSONFlushTimesSerializer(),
+	} {
+		store := mem.NewStore()
+		opts := NewFlushTimesManagerOptions().
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store)
+		writer := NewFlushTimesManager(opts.SetFlushTimesSerializer(serializer))
+		require.NoError(t, writer.Open(testShardSetID))
+
+		// Readers detect the format regardless of their own serializer.
+		reader := NewFlushTimesManager(opts)
+		require.NoError(t, reader.Open(testShardSetID))
+
+		require.NoError(t, writer.StoreAsync(testFlushTimesProto))
+		for {
+			res, err := reader.Get()
+			require.NoError(t, err)
+			if res != nil {
+				require.Equal(t, testFlushTimesProto, res)
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		payload, err := writer.DryRunStore(testFlushTimesProto)
+		require.NoError(t, err)
+		var res schema.ShardSetFlushTimes
+		require.NoError(t, serializer.Unmarshal(payload, &res))
+		require.Equal(t, *testFlushTimesProto, res)
+
+		require.NoError(t, writer.Close())
+		require.NoError(t, reader.Close())
+	}
+}
+
+func TestFlushTimesManagerDryRunStoreNoFlushTimes(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	_, err := mgr.DryRunStore(nil)
+	require.Equal(t, errNoFlushTimes, err)
+}
+
+func TestFlushTimesManagerDryRunStoreMatchesStore(t *testing.T) {
+	mgr, store := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// NB: map entries are serialized in random order so only a single entry
+	// per map is used here to get deterministic payloads.
+	flushTimes := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			0: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): 1000,
+				},
+			},
+		},
+	}
+	payload, err := mgr.DryRunStore(flushTimes)
+	require.NoError(t, err)
+
+	// Dry run should not write to kv.
+	_, err = store.Get(testFlushTimesKey)
+	require.Equal(t, kv.ErrNotFound, err)
+
+	// Store flush times and wait for change to propagate.
+	require.NoError(t, mgr.StoreAsync(flushTimes))
+	var value kv.Value
+	for {
+		value, err = store.Get(testFlushTimesKey)
+		if value != nil && err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var stored schema.ShardSetFlushTimes
+	require.NoError(t, value.Unmarshal(&stored))
+	storedPayload, err := proto.Marshal(&stored)
+	require.NoError(t, err)
+	require.Equal(t, storedPayload, payload)
+}
+
+func TestFlushTimesManagerReportFlushAges(t *testing.T) {
+	var (
+		scope = tally.NewTestScope("", nil)
+		now   = time.Unix(0, 0).Add(time.Minute)
+		store = mem.NewStore()
+		opts  = NewFlushTimesManagerOptions().
+			SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time { return now })).
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+			SetFlushAgeResolutions([]time.Duration{time.Second, time.Minute}).
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store)
+		mgr = NewFlushTimesManager(opts)
+	)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+
+	gauges := scope.Snapshot().Gauges()
+	for _, input := range []struct {
+		id       string
+		expected time.Duration
+	}{
+		// The oldest flush time for the 1s resolution is the timed flush time of shard 0.
+		{id: "flush-age+resolution=1s", expected: time.Minute - 500},
+		{id: "flush-age+resolution=1m0s", expected: time.Minute - 2000},
+	} {
+		g, exists := gauges[input.id]
+		require.True(t, exists, input.id)
+		require.Equal(t, input.expected.Seconds(), g.Value())
+	}
+}
+
+func TestFlushTimesManagerReportStoreMetrics(t *testing.T) {
+	var (
+		nowLock sync.Mutex
+		now     = time.Unix(0, 0).Add(time.Minute)
+		nowFn   = func() time.Time {
+			nowLock.Lock()
+			defer nowLock.Unlock()
+			return now
+		}
+		scope = tally.NewTestScope("", nil)
+		store = mem.NewStore()
+		opts  = NewFlushTimesManagerOptions().
+			SetClockOptions(clock.NewOptions().SetNowFn(nowFn)).
+			SetInstrumentOptions(instrument.NewOptions().
+				SetMetricsScope(scope).
+				SetReportInterval(10 * time.Millisecond)).
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store)
+		mgr = NewFlushTimesManager(opts)
+	)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// Time since last store is not reported until flush times are first stored.
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, float64(0), scope.Snapshot().Gauges()["time-since-last-store+"].Value())
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+	}
+	require.Equal(t, int64(3), scope.Snapshot().Counters()["flush-times-stores+"].Value())
+
+	nowLock.Lock()
+	now = now.Add(5 * time.Second)
+	nowLock.Unlock()
+	for {
+		g, exists := scope.Snapshot().Gauges()["time-since-last-store+"]
+		if exists && g.Value() == 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFlushTimesManagerReportStoreDelta(t *testing.T) {
+	var (
+		scope = tally.NewTestScope("", nil)
+		store = mem.NewStore()
+		opts  = NewFlushTimesManagerOptions().
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store)
+		mgr = NewFlushTimesManager(opts)
+	)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	withShards := func(numShards int) *schema.ShardSetFlushTimes {
+		flushTimes := &schema.ShardSetFlushTimes{
+			ByShard: make(map[uint32]*schema.ShardFlushTimes, numShards),
+		}
+		for i := 0; i < numShards; i++ {
+			flushTimes.ByShard[uint32(i)] = &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{int64(time.Second): 1000},
+			}
+		}
+		return flushTimes
+	}
+	storeAndGetDelta := func(version int, flushTimes *schema.ShardSetFlushTimes) float64 {
+		require.NoError(t, mgr.StoreAsync(flushTimes))
+		for {
+			value, err := store.Get(testFlushTimesKey)
+			if err == nil && value.Version() == version {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return scope.Snapshot().Gauges()["store-delta-bytes+"].Value()
+	}
+
+	// The payload grows as shards are added, and shrinks once flush times of
+	// shards no longer owned are removed.
+	require.True(t, storeAndGetDelta(1, withShards(1)) > 0)
+	require.True(t, storeAndGetDelta(2, withShards(3)) > 0)
+	require.True(t, storeAndGetDelta(3, withShards(5)) > 0)
+	require.Equal(t, float64(0), storeAndGetDelta(4, withShards(5)))
+	require.True(t, storeAndGetDelta(5, withShards(2)) < 0)
+}
+
+func TestFlushTimesManagerMetricsOnly(t *testing.T) {
+	var (
+		scope = tally.NewTestScope("", nil)
+		store = mem.NewStore()
+		opts  = NewFlushTimesManagerOptions().
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store).
+			SetMetricsOnly(true)
+		mgr = NewFlushTimesManager(opts)
+	)
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, mgr.StoreAsync(testFlushTimesProto))
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	watch, err := mgr.Watch()
+	require.NoError(t, err)
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+	<-watch.C()
+	require.Equal(t, testFlushTimesProto, watch.Get())
+
+	// The in-memory flush times are returned immediately.
+	flushTimes, err := mgr.Get()
+	require.NoError(t, err)
+	require.Equal(t, testFlushTimesProto, flushTimes)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["flush-times-stores+"].Value())
+
+	require.NoError(t, mgr.Close())
+
+	// Nothing was persisted to kv.
+	_, err = store.Get(testFlushTimesKey)
+	require.Equal(t, kv.ErrNotFound, err)
+	require.Equal(t, int64(0), scope.Snapshot().Counters()["flush-times-persist.success+"].Value())
+}
+
+func TestFlushTimesManagerVerifyConsistencyNoPlacementManager(t *testing.T) {
+	mgr, _ := testFlushTimesManager()
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	_, err := mgr.VerifyConsistency()
+	require.Equal(t, errNoPlacementManager, err)
+}
+
+func TestFlushTimesManagerVerifyConsistency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// NB: flush times are tracked for shards 0 and 1 while the instance owns
+	// shards 1, 2 and 3, the latter leaving.
+	shards := shard.NewShards([]shard.Shard{
+		shard.NewShard(1).SetState(shard.Available),
+		shard.NewShard(2).SetState(shard.Initializing),
+		shard.NewShard(3).SetState(shard.Leaving),
+	})
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().Shards().Return(shards, nil).AnyTimes()
+
+	store := mem.NewStore()
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetPlacementManager(placementManager)
+	mgr := NewFlushTimesManager(opts)
+	_, err := mgr.VerifyConsistency()
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// All owned shards are untracked before flush times are stored.
+	report, err := mgr.VerifyConsistency()
+	require.NoError(t, err)
+	require.Equal(t, ConsistencyReport{UntrackedShards: []uint32{1, 2, 3}}, report)
+	require.False(t, report.Consistent())
+
+	_, err = store.Set(testFlushTimesKey, testFlushTimesProto)
+	require.NoError(t, err)
+	for {
+		flushTimes, err := mgr.Get()
+		require.NoError(t, err)
+		if flushTimes != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	report, err = mgr.VerifyConsistency()
+	require.NoError(t, err)
+	require.Equal(t, ConsistencyReport{
+		UntrackedShards: []uint32{2, 3},
+		StaleShards:     []uint32{0},
+	}, report)
+	require.False(t, report.Consistent())
+	require.True(t, ConsistencyReport{}.Consistent())
+}
+
 func TestFlushTimesManagerCloseClosed(t *testing.T) {
 	mgr, _ := testFlushTimesManager()
 	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, mgr.Close())
@@ -221,3 +720,785 @@ func testFlushTimesManager() (*flushTimesManager, kv.Store) {
 		SetFlushTimesStore(store)
 	return NewFlushTimesManager(opts).(*flushTimesManager), store
 }
+
+func TestFlushTimesManagerAdoptFrom(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := placement.NewPlacement().SetInstances([]placement.Instance{
+		placement.NewInstance().SetID("local").SetShardSetID(testShardSetID),
+		placement.NewInstance().SetID("remote").SetShardSetID(2),
+		placement.NewInstance().SetID("corrupt").SetShardSetID(3),
+		placement.NewInstance().SetID("missing").SetShardSetID(4),
+	})
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+
+	remoteProto := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			5: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): 3000,
+				},
+			},
+		},
+	}
+	store := mem.NewStore()
+	_, err := store.Set(testFlushTimesKey, testFlushTimesProto)
+	require.NoError(t, err)
+	_, err = store.Set(fmt.Sprintf(testFlushTimesKeyFmt, 2), remoteProto)
+	require.NoError(t, err)
+	_, err = store.Set(fmt.Sprintf(testFlushTimesKeyFmt, 3), &serializedFlushTimes{
+		data: []byte(`{"byShard": {"5":`),
+	})
+	require.NoError(t, err)
+
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetPlacementManager(placementManager)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	waitForFlushTimes := func(expected *schema.ShardSetFlushTimes) {
+		for {
+			flushTimes, err := mgr.Get()
+			require.NoError(t, err)
+			if flushTimes != nil && proto.Equal(expected, flushTimes) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	waitForFlushTimes(testFlushTimesProto)
+
+	// Failed reads leave the local flush times untouched.
+	for _, instanceID := range []string{"corrupt", "missing", "unknown"} {
+		require.Error(t, mgr.AdoptFrom(instanceID))
+		flushTimes, err := mgr.Get()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(testFlushTimesProto, flushTimes))
+
+		value, err := store.Get(testFlushTimesKey)
+		require.NoError(t, err)
+		var stored schema.ShardSetFlushTimes
+		require.NoError(t, value.Unmarshal(&stored))
+		require.True(t, proto.Equal(testFlushTimesProto, &stored))
+	}
+
+	require.NoError(t, mgr.AdoptFrom("remote"))
+	waitForFlushTimes(remoteProto)
+	value, err := store.Get(testFlushTimesKey)
+	require.NoError(t, err)
+	adopted, err := decodeFlushTimes(value)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(remoteProto, adopted))
+}
+
+func TestFlushTimesManagerAdoptFromConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	p := placement.NewPlacement().SetInstances([]placement.Instance{
+		placement.NewInstance().SetID("local").SetShardSetID(testShardSetID),
+		placement.NewInstance().SetID("remote").SetShardSetID(2),
+	})
+	placementManager := NewMockPlacementManager(ctrl)
+	placementManager.EXPECT().Placement().Return(nil, p, nil).AnyTimes()
+
+	remoteProto := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			5: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{int64(time.Second): 3000},
+			},
+		},
+	}
+	flushed := proto.Clone(testFlushTimesProto).(*schema.ShardSetFlushTimes)
+	flushed.ByShard[1].StandardByResolution[int64(time.Minute)] = 20000
+
+	memStore := mem.NewStore()
+	_, err := memStore.Set(testFlushTimesKey, testFlushTimesProto)
+	require.NoError(t, err)
+	_, err = memStore.Set(fmt.Sprintf(testFlushTimesKeyFmt, 2), remoteProto)
+	require.NoError(t, err)
+	// NB: the local flush times are stored concurrently with the adoption.
+	store := &conflictingStore{
+		Store: memStore,
+		conflictFn: func() {
+			_, err := memStore.Set(testFlushTimesKey, flushed)
+			require.NoError(t, err)
+		},
+	}
+
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetPlacementManager(placementManager).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewFlushTimesManager(opts)
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, mgr.AdoptFrom("remote"))
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// The conflict is returned rather than overwriting the concurrent store.
+	require.Equal(t, kv.ErrVersionMismatch, mgr.AdoptFrom("remote"))
+	value, err := memStore.Get(testFlushTimesKey)
+	require.NoError(t, err)
+	persisted, err := decodeFlushTimes(value)
+	require.NoError(t, err)
+	require.True(t, proto.Equal(flushed, persisted))
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["flush-times-store-if-conflicts+"].Value())
+}
+
+// conflictingStore is a kv store that runs the given function once before the
+// first check-and-set, e.g. to store a conflicting value.
+type conflictingStore struct {
+	kv.Store
+
+	conflictFn func()
+	conflicted bool
+}
+
+func (s *conflictingStore) CheckAndSet(key string, version int, v golangproto.Message) (int, error) {
+	if !s.conflicted {
+		s.conflicted = true
+		s.conflictFn()
+	}
+	return s.Store.CheckAndSet(key, version, v)
+}
+
+func TestFlushTimesManagerStoreCoalesceWindow(t *testing.T) {
+	newFlushTimes := func(flushedNanos int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{
+						int64(time.Second): flushedNanos,
+					},
+				},
+			},
+		}
+	}
+
+	store := mem.NewStore()
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetStoreCoalesceWindow(200 * time.Millisecond).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	const numStores = 10
+	for i := 1; i <= numStores; i++ {
+		require.NoError(t, mgr.StoreAsync(newFlushTimes(int64(i))))
+	}
+
+	var value kv.Value
+	for {
+		var err error
+		value, err = store.Get(testFlushTimesKey)
+		if err == nil {
+			break
+		}
+		require.Equal(t, kv.ErrNotFound, err)
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Only the latest flush times are persisted, in a single write.
+	time.Sleep(300 * time.Millisecond)
+	value, err := store.Get(testFlushTimesKey)
+	require.NoError(t, err)
+	require.Equal(t, 1, value.Version())
+	var actual schema.ShardSetFlushTimes
+	require.NoError(t, value.Unmarshal(&actual))
+	require.True(t, proto.Equal(newFlushTimes(numStores), &actual))
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(numStores-1), counters["flush-times-coalesced-stores+"].Value())
+
+	// The final flush times are persisted even if the manager is closed before
+	// the window elapses.
+	require.NoError(t, mgr.StoreAsync(newFlushTimes(numStores+1)))
+	require.NoError(t, mgr.Close())
+	value, err = store.Get(testFlushTimesKey)
+	require.NoError(t, err)
+	require.Equal(t, 2, value.Version())
+	require.NoError(t, value.Unmarshal(&actual))
+	require.True(t, proto.Equal(newFlushTimes(numStores+1), &actual))
+}
+
+func TestFlushTimesManagerBackfill(t *testing.T) {
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetMetricsOnly(true)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// Backfilling before any flush times are loaded is rejected.
+	backfill := map[uint32]map[time.Duration]int64{0: {time.Second: 5000}}
+	require.Equal(t, errNoFlushTimes, mgr.Backfill(backfill))
+
+	original := proto.Clone(testFlushTimesProto).(*schema.ShardSetFlushTimes)
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+	require.NoError(t, mgr.Backfill(map[uint32]map[time.Duration]int64{
+		0: {time.Second: 5000, 10 * time.Second: 7000},
+		3: {time.Second: 9000},
+	}))
+
+	flushTimes, err := mgr.Get()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(flushTimes.ByShard))
+	shard0 := flushTimes.ByShard[0]
+	require.Equal(t, map[int64]int64{
+		int64(time.Second):      5000,
+		int64(10 * time.Second): 7000,
+	}, shard0.StandardByResolution)
+	require.Equal(t, testFlushTimesProto.ByShard[0].ForwardedByResolution, shard0.ForwardedByResolution)
+	require.Equal(t, testFlushTimesProto.ByShard[0].TimedByResolution, shard0.TimedByResolution)
+	require.True(t, proto.Equal(testFlushTimesProto.ByShard[1], flushTimes.ByShard[1]))
+	require.Equal(t, map[int64]int64{int64(time.Second): 9000}, flushTimes.ByShard[3].StandardByResolution)
+	// The previously stored flush times are left untouched.
+	require.True(t, proto.Equal(original, testFlushTimesProto))
+
+	// Moving any flush time backward rejects the whole backfill.
+	err = mgr.Backfill(map[uint32]map[time.Duration]int64{
+		0: {time.Second: 6000},
+		1: {time.Minute: 1500},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "backward")
+	after, err := mgr.Get()
+	require.NoError(t, err)
+	require.True(t, proto.Equal(flushTimes, after))
+}
+
+func TestFlushTimesManagerBackfillConcurrentMetricsOnly(t *testing.T) {
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetMetricsOnly(true)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+	const (
+		numShards    = 1000
+		numBackfills = 50
+	)
+	initial := &schema.ShardSetFlushTimes{
+		ByShard: make(map[uint32]*schema.ShardFlushTimes, numShards),
+	}
+	for i := 0; i < numShards; i++ {
+		initial.ByShard[uint32(i)] = &schema.ShardFlushTimes{
+			StandardByResolution: map[int64]int64{int64(time.Second): 500},
+		}
+	}
+	require.NoError(t, mgr.StoreAsync(initial))
+
+	// Concurrent backfills each merge into the latest flush times, so none of
+	// the backfilled shards are lost.
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+	)
+	for i := 0; i < numBackfills; i++ {
+		wg.Add(1)
+		shardID := uint32(numShards + i)
+		go func() {
+			defer wg.Done()
+			<-start
+			require.NoError(t, mgr.Backfill(map[uint32]map[time.Duration]int64{
+				shardID: {time.Second: 1000},
+			}))
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	flushTimes, err := mgr.Get()
+	require.NoError(t, err)
+	require.Equal(t, numShards+numBackfills, len(flushTimes.ByShard))
+	for i := 0; i < numBackfills; i++ {
+		require.Equal(t, map[int64]int64{int64(time.Second): 1000},
+			flushTimes.ByShard[uint32(numShards+i)].StandardByResolution)
+	}
+}
+
+func TestFlushTimesManagerBackfillCheckAndSet(t *testing.T) {
+	store := mem.NewStore()
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// Backfilling before the flush times are loaded from kv is rejected rather
+	// than persisting the backfilled shards alone.
+	backfill := map[uint32]map[time.Duration]int64{3: {time.Second: 9000}}
+	require.Equal(t, errNoFlushTimes, mgr.Backfill(backfill))
+	_, err := store.Get(testFlushTimesKey)
+	require.Equal(t, kv.ErrNotFound, err)
+
+	_, err = store.Set(testFlushTimesKey, testFlushTimesProto)
+	require.NoError(t, err)
+	for {
+		if flushTimes, err := mgr.Get(); err == nil && flushTimes != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The backfill applies to the flush times in kv, so flushes stored since the
+	// flush times were loaded are not lost.
+	flushed := proto.Clone(testFlushTimesProto).(*schema.ShardSetFlushTimes)
+	flushed.ByShard[1].StandardByResolution[int64(time.Minute)] = 20000
+	_, err = store.Set(testFlushTimesKey, flushed)
+	require.NoError(t, err)
+	require.NoError(t, mgr.Backfill(backfill))
+
+	value, err := store.Get(testFlushTimesKey)
+	require.NoError(t, err)
+	persisted, err := decodeFlushTimes(value)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(persisted.ByShard))
+	require.True(t, proto.Equal(flushed.ByShard[0], persisted.ByShard[0]))
+	require.True(t, proto.Equal(flushed.ByShard[1], persisted.ByShard[1]))
+	require.Equal(t, map[int64]int64{int64(time.Second): 9000},
+		persisted.ByShard[3].StandardByResolution)
+}
+
+func TestFlushTimesManagerDetectRegressions(t *testing.T) {
+	newFlushTimes := func(shard0Nanos, shard1Nanos int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Second): shard0Nanos},
+				},
+				1: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Minute): shard1Nanos},
+				},
+			},
+		}
+	}
+
+	store := mem.NewStore()
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	waitForFlushTimes := func(expected *schema.ShardSetFlushTimes) {
+		for {
+			flushTimes, err := mgr.Get()
+			require.NoError(t, err)
+			if flushTimes != nil && proto.Equal(expected, flushTimes) {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	regressions := func() int64 {
+		return scope.Snapshot().Counters()["flush-time-regressions+"].Value()
+	}
+
+	for _, flushTimes := range []*schema.ShardSetFlushTimes{
+		newFlushTimes(1000, 2000),
+		newFlushTimes(3000, 2000),
+	} {
+		_, err := store.Set(testFlushTimesKey, flushTimes)
+		require.NoError(t, err)
+		waitForFlushTimes(flushTimes)
+	}
+	require.Equal(t, int64(0), regressions())
+
+	// NB: simulate a stale leader persisting outdated flush times.
+	regressed := newFlushTimes(500, 2000)
+	_, err := store.Set(testFlushTimesKey, regressed)
+	require.NoError(t, err)
+	waitForFlushTimes(regressed)
+	require.Equal(t, int64(1), regressions())
+}
+
+func TestFlushTimesManagerDumpOpenMetrics(t *testing.T) {
+	flushTimes := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			0: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{
+					int64(time.Second): int64(90 * time.Second),
+					int64(time.Minute): int64(40 * time.Second),
+				},
+			},
+			1: &schema.ShardFlushTimes{
+				TimedByResolution: map[int64]int64{
+					int64(time.Second): int64(95 * time.Second),
+				},
+				ForwardedByResolution: map[int64]*schema.ForwardedFlushTimesForResolution{
+					int64(time.Second): &schema.ForwardedFlushTimesForResolution{
+						ByNumForwardedTimes: map[int32]int64{
+							1: int64(80 * time.Second),
+							2: int64(70 * time.Second),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	mgr, store := testFlushTimesManager()
+	var buf bytes.Buffer
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, mgr.DumpOpenMetrics(&buf))
+	mgr.nowFn = func() time.Time { return time.Unix(100, 0) }
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	_, err := store.Set(testFlushTimesKey, flushTimes)
+	require.NoError(t, err)
+	for {
+		if mgr.flushTimesWatchable.Get() != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, mgr.DumpOpenMetrics(&buf))
+
+	var (
+		parser = textparse.NewOpenMetricsParser(buf.Bytes())
+		ages   = make(map[string]float64)
+		types  = make(map[string]textparse.MetricType)
+	)
+	for {
+		entry, err := parser.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		switch entry {
+		case textparse.EntryType:
+			name, metricType := parser.Type()
+			types[string(name)] = metricType
+		case textparse.EntrySeries:
+			series, _, value := parser.Series()
+			ages[string(series)] = value
+		}
+	}
+	require.Equal(t, map[string]textparse.MetricType{
+		flushAgeMetricName: textparse.MetricTypeGauge,
+	}, types)
+	require.Equal(t, map[string]float64{
+		`aggregator_flush_age_seconds{shard="0",type="standard",resolution="1s"}`:   10,
+		`aggregator_flush_age_seconds{shard="0",type="standard",resolution="1m0s"}`: 60,
+		`aggregator_flush_age_seconds{shard="1",type="timed",resolution="1s"}`:      5,
+		`aggregator_flush_age_seconds{shard="1",type="forwarded",resolution="1s"}`:  30,
+	}, ages)
+}
+
+func TestFlushTimesManagerStoreIf(t *testing.T) {
+	newFlushTimes := func(flushedNanos int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Second): flushedNanos},
+				},
+			},
+		}
+	}
+	// NB: only store flush times moving forward.
+	newerThan := func(flushedNanos int64) func(*schema.ShardSetFlushTimes) bool {
+		return func(current *schema.ShardSetFlushTimes) bool {
+			if current == nil {
+				return true
+			}
+			return current.ByShard[0].StandardByResolution[int64(time.Second)] < flushedNanos
+		}
+	}
+	persisted := func(store kv.Store) *schema.ShardSetFlushTimes {
+		value, err := store.Get(testFlushTimesKey)
+		require.NoError(t, err)
+		flushTimes, err := decodeFlushTimes(value)
+		require.NoError(t, err)
+		return flushTimes
+	}
+
+	store := mem.NewStore()
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(store).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewFlushTimesManager(opts)
+	_, err := mgr.StoreIf(newerThan(1000), newFlushTimes(1000))
+	require.Equal(t, errFlushTimesManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+
+	// The predicate is evaluated against no flush times if none are persisted.
+	stored, err := mgr.StoreIf(newerThan(1000), newFlushTimes(1000))
+	require.NoError(t, err)
+	require.True(t, stored)
+	require.True(t, proto.Equal(newFlushTimes(1000), persisted(store)))
+
+	// The predicate passing stores the flush times.
+	stored, err = mgr.StoreIf(newerThan(2000), newFlushTimes(2000))
+	require.NoError(t, err)
+	require.True(t, stored)
+	require.True(t, proto.Equal(newFlushTimes(2000), persisted(store)))
+
+	// The predicate failing leaves the flush times untouched.
+	stored, err = mgr.StoreIf(newerThan(1500), newFlushTimes(1500))
+	require.NoError(t, err)
+	require.False(t, stored)
+	require.True(t, proto.Equal(newFlushTimes(2000), persisted(store)))
+
+	// A concurrent store conflicting with the store re-evaluates the predicate
+	// against the newer flush times, which then fails.
+	var evaluations int
+	stored, err = mgr.StoreIf(func(current *schema.ShardSetFlushTimes) bool {
+		evaluations++
+		if evaluations == 1 {
+			_, err := store.Set(testFlushTimesKey, newFlushTimes(4000))
+			require.NoError(t, err)
+		}
+		return newerThan(3000)(current)
+	}, newFlushTimes(3000))
+	require.NoError(t, err)
+	require.False(t, stored)
+	require.Equal(t, 2, evaluations)
+	require.True(t, proto.Equal(newFlushTimes(4000), persisted(store)))
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["flush-times-store-if-conflicts+"].Value())
+}
+
+func TestFlushTimesManagerPayloadLimit(t *testing.T) {
+	newFlushTimes := func(numShards int) *schema.ShardSetFlushTimes {
+		flushTimes := &schema.ShardSetFlushTimes{
+			ByShard: make(map[uint32]*schema.ShardFlushTimes, numShards),
+		}
+		for i := 0; i < numShards; i++ {
+			flushTimes.ByShard[uint32(i)] = &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{int64(time.Second): 1000},
+			}
+		}
+		return flushTimes
+	}
+	always := func(*schema.ShardSetFlushTimes) bool { return true }
+	data, err := NewFlushTimesManager(NewFlushTimesManagerOptions()).DryRunStore(newFlushTimes(10))
+	require.NoError(t, err)
+	// NB: a single shard fits under the warn ratio, ten shards exactly fit under
+	// the limit and twenty shards exceed it.
+	limit := len(data)
+
+	for _, reject := range []bool{false, true} {
+		store := mem.NewStore()
+		scope := tally.NewTestScope("", nil)
+		opts := NewFlushTimesManagerOptions().
+			SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+			SetFlushTimesStore(store).
+			SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+			SetPayloadLimitBytes(limit).
+			SetPayloadWarnRatio(0.5).
+			SetRejectOversizedPayloads(reject)
+		mgr := NewFlushTimesManager(opts)
+		require.NoError(t, mgr.Open(testShardSetID))
+
+		for _, numShards := range []int{1, 10} {
+			stored, err := mgr.StoreIf(always, newFlushTimes(numShards))
+			require.NoError(t, err)
+			require.True(t, stored)
+		}
+		counters := scope.Snapshot().Counters()
+		require.Equal(t, int64(1), counters["flush-times-payload-size-warnings+"].Value())
+
+		stored, err := mgr.StoreIf(always, newFlushTimes(20))
+		value, getErr := store.Get(testFlushTimesKey)
+		require.NoError(t, getErr)
+		persisted, decodeErr := decodeFlushTimes(value)
+		require.NoError(t, decodeErr)
+		if reject {
+			require.Equal(t, errFlushTimesPayloadTooLarge, err)
+			require.False(t, stored)
+			require.True(t, proto.Equal(newFlushTimes(10), persisted))
+		} else {
+			require.NoError(t, err)
+			require.True(t, stored)
+			require.True(t, proto.Equal(newFlushTimes(20), persisted))
+		}
+		counters = scope.Snapshot().Counters()
+		require.Equal(t, int64(1), counters["flush-times-payload-size-warnings+"].Value())
+		require.Equal(t, int64(1), counters["flush-times-oversized-payloads+"].Value())
+		require.NoError(t, mgr.Close())
+	}
+}
+
+type fakeFlushTimesSink struct {
+	publishFn func(value *schema.ShardSetFlushTimes) error
+}
+
+func (s fakeFlushTimesSink) Publish(value *schema.ShardSetFlushTimes) error {
+	return s.publishFn(value)
+}
+
+func TestFlushTimesManagerFlushTimesSink(t *testing.T) {
+	newFlushTimes := func(flushedNanos int64) *schema.ShardSetFlushTimes {
+		return &schema.ShardSetFlushTimes{
+			ByShard: map[uint32]*schema.ShardFlushTimes{
+				0: &schema.ShardFlushTimes{
+					StandardByResolution: map[int64]int64{int64(time.Second): flushedNanos},
+				},
+			},
+		}
+	}
+	always := func(*schema.ShardSetFlushTimes) bool { return true }
+
+	var (
+		published = make(chan *schema.ShardSetFlushTimes)
+		blockedCh = make(chan struct{})
+		unblockCh = make(chan struct{})
+		blocked   int32
+	)
+	sink := fakeFlushTimesSink{publishFn: func(value *schema.ShardSetFlushTimes) error {
+		if atomic.LoadInt32(&blocked) == 1 {
+			blockedCh <- struct{}{}
+			<-unblockCh
+		}
+		published <- value
+		return nil
+	}}
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetFlushTimesStore(mem.NewStore()).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetFlushTimesSink(sink).
+		SetFlushTimesSinkBufferSize(1)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	defer mgr.Close()
+	store := func(flushedNanos int64) {
+		stored, err := mgr.StoreIf(always, newFlushTimes(flushedNanos))
+		require.NoError(t, err)
+		require.True(t, stored)
+	}
+
+	// Each stored value is published.
+	for _, flushedNanos := range []int64{1000, 2000, 3000} {
+		store(flushedNanos)
+		require.True(t, proto.Equal(newFlushTimes(flushedNanos), <-published))
+	}
+
+	// Once the sink falls behind, a single value is buffered and further stores
+	// are dropped rather than blocking.
+	atomic.StoreInt32(&blocked, 1)
+	store(4000)
+	<-blockedCh
+	store(5000)
+	store(6000)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["flush-times-sink-drops+"].Value())
+
+	atomic.StoreInt32(&blocked, 0)
+	close(unblockCh)
+	require.True(t, proto.Equal(newFlushTimes(4000), <-published))
+	require.True(t, proto.Equal(newFlushTimes(5000), <-published))
+}
+
+// inMemoryFlushLagReporter records the flush lag reported to it.
+type inMemoryFlushLagReporter struct {
+	sync.Mutex
+
+	reported []FlushLagReport
+}
+
+func (r *inMemoryFlushLagReporter) Report(report FlushLagReport) error {
+	r.Lock()
+	r.reported = append(r.reported, report)
+	r.Unlock()
+	return nil
+}
+
+func (r *inMemoryFlushLagReporter) reports() []FlushLagReport {
+	r.Lock()
+	defer r.Unlock()
+	return append([]FlushLagReport(nil), r.reported...)
+}
+
+func TestFlushTimesManagerFlushLagReporter(t *testing.T) {
+	var (
+		now      = time.Unix(0, 10500)
+		reporter = &inMemoryFlushLagReporter{}
+		scope    = tally.NewTestScope("", nil)
+		opts     = NewFlushTimesManagerOptions().
+				SetClockOptions(clock.NewOptions().SetNowFn(func() time.Time { return now })).
+				SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+				SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+				SetMetricsOnly(true).
+				SetFlushLagReporter(reporter).
+				SetFlushLagReportInterval(10 * time.Millisecond)
+		mgr = NewFlushTimesManager(opts)
+	)
+	require.NoError(t, mgr.Open(testShardSetID))
+
+	// Nothing is reported until there are flush times.
+	time.Sleep(50 * time.Millisecond)
+	require.Empty(t, reporter.reports())
+
+	// The lag since the earliest flush time is reported periodically.
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+	for len(reporter.reports()) < 3 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, mgr.Close())
+
+	// Nothing is reported once closed.
+	numReports := len(reporter.reports())
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, numReports, len(reporter.reports()))
+
+	expected := FlushLagReport{
+		ShardSetID:    testShardSetID,
+		NumShards:     2,
+		OldestShardID: 0,
+		MaxLag:        10 * time.Microsecond,
+		Timestamp:     now,
+	}
+	for _, report := range reporter.reports() {
+		require.Equal(t, expected, report)
+	}
+	require.Equal(t, 0.00001, scope.Snapshot().Gauges()["flush-lag+"].Value())
+}
+
+func TestFlushTimesManagerFlushLagReporterDisabled(t *testing.T) {
+	reporter := &inMemoryFlushLagReporter{}
+	opts := NewFlushTimesManagerOptions().
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetMetricsOnly(true).
+		SetFlushLagReporter(reporter).
+		SetFlushLagReportInterval(0)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, mgr.Close())
+	require.Empty(t, reporter.reports())
+}
+
+func TestFlushTimesManagerFlushLagReporterNoop(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	opts := NewFlushTimesManagerOptions().
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetFlushTimesKeyFmt(testFlushTimesKeyFmt).
+		SetMetricsOnly(true).
+		SetFlushLagReportInterval(10 * time.Millisecond)
+	mgr := NewFlushTimesManager(opts)
+	require.NoError(t, mgr.Open(testShardSetID))
+	require.NoError(t, mgr.StoreAsync(testFlushTimesProto))
+
+	// The flush lag is not computed with the default no-op reporter.
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 0.0, scope.Snapshot().Gauges()["flush-lag+"].Value())
+	require.NoError(t, mgr.Close())
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"bytes"
+
+	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+)
+
+// FlushTimesSerializer serializes flush times to and from the payload
+// persisted in kv.
+type FlushTimesSerializer interface {
+	// Marshal serializes the flush times.
+	Marshal(value *schema.ShardSetFlushTimes) ([]byte, error)
+
+	// Unmarshal deserializes the flush times from the given payload.
+	Unmarshal(data []byte, value *schema.ShardSetFlushTimes) error
+}
+
+type protoFlushTimesSerializer struct{}
+
+// NewProtoFlushTimesSerializer creates a new serializer persisting flush times
+// in the proto wire format, which is the default.
+func NewProtoFlushTimesSerializer() FlushTimesSerializer {
+	return protoFlushTimesSerializer{}
+}
+
+func (protoFlushTimesSerializer) Marshal(value *schema.ShardSetFlushTimes) ([]byte, error) {
+	return proto.Marshal(value)
+}
+
+func (protoFlushTimesSerializer) Unmarshal(data []byte, value *schema.ShardSetFlushTimes) error {
+	return proto.Unmarshal(data, value)
+}
+
+type jsonFlushTimesSerializer struct {
+	marshaler jsonpb.Marshaler
+}
+
+// NewJSONFlushTimesSerializer creates a new serializer persisting flush times
+// as human-readable JSON, which is useful for debugging.
+func NewJSONFlushTimesSerializer() FlushTimesSerializer {
+	return jsonFlushTimesSerializer{marshaler: jsonpb.Marshaler{OrigName: true}}
+}
+
+func (s jsonFlushTimesSerializer) Marshal(value *schema.ShardSetFlushTimes) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.marshaler.Marshal(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (jsonFlushTimesSerializer) Unmarshal(data []byte, value *schema.ShardSetFlushTimes) error {
+	return jsonpb.Unmarshal(bytes.NewReader(data), value)
+}
+
+var (
+	defaultProtoFlushTimesSerializer = NewProtoFlushTimesSerializer()
+	defaultJSONFlushTimesSerializer  = NewJSONFlushTimesSerializer()
+)
+
+// unmarshalFlushTimes deserializes flush times persisted in either format,
+// detecting JSON payloads by their leading brace. This is unambiguous as a
+// proto payload for flush times never starts with that byte, which would be
+// a group start for field 15.
+func unmarshalFlushTimes(data []byte, value *schema.ShardSetFlushTimes) error {
+	if trimmed := bytes.TrimLeft(data, " \t\r\n"); len(trimmed) > 0 && trimmed[0] == '{' {
+		return defaultJSONFlushTimesSerializer.Unmarshal(trimmed, value)
+	}
+	return defaultProtoFlushTimesSerializer.Unmarshal(data, value)
+}
+
+// serializedFlushTimes is a proto message wrapping an already serialized flush
+// times payload, so that it is persisted in and read from kv as is regardless
+// of its format.
+type serializedFlushTimes struct {
+	data []byte
+}
+
+func (m *serializedFlushTimes) Reset()         { m.data = nil }
+func (m *serializedFlushTimes) String() string { return string(m.data) }
+func (*serializedFlushTimes) ProtoMessage()    {}
+
+func (m *serializedFlushTimes) Marshal() ([]byte, error) {
+	return m.data, nil
+}
+
+func (m *serializedFlushTimes) Unmarshal(data []byte) error {
+	m.data = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"testing"
+
+	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlushTimesSerializerRoundTrip(t *testing.T) {
+	for _, serializer := range []FlushTimesSerializer{
+		NewProtoFlushTimesSerializer(),
+		NewJSONFlushTimesSerializer(),
+	} {
+		data, err := serializer.Marshal(testFlushTimesProto)
+		require.NoError(t, err)
+
+		var res schema.ShardSetFlushTimes
+		require.NoError(t, serializer.Unmarshal(data, &res))
+		require.Equal(t, *testFlushTimesProto, res)
+
+		// Reads detect the format of the payload.
+		var detected schema.ShardSetFlushTimes
+		require.NoError(t, unmarshalFlushTimes(data, &detected))
+		require.Equal(t, *testFlushTimesProto, detected)
+	}
+}
+
+func TestJSONFlushTimesSerializerIsReadable(t *testing.T) {
+	flushTimes := &schema.ShardSetFlushTimes{
+		ByShard: map[uint32]*schema.ShardFlushTimes{
+			3: &schema.ShardFlushTimes{
+				StandardByResolution: map[int64]int64{1000000000: 1234},
+			},
+		},
+	}
+	data, err := NewJSONFlushTimesSerializer().Marshal(flushTimes)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"by_shard":{"3":{"standard_by_resolution":{"1000000000":"1234"}}}}`,
+		string(data))
+}
+
+func TestUnmarshalFlushTimesEmptyPayload(t *testing.T) {
+	var res schema.ShardSetFlushTimes
+	require.NoError(t, unmarshalFlushTimes(nil, &res))
+	require.Equal(t, schema.ShardSetFlushTimes{}, res)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
+)
+
+// FlushTimesSink receives the flush times stored by the flush times manager,
+// e.g. so that a central service can monitor flush lag across instances.
+type FlushTimesSink interface {
+	// Publish publishes the given flush times.
+	Publish(value *schema.ShardSetFlushTimes) error
+}
+
+type noopFlushTimesSink struct{}
+
+// NewNoopFlushTimesSink creates a new sink discarding flush times, which is the
+// default.
+func NewNoopFlushTimesSink() FlushTimesSink {
+	return noopFlushTimesSink{}
+}
+
+func (noopFlushTimesSink) Publish(*schema.ShardSetFlushTimes) error {
+	return nil
+}
+
+type httpFlushTimesSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPFlushTimesSink creates a new sink POSTing flush times as JSON to the
+// given URL with the given client.
+func NewHTTPFlushTimesSink(url string, client *http.Client) FlushTimesSink {
+	return httpFlushTimesSink{url: url, client: client}
+}
+
+func (s httpFlushTimesSink) Publish(value *schema.ShardSetFlushTimes) error {
+	data, err := defaultJSONFlushTimesSerializer.Marshal(value)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// NB: drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("publishing flush times to %s failed with status code %d",
+			s.url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package aggregator
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	schema "github.com/m3db/m3/src/aggregator/generated/proto/flush"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPFlushTimesSinkPublish(t *testing.T) {
+	var received []*schema.ShardSetFlushTimes
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var flushTimes schema.ShardSetFlushTimes
+		require.NoError(t, NewJSONFlushTimesSerializer().Unmarshal(data, &flushTimes))
+		received = append(received, &flushTimes)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPFlushTimesSink(server.URL, server.Client())
+	require.NoError(t, sink.Publish(testFlushTimesProto))
+	require.Equal(t, []*schema.ShardSetFlushTimes{testFlushTimesProto}, received)
+}
+
+func TestHTTPFlushTimesSinkPublishError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewHTTPFlushTimesSink(server.URL, server.Client()).Publish(testFlushTimesProto)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "status code 503")
+}
@@ -106,7 +106,7 @@ type followerFlushManager struct {
 	checkEvery            time.Duration
 	workers               xsync.WorkerPool
 	placementManager      PlacementManager
-	electionManager       ElectionManager
+	electionManager       LeadershipProvider
 	flushTimesManager     FlushTimesManager
 	maxBufferSize         time.Duration
 	forcedFlushWindowSize time.Duration
@@ -68,7 +68,7 @@ func TestFollowerFlushManagerCanNotLeadNotCampaigning(t *testing.T) {
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(false)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
@@ -80,7 +80,7 @@ func TestFollowerFlushManagerCanNotLeadProtoNotUpdated(t *testing.T) {
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(true)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
@@ -92,7 +92,7 @@ func TestFollowerFlushManagerCanNotLeadStandardFlushWindowsNotEnded(t *testing.T
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(true)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
@@ -106,7 +106,7 @@ func TestFollowerFlushManagerCanNotLeadTimedFlushWindowsNotEnded(t *testing.T) {
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
 	mgr.processed = testFlushTimes2
@@ -124,7 +124,7 @@ func TestFollowerFlushManagerCanNotLeadForwardedFlushWindowsNotEnded(t *testing.
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(true)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
@@ -164,7 +164,7 @@ func TestFollowerFlushManagerCanLeadNotFlushed(t *testing.T) {
 		defer ctrl.Finish()
 
 		doneCh := make(chan struct{})
-		electionManager := NewMockElectionManager(ctrl)
+		electionManager := NewMockLeadershipProvider(ctrl)
 		electionManager.EXPECT().IsCampaigning().Return(true).AnyTimes()
 		clockOpts := clock.NewOptions().SetNowFn(func() time.Time {
 			return now
@@ -245,7 +245,7 @@ func TestFollowerFlushManagerCanLeadNoTombstonedShards(t *testing.T) {
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(true)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
@@ -260,7 +260,7 @@ func TestFollowerFlushManagerCanLeadWithTombstonedShards(t *testing.T) {
 	defer ctrl.Finish()
 
 	doneCh := make(chan struct{})
-	electionManager := NewMockElectionManager(ctrl)
+	electionManager := NewMockLeadershipProvider(ctrl)
 	electionManager.EXPECT().IsCampaigning().Return(true)
 	opts := NewFlushManagerOptions().SetElectionManager(electionManager)
 	mgr := newFollowerFlushManager(doneCh, opts).(*followerFlushManager)
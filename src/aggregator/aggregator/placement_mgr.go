@@ -21,13 +21,20 @@
 package aggregator
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/x/clock"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/uber-go/tally"
 )
 
@@ -35,8 +42,19 @@ var (
 	// ErrInstanceNotFoundInPlacement is returned when instance is not found in placement.
 	ErrInstanceNotFoundInPlacement = errors.New("instance not found in placement")
 
+	// ErrNoFailoverTarget is returned when no instance in the placement can take
+	// over the shards of the current instance.
+	ErrNoFailoverTarget = errors.New("no failover target in placement")
+
+	// ErrShardNotFoundInPlacement is returned when no instance in the placement
+	// owns a shard.
+	ErrShardNotFoundInPlacement = errors.New("shard not found in placement")
+
+	errUnknownRoutingPreference        = errors.New("unknown routing preference")
 	errPlacementManagerNotOpenOrClosed = errors.New("placement manager not open or closed")
 	errPlacementManagerOpenOrClosed    = errors.New("placement manager already open or closed")
+
+	placementJSONMarshaler = jsonpb.Marshaler{OrigName: true}
 )
 
 // PlacementManager manages agg tier placements.
@@ -60,17 +78,221 @@ type PlacementManager interface {
 	// the current instance, and false otherwise.
 	HasReplacementInstance() (bool, error)
 
+	// WatchReplacementComplete watches for the replacement of the current
+	// instance completing across placement updates, checking the placement at
+	// the placement check interval, so that the replaced instance can be
+	// decommissioned once the replacing instance has fully taken over. A
+	// notification is sent each time HasReplacementInstance goes from true to
+	// false, including when the instance is removed from the placement. The
+	// channel is closed once the returned function is called or the manager is
+	// closed.
+	WatchReplacementComplete() (<-chan struct{}, func(), error)
+
 	// Shards returns the current shards owned by the instance.
 	Shards() (shard.Shards, error)
 
+	// UnassignedShards returns the shards in the current placement that are neither
+	// available nor initializing on any instance, in ascending order.
+	UnassignedShards() ([]uint32, error)
+
+	// RoutingTable returns the instances owning each shard in the current placement,
+	// i.e. the instances the shard is available or initializing on, ordered by
+	// instance ID. The returned table is shared and must not be modified.
+	RoutingTable() (map[uint32][]placement.Instance, error)
+
+	// MovementCost returns the number of shards the instance would gain and lose
+	// if the candidate placement were applied, by diffing the shards it currently
+	// owns against those it owns in the candidate. Leaving shards are not owned.
+	MovementCost(candidate placement.Placement) (shardsAdded, shardsRemoved int, err error)
+
+	// PreferredFailoverTarget returns the instance best suited to take over the
+	// shards owned by the current instance, which is the instance in a different
+	// isolation group with the most available shards overlapping them. Ties are
+	// broken by the lowest instance ID.
+	PreferredFailoverTarget() (placement.Instance, error)
+
+	// DetectIsolationViolations returns the shards in the current placement owned
+	// by more than one instance in the same isolation group, ordered by shard ID
+	// and isolation group. Leaving shards are not owned.
+	DetectIsolationViolations() ([]ShardViolation, error)
+
+	// ShardDistribution returns the number of shards owned by the instances in
+	// each isolation group of the current placement, keyed by isolation group.
+	// Leaving shards are not owned, so shards being moved are only counted once.
+	ShardDistribution() (map[string]int, error)
+
+	// QuorumForShard returns the number of instances forming a majority of the
+	// instances owning the given shard in the current placement, i.e. the number
+	// of replicas a read or write must reach for quorum consistency. Leaving
+	// shards are not owned.
+	QuorumForShard(shardID uint32) (int, error)
+
+	// RebalanceRecommendation analyzes the current placement for instances owning
+	// more shards than others, beyond the rebalance skew threshold, and suggests
+	// shard moves evening them out without applying them. Moves never place two
+	// replicas of a shard in the same isolation group, and prefer targets in the
+	// least loaded isolation groups. Leaving shards are not owned, and instances
+	// marked for removal are neither moved from nor to.
+	RebalanceRecommendation() (*RebalancePlan, error)
+
+	// PreferredOwner returns the instance to route to among those having the given
+	// shard available in the current placement, selected by the given preference.
+	// Ties are broken by the lowest instance ID, and ErrShardNotFoundInPlacement
+	// is returned if no instance has the shard available.
+	PreferredOwner(shardID uint32, preference RoutingPreference) (placement.Instance, error)
+
+	// VerifyRedistribution verifies that every shard owned by the removed instance
+	// in the placement before its removal, including shards it is already leaving
+	// once marked for removal, is owned by the replication factor of other
+	// instances in the placement after, returning a *ShardLeakError listing the
+	// shards which are not. Leaving shards are not owned in the placement after.
+	VerifyRedistribution(removedInstanceID string, before, after placement.Placement) error
+
+	// WatchInstanceWeight watches for changes to the weight of the instance across
+	// placement updates, checking the placement at the placement check interval.
+	// The weight when the watch starts is not notified, and the returned channel
+	// only holds the latest weight if the subscriber falls behind. The channel is
+	// closed once the returned function is called or the manager is closed.
+	WatchInstanceWeight() (<-chan uint32, func(), error)
+
+	// IsMarkedForRemoval returns true if the instance is scheduled for removal
+	// from the current placement, i.e. all of the shards it owns are leaving.
+	IsMarkedForRemoval() (bool, error)
+
+	// WatchMarkedForRemoval watches for the instance being marked for removal
+	// across placement updates, checking the placement at the placement check
+	// interval, so that it can hand off leadership and flush before its shards
+	// are taken away. A notification is sent each time the instance becomes
+	// marked for removal, but not if it already is when the watch starts. The
+	// channel is closed once the returned function is called or the manager is
+	// closed.
+	WatchMarkedForRemoval() (<-chan struct{}, func(), error)
+
+	// WaitForShardState blocks until all shards owned by the instance are in the
+	// given state, or until the context is done.
+	WaitForShardState(ctx context.Context, state shard.State) error
+
+	// WaitForCutover blocks until no shard owned by the instance is still
+	// initializing, i.e. the instance has completed the cutover of all shards
+	// added by placement changes, or until the context is done.
+	WaitForCutover(ctx context.Context) error
+
+	// PendingStages returns the stages of the active staged placement which have
+	// yet to take effect in ascending cutover order, revealing scheduled topology
+	// changes before they activate.
+	PendingStages() ([]StagedPlacementInfo, error)
+
+	// ExportJSON returns the active placement rendered as JSON from its proto
+	// representation, which can be parsed back into an equal placement.
+	ExportJSON() ([]byte, error)
+
 	// Close closes the placement manager.
 	Close() error
 }
 
+// ShardViolation is a shard owned by more than one instance in the same isolation
+// group, which a correct placement never does.
+type ShardViolation struct {
+	// ShardID is the ID of the shard.
+	ShardID uint32
+
+	// IsolationGroup is the isolation group of the instances owning the shard.
+	IsolationGroup string
+
+	// InstanceIDs are the IDs of the instances owning the shard, in ascending order.
+	InstanceIDs []string
+}
+
+// RebalancePlan is a set of shard moves suggested to balance the shards owned by
+// the instances in a placement.
+type RebalancePlan struct {
+	// Moves are the suggested shard moves, in the order they were planned.
+	Moves []ShardMove
+}
+
+// ShardMove is a shard suggested to move from one instance to another.
+type ShardMove struct {
+	// ShardID is the ID of the shard.
+	ShardID uint32
+
+	// FromInstanceID is the ID of the instance owning the shard.
+	FromInstanceID string
+
+	// ToInstanceID is the ID of the instance suggested to take over the shard.
+	ToInstanceID string
+}
+
+// ShardLeak is a shard of a removed instance which is not owned by the expected
+// number of replicas once the instance has been removed.
+type ShardLeak struct {
+	// ShardID is the ID of the shard.
+	ShardID uint32
+
+	// Replicas is the number of instances owning the shard after the removal.
+	Replicas int
+
+	// Expected is the number of instances expected to own the shard.
+	Expected int
+}
+
+// ShardLeakError is returned when the shards of a removed instance have not been
+// fully redistributed to the remaining instances.
+type ShardLeakError struct {
+	// RemovedInstanceID is the ID of the removed instance.
+	RemovedInstanceID string
+
+	// Leaks are the shards not fully redistributed, in ascending shard ID order.
+	Leaks []ShardLeak
+}
+
+func (e *ShardLeakError) Error() string {
+	leaks := make([]string, 0, len(e.Leaks))
+	for _, leak := range e.Leaks {
+		leaks = append(leaks, fmt.Sprintf("shard %d owned by %d of %d replicas",
+			leak.ShardID, leak.Replicas, leak.Expected))
+	}
+	return fmt.Sprintf("shards of removed instance %s not redistributed: %s",
+		e.RemovedInstanceID, strings.Join(leaks, ", "))
+}
+
+// RoutingPreference determines which of the instances owning a shard is preferred
+// for routing.
+type RoutingPreference int
+
+const (
+	// SameZoneRoutingPreference prefers owners in the zone of the current instance,
+	// falling back to any owner if there are none.
+	SameZoneRoutingPreference RoutingPreference = iota
+
+	// LowestWeightRoutingPreference prefers the owner with the lowest weight.
+	LowestWeightRoutingPreference
+
+	// LocalFirstRoutingPreference prefers the current instance if it owns the shard,
+	// falling back to the same zone preference otherwise.
+	LocalFirstRoutingPreference
+)
+
+// StagedPlacementInfo describes a stage of the staged placement.
+type StagedPlacementInfo struct {
+	// CutoverNanos is the time the stage takes effect.
+	CutoverNanos int64
+
+	// Placement is the placement taking effect at the cutover time.
+	Placement placement.Placement
+}
+
 type placementManagerMetrics struct {
 	activeStagedPlacementErrors tally.Counter
 	activePlacementErrors       tally.Counter
 	instanceNotFound            tally.Counter
+	instanceCacheHits           tally.Counter
+	instanceCacheMisses         tally.Counter
+	routingTableRebuilds        tally.Counter
+	routingTableRebuildLatency  tally.Timer
+	unassignedShards            tally.Gauge
+	instanceWeightChanges       tally.Counter
+	markedForRemoval            tally.Counter
+	replacementsCompleted       tally.Counter
 }
 
 func newPlacementManagerMetrics(scope tally.Scope) placementManagerMetrics {
@@ -78,6 +300,14 @@ func newPlacementManagerMetrics(scope tally.Scope) placementManagerMetrics {
 		activeStagedPlacementErrors: scope.Counter("active-staged-placement-errors"),
 		activePlacementErrors:       scope.Counter("active-placement-errors"),
 		instanceNotFound:            scope.Counter("instance-not-found"),
+		instanceCacheHits:           scope.Counter("instance-cache-hits"),
+		instanceCacheMisses:         scope.Counter("instance-cache-misses"),
+		routingTableRebuilds:        scope.Counter("routing-table-rebuilds"),
+		routingTableRebuildLatency:  scope.Timer("routing-table-rebuild-latency"),
+		unassignedShards:            scope.Gauge("unassigned-shards"),
+		instanceWeightChanges:       scope.Counter("instance-weight-changes"),
+		markedForRemoval:            scope.Counter("marked-for-removal"),
+		replacementsCompleted:       scope.Counter("replacements-completed"),
 	}
 }
 
@@ -89,25 +319,58 @@ const (
 	placementManagerClosed
 )
 
+// placementCacheKey identifies the active placement cached values were
+// derived from.
+type placementCacheKey struct {
+	stagedPlacementVersion int
+	cutoverNanos           int64
+}
+
+func newPlacementCacheKey(
+	stagedPlacement placement.ActiveStagedPlacement,
+	placement placement.Placement,
+) placementCacheKey {
+	return placementCacheKey{
+		stagedPlacementVersion: stagedPlacement.Version(),
+		cutoverNanos:           placement.CutoverNanos(),
+	}
+}
+
 type placementManager struct {
 	sync.RWMutex
 
-	nowFn            clock.NowFn
-	instanceID       string
-	placementWatcher placement.StagedPlacementWatcher
+	nowFn                  clock.NowFn
+	instanceID             string
+	placementWatcher       placement.StagedPlacementWatcher
+	placementCheckInterval time.Duration
+	rebalanceSkewThreshold int
+	validateErr            error
 
 	state   placementManagerState
 	metrics placementManagerMetrics
+
+	// NB: the caches are guarded by their own locks since they're updated
+	// while the manager lock is only held for reading. The instance cache is
+	// read far more often than it's updated, so it's guarded by a read write lock.
+	instanceCacheLock sync.RWMutex
+	instanceCacheKey  placementCacheKey
+	instanceCache     placement.Instance
+	cacheLock         sync.Mutex
+	routingTableKey   placementCacheKey
+	routingTable      map[uint32][]placement.Instance
 }
 
 // NewPlacementManager creates a new placement manager.
 func NewPlacementManager(opts PlacementManagerOptions) PlacementManager {
 	instrumentOpts := opts.InstrumentOptions()
 	return &placementManager{
-		nowFn:            opts.ClockOptions().NowFn(),
-		instanceID:       opts.InstanceID(),
-		placementWatcher: opts.StagedPlacementWatcher(),
-		metrics:          newPlacementManagerMetrics(instrumentOpts.MetricsScope()),
+		nowFn:                  opts.ClockOptions().NowFn(),
+		instanceID:             opts.InstanceID(),
+		placementWatcher:       opts.StagedPlacementWatcher(),
+		placementCheckInterval: opts.PlacementCheckInterval(),
+		rebalanceSkewThreshold: opts.RebalanceSkewThreshold(),
+		validateErr:            opts.Validate(),
+		metrics:                newPlacementManagerMetrics(instrumentOpts.MetricsScope()),
 	}
 }
 
@@ -118,6 +381,9 @@ func (mgr *placementManager) Open() error {
 	if mgr.state != placementManagerNotOpen {
 		return errPlacementManagerOpenOrClosed
 	}
+	if mgr.validateErr != nil {
+		return mgr.validateErr
+	}
 	if err := mgr.placementWatcher.Watch(); err != nil {
 		return err
 	}
@@ -202,6 +468,615 @@ func (mgr *placementManager) Shards() (shard.Shards, error) {
 	return instance.Shards(), nil
 }
 
+func (mgr *placementManager) UnassignedShards() ([]uint32, error) {
+	_, placement, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	assigned := make(map[uint32]struct{}, placement.NumShards())
+	for _, instance := range placement.Instances() {
+		for _, s := range instance.Shards().All() {
+			if state := s.State(); state == shard.Available || state == shard.Initializing {
+				assigned[s.ID()] = struct{}{}
+			}
+		}
+	}
+	var unassigned []uint32
+	for _, shardID := range placement.Shards() {
+		if _, exists := assigned[shardID]; !exists {
+			unassigned = append(unassigned, shardID)
+		}
+	}
+	sort.Slice(unassigned, func(i, j int) bool { return unassigned[i] < unassigned[j] })
+	mgr.metrics.unassignedShards.Update(float64(len(unassigned)))
+	return unassigned, nil
+}
+
+func (mgr *placementManager) MovementCost(
+	candidate placement.Placement,
+) (shardsAdded, shardsRemoved int, err error) {
+	_, current, err := mgr.Placement()
+	if err != nil {
+		return 0, 0, err
+	}
+	currShards, err := mgr.ownedShards(current)
+	if err != nil {
+		return 0, 0, err
+	}
+	candidateShards, err := mgr.ownedShards(candidate)
+	if err != nil {
+		return 0, 0, err
+	}
+	for shardID := range candidateShards {
+		if _, exists := currShards[shardID]; !exists {
+			shardsAdded++
+		}
+	}
+	for shardID := range currShards {
+		if _, exists := candidateShards[shardID]; !exists {
+			shardsRemoved++
+		}
+	}
+	return shardsAdded, shardsRemoved, nil
+}
+
+func (mgr *placementManager) PreferredFailoverTarget() (placement.Instance, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	currInstance, err := mgr.instanceFrom(p)
+	if err != nil {
+		return nil, err
+	}
+	currShards, err := mgr.ownedShards(p)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		target      placement.Instance
+		maxOverlaps int
+	)
+	// NB: instances are returned in ascending ID order, so the first instance
+	// with the most overlapping shards has the lowest ID.
+	for _, instance := range p.Instances() {
+		if instance.ID() == mgr.instanceID ||
+			instance.IsolationGroup() == currInstance.IsolationGroup() {
+			continue
+		}
+		overlaps := 0
+		for _, s := range instance.Shards().ShardsForState(shard.Available) {
+			if _, exists := currShards[s.ID()]; exists {
+				overlaps++
+			}
+		}
+		if overlaps > maxOverlaps {
+			target, maxOverlaps = instance, overlaps
+		}
+	}
+	if target == nil {
+		return nil, ErrNoFailoverTarget
+	}
+	return target, nil
+}
+
+func (mgr *placementManager) RoutingTable() (map[uint32][]placement.Instance, error) {
+	stagedPlacement, placement, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+
+	key := newPlacementCacheKey(stagedPlacement, placement)
+	mgr.cacheLock.Lock()
+	defer mgr.cacheLock.Unlock()
+
+	if mgr.routingTable != nil && mgr.routingTableKey == key {
+		return mgr.routingTable, nil
+	}
+	start := mgr.nowFn()
+	mgr.routingTable = newRoutingTable(placement)
+	mgr.routingTableKey = key
+	mgr.metrics.routingTableRebuilds.Inc(1)
+	mgr.metrics.routingTableRebuildLatency.Record(mgr.nowFn().Sub(start))
+	return mgr.routingTable, nil
+}
+
+func (mgr *placementManager) DetectIsolationViolations() ([]ShardViolation, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	type shardGroup struct {
+		shardID        uint32
+		isolationGroup string
+	}
+	// NB: instances are returned in ascending ID order, so the owners of each
+	// shard are too.
+	owners := make(map[shardGroup][]string)
+	for _, instance := range p.Instances() {
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				continue
+			}
+			key := shardGroup{shardID: s.ID(), isolationGroup: instance.IsolationGroup()}
+			owners[key] = append(owners[key], instance.ID())
+		}
+	}
+	var violations []ShardViolation
+	for key, instanceIDs := range owners {
+		if len(instanceIDs) < 2 {
+			continue
+		}
+		violations = append(violations, ShardViolation{
+			ShardID:        key.shardID,
+			IsolationGroup: key.isolationGroup,
+			InstanceIDs:    instanceIDs,
+		})
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].ShardID != violations[j].ShardID {
+			return violations[i].ShardID < violations[j].ShardID
+		}
+		return violations[i].IsolationGroup < violations[j].IsolationGroup
+	})
+	return violations, nil
+}
+
+func (mgr *placementManager) ShardDistribution() (map[string]int, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	distribution := make(map[string]int)
+	for _, instance := range p.Instances() {
+		shards := instance.Shards()
+		distribution[instance.IsolationGroup()] += shards.NumShards() -
+			shards.NumShardsForState(shard.Leaving)
+	}
+	return distribution, nil
+}
+
+func (mgr *placementManager) QuorumForShard(shardID uint32) (int, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return 0, err
+	}
+	replicas := 0
+	for _, instance := range p.Instances() {
+		s, ok := instance.Shards().Shard(shardID)
+		if ok && s.State() != shard.Leaving {
+			replicas++
+		}
+	}
+	if replicas == 0 {
+		return 0, ErrShardNotFoundInPlacement
+	}
+	return replicas/2 + 1, nil
+}
+
+func (mgr *placementManager) RebalanceRecommendation() (*RebalancePlan, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		instances   []*rebalanceInstance
+		groupLoads  = make(map[string]int)
+		groupOwners = make(map[uint32]map[string]int)
+	)
+	// NB: instances are returned in ascending ID order, so ties between
+	// instances are broken by the lowest instance ID.
+	for _, instance := range p.Instances() {
+		if isMarkedForRemoval(instance) {
+			continue
+		}
+		owned := make(map[uint32]struct{})
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				continue
+			}
+			owned[s.ID()] = struct{}{}
+			owners, exists := groupOwners[s.ID()]
+			if !exists {
+				owners = make(map[string]int)
+				groupOwners[s.ID()] = owners
+			}
+			owners[instance.IsolationGroup()]++
+		}
+		groupLoads[instance.IsolationGroup()] += len(owned)
+		instances = append(instances, &rebalanceInstance{
+			id:     instance.ID(),
+			group:  instance.IsolationGroup(),
+			shards: owned,
+		})
+	}
+
+	plan := &RebalancePlan{}
+	for {
+		move, found := nextRebalanceMove(instances, groupLoads, groupOwners,
+			mgr.rebalanceSkewThreshold)
+		if !found {
+			break
+		}
+		from, to := move.from, move.to
+		delete(from.shards, move.shardID)
+		to.shards[move.shardID] = struct{}{}
+		groupOwners[move.shardID][from.group]--
+		groupOwners[move.shardID][to.group]++
+		groupLoads[from.group]--
+		groupLoads[to.group]++
+		plan.Moves = append(plan.Moves, ShardMove{
+			ShardID:        move.shardID,
+			FromInstanceID: from.id,
+			ToInstanceID:   to.id,
+		})
+	}
+	return plan, nil
+}
+
+func (mgr *placementManager) PreferredOwner(
+	shardID uint32,
+	preference RoutingPreference,
+) (placement.Instance, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	// NB: instances are returned in ascending ID order, so owners are too.
+	var owners []placement.Instance
+	for _, instance := range p.Instances() {
+		s, ok := instance.Shards().Shard(shardID)
+		if ok && s.State() == shard.Available {
+			owners = append(owners, instance)
+		}
+	}
+	if len(owners) == 0 {
+		return nil, ErrShardNotFoundInPlacement
+	}
+
+	switch preference {
+	case LowestWeightRoutingPreference:
+		preferred := owners[0]
+		for _, owner := range owners[1:] {
+			if owner.Weight() < preferred.Weight() {
+				preferred = owner
+			}
+		}
+		return preferred, nil
+	case LocalFirstRoutingPreference:
+		for _, owner := range owners {
+			if owner.ID() == mgr.instanceID {
+				return owner, nil
+			}
+		}
+		fallthrough
+	case SameZoneRoutingPreference:
+		currInstance, err := mgr.instanceFrom(p)
+		if err != nil {
+			return nil, err
+		}
+		for _, owner := range owners {
+			if owner.Zone() == currInstance.Zone() {
+				return owner, nil
+			}
+		}
+		return owners[0], nil
+	default:
+		return nil, errUnknownRoutingPreference
+	}
+}
+
+func (mgr *placementManager) VerifyRedistribution(
+	removedInstanceID string,
+	before, after placement.Placement,
+) error {
+	removed, ok := before.Instance(removedInstanceID)
+	if !ok {
+		return ErrInstanceNotFoundInPlacement
+	}
+	var (
+		expected = after.ReplicaFactor()
+		leaks    []ShardLeak
+	)
+	// NB: shards the removed instance is leaving still need to be redistributed,
+	// otherwise a placement with the instance already marked for removal would
+	// pass without verifying any shard.
+	for _, s := range removed.Shards().All() {
+		replicas := 0
+		for _, instance := range after.Instances() {
+			if instance.ID() == removedInstanceID {
+				continue
+			}
+			owned, ok := instance.Shards().Shard(s.ID())
+			if ok && owned.State() != shard.Leaving {
+				replicas++
+			}
+		}
+		if replicas != expected {
+			leaks = append(leaks, ShardLeak{
+				ShardID:  s.ID(),
+				Replicas: replicas,
+				Expected: expected,
+			})
+		}
+	}
+	if len(leaks) == 0 {
+		return nil
+	}
+	sort.Slice(leaks, func(i, j int) bool { return leaks[i].ShardID < leaks[j].ShardID })
+	return &ShardLeakError{RemovedInstanceID: removedInstanceID, Leaks: leaks}
+}
+
+func (mgr *placementManager) WatchInstanceWeight() (<-chan uint32, func(), error) {
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	if state != placementManagerOpen {
+		return nil, nil, errPlacementManagerNotOpenOrClosed
+	}
+
+	var (
+		weightCh = make(chan uint32, 1)
+		doneCh   = make(chan struct{})
+		doneOnce sync.Once
+		closeFn  = func() { doneOnce.Do(func() { close(doneCh) }) }
+	)
+	// NB: the weight is read before returning so changes made right after the
+	// watch is created are not missed.
+	lastWeight, found := mgr.instanceWeight()
+	go func() {
+		defer close(weightCh)
+
+		ticker := time.NewTicker(mgr.placementCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-doneCh:
+				return
+			}
+
+			instance, err := mgr.Instance()
+			if err == errPlacementManagerNotOpenOrClosed {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			weight := instance.Weight()
+			if !found {
+				lastWeight, found = weight, true
+				continue
+			}
+			if weight == lastWeight {
+				continue
+			}
+			lastWeight = weight
+			mgr.metrics.instanceWeightChanges.Inc(1)
+
+			// NB: this is the only sender so after draining any stale weight
+			// the send below never blocks.
+			select {
+			case <-weightCh:
+			default:
+			}
+			weightCh <- weight
+		}
+	}()
+	return weightCh, closeFn, nil
+}
+
+func (mgr *placementManager) instanceWeight() (uint32, bool) {
+	instance, err := mgr.Instance()
+	if err != nil {
+		return 0, false
+	}
+	return instance.Weight(), true
+}
+
+func (mgr *placementManager) IsMarkedForRemoval() (bool, error) {
+	instance, err := mgr.Instance()
+	if err != nil {
+		return false, err
+	}
+	return isMarkedForRemoval(instance), nil
+}
+
+func (mgr *placementManager) WatchMarkedForRemoval() (<-chan struct{}, func(), error) {
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	if state != placementManagerOpen {
+		return nil, nil, errPlacementManagerNotOpenOrClosed
+	}
+
+	var (
+		markedCh = make(chan struct{}, 1)
+		doneCh   = make(chan struct{})
+		doneOnce sync.Once
+		closeFn  = func() { doneOnce.Do(func() { close(doneCh) }) }
+	)
+	// NB: the state is read before returning so changes made right after the
+	// watch is created are not missed.
+	lastMarked, _ := mgr.IsMarkedForRemoval()
+	go func() {
+		defer close(markedCh)
+
+		ticker := time.NewTicker(mgr.placementCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-doneCh:
+				return
+			}
+
+			marked, err := mgr.IsMarkedForRemoval()
+			if err == errPlacementManagerNotOpenOrClosed {
+				return
+			}
+			if err != nil {
+				continue
+			}
+			wasMarked := lastMarked
+			lastMarked = marked
+			if !marked || wasMarked {
+				continue
+			}
+			mgr.metrics.markedForRemoval.Inc(1)
+
+			// NB: a pending notification already signals the instance is marked.
+			select {
+			case markedCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return markedCh, closeFn, nil
+}
+
+func (mgr *placementManager) WatchReplacementComplete() (<-chan struct{}, func(), error) {
+	mgr.RLock()
+	state := mgr.state
+	mgr.RUnlock()
+	if state != placementManagerOpen {
+		return nil, nil, errPlacementManagerNotOpenOrClosed
+	}
+
+	var (
+		completeCh = make(chan struct{}, 1)
+		doneCh     = make(chan struct{})
+		doneOnce   sync.Once
+		closeFn    = func() { doneOnce.Do(func() { close(doneCh) }) }
+	)
+	// NB: the state is read before returning so a replacement which starts
+	// right after the watch is created is not missed.
+	lastReplaced, _ := mgr.HasReplacementInstance()
+	go func() {
+		defer close(completeCh)
+
+		ticker := time.NewTicker(mgr.placementCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-doneCh:
+				return
+			}
+
+			replaced, err := mgr.HasReplacementInstance()
+			if err == errPlacementManagerNotOpenOrClosed {
+				return
+			}
+			if err == ErrInstanceNotFoundInPlacement {
+				// NB: the replaced instance may be removed from the placement
+				// in the same update that completes the replacement.
+				replaced, err = false, nil
+			}
+			if err != nil {
+				continue
+			}
+			wasReplaced := lastReplaced
+			lastReplaced = replaced
+			if replaced || !wasReplaced {
+				continue
+			}
+			mgr.metrics.replacementsCompleted.Inc(1)
+
+			// NB: a pending notification already signals the replacement completed.
+			select {
+			case completeCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return completeCh, closeFn, nil
+}
+
+func (mgr *placementManager) WaitForShardState(ctx context.Context, state shard.State) error {
+	return mgr.waitForShards(ctx, func(shards []shard.Shard) bool {
+		return allShardsInState(shards, state)
+	})
+}
+
+func (mgr *placementManager) WaitForCutover(ctx context.Context) error {
+	return mgr.waitForShards(ctx, func(shards []shard.Shard) bool {
+		for _, s := range shards {
+			if s.State() == shard.Initializing {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func (mgr *placementManager) PendingStages() ([]StagedPlacementInfo, error) {
+	mgr.RLock()
+	stagedPlacement, _, err := mgr.placementWithLock()
+	mgr.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	pending, err := stagedPlacement.PendingPlacements()
+	if err != nil {
+		return nil, err
+	}
+	stages := make([]StagedPlacementInfo, 0, len(pending))
+	for _, p := range pending {
+		stages = append(stages, StagedPlacementInfo{
+			CutoverNanos: p.CutoverNanos(),
+			Placement:    p,
+		})
+	}
+	return stages, nil
+}
+
+func (mgr *placementManager) ExportJSON() ([]byte, error) {
+	_, p, err := mgr.Placement()
+	if err != nil {
+		return nil, err
+	}
+	pb, err := p.Proto()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := placementJSONMarshaler.Marshal(&buf, pb); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// waitForShards blocks until the shards owned by the instance satisfy the given
+// condition, or until the context is done.
+func (mgr *placementManager) waitForShards(
+	ctx context.Context,
+	conditionFn func(shards []shard.Shard) bool,
+) error {
+	ticker := time.NewTicker(mgr.placementCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		// NB: errors other than the manager being closed may be transient (e.g. the
+		// instance has not been added to the placement yet) and are retried.
+		shards, err := mgr.Shards()
+		if err == errPlacementManagerNotOpenOrClosed {
+			return err
+		}
+		if err == nil && conditionFn(shards.All()) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
 func (mgr *placementManager) Close() error {
 	mgr.Lock()
 	defer mgr.Unlock()
@@ -240,11 +1115,33 @@ func (mgr *placementManager) placementWithLock() (placement.ActiveStagedPlacemen
 }
 
 func (mgr *placementManager) instanceWithLock() (placement.Instance, error) {
-	_, placement, err := mgr.placementWithLock()
+	stagedPlacement, placement, err := mgr.placementWithLock()
 	if err != nil {
 		return nil, err
 	}
-	return mgr.instanceFrom(placement)
+
+	// NB: the cached instance is invalidated by any change to the version of the
+	// staged placement or the active placement.
+	key := newPlacementCacheKey(stagedPlacement, placement)
+	mgr.instanceCacheLock.RLock()
+	if mgr.instanceCache != nil && mgr.instanceCacheKey == key {
+		instance := mgr.instanceCache
+		mgr.instanceCacheLock.RUnlock()
+		mgr.metrics.instanceCacheHits.Inc(1)
+		return instance, nil
+	}
+	mgr.instanceCacheLock.RUnlock()
+
+	mgr.metrics.instanceCacheMisses.Inc(1)
+	instance, err := mgr.instanceFrom(placement)
+	if err != nil {
+		return nil, err
+	}
+	mgr.instanceCacheLock.Lock()
+	mgr.instanceCacheKey = key
+	mgr.instanceCache = instance
+	mgr.instanceCacheLock.Unlock()
+	return instance, nil
 }
 
 func (mgr *placementManager) instanceFrom(placement placement.Placement) (placement.Instance, error) {
@@ -255,3 +1152,131 @@ func (mgr *placementManager) instanceFrom(placement placement.Placement) (placem
 	}
 	return instance, nil
 }
+
+// ownedShards returns the IDs of the shards owned by the instance in the given
+// placement, which is empty if the instance is not in the placement.
+func (mgr *placementManager) ownedShards(p placement.Placement) (map[uint32]struct{}, error) {
+	instance, err := mgr.instanceFrom(p)
+	if err == ErrInstanceNotFoundInPlacement {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	shards := instance.Shards().All()
+	owned := make(map[uint32]struct{}, len(shards))
+	for _, s := range shards {
+		if s.State() != shard.Leaving {
+			owned[s.ID()] = struct{}{}
+		}
+	}
+	return owned, nil
+}
+
+func allShardsInState(shards []shard.Shard, state shard.State) bool {
+	for _, s := range shards {
+		if s.State() != state {
+			return false
+		}
+	}
+	return true
+}
+
+// rebalanceInstance tracks the shards owned by an instance while planning a
+// rebalance.
+type rebalanceInstance struct {
+	id     string
+	group  string
+	shards map[uint32]struct{}
+}
+
+type rebalanceMove struct {
+	shardID  uint32
+	from, to *rebalanceInstance
+}
+
+// nextRebalanceMove returns the move of a shard from the most loaded instance
+// to the least loaded instance it can move to, for the pair of instances owning
+// more shards apart than the threshold with the largest difference. Instances
+// must own at least two shards more than their target, so that every move
+// strictly reduces the skew and recommendations always terminate. Targets are
+// ordered by their number of shards, then the load of their isolation group.
+func nextRebalanceMove(
+	instances []*rebalanceInstance,
+	groupLoads map[string]int,
+	groupOwners map[uint32]map[string]int,
+	threshold int,
+) (rebalanceMove, bool) {
+	sources := append([]*rebalanceInstance(nil), instances...)
+	sort.SliceStable(sources, func(i, j int) bool {
+		return len(sources[i].shards) > len(sources[j].shards)
+	})
+	targets := append([]*rebalanceInstance(nil), instances...)
+	sort.SliceStable(targets, func(i, j int) bool {
+		if len(targets[i].shards) != len(targets[j].shards) {
+			return len(targets[i].shards) < len(targets[j].shards)
+		}
+		return groupLoads[targets[i].group] < groupLoads[targets[j].group]
+	})
+	for _, from := range sources {
+		for _, to := range targets {
+			if diff := len(from.shards) - len(to.shards); diff <= threshold || diff < 2 {
+				break
+			}
+			if shardID, found := from.movableShard(to, groupOwners); found {
+				return rebalanceMove{shardID: shardID, from: from, to: to}, true
+			}
+		}
+	}
+	return rebalanceMove{}, false
+}
+
+// movableShard returns the lowest shard owned by the instance which can move to
+// the given instance without it owning the shard already, or the isolation
+// group of the given instance then owning more than one replica of the shard.
+func (i *rebalanceInstance) movableShard(
+	to *rebalanceInstance,
+	groupOwners map[uint32]map[string]int,
+) (uint32, bool) {
+	var (
+		shardID uint32
+		found   bool
+	)
+	for id := range i.shards {
+		if _, owned := to.shards[id]; owned {
+			continue
+		}
+		if to.group != i.group && groupOwners[id][to.group] > 0 {
+			continue
+		}
+		if !found || id < shardID {
+			shardID, found = id, true
+		}
+	}
+	return shardID, found
+}
+
+// isMarkedForRemoval returns true if the instance owns shards which are all
+// leaving.
+func isMarkedForRemoval(instance placement.Instance) bool {
+	shards := instance.Shards()
+	return shards.NumShards() > 0 &&
+		shards.NumShards() == shards.NumShardsForState(shard.Leaving)
+}
+
+// newRoutingTable returns the instances owning each shard in the given placement,
+// i.e. the instances the shard is available or initializing on. Instances the
+// shard is leaving are not routed to.
+func newRoutingTable(p placement.Placement) map[uint32][]placement.Instance {
+	table := make(map[uint32][]placement.Instance, p.NumShards())
+	// NB: instances are returned in ascending ID order.
+	for _, instance := range p.Instances() {
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				continue
+			}
+			table[s.ID()] = append(table[s.ID()], instance)
+		}
+	}
+	return table
+}
@@ -21,13 +21,18 @@
 package aggregator
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/instrument"
 )
 
 const (
-	defaultInstanceID = "localhost"
+	defaultInstanceID             = "localhost"
+	defaultPlacementCheckInterval = time.Second
+	defaultRebalanceSkewThreshold = 1
 )
 
 // PlacementManagerOptions provide a set of options for the placement manager.
@@ -55,21 +60,44 @@ type PlacementManagerOptions interface {
 
 	// StagedPlacementWatcher returns the staged placement watcher.
 	StagedPlacementWatcher() placement.StagedPlacementWatcher
+
+	// SetPlacementCheckInterval sets the interval to check the placement when
+	// waiting for it to change.
+	SetPlacementCheckInterval(value time.Duration) PlacementManagerOptions
+
+	// PlacementCheckInterval returns the interval to check the placement when
+	// waiting for it to change.
+	PlacementCheckInterval() time.Duration
+
+	// SetRebalanceSkewThreshold sets the largest difference in the number of shards
+	// owned by instances which is considered balanced when recommending rebalances.
+	SetRebalanceSkewThreshold(value int) PlacementManagerOptions
+
+	// RebalanceSkewThreshold returns the largest difference in the number of shards
+	// owned by instances which is considered balanced when recommending rebalances.
+	RebalanceSkewThreshold() int
+
+	// Validate validates the options.
+	Validate() error
 }
 
 type placementManagerOptions struct {
-	clockOpts        clock.Options
-	instrumentOpts   instrument.Options
-	instanceID       string
-	placementWatcher placement.StagedPlacementWatcher
+	clockOpts              clock.Options
+	instrumentOpts         instrument.Options
+	instanceID             string
+	placementWatcher       placement.StagedPlacementWatcher
+	placementCheckInterval time.Duration
+	rebalanceSkewThreshold int
 }
 
 // NewPlacementManagerOptions creates a new set of placement manager options.
 func NewPlacementManagerOptions() PlacementManagerOptions {
 	return &placementManagerOptions{
-		clockOpts:      clock.NewOptions(),
-		instrumentOpts: instrument.NewOptions(),
-		instanceID:     defaultInstanceID,
+		clockOpts:              clock.NewOptions(),
+		instrumentOpts:         instrument.NewOptions(),
+		instanceID:             defaultInstanceID,
+		placementCheckInterval: defaultPlacementCheckInterval,
+		rebalanceSkewThreshold: defaultRebalanceSkewThreshold,
 	}
 }
 
@@ -112,3 +140,30 @@ func (o *placementManagerOptions) SetStagedPlacementWatcher(value placement.Stag
 func (o *placementManagerOptions) StagedPlacementWatcher() placement.StagedPlacementWatcher {
 	return o.placementWatcher
 }
+
+func (o *placementManagerOptions) SetPlacementCheckInterval(value time.Duration) PlacementManagerOptions {
+	opts := *o
+	opts.placementCheckInterval = value
+	return &opts
+}
+
+func (o *placementManagerOptions) PlacementCheckInterval() time.Duration {
+	return o.placementCheckInterval
+}
+
+func (o *placementManagerOptions) SetRebalanceSkewThreshold(value int) PlacementManagerOptions {
+	opts := *o
+	opts.rebalanceSkewThreshold = value
+	return &opts
+}
+
+func (o *placementManagerOptions) RebalanceSkewThreshold() int {
+	return o.rebalanceSkewThreshold
+}
+
+func (o *placementManagerOptions) Validate() error {
+	if o.rebalanceSkewThreshold < 1 {
+		return fmt.Errorf("invalid rebalance skew threshold: %d", o.rebalanceSkewThreshold)
+	}
+	return nil
+}
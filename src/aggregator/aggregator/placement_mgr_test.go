@@ -21,14 +21,20 @@
 package aggregator
 
 import (
+	"bytes"
+	"context"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/cluster/kv"
 	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/x/instrument"
 
+	"github.com/gogo/protobuf/jsonpb"
 	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -158,6 +164,901 @@ func TestPlacementManagerInstanceFound(t *testing.T) {
 	}
 }
 
+func TestPlacementManagerInstanceCache(t *testing.T) {
+	mgr, store := testPlacementManager(t)
+	mgr.instanceID = testInstanceID1
+	scope := tally.NewTestScope("", nil)
+	mgr.metrics = newPlacementManagerMetrics(scope)
+	require.NoError(t, mgr.Open())
+
+	// Wait for change to propagate.
+	_, err := store.Set(testPlacementKey, testStagedPlacementProto)
+	require.NoError(t, err)
+	for {
+		if _, err := mgr.Instance(); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	instance, err := mgr.Instance()
+	require.NoError(t, err)
+	require.Equal(t, 2, instance.Shards().NumShards())
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["instance-cache-misses+"].Value())
+	require.Equal(t, int64(1), counters["instance-cache-hits+"].Value())
+
+	// A new placement version should invalidate the cached instance.
+	newProto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards:   4,
+				CutoverTime: 10000,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: &placementpb.Instance{
+						Id:       testInstanceID1,
+						Endpoint: testInstanceID1,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 0, State: placementpb.ShardState_INITIALIZING},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err = store.Set(testPlacementKey, newProto)
+	require.NoError(t, err)
+	for {
+		instance, err = mgr.Instance()
+		require.NoError(t, err)
+		if instance.Shards().NumShards() == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	counters = scope.Snapshot().Counters()
+	require.True(t, counters["instance-cache-misses+"].Value() >= 2)
+}
+
+func TestPlacementManagerRoutingTable(t *testing.T) {
+	mgr, store := testPlacementManager(t)
+	scope := tally.NewTestScope("", nil)
+	mgr.metrics = newPlacementManagerMetrics(scope)
+	_, err := mgr.RoutingTable()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	table, err := mgr.RoutingTable()
+	require.NoError(t, err)
+
+	routes := func(table map[uint32][]placement.Instance) map[uint32][]string {
+		res := make(map[uint32][]string, len(table))
+		for shardID, instances := range table {
+			for _, instance := range instances {
+				res[shardID] = append(res[shardID], instance.ID())
+			}
+		}
+		return res
+	}
+	expected := map[uint32][]string{
+		0: []string{testInstanceID1},
+		1: []string{testInstanceID1},
+		2: []string{testInstanceID2},
+		3: []string{testInstanceID2},
+	}
+	require.Equal(t, expected, routes(table))
+
+	// The table should not be rebuilt for the same placement version.
+	_, err = mgr.RoutingTable()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["routing-table-rebuilds+"].Value())
+
+	// A new placement version should rebuild the table.
+	newProto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards:   2,
+				CutoverTime: 10000,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: &placementpb.Instance{
+						Id:       testInstanceID1,
+						Endpoint: testInstanceID1,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 0, State: placementpb.ShardState_AVAILABLE},
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_AVAILABLE},
+						},
+					},
+					testInstanceID2: &placementpb.Instance{
+						Id:       testInstanceID2,
+						Endpoint: testInstanceID2,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_AVAILABLE},
+						},
+					},
+					testInstanceID3: &placementpb.Instance{
+						Id:       testInstanceID3,
+						Endpoint: testInstanceID3,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 0, State: placementpb.ShardState_LEAVING},
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_INITIALIZING},
+						},
+					},
+				},
+			},
+		},
+	}
+	_, err = store.Set(testPlacementKey, newProto)
+	require.NoError(t, err)
+	// Leaving replicas are not routed to.
+	expected = map[uint32][]string{
+		0: []string{testInstanceID1},
+		1: []string{testInstanceID1, testInstanceID2, testInstanceID3},
+	}
+	for {
+		table, err = mgr.RoutingTable()
+		require.NoError(t, err)
+		if len(table) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, expected, routes(table))
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["routing-table-rebuilds+"].Value())
+}
+
+func TestPlacementManagerUnassignedShards(t *testing.T) {
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards:   6,
+				CutoverTime: 0,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: &placementpb.Instance{
+						Id:       testInstanceID1,
+						Endpoint: testInstanceID1,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 0, State: placementpb.ShardState_AVAILABLE},
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_LEAVING},
+							&placementpb.Shard{Id: 2, State: placementpb.ShardState_LEAVING},
+						},
+					},
+					testInstanceID2: &placementpb.Instance{
+						Id:       testInstanceID2,
+						Endpoint: testInstanceID2,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_INITIALIZING},
+							&placementpb.Shard{Id: 3, State: placementpb.ShardState_AVAILABLE},
+						},
+					},
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	scope := tally.NewTestScope("", nil)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewPlacementManager(opts)
+	_, err := mgr.UnassignedShards()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	// Shard 2 is only leaving and shards 4 and 5 are not owned by any instance.
+	unassigned, err := mgr.UnassignedShards()
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2, 4, 5}, unassigned)
+	require.Equal(t, float64(3), scope.Snapshot().Gauges()["unassigned-shards+"].Value())
+}
+
+func TestPlacementManagerMovementCost(t *testing.T) {
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards:   4,
+				CutoverTime: 0,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: &placementpb.Instance{
+						Id:       testInstanceID1,
+						Endpoint: testInstanceID1,
+						Shards: []*placementpb.Shard{
+							&placementpb.Shard{Id: 0, State: placementpb.ShardState_AVAILABLE},
+							&placementpb.Shard{Id: 1, State: placementpb.ShardState_INITIALIZING},
+							&placementpb.Shard{Id: 2, State: placementpb.ShardState_LEAVING},
+						},
+					},
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher)
+	mgr := NewPlacementManager(opts)
+	_, _, err := mgr.MovementCost(placement.NewPlacement())
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	newCandidate := func(shards ...shard.Shard) placement.Placement {
+		instance := placement.NewInstance().
+			SetID(testInstanceID1).
+			SetShards(shard.NewShards(shards))
+		return placement.NewPlacement().SetInstances([]placement.Instance{instance})
+	}
+	inputs := []struct {
+		candidate placement.Placement
+		added     int
+		removed   int
+	}{
+		{
+			// Same owned shards, with the leaving shard gone.
+			candidate: newCandidate(
+				shard.NewShard(0).SetState(shard.Available),
+				shard.NewShard(1).SetState(shard.Available),
+			),
+		},
+		{
+			candidate: newCandidate(
+				shard.NewShard(0).SetState(shard.Available),
+				shard.NewShard(1).SetState(shard.Leaving),
+				shard.NewShard(2).SetState(shard.Initializing),
+				shard.NewShard(3).SetState(shard.Initializing),
+			),
+			added:   2,
+			removed: 1,
+		},
+		{
+			// Instance is not in the candidate placement.
+			candidate: placement.NewPlacement().SetInstances([]placement.Instance{
+				placement.NewInstance().SetID(testInstanceID2),
+			}),
+			removed: 2,
+		},
+	}
+	for _, input := range inputs {
+		added, removed, err := mgr.MovementCost(input.candidate)
+		require.NoError(t, err)
+		require.Equal(t, input.added, added)
+		require.Equal(t, input.removed, removed)
+	}
+}
+
+func TestPlacementManagerPreferredFailoverTarget(t *testing.T) {
+	newInstance := func(id, group string, shards ...*placementpb.Shard) *placementpb.Instance {
+		return &placementpb.Instance{
+			Id:             id,
+			IsolationGroup: group,
+			Endpoint:       id,
+			Shards:         shards,
+		}
+	}
+	available := func(id uint32) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: placementpb.ShardState_AVAILABLE}
+	}
+	initializing := func(id uint32) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: placementpb.ShardState_INITIALIZING}
+	}
+	local := newInstance(testInstanceID1, "g1", available(0), available(1), available(2))
+
+	inputs := []struct {
+		peers    []*placementpb.Instance
+		expected string
+	}{
+		{
+			// Peers in the same isolation group are never selected, and only
+			// available shards count towards the overlap.
+			peers: []*placementpb.Instance{
+				newInstance(testInstanceID2, "g1", available(0), available(1), available(2)),
+				newInstance(testInstanceID3, "g2", available(0), initializing(1), initializing(2)),
+				newInstance("testInstance4", "g3", available(1), available(2), available(3)),
+			},
+			expected: "testInstance4",
+		},
+		{
+			// Ties are broken by the lowest instance ID.
+			peers: []*placementpb.Instance{
+				newInstance("testInstance4", "g3", available(0), available(1)),
+				newInstance(testInstanceID3, "g2", available(1), available(2)),
+			},
+			expected: testInstanceID3,
+		},
+		{
+			peers: []*placementpb.Instance{
+				newInstance(testInstanceID2, "g1", available(0), available(1), available(2)),
+				newInstance(testInstanceID3, "g2", available(3), initializing(0)),
+			},
+		},
+	}
+	for _, input := range inputs {
+		instances := map[string]*placementpb.Instance{testInstanceID1: local}
+		for _, peer := range input.peers {
+			instances[peer.Id] = peer
+		}
+		proto := &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 4,
+					Instances: instances,
+				},
+			},
+		}
+		watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+		opts := NewPlacementManagerOptions().
+			SetInstanceID(testInstanceID1).
+			SetStagedPlacementWatcher(watcher)
+		mgr := NewPlacementManager(opts)
+		_, err := mgr.PreferredFailoverTarget()
+		require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+		require.NoError(t, mgr.Open())
+
+		target, err := mgr.PreferredFailoverTarget()
+		if input.expected == "" {
+			require.Equal(t, ErrNoFailoverTarget, err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, input.expected, target.ID())
+		}
+		require.NoError(t, mgr.Close())
+	}
+}
+
+func TestPlacementManagerDetectIsolationViolations(t *testing.T) {
+	newInstance := func(id, group string, shards ...*placementpb.Shard) *placementpb.Instance {
+		return &placementpb.Instance{
+			Id:             id,
+			IsolationGroup: group,
+			Endpoint:       id,
+			Shards:         shards,
+		}
+	}
+	newShard := func(id uint32, state placementpb.ShardState) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: state}
+	}
+	// NB: shard 0 is owned twice in g1 and shard 2 three times in g2, while the
+	// leaving copy of shard 1 in g1 is not a violation.
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards: 3,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: newInstance(testInstanceID1, "g1",
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_LEAVING)),
+					testInstanceID2: newInstance(testInstanceID2, "g1",
+						newShard(0, placementpb.ShardState_INITIALIZING),
+						newShard(1, placementpb.ShardState_INITIALIZING)),
+					testInstanceID3: newInstance(testInstanceID3, "g2",
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE)),
+					"testInstance4": newInstance("testInstance4", "g2",
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE)),
+					"testInstance5": newInstance("testInstance5", "g2",
+						newShard(2, placementpb.ShardState_INITIALIZING)),
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher)
+	mgr := NewPlacementManager(opts)
+	_, err := mgr.DetectIsolationViolations()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	violations, err := mgr.DetectIsolationViolations()
+	require.NoError(t, err)
+	require.Equal(t, []ShardViolation{
+		{
+			ShardID:        0,
+			IsolationGroup: "g1",
+			InstanceIDs:    []string{testInstanceID1, testInstanceID2},
+		},
+		{
+			ShardID:        2,
+			IsolationGroup: "g2",
+			InstanceIDs:    []string{testInstanceID3, "testInstance4", "testInstance5"},
+		},
+	}, violations)
+}
+
+func TestPlacementManagerDetectIsolationViolationsValidPlacement(t *testing.T) {
+	mgr, _ := testPlacementManager(t)
+	require.NoError(t, mgr.Open())
+
+	violations, err := mgr.DetectIsolationViolations()
+	require.NoError(t, err)
+	require.Empty(t, violations)
+}
+
+func TestPlacementManagerShardDistribution(t *testing.T) {
+	newInstance := func(id, group string, shards ...*placementpb.Shard) *placementpb.Instance {
+		return &placementpb.Instance{
+			Id:             id,
+			IsolationGroup: group,
+			Endpoint:       id,
+			Shards:         shards,
+		}
+	}
+	newShard := func(id uint32, state placementpb.ShardState) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: state}
+	}
+	// NB: shard 3 is moving from g1 to g3 and is only counted for g3.
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards: 4,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: newInstance(testInstanceID1, "g1",
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(3, placementpb.ShardState_LEAVING)),
+					testInstanceID2: newInstance(testInstanceID2, "g1",
+						newShard(2, placementpb.ShardState_AVAILABLE)),
+					testInstanceID3: newInstance(testInstanceID3, "g2",
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE),
+						newShard(3, placementpb.ShardState_AVAILABLE)),
+					"testInstance4": newInstance("testInstance4", "g3",
+						newShard(3, placementpb.ShardState_INITIALIZING)),
+					"testInstance5": newInstance("testInstance5", "g4"),
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher)
+	mgr := NewPlacementManager(opts)
+	_, err := mgr.ShardDistribution()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	distribution, err := mgr.ShardDistribution()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"g1": 3, "g2": 4, "g3": 1, "g4": 0}, distribution)
+}
+
+func TestPlacementManagerQuorumForShard(t *testing.T) {
+	newInstance := func(id string, shards ...*placementpb.Shard) *placementpb.Instance {
+		return &placementpb.Instance{Id: id, Endpoint: id, Shards: shards}
+	}
+	newShard := func(id uint32, state placementpb.ShardState) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: state}
+	}
+	// NB: shard 0 has three replicas, shard 1 two replicas besides the leaving
+	// one, shard 2 a single replica, and shard 3 only a leaving one.
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards: 4,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: newInstance(testInstanceID1,
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_LEAVING),
+						newShard(3, placementpb.ShardState_LEAVING)),
+					testInstanceID2: newInstance(testInstanceID2,
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE)),
+					testInstanceID3: newInstance(testInstanceID3,
+						newShard(0, placementpb.ShardState_INITIALIZING),
+						newShard(1, placementpb.ShardState_INITIALIZING)),
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher)
+	mgr := NewPlacementManager(opts)
+	_, err := mgr.QuorumForShard(0)
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	for shardID, expected := range map[uint32]int{0: 2, 1: 2, 2: 1} {
+		quorum, err := mgr.QuorumForShard(shardID)
+		require.NoError(t, err)
+		require.Equal(t, expected, quorum, "shard %d", shardID)
+	}
+	for _, shardID := range []uint32{3, 4} {
+		_, err := mgr.QuorumForShard(shardID)
+		require.Equal(t, ErrShardNotFoundInPlacement, err)
+	}
+}
+
+func TestPlacementManagerRebalanceRecommendation(t *testing.T) {
+	newInstance := func(id, group string, shards ...*placementpb.Shard) *placementpb.Instance {
+		return &placementpb.Instance{
+			Id:             id,
+			IsolationGroup: group,
+			Endpoint:       id,
+			Shards:         shards,
+		}
+	}
+	newShards := func(state placementpb.ShardState, ids ...uint32) []*placementpb.Shard {
+		shards := make([]*placementpb.Shard, 0, len(ids))
+		for _, id := range ids {
+			shards = append(shards, &placementpb.Shard{Id: id, State: state})
+		}
+		return shards
+	}
+	available := func(ids ...uint32) []*placementpb.Shard {
+		return newShards(placementpb.ShardState_AVAILABLE, ids...)
+	}
+
+	inputs := []struct {
+		instances []*placementpb.Instance
+		expected  []ShardMove
+	}{
+		{
+			// Shards move onto the least loaded instances, ignoring leaving shards
+			// and instances marked for removal.
+			instances: []*placementpb.Instance{
+				newInstance(testInstanceID1, "g1",
+					append(available(0, 1, 2, 3, 4, 5),
+						newShards(placementpb.ShardState_LEAVING, 7)...)...),
+				newInstance(testInstanceID2, "g2",
+					append(available(6),
+						newShards(placementpb.ShardState_INITIALIZING, 8)...)...),
+				newInstance(testInstanceID3, "g3"),
+				newInstance("testInstance4", "g4",
+					newShards(placementpb.ShardState_LEAVING, 8)...),
+			},
+			expected: []ShardMove{
+				{ShardID: 0, FromInstanceID: testInstanceID1, ToInstanceID: testInstanceID3},
+				{ShardID: 1, FromInstanceID: testInstanceID1, ToInstanceID: testInstanceID3},
+				{ShardID: 2, FromInstanceID: testInstanceID1, ToInstanceID: testInstanceID2},
+			},
+		},
+		{
+			// Shards never move into an isolation group owning a replica already.
+			instances: []*placementpb.Instance{
+				newInstance(testInstanceID1, "g1", available(0, 1, 2, 3)...),
+				newInstance(testInstanceID2, "g1"),
+				newInstance(testInstanceID3, "g2", available(0, 1, 2, 3)...),
+			},
+			expected: []ShardMove{
+				{ShardID: 0, FromInstanceID: testInstanceID1, ToInstanceID: testInstanceID2},
+				{ShardID: 1, FromInstanceID: testInstanceID1, ToInstanceID: testInstanceID2},
+			},
+		},
+		{
+			// Skews within the threshold are balanced.
+			instances: []*placementpb.Instance{
+				newInstance(testInstanceID1, "g1", available(0, 1)...),
+				newInstance(testInstanceID2, "g2", available(2)...),
+				newInstance(testInstanceID3, "g3", available(3, 4)...),
+			},
+		},
+	}
+	for _, input := range inputs {
+		instances := make(map[string]*placementpb.Instance, len(input.instances))
+		for _, instance := range input.instances {
+			instances[instance.Id] = instance
+		}
+		proto := &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 9,
+					Instances: instances,
+				},
+			},
+		}
+		watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+		opts := NewPlacementManagerOptions().
+			SetInstanceID(testInstanceID1).
+			SetStagedPlacementWatcher(watcher)
+		mgr := NewPlacementManager(opts)
+		_, err := mgr.RebalanceRecommendation()
+		require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+		require.NoError(t, mgr.Open())
+
+		plan, err := mgr.RebalanceRecommendation()
+		require.NoError(t, err)
+		require.Equal(t, input.expected, plan.Moves)
+		require.NoError(t, mgr.Close())
+	}
+}
+
+func TestPlacementManagerRebalanceSkewThresholdZero(t *testing.T) {
+	opts := NewPlacementManagerOptions().SetRebalanceSkewThreshold(0)
+	require.Error(t, opts.Validate())
+	require.Error(t, NewPlacementManager(opts).Open())
+	require.NoError(t, opts.SetRebalanceSkewThreshold(1).Validate())
+
+	// A skew of one shard can't be reduced by moving a shard, so no move is
+	// recommended even without a threshold.
+	instances := []*rebalanceInstance{
+		{id: testInstanceID1, group: "g1", shards: map[uint32]struct{}{0: {}, 1: {}}},
+		{id: testInstanceID2, group: "g2", shards: map[uint32]struct{}{2: {}}},
+	}
+	groupLoads := map[string]int{"g1": 2, "g2": 1}
+	groupOwners := map[uint32]map[string]int{
+		0: {"g1": 1},
+		1: {"g1": 1},
+		2: {"g2": 1},
+	}
+	_, found := nextRebalanceMove(instances, groupLoads, groupOwners, 0)
+	require.False(t, found)
+}
+
+func TestPlacementManagerPreferredOwner(t *testing.T) {
+	newInstance := func(
+		id, zone string,
+		weight uint32,
+		shards ...*placementpb.Shard,
+	) *placementpb.Instance {
+		return &placementpb.Instance{Id: id, Endpoint: id, Zone: zone, Weight: weight, Shards: shards}
+	}
+	newShard := func(id uint32, state placementpb.ShardState) *placementpb.Shard {
+		return &placementpb.Shard{Id: id, State: state}
+	}
+	// NB: the current instance is testInstanceID1 in zone z1, shard 3 is only
+	// initializing and shard 4 is only owned outside of zone z1.
+	proto := &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			&placementpb.Placement{
+				NumShards: 6,
+				Instances: map[string]*placementpb.Instance{
+					testInstanceID1: newInstance(testInstanceID1, "z1", 100,
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_LEAVING)),
+					testInstanceID2: newInstance(testInstanceID2, "z1", 50,
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE),
+						newShard(5, placementpb.ShardState_AVAILABLE)),
+					testInstanceID3: newInstance(testInstanceID3, "z2", 10,
+						newShard(0, placementpb.ShardState_AVAILABLE),
+						newShard(1, placementpb.ShardState_AVAILABLE),
+						newShard(2, placementpb.ShardState_AVAILABLE),
+						newShard(4, placementpb.ShardState_AVAILABLE)),
+					"testInstance4": newInstance("testInstance4", "z1", 50,
+						newShard(3, placementpb.ShardState_INITIALIZING),
+						newShard(5, placementpb.ShardState_AVAILABLE)),
+				},
+			},
+		},
+	}
+	watcher, _ := testPlacementWatcherWithPlacementProto(t, testPlacementKey, proto)
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher)
+	mgr := NewPlacementManager(opts)
+	_, err := mgr.PreferredOwner(0, SameZoneRoutingPreference)
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	inputs := []struct {
+		shardID    uint32
+		preference RoutingPreference
+		expected   string
+	}{
+		{shardID: 0, preference: SameZoneRoutingPreference, expected: testInstanceID1},
+		{shardID: 2, preference: SameZoneRoutingPreference, expected: testInstanceID2},
+		{shardID: 4, preference: SameZoneRoutingPreference, expected: testInstanceID3},
+		{shardID: 1, preference: LowestWeightRoutingPreference, expected: testInstanceID3},
+		{shardID: 5, preference: LowestWeightRoutingPreference, expected: testInstanceID2},
+		{shardID: 1, preference: LocalFirstRoutingPreference, expected: testInstanceID1},
+		{shardID: 2, preference: LocalFirstRoutingPreference, expected: testInstanceID2},
+		{shardID: 4, preference: LocalFirstRoutingPreference, expected: testInstanceID3},
+	}
+	for _, input := range inputs {
+		owner, err := mgr.PreferredOwner(input.shardID, input.preference)
+		require.NoError(t, err)
+		require.Equal(t, input.expected, owner.ID(),
+			"shard %d, preference %d", input.shardID, input.preference)
+	}
+
+	for _, shardID := range []uint32{3, 6} {
+		_, err := mgr.PreferredOwner(shardID, SameZoneRoutingPreference)
+		require.Equal(t, ErrShardNotFoundInPlacement, err)
+	}
+	_, err = mgr.PreferredOwner(0, RoutingPreference(-1))
+	require.Equal(t, errUnknownRoutingPreference, err)
+	require.NoError(t, mgr.Close())
+}
+
+func TestPlacementManagerVerifyRedistribution(t *testing.T) {
+	newInstance := func(id string, shards ...shard.Shard) placement.Instance {
+		return placement.NewInstance().
+			SetID(id).
+			SetEndpoint(id).
+			SetShards(shard.NewShards(shards))
+	}
+	available := func(id uint32) shard.Shard {
+		return shard.NewShard(id).SetState(shard.Available)
+	}
+	newPlacement := func(instances ...placement.Instance) placement.Placement {
+		return placement.NewPlacement().
+			SetInstances(instances).
+			SetShards([]uint32{0, 1, 2, 3}).
+			SetReplicaFactor(2)
+	}
+	// NB: testInstance4 owns shards 2 and 3 along with the other instances, and
+	// is removed after handing shard 1 off for good.
+	before := newPlacement(
+		newInstance(testInstanceID1, available(0), available(1)),
+		newInstance(testInstanceID2, available(0), available(2)),
+		newInstance(testInstanceID3, available(3)),
+		newInstance("testInstance4",
+			shard.NewShard(1).SetState(shard.Leaving), available(2), available(3)),
+	)
+	mgr, _ := testPlacementManager(t)
+
+	// Shards of the removed instance are initializing or available elsewhere.
+	after := newPlacement(
+		newInstance(testInstanceID1, available(0), available(1), available(2)),
+		newInstance(testInstanceID2, available(0), available(2), available(3)),
+		newInstance(testInstanceID3, available(1),
+			shard.NewShard(3).SetState(shard.Initializing)),
+	)
+	require.NoError(t, mgr.VerifyRedistribution("testInstance4", before, after))
+
+	// Shard 2 is left with a single replica while the removed instance still owns
+	// shard 3, which leaves it with none elsewhere once the instance is gone.
+	after = newPlacement(
+		newInstance(testInstanceID1, available(0), available(1)),
+		newInstance(testInstanceID2, available(0), available(2)),
+		newInstance(testInstanceID3, available(1)),
+		newInstance("testInstance4", available(3)),
+	)
+	err := mgr.VerifyRedistribution("testInstance4", before, after)
+	require.Equal(t, &ShardLeakError{
+		RemovedInstanceID: "testInstance4",
+		Leaks: []ShardLeak{
+			{ShardID: 2, Replicas: 1, Expected: 2},
+			{ShardID: 3, Replicas: 0, Expected: 2},
+		},
+	}, err)
+	require.Equal(t, "shards of removed instance testInstance4 not redistributed: "+
+		"shard 2 owned by 1 of 2 replicas, shard 3 owned by 0 of 2 replicas", err.Error())
+
+	// Shards are verified even if the removed instance is already marked for
+	// removal in the placement before.
+	leaving := func(id uint32) shard.Shard {
+		return shard.NewShard(id).SetState(shard.Leaving)
+	}
+	before = newPlacement(
+		newInstance(testInstanceID1, available(0), available(1)),
+		newInstance(testInstanceID2, available(0), available(2)),
+		newInstance(testInstanceID3, available(1), available(3)),
+		newInstance("testInstance4", leaving(2), leaving(3)),
+	)
+	after = newPlacement(
+		newInstance(testInstanceID1, available(0), available(1), available(2)),
+		newInstance(testInstanceID2, available(0), available(2)),
+		newInstance(testInstanceID3, available(1), available(3)),
+	)
+	err = mgr.VerifyRedistribution("testInstance4", before, after)
+	require.Equal(t, &ShardLeakError{
+		RemovedInstanceID: "testInstance4",
+		Leaks:             []ShardLeak{{ShardID: 3, Replicas: 1, Expected: 2}},
+	}, err)
+
+	err = mgr.VerifyRedistribution("unknown", before, after)
+	require.Equal(t, ErrInstanceNotFoundInPlacement, err)
+}
+
+func TestPlacementManagerWatchReplacementComplete(t *testing.T) {
+	newProto := func(instances ...*placementpb.Instance) *placementpb.PlacementSnapshots {
+		instancesByID := make(map[string]*placementpb.Instance, len(instances))
+		for _, instance := range instances {
+			instancesByID[instance.Id] = instance
+		}
+		return &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 2,
+					Instances: instancesByID,
+				},
+			},
+		}
+	}
+	newInstance := func(id string, state placementpb.ShardState) *placementpb.Instance {
+		return &placementpb.Instance{
+			Id:       id,
+			Endpoint: id,
+			Shards: []*placementpb.Shard{
+				&placementpb.Shard{Id: 0, State: state, CutoverNanos: 1000, CutoffNanos: 1000},
+				&placementpb.Shard{Id: 1, State: state, CutoverNanos: 1000, CutoffNanos: 1000},
+			},
+		}
+	}
+	waitForReplaced := func(mgr *placementManager, expected bool) {
+		for {
+			replaced, err := mgr.HasReplacementInstance()
+			require.NoError(t, err)
+			if replaced == expected {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	requireNoNotification := func(completeCh <-chan struct{}) {
+		select {
+		case <-completeCh:
+			require.FailNow(t, "unexpected replacement complete notification")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	requireNotification := func(completeCh <-chan struct{}) {
+		select {
+		case <-completeCh:
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "timed out waiting for replacement complete notification")
+		}
+	}
+
+	scope := tally.NewTestScope("", nil)
+	watcher, store := testPlacementWatcherWithPlacementProto(t, testPlacementKey,
+		newProto(newInstance(testInstanceID1, placementpb.ShardState_AVAILABLE)))
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher).
+		SetPlacementCheckInterval(10 * time.Millisecond).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewPlacementManager(opts).(*placementManager)
+	_, _, err := mgr.WatchReplacementComplete()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+	waitForReplaced(mgr, false)
+
+	completeCh, closeFn, err := mgr.WatchReplacementComplete()
+	require.NoError(t, err)
+	requireNoNotification(completeCh)
+
+	// The replacement starting is not notified.
+	_, err = store.Set(testPlacementKey, newProto(
+		newInstance(testInstanceID1, placementpb.ShardState_LEAVING),
+		newInstance(testInstanceID2, placementpb.ShardState_INITIALIZING),
+	))
+	require.NoError(t, err)
+	waitForReplaced(mgr, true)
+	requireNoNotification(completeCh)
+
+	// The replacing instance taking over is notified exactly once.
+	_, err = store.Set(testPlacementKey, newProto(
+		newInstance(testInstanceID1, placementpb.ShardState_LEAVING),
+		newInstance(testInstanceID2, placementpb.ShardState_AVAILABLE),
+	))
+	require.NoError(t, err)
+	requireNotification(completeCh)
+	waitForReplaced(mgr, false)
+	requireNoNotification(completeCh)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["replacements-completed+"].Value())
+
+	// The replaced instance being removed in the same update that completes
+	// the replacement is notified as well.
+	_, err = store.Set(testPlacementKey, newProto(
+		newInstance(testInstanceID1, placementpb.ShardState_LEAVING),
+		newInstance(testInstanceID2, placementpb.ShardState_INITIALIZING),
+	))
+	require.NoError(t, err)
+	waitForReplaced(mgr, true)
+	requireNoNotification(completeCh)
+	_, err = store.Set(testPlacementKey, newProto(
+		newInstance(testInstanceID2, placementpb.ShardState_AVAILABLE),
+	))
+	require.NoError(t, err)
+	requireNotification(completeCh)
+	requireNoNotification(completeCh)
+	require.Equal(t, int64(2), scope.Snapshot().Counters()["replacements-completed+"].Value())
+
+	closeFn()
+	for range completeCh {
+	}
+	require.NoError(t, mgr.Close())
+}
+
 func TestPlacementHasReplacementInstance(t *testing.T) {
 	protos := []*placementpb.PlacementSnapshots{
 		&placementpb.PlacementSnapshots{
@@ -320,6 +1221,215 @@ func TestPlacementManagerShards(t *testing.T) {
 	}
 }
 
+func TestPlacementManagerWaitForShardState(t *testing.T) {
+	newProto := func(state placementpb.ShardState) *placementpb.PlacementSnapshots {
+		return &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 2,
+					Instances: map[string]*placementpb.Instance{
+						testInstanceID1: &placementpb.Instance{
+							Id:       testInstanceID1,
+							Endpoint: testInstanceID1,
+							Shards: []*placementpb.Shard{
+								&placementpb.Shard{Id: 0, State: placementpb.ShardState_AVAILABLE},
+								&placementpb.Shard{Id: 1, State: state},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	mgr, store := testPlacementManager(t)
+	mgr.instanceID = testInstanceID1
+	mgr.placementCheckInterval = 10 * time.Millisecond
+	require.NoError(t, mgr.Open())
+	_, err := store.Set(testPlacementKey, newProto(placementpb.ShardState_INITIALIZING))
+	require.NoError(t, err)
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- mgr.WaitForShardState(context.Background(), shard.Available)
+	}()
+
+	// The wait should not complete while there are initializing shards.
+	select {
+	case err := <-doneCh:
+		require.FailNow(t, "unexpected wait completion", "err: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = store.Set(testPlacementKey, newProto(placementpb.ShardState_AVAILABLE))
+	require.NoError(t, err)
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for shards to become available")
+	}
+}
+
+func TestPlacementManagerWaitForShardStateContextDone(t *testing.T) {
+	mgr, _ := testPlacementManager(t)
+	mgr.instanceID = testInstanceID1
+	mgr.placementCheckInterval = 10 * time.Millisecond
+	require.NoError(t, mgr.Open())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.Equal(t, context.DeadlineExceeded, mgr.WaitForShardState(ctx, shard.Available))
+}
+
+func TestPlacementManagerWaitForShardStateNotOpen(t *testing.T) {
+	mgr, _ := testPlacementManager(t)
+	err := mgr.WaitForShardState(context.Background(), shard.Available)
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+}
+
+func TestPlacementManagerWaitForCutover(t *testing.T) {
+	newProto := func(state placementpb.ShardState) *placementpb.PlacementSnapshots {
+		return &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 3,
+					Instances: map[string]*placementpb.Instance{
+						testInstanceID1: &placementpb.Instance{
+							Id:       testInstanceID1,
+							Endpoint: testInstanceID1,
+							Shards: []*placementpb.Shard{
+								&placementpb.Shard{Id: 0, State: placementpb.ShardState_AVAILABLE},
+								&placementpb.Shard{Id: 1, State: placementpb.ShardState_LEAVING},
+								&placementpb.Shard{Id: 2, State: state},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	mgr, store := testPlacementManager(t)
+	mgr.instanceID = testInstanceID1
+	mgr.placementCheckInterval = 10 * time.Millisecond
+	require.NoError(t, mgr.Open())
+	_, err := store.Set(testPlacementKey, newProto(placementpb.ShardState_INITIALIZING))
+	require.NoError(t, err)
+
+	doneCh := make(chan error, 1)
+	go func() {
+		doneCh <- mgr.WaitForCutover(context.Background())
+	}()
+
+	// The wait should not complete while there are initializing shards.
+	select {
+	case err := <-doneCh:
+		require.FailNow(t, "unexpected wait completion", "err: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Leaving shards do not hold up the cutover.
+	_, err = store.Set(testPlacementKey, newProto(placementpb.ShardState_AVAILABLE))
+	require.NoError(t, err)
+	select {
+	case err := <-doneCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for cutover")
+	}
+
+	require.NoError(t, mgr.Close())
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, mgr.WaitForCutover(context.Background()))
+}
+
+func TestPlacementManagerPendingStages(t *testing.T) {
+	var (
+		now      = time.Now()
+		cutover1 = now.Add(time.Hour).UnixNano()
+		cutover2 = now.Add(2 * time.Hour).UnixNano()
+	)
+	newStage := func(cutoverNanos int64, instanceIDs ...string) *placementpb.Placement {
+		instances := make(map[string]*placementpb.Instance, len(instanceIDs))
+		for _, id := range instanceIDs {
+			instances[id] = &placementpb.Instance{Id: id, Endpoint: id}
+		}
+		return &placementpb.Placement{
+			NumShards:   4,
+			CutoverTime: cutoverNanos,
+			Instances:   instances,
+		}
+	}
+
+	mgr, store := testPlacementManager(t)
+	_, err := mgr.PendingStages()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	// All stages of the initial staged placement have taken effect.
+	stages, err := mgr.PendingStages()
+	require.NoError(t, err)
+	require.Empty(t, stages)
+
+	_, err = store.Set(testPlacementKey, &placementpb.PlacementSnapshots{
+		Snapshots: []*placementpb.Placement{
+			newStage(0, testInstanceID1),
+			newStage(cutover1, testInstanceID1, testInstanceID2),
+			newStage(cutover2, testInstanceID2),
+		},
+	})
+	require.NoError(t, err)
+	for {
+		stages, err = mgr.PendingStages()
+		require.NoError(t, err)
+		if len(stages) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Equal(t, 2, len(stages))
+	expected := []struct {
+		cutoverNanos int64
+		instanceIDs  []string
+	}{
+		{cutoverNanos: cutover1, instanceIDs: []string{testInstanceID1, testInstanceID2}},
+		{cutoverNanos: cutover2, instanceIDs: []string{testInstanceID2}},
+	}
+	for i, stage := range stages {
+		require.Equal(t, expected[i].cutoverNanos, stage.CutoverNanos)
+		require.Equal(t, expected[i].cutoverNanos, stage.Placement.CutoverNanos())
+		var instanceIDs []string
+		for _, instance := range stage.Placement.Instances() {
+			instanceIDs = append(instanceIDs, instance.ID())
+		}
+		require.Equal(t, expected[i].instanceIDs, instanceIDs)
+	}
+}
+
+func TestPlacementManagerExportJSON(t *testing.T) {
+	mgr, _ := testPlacementManager(t)
+	_, err := mgr.ExportJSON()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+
+	data, err := mgr.ExportJSON()
+	require.NoError(t, err)
+	var pb placementpb.Placement
+	require.NoError(t, jsonpb.Unmarshal(bytes.NewReader(data), &pb))
+	exported, err := placement.NewPlacementFromProto(&pb)
+	require.NoError(t, err)
+
+	_, active, err := mgr.Placement()
+	require.NoError(t, err)
+	activeProto, err := active.Proto()
+	require.NoError(t, err)
+	exportedProto, err := exported.Proto()
+	require.NoError(t, err)
+	require.Equal(t, activeProto, exportedProto)
+	require.Equal(t, int64(10000), exported.CutoverNanos())
+}
+
 func TestPlacementClose(t *testing.T) {
 	mgr, _ := testPlacementManager(t)
 	require.NoError(t, mgr.Open())
@@ -327,7 +1437,7 @@ func TestPlacementClose(t *testing.T) {
 	require.Equal(t, placementManagerClosed, mgr.state)
 }
 
-func testPlacementManager(t *testing.T) (*placementManager, kv.Store) {
+func testPlacementManager(t testing.TB) (*placementManager, kv.Store) {
 	watcher, store := testPlacementWatcherWithPlacementProto(t, testPlacementKey, testStagedPlacementProto)
 	opts := NewPlacementManagerOptions().
 		SetInstanceID(testInstanceID).
@@ -335,3 +1445,179 @@ func testPlacementManager(t *testing.T) (*placementManager, kv.Store) {
 	placementManager := NewPlacementManager(opts).(*placementManager)
 	return placementManager, store
 }
+
+func TestPlacementManagerWatchInstanceWeight(t *testing.T) {
+	newProto := func(weight1, weight2 uint32, state placementpb.ShardState) *placementpb.PlacementSnapshots {
+		return &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 2,
+					Instances: map[string]*placementpb.Instance{
+						testInstanceID1: &placementpb.Instance{
+							Id:       testInstanceID1,
+							Endpoint: testInstanceID1,
+							Weight:   weight1,
+							Shards: []*placementpb.Shard{
+								&placementpb.Shard{Id: 0, State: state},
+							},
+						},
+						testInstanceID2: &placementpb.Instance{
+							Id:       testInstanceID2,
+							Endpoint: testInstanceID2,
+							Weight:   weight2,
+							Shards: []*placementpb.Shard{
+								&placementpb.Shard{Id: 1, State: placementpb.ShardState_AVAILABLE},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	waitForWeight := func(mgr *placementManager, instanceID string, weight uint32) {
+		for {
+			_, p, err := mgr.Placement()
+			require.NoError(t, err)
+			if instance, found := p.Instance(instanceID); found && instance.Weight() == weight {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	requireNoWeight := func(weightCh <-chan uint32) {
+		select {
+		case weight := <-weightCh:
+			require.FailNow(t, "unexpected weight notification", "weight: %d", weight)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	scope := tally.NewTestScope("", nil)
+	watcher, store := testPlacementWatcherWithPlacementProto(t, testPlacementKey,
+		newProto(1, 1, placementpb.ShardState_INITIALIZING))
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher).
+		SetPlacementCheckInterval(10 * time.Millisecond).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewPlacementManager(opts).(*placementManager)
+	_, _, err := mgr.WatchInstanceWeight()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+	waitForWeight(mgr, testInstanceID1, 1)
+
+	weightCh, closeFn, err := mgr.WatchInstanceWeight()
+	require.NoError(t, err)
+	requireNoWeight(weightCh)
+
+	// Changes unrelated to the weight of the instance are not notified.
+	_, err = store.Set(testPlacementKey, newProto(1, 5, placementpb.ShardState_AVAILABLE))
+	require.NoError(t, err)
+	waitForWeight(mgr, testInstanceID2, 5)
+	requireNoWeight(weightCh)
+
+	// A weight change is notified exactly once.
+	_, err = store.Set(testPlacementKey, newProto(3, 5, placementpb.ShardState_AVAILABLE))
+	require.NoError(t, err)
+	select {
+	case weight := <-weightCh:
+		require.Equal(t, uint32(3), weight)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for weight notification")
+	}
+	requireNoWeight(weightCh)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["instance-weight-changes+"].Value())
+
+	closeFn()
+	for range weightCh {
+	}
+	require.NoError(t, mgr.Close())
+}
+
+func TestPlacementManagerMarkedForRemoval(t *testing.T) {
+	newProto := func(states ...placementpb.ShardState) *placementpb.PlacementSnapshots {
+		shards := make([]*placementpb.Shard, 0, len(states))
+		for i, state := range states {
+			shards = append(shards, &placementpb.Shard{Id: uint32(i), State: state})
+		}
+		return &placementpb.PlacementSnapshots{
+			Snapshots: []*placementpb.Placement{
+				&placementpb.Placement{
+					NumShards: 2,
+					Instances: map[string]*placementpb.Instance{
+						testInstanceID1: &placementpb.Instance{
+							Id:       testInstanceID1,
+							Endpoint: testInstanceID1,
+							Shards:   shards,
+						},
+					},
+				},
+			},
+		}
+	}
+	waitForMarked := func(mgr *placementManager, expected bool) {
+		for {
+			marked, err := mgr.IsMarkedForRemoval()
+			require.NoError(t, err)
+			if marked == expected {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	requireNoNotification := func(markedCh <-chan struct{}) {
+		select {
+		case <-markedCh:
+			require.FailNow(t, "unexpected marked for removal notification")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	scope := tally.NewTestScope("", nil)
+	watcher, store := testPlacementWatcherWithPlacementProto(t, testPlacementKey,
+		newProto(placementpb.ShardState_AVAILABLE, placementpb.ShardState_AVAILABLE))
+	opts := NewPlacementManagerOptions().
+		SetInstanceID(testInstanceID1).
+		SetStagedPlacementWatcher(watcher).
+		SetPlacementCheckInterval(10 * time.Millisecond).
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope))
+	mgr := NewPlacementManager(opts).(*placementManager)
+	_, err := mgr.IsMarkedForRemoval()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	_, _, err = mgr.WatchMarkedForRemoval()
+	require.Equal(t, errPlacementManagerNotOpenOrClosed, err)
+	require.NoError(t, mgr.Open())
+	waitForMarked(mgr, false)
+
+	markedCh, closeFn, err := mgr.WatchMarkedForRemoval()
+	require.NoError(t, err)
+	requireNoNotification(markedCh)
+
+	// Some of the shards leaving is not a removal.
+	_, err = store.Set(testPlacementKey,
+		newProto(placementpb.ShardState_LEAVING, placementpb.ShardState_AVAILABLE))
+	require.NoError(t, err)
+	requireNoNotification(markedCh)
+	marked, err := mgr.IsMarkedForRemoval()
+	require.NoError(t, err)
+	require.False(t, marked)
+
+	// All of the shards leaving is notified exactly once.
+	_, err = store.Set(testPlacementKey,
+		newProto(placementpb.ShardState_LEAVING, placementpb.ShardState_LEAVING))
+	require.NoError(t, err)
+	select {
+	case <-markedCh:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for marked for removal notification")
+	}
+	waitForMarked(mgr, true)
+	requireNoNotification(markedCh)
+	require.Equal(t, int64(1), scope.Snapshot().Counters()["marked-for-removal+"].Value())
+
+	// The manager being closed closes the channel.
+	require.NoError(t, mgr.Close())
+	for range markedCh {
+	}
+	closeFn()
+}
@@ -1134,6 +1134,21 @@ func (mr *MockActiveStagedPlacementMockRecorder) ActivePlacement() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivePlacement", reflect.TypeOf((*MockActiveStagedPlacement)(nil).ActivePlacement))
 }
 
+// PendingPlacements mocks base method
+func (m *MockActiveStagedPlacement) PendingPlacements() (Placements, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingPlacements")
+	ret0, _ := ret[0].(Placements)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingPlacements indicates an expected call of PendingPlacements
+func (mr *MockActiveStagedPlacementMockRecorder) PendingPlacements() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingPlacements", reflect.TypeOf((*MockActiveStagedPlacement)(nil).PendingPlacements))
+}
+
 // Version mocks base method
 func (m *MockActiveStagedPlacement) Version() int {
 	m.ctrl.T.Helper()
@@ -84,6 +84,18 @@ func (p *activeStagedPlacement) ActivePlacement() (Placement, DoneFn, error) {
 	return placement, p.doneFn, nil
 }
 
+func (p *activeStagedPlacement) PendingPlacements() (Placements, error) {
+	p.RLock()
+	defer p.RUnlock()
+
+	if p.closed {
+		return nil, errActiveStagedPlacementClosed
+	}
+	idx := p.placements.ActiveIndex(p.nowFn().UnixNano())
+	pending := make(Placements, 0, len(p.placements)-idx-1)
+	return append(pending, p.placements[idx+1:]...), nil
+}
+
 func (p *activeStagedPlacement) Close() error {
 	p.Lock()
 	defer p.Unlock()
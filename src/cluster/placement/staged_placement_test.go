@@ -356,6 +356,34 @@ func TestActiveStagedPlacementActivePlacementFoundWithExpiry(t *testing.T) {
 	require.Equal(t, testActivePlacements[0].Instances(), removedInstances[0])
 }
 
+func TestActiveStagedPlacementPendingPlacements(t *testing.T) {
+	inputs := []struct {
+		timeNanos int64
+		expected  Placements
+	}{
+		{timeNanos: 0, expected: Placements{testActivePlacements[0], testActivePlacements[1]}},
+		{timeNanos: 12345, expected: Placements{testActivePlacements[1]}},
+		{timeNanos: 99999, expected: Placements{}},
+	}
+	for _, input := range inputs {
+		p := &activeStagedPlacement{
+			placements: append([]Placement{}, testActivePlacements...),
+			nowFn:      func() time.Time { return time.Unix(0, input.timeNanos) },
+		}
+		pending, err := p.PendingPlacements()
+		require.NoError(t, err)
+		require.Equal(t, input.expected, pending)
+	}
+
+	p := &activeStagedPlacement{
+		placements: append([]Placement{}, testActivePlacements...),
+		nowFn:      time.Now,
+		closed:     true,
+	}
+	_, err := p.PendingPlacements()
+	require.Equal(t, errActiveStagedPlacementClosed, err)
+}
+
 func TestActiveStagedPlacementCloseAlreadyClosed(t *testing.T) {
 	p := &activeStagedPlacement{
 		placements: append([]Placement{}, testActivePlacements...),
@@ -168,6 +168,8 @@ func (mp *mockPlacement) ActivePlacement() (Placement, DoneFn, error) {
 	return nil, func() {}, nil
 }
 
+func (mp *mockPlacement) PendingPlacements() (Placements, error) { return nil, nil }
+
 func (mp *mockPlacement) Close() error { return mp.closeFn() }
 
 func (mp *mockPlacement) Version() int { return 0 }
@@ -271,6 +271,10 @@ type ActiveStagedPlacement interface {
 	// function when the caller is done using the placement, and any errors encountered.
 	ActivePlacement() (Placement, DoneFn, error)
 
+	// PendingPlacements returns the placements which have yet to take effect,
+	// in ascending cutover order.
+	PendingPlacements() (Placements, error)
+
 	// Version returns the version of the underlying staged placement.
 	Version() int
 
@@ -183,6 +183,20 @@ func (c *client) leader() (string, error) {
 	return ld, err
 }
 
+func (c *client) leaderRevision() (int64, error) {
+	if c.isClosed() {
+		return 0, errClientClosed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.opts.LeaderTimeout())
+	defer cancel()
+	rev, err := c.client.LeaderRevision(ctx)
+	if err == concurrency.ErrElectionNoLeader {
+		return rev, ErrNoLeader
+	}
+	return rev, err
+}
+
 func (c *client) observe() (<-chan string, error) {
 	if c.isClosed() {
 		return nil, errClientClosed
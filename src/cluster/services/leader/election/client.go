@@ -180,6 +180,23 @@ func (c *Client) Leader(ctx context.Context) (string, error) {
 	return string(resp.Kvs[0].Value), nil
 }
 
+// LeaderRevision returns the mod revision of the key of the currently elected
+// leader of the election, which increases whenever leadership changes hands.
+func (c *Client) LeaderRevision(ctx context.Context) (int64, error) {
+	if c.isClosed() {
+		return 0, ErrClientClosed
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resp, err := c.election.Leader(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return resp.Kvs[0].ModRevision, nil
+}
+
 // Observe returns a channel which receives that value of the latest leader for
 // the election. The channel is closed when the context is cancelled.
 func (c *Client) Observe(ctx context.Context) (<-chan string, error) {
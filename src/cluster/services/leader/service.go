@@ -193,6 +193,22 @@ func (s *multiClient) Leader(electionID string) (string, error) {
 	return client.leader()
 }
 
+// LeaderRevision returns the etcd mod revision of the leader key of the given
+// election, which is not part of the services.LeaderService interface as it is
+// specific to etcd.
+func (s *multiClient) LeaderRevision(electionID string) (int64, error) {
+	if s.isClosed() {
+		return 0, errClientClosed
+	}
+
+	client, err := s.getOrCreateClient(electionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return client.leaderRevision()
+}
+
 func (s *multiClient) Observe(electionID string) (<-chan string, error) {
 	if s.isClosed() {
 		return nil, errClientClosed
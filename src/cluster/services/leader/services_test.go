@@ -103,6 +103,35 @@ func TestService_Leader(t *testing.T) {
 	assert.Equal(t, "foo1", ld)
 }
 
+func TestService_LeaderRevision(t *testing.T) {
+	tc := newTestCluster(t)
+	defer tc.close()
+
+	svc := tc.service().(*multiClient)
+
+	_, err := svc.LeaderRevision("e")
+	assert.Equal(t, ErrNoLeader, err)
+
+	sc, err := svc.Campaign("e", overrideOpts(t, "foo1"))
+	assert.NoError(t, err)
+	assert.NoError(t, waitForStates(sc, true, followerS, leaderS))
+
+	rev1, err := svc.LeaderRevision("e")
+	assert.NoError(t, err)
+	assert.True(t, rev1 > 0)
+
+	// The revision advances once leadership changes hands.
+	assert.NoError(t, svc.Resign("e"))
+	assert.NoError(t, waitForStates(sc, false, followerS))
+	sc, err = svc.Campaign("e", overrideOpts(t, "foo2"))
+	assert.NoError(t, err)
+	assert.NoError(t, waitForStates(sc, true, followerS, leaderS))
+
+	rev2, err := svc.LeaderRevision("e")
+	assert.NoError(t, err)
+	assert.True(t, rev2 > rev1)
+}
+
 func TestService_Observe(t *testing.T) {
 	tc := newTestCluster(t)
 	defer tc.close()
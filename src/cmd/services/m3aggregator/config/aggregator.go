@@ -349,7 +349,8 @@ func (c *AggregatorConfiguration) NewAggregatorOptions(
 
 	// Set flush times manager.
 	iOpts = instrumentOpts.SetMetricsScope(scope.SubScope("flush-times-manager"))
-	flushTimesManager, err := c.FlushTimesManager.NewFlushTimesManager(client, iOpts)
+	flushTimesManager, err := c.FlushTimesManager.NewFlushTimesManager(client,
+		placementManager, iOpts)
 	if err != nil {
 		return nil, err
 	}
@@ -646,6 +647,7 @@ type flushTimesManagerConfiguration struct {
 
 func (c flushTimesManagerConfiguration) NewFlushTimesManager(
 	client client.Client,
+	placementManager aggregator.PlacementManager,
 	instrumentOpts instrument.Options,
 ) (aggregator.FlushTimesManager, error) {
 	kvOpts, err := c.KVConfig.NewOverrideOptions()
@@ -662,7 +664,8 @@ func (c flushTimesManagerConfiguration) NewFlushTimesManager(
 		SetInstrumentOptions(instrumentOpts).
 		SetFlushTimesKeyFmt(c.FlushTimesKeyFmt).
 		SetFlushTimesStore(store).
-		SetFlushTimesPersistRetrier(retrier)
+		SetFlushTimesPersistRetrier(retrier).
+		SetPlacementManager(placementManager)
 	return aggregator.NewFlushTimesManager(flushTimesManagerOpts), nil
 }
 
@@ -671,11 +674,13 @@ type electionManagerConfiguration struct {
 	ServiceID                  serviceIDConfiguration `yaml:"serviceID"`
 	LeaderValue                string                 `yaml:"leaderValue"`
 	ElectionKeyFmt             string                 `yaml:"electionKeyFmt" validate:"nonzero"`
+	ElectionKeyPrefix          string                 `yaml:"electionKeyPrefix"`
 	CampaignRetrier            retry.Configuration    `yaml:"campaignRetrier"`
 	ChangeRetrier              retry.Configuration    `yaml:"changeRetrier"`
 	ResignRetrier              retry.Configuration    `yaml:"resignRetrier"`
 	CampaignStateCheckInterval time.Duration          `yaml:"campaignStateCheckInterval"`
 	ShardCutoffCheckOffset     time.Duration          `yaml:"shardCutoffCheckOffset"`
+	MinLeadershipHold          time.Duration          `yaml:"minLeadershipHold"`
 }
 
 func (c electionManagerConfiguration) NewElectionManager(
@@ -722,6 +727,7 @@ func (c electionManagerConfiguration) NewElectionManager(
 		SetChangeRetryOptions(changeRetryOpts).
 		SetResignRetryOptions(resignRetryOpts).
 		SetElectionKeyFmt(c.ElectionKeyFmt).
+		SetElectionKeyPrefix(c.ElectionKeyPrefix).
 		SetLeaderService(leaderService).
 		SetPlacementManager(placementManager).
 		SetFlushTimesManager(flushTimesManager)
@@ -731,6 +737,12 @@ func (c electionManagerConfiguration) NewElectionManager(
 	if c.ShardCutoffCheckOffset != 0 {
 		opts = opts.SetShardCutoffCheckOffset(c.ShardCutoffCheckOffset)
 	}
+	if c.MinLeadershipHold != 0 {
+		opts = opts.SetMinLeadershipHold(c.MinLeadershipHold)
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
 	electionManager := aggregator.NewElectionManager(opts)
 	return electionManager, nil
 }
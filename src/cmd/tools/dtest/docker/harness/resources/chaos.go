@@ -0,0 +1,296 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	dc "github.com/ory/dockertest/docker"
+	"go.uber.org/zap"
+)
+
+// chaosFaultKind is a kind of fault injected by a chaos scenario.
+type chaosFaultKind int
+
+const (
+	// pauseFault suspends the container, as a hung process would be.
+	pauseFault chaosFaultKind = iota
+	// disconnectFault disconnects the container from the test network.
+	disconnectFault
+	// killRestartFault SIGKILLs the container and waits for it to recover.
+	killRestartFault
+	// clockSkewFault skews the clock seen by the container.
+	clockSkewFault
+)
+
+var allChaosFaultKinds = []chaosFaultKind{
+	pauseFault,
+	disconnectFault,
+	killRestartFault,
+	clockSkewFault,
+}
+
+func (k chaosFaultKind) String() string {
+	switch k {
+	case pauseFault:
+		return "pause"
+	case disconnectFault:
+		return "disconnect"
+	case killRestartFault:
+		return "kill-restart"
+	case clockSkewFault:
+		return "clock-skew"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// chaosFault is a fault injected into one of the containers of a chaos
+// scenario, identified by its index.
+type chaosFault struct {
+	kind        chaosFaultKind
+	instance    int
+	clockOffset time.Duration
+}
+
+func (f chaosFault) String() string {
+	if f.kind == clockSkewFault {
+		return fmt.Sprintf("%v %d %v", f.kind, f.instance, f.clockOffset)
+	}
+
+	return fmt.Sprintf("%v %d", f.kind, f.instance)
+}
+
+// chaosOptions configure a chaos scenario.
+type chaosOptions struct {
+	// seed seeds the selection of faults and containers, so that a scenario
+	// can be reproduced.
+	seed int64
+	// duration is the time faults are injected for.
+	duration time.Duration
+	// faultInterval is the time between healing a fault and injecting the next.
+	faultInterval time.Duration
+	// faultDuration is the time pause, disconnect and clock skew faults last
+	// before they are healed.
+	faultDuration time.Duration
+	// faults are the kinds of faults to inject, defaulting to all of them.
+	faults []chaosFaultKind
+	// maxClockSkew bounds the clock offset of clock skew faults, which is drawn
+	// uniformly in both directions.
+	maxClockSkew time.Duration
+	// recoveryTimeout bounds the time taken by a killed container to recover.
+	recoveryTimeout time.Duration
+	// healthy, if set, returns nil once a killed container has recovered.
+	healthy func(c *dockerResource) error
+	// invariant returns an error if the system under test misbehaves, and is
+	// checked continuously while faults are injected.
+	invariant func() error
+}
+
+// chaosPlan draws the faults of a chaos scenario from a seeded source, so that
+// the same seed always yields the same sequence of faults.
+type chaosPlan struct {
+	rng          *rand.Rand
+	numInstances int
+	faults       []chaosFaultKind
+	maxClockSkew time.Duration
+}
+
+func newChaosPlan(
+	seed int64,
+	numInstances int,
+	faults []chaosFaultKind,
+	maxClockSkew time.Duration,
+) *chaosPlan {
+	if len(faults) == 0 {
+		faults = allChaosFaultKinds
+	}
+
+	return &chaosPlan{
+		rng:          rand.New(rand.NewSource(seed)),
+		numInstances: numInstances,
+		faults:       faults,
+		maxClockSkew: maxClockSkew,
+	}
+}
+
+func (p *chaosPlan) next() chaosFault {
+	f := chaosFault{
+		kind:     p.faults[p.rng.Intn(len(p.faults))],
+		instance: p.rng.Intn(p.numInstances),
+	}
+
+	if f.kind == clockSkewFault {
+		// NB: libfaketime offsets are applied in seconds.
+		maxSecs := int64(p.maxClockSkew / time.Second)
+		f.clockOffset = time.Duration(p.rng.Int63n(2*maxSecs+1)-maxSecs) * time.Second
+	}
+
+	return f
+}
+
+// runChaos injects randomly selected faults into randomly selected instances
+// one at a time for the configured duration, while checking the invariant
+// continuously. The faults injected are returned, and are reproducible from the
+// seed. An error is returned if a fault could not be injected or healed, or if
+// the invariant is violated.
+func runChaos(instances []*dockerResource, opts chaosOptions) ([]chaosFault, error) {
+	if opts.healthy == nil {
+		opts.healthy = func(*dockerResource) error { return nil }
+	}
+
+	var (
+		plan = newChaosPlan(opts.seed, len(instances), opts.faults,
+			opts.maxClockSkew)
+		invariantErrCh = make(chan error, 1)
+		doneCh         = make(chan struct{})
+		wg             sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		checkInvariant(opts.invariant, doneCh, invariantErrCh)
+	}()
+
+	defer func() {
+		close(doneCh)
+		wg.Wait()
+	}()
+
+	var (
+		faults []chaosFault
+		end    = time.Now().Add(opts.duration)
+	)
+
+	for time.Now().Before(end) {
+		f := plan.next()
+		c := instances[f.instance]
+		c.logger.Info("injecting fault", zapMethod("runChaos"),
+			zap.Stringer("fault", f), zap.Int64("seed", opts.seed))
+		if err := c.injectFault(f, opts); err != nil {
+			return faults, fmt.Errorf("could not inject fault %v: %v", f, err)
+		}
+
+		faults = append(faults, f)
+		select {
+		case err := <-invariantErrCh:
+			return faults, fmt.Errorf("invariant violated after fault %v: %v", f, err)
+		case <-time.After(opts.faultInterval):
+		}
+	}
+
+	select {
+	case err := <-invariantErrCh:
+		return faults, fmt.Errorf("invariant violated: %v", err)
+	default:
+		return faults, nil
+	}
+}
+
+// checkInvariant polls the invariant until done, sending the first violation.
+func checkInvariant(invariant func() error, doneCh <-chan struct{}, errCh chan<- error) {
+	for {
+		if err := invariant(); err != nil {
+			errCh <- err
+			return
+		}
+
+		select {
+		case <-doneCh:
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// injectFault injects the given fault into the container and heals it, either
+// once the fault duration has elapsed or once a killed container recovered.
+func (c *dockerResource) injectFault(f chaosFault, opts chaosOptions) error {
+	switch f.kind {
+	case pauseFault:
+		if err := c.pause(); err != nil {
+			return err
+		}
+
+		time.Sleep(opts.faultDuration)
+		return c.unpause()
+	case disconnectFault:
+		if err := c.disconnect(); err != nil {
+			return err
+		}
+
+		time.Sleep(opts.faultDuration)
+		return c.reconnect()
+	case killRestartFault:
+		_, err := c.killAndRecover(opts.recoveryTimeout, func() error {
+			return opts.healthy(c)
+		})
+		return err
+	case clockSkewFault:
+		if err := c.setClockOffset(f.clockOffset); err != nil {
+			return err
+		}
+
+		time.Sleep(opts.faultDuration)
+		return c.setClockOffset(0)
+	default:
+		return fmt.Errorf("unknown fault kind %v", f.kind)
+	}
+}
+
+// disconnect disconnects the container from the test network, partitioning it
+// from the other containers while it keeps running.
+func (c *dockerResource) disconnect() error {
+	if c.closed {
+		return errClosed
+	}
+
+	if err := c.pool.Client.DisconnectNetwork(c.networkName, dc.NetworkConnectionOptions{
+		Container: c.resource.Container.ID,
+	}); err != nil {
+		c.logger.Error("could not disconnect container",
+			zapMethod("disconnect"), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// reconnect reconnects the container disconnected by disconnect to the test
+// network, restoring its network aliases.
+func (c *dockerResource) reconnect() error {
+	if c.closed {
+		return errClosed
+	}
+
+	if err := c.pool.Client.ConnectNetwork(c.networkName, newNetworkConnectionOptions(
+		c.resource.Container.ID, c.networkAliases)); err != nil {
+		c.logger.Error("could not reconnect container",
+			zapMethod("reconnect"), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
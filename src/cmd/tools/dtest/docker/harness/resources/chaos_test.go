@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChaosPlanDeterministic(t *testing.T) {
+	expected := []chaosFault{
+		{kind: disconnectFault, instance: 2},
+		{kind: pauseFault, instance: 0},
+		{kind: clockSkewFault, instance: 1, clockOffset: 5 * time.Second},
+		{kind: pauseFault, instance: 2},
+		{kind: clockSkewFault, instance: 1, clockOffset: -time.Second},
+		{kind: clockSkewFault, instance: 1, clockOffset: -2 * time.Second},
+	}
+
+	for i := 0; i < 2; i++ {
+		plan := newChaosPlan(42, 3, nil, 5*time.Second)
+		for _, f := range expected {
+			assert.Equal(t, f, plan.next())
+		}
+	}
+}
+
+func TestRunChaos(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	var instances []*dockerResource
+	for _, name := range []string{"dbnode01", "dbnode02"} {
+		resource, err := newDockerResource(docker.pool(t), testResourceOptions(name))
+		require.NoError(t, err)
+		instances = append(instances, resource)
+	}
+
+	var checks int
+	opts := chaosOptions{
+		seed:            7,
+		duration:        300 * time.Millisecond,
+		faultInterval:   10 * time.Millisecond,
+		faultDuration:   10 * time.Millisecond,
+		faults:          []chaosFaultKind{pauseFault, disconnectFault, killRestartFault},
+		recoveryTimeout: 5 * time.Second,
+		invariant: func() error {
+			checks++
+			return nil
+		},
+	}
+
+	faults, err := runChaos(instances, opts)
+	require.NoError(t, err)
+	require.NotEmpty(t, faults)
+	assert.True(t, checks > 0)
+
+	// The faults injected follow the plan for the seed, and are applied to the
+	// selected containers.
+	plan := newChaosPlan(opts.seed, len(instances), opts.faults, 0)
+	expectedActions := []string{"start dbnode01", "start dbnode02"}
+	for _, f := range faults {
+		require.Equal(t, plan.next(), f)
+		name := []string{"dbnode01", "dbnode02"}[f.instance]
+		switch f.kind {
+		case pauseFault:
+			expectedActions = append(expectedActions, "pause "+name, "unpause "+name)
+		case killRestartFault:
+			expectedActions = append(expectedActions, "kill "+name, "start "+name)
+		}
+	}
+	assert.Equal(t, expectedActions, docker.recordedActions())
+
+	for _, resource := range instances {
+		require.NoError(t, resource.close())
+	}
+}
+
+func TestRunChaosInvariantViolated(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	_, err = runChaos([]*dockerResource{resource}, chaosOptions{
+		seed:          1,
+		duration:      5 * time.Second,
+		faultInterval: time.Second,
+		faultDuration: 10 * time.Millisecond,
+		faults:        []chaosFaultKind{pauseFault},
+		invariant: func() error {
+			return errors.New("split brain")
+		},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invariant violated after fault pause 0: split brain")
+	require.NoError(t, resource.close())
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// faketimeFile is the libfaketime timestamp file read by processes in
+	// clock-skew capable containers.
+	faketimeFile = "/etc/faketimerc"
+
+	// clockOffsetTolerance is the allowed difference between the requested and
+	// observed container clock, accounting for exec round trips.
+	clockOffsetTolerance = 2 * time.Second
+)
+
+// setClockOffset skews the clock seen by processes in the container by the
+// given offset, relative to the host clock, and verifies the skew took
+// effect.
+//
+// This relies on libfaketime, so the container image must:
+//   - preload libfaketime for every process, e.g. via LD_PRELOAD or
+//     /etc/ld.so.preload;
+//   - set FAKETIME_TIMESTAMP_FILE=/etc/faketimerc and FAKETIME_NO_CACHE=1 so
+//     that changes to the offset are picked up by running processes;
+//   - leave FAKETIME unset, since it takes precedence over the file.
+//
+// An error is returned if the container clock does not reflect the offset,
+// which usually means the image does not meet these requirements.
+func (c *dockerResource) setClockOffset(d time.Duration) error {
+	if c.closed {
+		return errClosed
+	}
+
+	logger := c.logger.With(zapMethod("setClockOffset"),
+		zap.Duration("offset", d))
+
+	offset := faketimeOffset(d)
+	if _, err := c.exec("sh", "-c",
+		fmt.Sprintf("echo '%s' > %s", offset, faketimeFile)); err != nil {
+		logger.Error("could not write faketime offset", zap.Error(err))
+		return err
+	}
+
+	output, err := c.exec("date", "+%s")
+	if err != nil {
+		logger.Error("could not read container clock", zap.Error(err))
+		return err
+	}
+
+	secs, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		logger.Error("could not parse container clock", zap.Error(err))
+		return err
+	}
+
+	var (
+		observed = time.Unix(secs, 0)
+		expected = time.Now().Add(d)
+		skew     = observed.Sub(expected)
+	)
+
+	if skew < -clockOffsetTolerance || skew > clockOffsetTolerance {
+		err := fmt.Errorf("container clock %v does not reflect offset %v, "+
+			"expected %v: is libfaketime preloaded in the image?",
+			observed, d, expected)
+		logger.Error("clock offset not applied", zap.Error(err))
+		return err
+	}
+
+	logger.Info("clock offset applied")
+	return nil
+}
+
+// faketimeOffset formats the duration as a libfaketime relative offset in
+// seconds, e.g. "+5" or "-1.5".
+func faketimeOffset(d time.Duration) string {
+	offset := strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	if d >= 0 {
+		offset = "+" + offset
+	}
+
+	return offset
+}
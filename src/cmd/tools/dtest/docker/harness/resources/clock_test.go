@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// faketimeExec simulates a container with libfaketime preloaded, applying the
+// offset written to the timestamp file to the output of date.
+func faketimeExec() func(c *fakeContainer, cmd []string) (string, string) {
+	var (
+		mu     sync.Mutex
+		offset time.Duration
+	)
+
+	return func(c *fakeContainer, cmd []string) (string, string) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case len(cmd) == 3 && cmd[0] == "sh" && strings.HasSuffix(cmd[2], faketimeFile):
+			value := strings.Fields(cmd[2])[1]
+			secs, err := strconv.ParseFloat(strings.Trim(value, "'"), 64)
+			if err != nil {
+				return "", err.Error()
+			}
+
+			offset = time.Duration(secs * float64(time.Second))
+			return "", ""
+		case len(cmd) == 2 && cmd[0] == "date":
+			now := time.Now().Add(offset).Unix()
+			return strconv.FormatInt(now, 10) + "\n", ""
+		}
+
+		return "", "unknown command"
+	}
+}
+
+func TestFaketimeOffset(t *testing.T) {
+	assert.Equal(t, "+0", faketimeOffset(0))
+	assert.Equal(t, "+5", faketimeOffset(5*time.Second))
+	assert.Equal(t, "-1.5", faketimeOffset(-1500*time.Millisecond))
+	assert.Equal(t, "+3600", faketimeOffset(time.Hour))
+}
+
+func TestSetClockOffset(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	docker.execFn = faketimeExec()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	require.NoError(t, resource.setClockOffset(time.Hour))
+	require.NoError(t, resource.setClockOffset(-10*time.Minute))
+	assert.Equal(t, []string{
+		"start dbnode01",
+		"exec dbnode01 sh -c echo '+3600' > /etc/faketimerc",
+		"exec dbnode01 date +%s",
+		"exec dbnode01 sh -c echo '-600' > /etc/faketimerc",
+		"exec dbnode01 date +%s",
+	}, docker.recordedActions())
+
+	require.NoError(t, resource.close())
+}
+
+func TestSetClockOffsetNotApplied(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	// NB: simulate an image without libfaketime, where the clock is unchanged.
+	docker.execFn = func(c *fakeContainer, cmd []string) (string, string) {
+		if cmd[0] == "date" {
+			return strconv.FormatInt(time.Now().Unix(), 10), ""
+		}
+
+		return "", ""
+	}
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	err = resource.setClockOffset(time.Hour)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "libfaketime")
+
+	require.NoError(t, resource.close())
+}
@@ -24,9 +24,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
+	"text/template"
+	"time"
 
 	"github.com/m3db/m3/src/x/instrument"
 
@@ -34,15 +38,22 @@ import (
 	"github.com/gogo/protobuf/proto"
 	dockertest "github.com/ory/dockertest"
 	dc "github.com/ory/dockertest/docker"
+	"github.com/ory/dockertest/docker/types/mount"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var (
-	networkName = "d-test"
-	volumeName  = "d-test"
+const (
+	pollInterval = 100 * time.Millisecond
 
-	errClosed = errors.New("container has been closed")
+	defaultNetworkName = "d-test"
+	defaultVolumeName  = "d-test"
+)
+
+var (
+	errClosed      = errors.New("container has been closed")
+	errStopTimeout = errors.New("container did not stop before timeout")
+	errNoDataDir   = errors.New("container has no data directory")
 )
 
 func zapMethod(s string) zapcore.Field { return zap.String("method", s) }
@@ -51,11 +62,30 @@ type dockerResourceOptions struct {
 	overrideDefaults bool
 	source           string
 	containerName    string
+	hostname         string
+	networkAliases   []string
+	networkName      string
+	volumeName       string
 	image            dockerImage
 	dockerFile       string
+	dockerFileVars   map[string]string
+	configFile       string
+	newConfig        func() interface{}
 	portList         []int
 	mounts           []string
-	iOpts            instrument.Options
+	dataDir          string
+	dataDirSource    string
+	labels           map[string]string
+	env              []string
+	healthBackoff    healthBackoff
+	logOutput        io.Writer
+	// memoryBytes is the memory limit of the container in bytes, unlimited if
+	// zero.
+	memoryBytes int64
+	// cpuShares is the relative CPU weight of the container, where the docker
+	// default is 1024, unset if zero.
+	cpuShares int64
+	iOpts     instrument.Options
 }
 
 // NB: this will fill unset fields with given default values.
@@ -73,6 +103,14 @@ func (o dockerResourceOptions) withDefaults(
 		o.containerName = defaultOpts.containerName
 	}
 
+	if len(o.hostname) == 0 {
+		o.hostname = defaultOpts.hostname
+	}
+
+	if len(o.networkAliases) == 0 {
+		o.networkAliases = defaultOpts.networkAliases
+	}
+
 	if o.image == (dockerImage{}) {
 		o.image = defaultOpts.image
 	}
@@ -81,6 +119,18 @@ func (o dockerResourceOptions) withDefaults(
 		o.dockerFile = defaultOpts.dockerFile
 	}
 
+	if len(o.dockerFileVars) == 0 {
+		o.dockerFileVars = defaultOpts.dockerFileVars
+	}
+
+	if len(o.configFile) == 0 {
+		o.configFile = defaultOpts.configFile
+	}
+
+	if o.newConfig == nil {
+		o.newConfig = defaultOpts.newConfig
+	}
+
 	if len(o.portList) == 0 {
 		o.portList = defaultOpts.portList
 	}
@@ -89,6 +139,38 @@ func (o dockerResourceOptions) withDefaults(
 		o.mounts = defaultOpts.mounts
 	}
 
+	if len(o.dataDir) == 0 {
+		o.dataDir = defaultOpts.dataDir
+	}
+
+	if len(o.dataDirSource) == 0 {
+		o.dataDirSource = defaultOpts.dataDirSource
+	}
+
+	if len(o.labels) == 0 {
+		o.labels = defaultOpts.labels
+	}
+
+	if len(o.env) == 0 {
+		o.env = defaultOpts.env
+	}
+
+	if o.healthBackoff == (healthBackoff{}) {
+		o.healthBackoff = defaultOpts.healthBackoff
+	}
+
+	if o.logOutput == nil {
+		o.logOutput = defaultOpts.logOutput
+	}
+
+	if o.memoryBytes == 0 {
+		o.memoryBytes = defaultOpts.memoryBytes
+	}
+
+	if o.cpuShares == 0 {
+		o.cpuShares = defaultOpts.cpuShares
+	}
+
 	if o.iOpts == nil {
 		o.iOpts = defaultOpts.iOpts
 	}
@@ -96,20 +178,75 @@ func (o dockerResourceOptions) withDefaults(
 	return o
 }
 
-func newOptions(name string) *dockertest.RunOptions {
+func newOptions(resourceOpts dockerResourceOptions) *dockertest.RunOptions {
 	return &dockertest.RunOptions{
-		Name:      name,
-		NetworkID: networkName,
+		Name:      resourceOpts.containerName,
+		Hostname:  resourceOpts.hostname,
+		NetworkID: networkNameOrDefault(resourceOpts.networkName),
+		Labels:    resourceOpts.labels,
+		Env:       resourceOpts.env,
 	}
 }
 
+func newNetworkConnectionOptions(
+	containerID string,
+	aliases []string,
+) dc.NetworkConnectionOptions {
+	return dc.NetworkConnectionOptions{
+		Container: containerID,
+		EndpointConfig: &dc.EndpointConfig{
+			Aliases: aliases,
+		},
+	}
+}
+
+// NB: containers are attached to the network on creation without any aliases,
+// so they need to be reconnected for the aliases to take effect.
+func connectNetworkWithAliases(
+	pool *dockertest.Pool,
+	networkName string,
+	containerID string,
+	aliases []string,
+) error {
+	if err := pool.Client.DisconnectNetwork(networkName, dc.NetworkConnectionOptions{
+		Container: containerID,
+	}); err != nil {
+		return err
+	}
+
+	return pool.Client.ConnectNetwork(networkName,
+		newNetworkConnectionOptions(containerID, aliases))
+}
+
 func useImage(opts *dockertest.RunOptions, image dockerImage) *dockertest.RunOptions {
 	opts.Repository = image.name
 	opts.Tag = image.tag
 	return opts
 }
 
-func setupNetwork(pool *dockertest.Pool) error {
+// networkNameOrDefault returns the given network name, or the default network
+// name if none is given.
+func networkNameOrDefault(name string) string {
+	if name == "" {
+		return defaultNetworkName
+	}
+
+	return name
+}
+
+// volumeNameOrDefault returns the given volume name, or the default volume name
+// if none is given.
+func volumeNameOrDefault(name string) string {
+	if name == "" {
+		return defaultVolumeName
+	}
+
+	return name
+}
+
+// setupNetwork creates the network with the given name, replacing any existing
+// network of the same name.
+func setupNetwork(pool *dockertest.Pool, networkName string) error {
 	networks, err := pool.Client.ListNetworks()
 	if err != nil {
 		return err
@@ -129,7 +266,9 @@ func setupNetwork(pool *dockertest.Pool) error {
 	return err
 }
 
-func setupVolume(pool *dockertest.Pool) error {
+// setupVolume creates the volume with the given name, replacing any existing
+// volume of the same name.
+func setupVolume(pool *dockertest.Pool, volumeName string) error {
 	volumes, err := pool.Client.ListVolumes(dc.ListVolumesOptions{})
 	if err != nil {
 		return err
@@ -176,11 +315,82 @@ func exposePorts(
 	return opts
 }
 
+// waitUntil polls the given function until it succeeds, returning the last
+// error encountered if it does not succeed before the deadline.
+func waitUntil(deadline time.Time, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			return fmt.Errorf("timed out: %v", err)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// newDataDirMount creates a fresh directory for the given container of the
+// given volume in the given source directory, or in the system temporary directory if no source is
+// given, and returns a mount binding it to the given path in the container,
+// isolating persisted data between runs. The directory should be removed by the
+// caller once the container is closed.
+func newDataDirMount(
+	volumeName string,
+	containerName string,
+	source string,
+	target string,
+) (dc.HostMount, error) {
+	dir, err := ioutil.TempDir(source, fmt.Sprintf("%s-%s-", volumeName, containerName))
+	if err != nil {
+		return dc.HostMount{}, err
+	}
+
+	return dc.HostMount{
+		Source: dir,
+		Target: target,
+		Type:   string(mount.TypeBind),
+	}, nil
+}
+
 func getDockerfile(file string) string {
 	src, _ := os.Getwd()
 	return fmt.Sprintf("%s/%s", src, file)
 }
 
+// renderDockerfile renders the Dockerfile template at the given path with the
+// given variables, returning the path of the rendered Dockerfile. The rendered
+// file is written next to the template so that the build context is unchanged,
+// and should be removed by the caller once the image has been built.
+func renderDockerfile(templatePath string, vars map[string]string) (string, error) {
+	tmpl, err := template.New(filepath.Base(templatePath)).
+		Option("missingkey=error").
+		ParseFiles(templatePath)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(templatePath), "Dockerfile-rendered-*")
+	if err != nil {
+		return "", err
+	}
+
+	if err := tmpl.Execute(f, vars); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
 func toResponse(
 	resp *http.Response,
 	response proto.Message,
@@ -0,0 +1,331 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOptionsHostname(t *testing.T) {
+	opts := newOptions(dockerResourceOptions{
+		containerName: "dbnode01",
+		hostname:      "m3db_local",
+	})
+
+	assert.Equal(t, "dbnode01", opts.Name)
+	assert.Equal(t, "m3db_local", opts.Hostname)
+	assert.Equal(t, defaultNetworkName, opts.NetworkID)
+}
+
+func TestNewDockerResourceEnv(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	env := []string{"M3DB_HOST_ID=m3db_local", "M3_FEATURE=enabled"}
+	opts := testResourceOptions("dbnode01")
+	opts.env = env
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, env, c.config.Env)
+	require.NoError(t, resource.close())
+}
+
+func TestNewDockerResourceResourceLimits(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	opts := testResourceOptions("dbnode01")
+	opts.memoryBytes = 512 << 20
+	opts.cpuShares = 512
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, int64(512<<20), c.hostConfig.Memory)
+	assert.Equal(t, int64(512), c.hostConfig.CPUShares)
+	assert.Equal(t, defaultNetworkName, c.hostConfig.NetworkMode)
+	require.NoError(t, resource.close())
+
+	resource, err = newDockerResource(docker.pool(t), testResourceOptions("dbnode02"))
+	require.NoError(t, err)
+
+	c, ok = docker.container("dbnode02")
+	require.True(t, ok)
+	assert.Equal(t, int64(0), c.hostConfig.Memory)
+	assert.Equal(t, int64(0), c.hostConfig.CPUShares)
+	require.NoError(t, resource.close())
+}
+
+func TestNewNetworkConnectionOptions(t *testing.T) {
+	opts := newNetworkConnectionOptions("abc", []string{"dbnode", "m3db_local"})
+	assert.Equal(t, "abc", opts.Container)
+	require.NotNil(t, opts.EndpointConfig)
+	assert.Equal(t, []string{"dbnode", "m3db_local"}, opts.EndpointConfig.Aliases)
+}
+
+func TestNewDockerResourceHostnameAndAliases(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	opts := testResourceOptions("dbnode01")
+	opts.hostname = "m3db_local"
+	opts.networkAliases = []string{"dbnode", "m3db_local"}
+
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, "m3db_local", c.config.Hostname)
+	assert.Equal(t, []string{"dbnode", "m3db_local"}, c.networkAliases)
+	require.NoError(t, resource.close())
+}
+
+func TestNewDockerResourceBuildsDockerfile(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"dbnode01"}, docker.recordedBuilds())
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, "dbnode01:latest", c.config.Image)
+	require.NoError(t, resource.close())
+}
+
+func TestNewDockerResourceRunsImage(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	opts := testResourceOptions("dbnode01")
+	opts.image = dockerImage{name: "m3db", tag: "latest"}
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+
+	assert.Empty(t, docker.recordedBuilds())
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, "m3db:latest", c.config.Image)
+	require.NoError(t, resource.close())
+}
+
+func TestRenderDockerfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dockerfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templatePath := filepath.Join(dir, "Dockerfile.tmpl")
+	require.NoError(t, ioutil.WriteFile(templatePath,
+		[]byte("FROM {{.BaseImage}}\nRUN echo {{.Message}}\n"), 0644))
+
+	rendered, err := renderDockerfile(templatePath, map[string]string{
+		"BaseImage": "alpine:3.11",
+		"Message":   "hello",
+	})
+	require.NoError(t, err)
+	defer os.Remove(rendered)
+
+	assert.Equal(t, dir, filepath.Dir(rendered))
+	b, err := ioutil.ReadFile(rendered)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine:3.11\nRUN echo hello\n", string(b))
+
+	_, err = renderDockerfile(templatePath, map[string]string{"BaseImage": "alpine:3.11"})
+	require.Error(t, err)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+}
+
+func TestNewDockerResourceRendersDockerfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dockerfile")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	templatePath := filepath.Join(dir, "Dockerfile.tmpl")
+	require.NoError(t, ioutil.WriteFile(templatePath,
+		[]byte("FROM {{.BaseImage}}\n"), 0644))
+
+	docker := newFakeDocker()
+	defer docker.close()
+
+	opts := testResourceOptions("dbnode01")
+	opts.dockerFile = templatePath
+	opts.dockerFileVars = map[string]string{"BaseImage": "alpine:3.11"}
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+
+	b, err := ioutil.ReadFile(resource.renderedDockerFile)
+	require.NoError(t, err)
+	assert.Equal(t, "FROM alpine:3.11\n", string(b))
+
+	require.NoError(t, resource.close())
+	_, err = os.Stat(resource.renderedDockerFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewDockerResourceMountsDataDir(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	newResource := func() (*dockerResource, *fakeContainer) {
+		opts := testResourceOptions("dbnode01")
+		opts.mounts = []string{"/tmp"}
+		opts.dataDir = "/var/lib/m3db"
+		resource, err := newDockerResource(docker.pool(t), opts)
+		require.NoError(t, err)
+		c, ok := docker.container("dbnode01")
+		require.True(t, ok)
+		return resource, c
+	}
+
+	resource, c := newResource()
+	info, err := os.Stat(resource.dataDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	require.Equal(t, 2, len(c.hostConfig.Mounts))
+	assert.Equal(t, "/tmp", c.hostConfig.Mounts[0].Target)
+	assert.Equal(t, "tmpfs", c.hostConfig.Mounts[0].Type)
+	assert.Equal(t, resource.dataDir, c.hostConfig.Mounts[1].Source)
+	assert.Equal(t, "/var/lib/m3db", c.hostConfig.Mounts[1].Target)
+	assert.Equal(t, "bind", c.hostConfig.Mounts[1].Type)
+
+	require.NoError(t, resource.close())
+	_, err = os.Stat(resource.dataDir)
+	assert.True(t, os.IsNotExist(err))
+
+	// Each run gets a fresh data dir.
+	next, _ := newResource()
+	defer next.close()
+	assert.NotEqual(t, resource.dataDir, next.dataDir)
+}
+
+func TestNewDataDirMountSource(t *testing.T) {
+	source, err := ioutil.TempDir("", "data-dir-source-")
+	require.NoError(t, err)
+	defer os.RemoveAll(source)
+
+	// The data dir is created in the given source.
+	m, err := newDataDirMount(defaultVolumeName, "dbnode01", source, "/var/lib/m3db")
+	require.NoError(t, err)
+	assert.Equal(t, source, filepath.Dir(m.Source))
+	assert.Equal(t, "/var/lib/m3db", m.Target)
+	assert.Equal(t, "bind", m.Type)
+	info, err := os.Stat(m.Source)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	// Without a source, the data dir is created in the temp dir.
+	m, err = newDataDirMount(defaultVolumeName, "dbnode01", "", "/var/lib/m3db")
+	require.NoError(t, err)
+	defer os.RemoveAll(m.Source)
+	assert.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(m.Source))
+	assert.Equal(t, "/var/lib/m3db", m.Target)
+}
+
+func TestSetupNetworkAndVolumeNames(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	var (
+		lock  sync.Mutex
+		calls []string
+	)
+	record := func(call string) {
+		lock.Lock()
+		calls = append(calls, call)
+		lock.Unlock()
+	}
+	created := func(r *http.Request) string {
+		var req struct{ Name string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		return req.Name
+	}
+	docker.handle(http.MethodGet, "/networks",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, []map[string]string{{"Name": defaultNetworkName}, {"Name": "stack-b"}})
+		})
+	docker.handle(http.MethodDelete, "/networks/stack-b",
+		func(w http.ResponseWriter, r *http.Request) { record("remove network stack-b") })
+	docker.handle(http.MethodPost, "/networks/create",
+		func(w http.ResponseWriter, r *http.Request) {
+			record("create network " + created(r))
+			writeJSON(w, map[string]string{"Id": "network"})
+		})
+	docker.handle(http.MethodGet, "/volumes",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]interface{}{
+				"Volumes": []map[string]string{{"Name": defaultVolumeName}, {"Name": "stack-b"}},
+			})
+		})
+	docker.handle(http.MethodDelete, "/volumes/stack-b",
+		func(w http.ResponseWriter, r *http.Request) { record("remove volume stack-b") })
+	docker.handle(http.MethodPost, "/volumes/create",
+		func(w http.ResponseWriter, r *http.Request) {
+			name := created(r)
+			record("create volume " + name)
+			writeJSON(w, map[string]string{"Name": name})
+		})
+
+	// Only the network and volume with the given name are replaced, leaving
+	// those of other setups untouched.
+	pool := docker.pool(t)
+	require.NoError(t, setupNetwork(pool, "stack-b"))
+	require.NoError(t, setupVolume(pool, "stack-b"))
+	assert.Equal(t, []string{
+		"remove network stack-b",
+		"create network stack-b",
+		"remove volume stack-b",
+		"create volume stack-b",
+	}, calls)
+
+	// Containers are attached to the given network, and their data dir is named
+	// after the given volume.
+	opts := testResourceOptions("dbnode01")
+	opts.networkName = "stack-b"
+	opts.volumeName = "stack-b"
+	opts.dataDir = "/var/lib/m3db"
+	resource, err := newDockerResource(pool, opts)
+	require.NoError(t, err)
+	defer resource.close()
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	assert.Equal(t, "stack-b", c.hostConfig.NetworkMode)
+	assert.True(t, strings.HasPrefix(filepath.Base(resource.dataDir), "stack-b-dbnode01-"))
+	require.NoError(t, verifyNoLeakedContainers(pool, defaultNetworkName))
+	require.Error(t, verifyNoLeakedContainers(pool, "stack-b"))
+}
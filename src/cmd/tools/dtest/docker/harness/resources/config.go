@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+
+	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	queryconfig "github.com/m3db/m3/src/cmd/services/m3query/config"
+	xconfig "github.com/m3db/m3/src/x/config"
+)
+
+// configError is returned when the config a container would be started with
+// is invalid.
+type configError struct {
+	path string
+	err  error
+}
+
+func (e configError) Error() string {
+	return fmt.Sprintf("invalid config %s: %v", e.path, e.err)
+}
+
+func newDBNodeConfig() interface{} { return &dbconfig.Configuration{} }
+
+func newCoordinatorConfig() interface{} { return &queryconfig.Configuration{} }
+
+// validateConfig loads the config file at the given path into the given
+// configuration as the service would on startup, failing if it is not valid
+// YAML, sets fields unknown to the configuration or fails its validation. This
+// surfaces bad configs before a container is started rather than through it
+// crashing.
+func validateConfig(path string, cfg interface{}) error {
+	if err := xconfig.LoadFile(cfg, path, xconfig.Options{}); err != nil {
+		return configError{path: path, err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTempConfig(t *testing.T, contents string) string {
+	f, err := ioutil.TempFile("", "config-*.yml")
+	require.NoError(t, err)
+	_, err = f.WriteString(contents)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func TestValidateConfigBundled(t *testing.T) {
+	require.NoError(t, validateConfig("config/m3dbnode.yml", newDBNodeConfig()))
+	require.NoError(t, validateConfig("config/m3coordinator.yml", newCoordinatorConfig()))
+}
+
+func TestValidateConfigInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		config   string
+		expected string
+	}{
+		{
+			name:     "malformed",
+			config:   "listenAddress: [0.0.0.0:7201\n",
+			expected: "yaml",
+		},
+		{
+			name:     "unknown field",
+			config:   "listenAddres: 0.0.0.0:7201\n",
+			expected: "listenAddres",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			path := writeTempConfig(t, test.config)
+			defer os.Remove(path)
+
+			err := validateConfig(path, newCoordinatorConfig())
+			require.Error(t, err)
+			_, ok := err.(configError)
+			require.True(t, ok)
+			assert.Contains(t, err.Error(), "invalid config "+path)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestNewDockerResourceInvalidConfig(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	path := writeTempConfig(t, "listenAddress: [0.0.0.0:7201\n")
+	defer os.Remove(path)
+
+	opts := testResourceOptions("coord01")
+	opts.configFile = path
+	opts.newConfig = newCoordinatorConfig
+	_, err := newDockerResource(docker.pool(t), opts)
+	require.Error(t, err)
+	assert.IsType(t, configError{}, err)
+
+	// The container should not have been started.
+	assert.Empty(t, docker.recordedActions())
+	_, found := docker.container("coord01")
+	assert.False(t, found)
+}
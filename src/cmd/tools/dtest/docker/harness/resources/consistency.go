@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const readAfterWriteTimeout = time.Minute
+
+// WriteAndReadEventually writes the given sample through the coordinator and
+// then queries its series until the written value is read back, returning the
+// number of queries it took. Reads may be served by replicas which have not yet
+// caught up with the write, so dtests reading their own writes should use this
+// rather than expecting the first read to observe the write. The sample should
+// be timestamped within the query lookback of now.
+func (c *coordinator) WriteAndReadEventually(sample TimedSample) (int, error) {
+	if c.resource.closed {
+		return 0, errClosed
+	}
+
+	return writeAndReadEventually(
+		c.resource.getURL(7201, promWritePath),
+		c.resource.getURL(7201, promQueryPath),
+		sample, readAfterWriteTimeout)
+}
+
+// writeAndReadEventually writes the given sample to the remote write URL, then
+// polls the instant query URL for its series until the written value is read
+// or the timeout fires, returning the number of queries made.
+func writeAndReadEventually(
+	writeURL string,
+	queryURL string,
+	sample TimedSample,
+	timeout time.Duration,
+) (int, error) {
+	if err := writePromSamples(writeURL, []TimedSample{sample}); err != nil {
+		return 0, err
+	}
+
+	var (
+		query    = promSelector(sample.Name, sample.Labels)
+		attempts int
+	)
+	err := waitUntil(time.Now().Add(timeout), func() error {
+		attempts++
+		results, err := queryPromSamples(queryURL, query)
+		if err != nil {
+			return err
+		}
+
+		for _, result := range results {
+			if result.Value == sample.Value {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("value %v not yet read for %s, read %v",
+			sample.Value, query, results)
+	})
+	if err != nil {
+		return attempts, fmt.Errorf("read after %d attempt(s) failed: %v", attempts, err)
+	}
+
+	return attempts, nil
+}
+
+// promSelector returns the PromQL selector matching exactly the series with
+// the given name and labels.
+func promSelector(name string, labels map[string]string) string {
+	matchers := make([]string, 0, len(labels))
+	for k, v := range labels {
+		matchers = append(matchers, k+"="+strconv.Quote(v))
+	}
+
+	sort.Strings(matchers)
+	return name + "{" + strings.Join(matchers, ",") + "}"
+}
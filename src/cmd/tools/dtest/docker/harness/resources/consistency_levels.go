@@ -0,0 +1,206 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+
+	"go.uber.org/zap"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// coordinatorConfigPath is where the coordinator image reads its config.
+	coordinatorConfigPath = "/etc/m3coordinator.yml"
+
+	configRestartTimeout = 30 * time.Second
+)
+
+// ConsistencyLevels are the write and read consistency levels the coordinator
+// uses for its M3DB client.
+type ConsistencyLevels struct {
+	Write topology.ConsistencyLevel
+	Read  topology.ReadConsistencyLevel
+}
+
+func (l ConsistencyLevels) String() string {
+	return fmt.Sprintf("write=%s,read=%s", l.Write, l.Read)
+}
+
+// ConsistencyLevelResult is the result of running a scenario under a set of
+// consistency levels.
+type ConsistencyLevelResult struct {
+	Levels   ConsistencyLevels
+	Duration time.Duration
+	Err      error
+}
+
+// RunAcrossConsistencyLevels runs the given scenario once for each of the given
+// consistency levels, restarting the coordinator configured with the levels
+// before each run, and returns the result of each run in the same order. A
+// scenario failing does not stop the remaining runs, whereas failing to
+// configure the coordinator does, returning the results so far.
+func (c *coordinator) RunAcrossConsistencyLevels(
+	levels []ConsistencyLevels,
+	scenario func(ConsistencyLevels) error,
+) ([]ConsistencyLevelResult, error) {
+	if c.resource.closed {
+		return nil, errClosed
+	}
+
+	return runAcrossConsistencyLevels(levels, c.configureConsistencyLevels, scenario)
+}
+
+// configureConsistencyLevels injects the default coordinator config with the
+// given consistency levels into the container and restarts the coordinator,
+// waiting for it to serve again.
+func (c *coordinator) configureConsistencyLevels(levels ConsistencyLevels) error {
+	logger := c.resource.logger.With(zapMethod("configureConsistencyLevels"),
+		zap.Stringer("levels", levels))
+	cfg, err := renderConsistencyLevelsConfig(defaultCoordinatorConfigFile, levels)
+	if err != nil {
+		logger.Error("could not render config", zap.Error(err))
+		return err
+	}
+
+	if err := c.resource.injectConfig(coordinatorConfigPath, cfg); err != nil {
+		return err
+	}
+
+	return c.WaitForNamespace("")
+}
+
+// runAcrossConsistencyLevels configures and then runs the given scenario for
+// each of the given consistency levels in turn.
+func runAcrossConsistencyLevels(
+	levels []ConsistencyLevels,
+	configure func(ConsistencyLevels) error,
+	scenario func(ConsistencyLevels) error,
+) ([]ConsistencyLevelResult, error) {
+	results := make([]ConsistencyLevelResult, 0, len(levels))
+	for _, l := range levels {
+		if err := configure(l); err != nil {
+			return results, fmt.Errorf("could not configure consistency levels %s: %v", l, err)
+		}
+
+		start := time.Now()
+		err := scenario(l)
+		results = append(results, ConsistencyLevelResult{
+			Levels:   l,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+
+	return results, nil
+}
+
+// injectConfig replaces the config file at the given path in the container
+// with the given config and restarts the container to pick it up.
+func (c *dockerResource) injectConfig(path string, cfg []byte) error {
+	if err := c.writeFile(path, cfg); err != nil {
+		return err
+	}
+
+	return c.restart(configRestartTimeout)
+}
+
+// renderConsistencyLevelsConfig returns the coordinator config at the given
+// path with the client of every cluster set to the given consistency levels.
+// The rendered config is validated as the coordinator would on startup.
+func renderConsistencyLevelsConfig(path string, levels ConsistencyLevels) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// NB: the config is edited as YAML rather than through the configuration
+	// type so that fields left unset are not rendered with zero values.
+	var cfg yaml.MapSlice
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, configError{path: path, err: err}
+	}
+
+	clusters, _ := mapSliceValue(cfg, "clusters").([]interface{})
+	if len(clusters) == 0 {
+		return nil, configError{path: path, err: fmt.Errorf("no clusters")}
+	}
+
+	for i, cluster := range clusters {
+		clusterCfg, _ := cluster.(yaml.MapSlice)
+		client, _ := mapSliceValue(clusterCfg, "client").(yaml.MapSlice)
+		client = setMapSliceValue(client, "writeConsistencyLevel", levels.Write.String())
+		client = setMapSliceValue(client, "readConsistencyLevel", levels.Read.String())
+		clusters[i] = setMapSliceValue(clusterCfg, "client", client)
+	}
+
+	rendered, err := yaml.Marshal(setMapSliceValue(cfg, "clusters", clusters))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile("", "m3coordinator-*.yml")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f.Name())
+	_, err = f.Write(rendered)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateConfig(f.Name(), newCoordinatorConfig()); err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
+
+func mapSliceValue(m yaml.MapSlice, key string) interface{} {
+	for _, item := range m {
+		if item.Key == key {
+			return item.Value
+		}
+	}
+
+	return nil
+}
+
+func setMapSliceValue(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, item := range m {
+		if item.Key == key {
+			m[i].Value = value
+			return m
+		}
+	}
+
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
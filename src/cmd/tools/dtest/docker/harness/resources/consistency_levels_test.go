@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	queryconfig "github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/dbnode/topology"
+	xconfig "github.com/m3db/m3/src/x/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+// loadConsistencyLevels returns the consistency levels the client of the single
+// cluster of the given coordinator config is set to.
+func loadConsistencyLevels(t *testing.T, cfg string) ConsistencyLevels {
+	path := writeTempConfig(t, cfg)
+	defer os.Remove(path)
+
+	var loaded queryconfig.Configuration
+	require.NoError(t, xconfig.LoadFile(&loaded, path, xconfig.Options{}))
+	require.Equal(t, 1, len(loaded.Clusters))
+	client := loaded.Clusters[0].Client
+	require.NotNil(t, client.WriteConsistencyLevel)
+	require.NotNil(t, client.ReadConsistencyLevel)
+	return ConsistencyLevels{
+		Write: *client.WriteConsistencyLevel,
+		Read:  *client.ReadConsistencyLevel,
+	}
+}
+
+func TestRenderConsistencyLevelsConfig(t *testing.T) {
+	levels := ConsistencyLevels{
+		Write: topology.ConsistencyLevelAll,
+		Read:  topology.ReadConsistencyLevelUnstrictMajority,
+	}
+	cfg, err := renderConsistencyLevelsConfig("config/m3coordinator.yml", levels)
+	require.NoError(t, err)
+	require.Equal(t, levels, loadConsistencyLevels(t, string(cfg)))
+
+	// Rendering fails if there is no cluster to configure.
+	path := writeTempConfig(t, "listenAddress: 0.0.0.0:7201\n")
+	defer os.Remove(path)
+	_, err = renderConsistencyLevelsConfig(path, levels)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no clusters")
+}
+
+func TestRunAcrossConsistencyLevels(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("coord01"))
+	require.NoError(t, err)
+	defer resource.close()
+	c, ok := docker.container("coord01")
+	require.True(t, ok)
+
+	var (
+		levels = []ConsistencyLevels{
+			{Write: topology.ConsistencyLevelOne, Read: topology.ReadConsistencyLevelOne},
+			{Write: topology.ConsistencyLevelMajority, Read: topology.ReadConsistencyLevelMajority},
+		}
+		configure = func(l ConsistencyLevels) error {
+			cfg, err := renderConsistencyLevelsConfig("config/m3coordinator.yml", l)
+			if err != nil {
+				return err
+			}
+
+			return resource.injectConfig(coordinatorConfigPath, cfg)
+		}
+		errScenario = errors.New("scenario failed")
+		ran         []ConsistencyLevels
+	)
+	results, err := runAcrossConsistencyLevels(levels, configure,
+		func(l ConsistencyLevels) error {
+			// Each run sees the coordinator restarted with its levels.
+			docker.Lock()
+			cfg := c.files[coordinatorConfigPath]
+			docker.Unlock()
+			require.Equal(t, l, loadConsistencyLevels(t, cfg))
+			require.Equal(t, len(ran)+1, countActions(docker, "restart coord01"))
+
+			ran = append(ran, l)
+			if len(ran) == 1 {
+				return errScenario
+			}
+
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, levels, ran)
+
+	// A failed run is reported without stopping the remaining runs.
+	require.Equal(t, 2, len(results))
+	require.Equal(t, levels[0], results[0].Levels)
+	require.Equal(t, errScenario, results[0].Err)
+	require.Equal(t, levels[1], results[1].Levels)
+	require.NoError(t, results[1].Err)
+}
+
+func TestRunAcrossConsistencyLevelsConfigureError(t *testing.T) {
+	var (
+		levels = []ConsistencyLevels{
+			{Write: topology.ConsistencyLevelOne, Read: topology.ReadConsistencyLevelOne},
+			{Write: topology.ConsistencyLevelAll, Read: topology.ReadConsistencyLevelAll},
+		}
+		errConfigure = errors.New("restart failed")
+		runs         int
+	)
+	results, err := runAcrossConsistencyLevels(levels,
+		func(l ConsistencyLevels) error {
+			if l == levels[1] {
+				return errConfigure
+			}
+
+			return nil
+		},
+		func(ConsistencyLevels) error {
+			runs++
+			return nil
+		})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "write=all,read=all")
+	require.Contains(t, err.Error(), errConfigure.Error())
+	require.Equal(t, 1, runs)
+	require.Equal(t, 1, len(results))
+}
+
+func countActions(docker *fakeDocker, action string) int {
+	var n int
+	for _, a := range docker.recordedActions() {
+		if a == action {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReplicaServer accepts remote writes and serves the last written value
+// for queries, after answering the first staleReads queries with a stale value
+// to simulate reads served by a replica which has not yet caught up.
+type fakeReplicaServer struct {
+	sync.Mutex
+
+	written    float64
+	queries    []string
+	staleReads int
+}
+
+func (s *fakeReplicaServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+promWritePath, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeWriteRequest(t, r)
+		require.Equal(t, 1, len(req.Timeseries))
+		require.Equal(t, 1, len(req.Timeseries[0].Samples))
+
+		s.Lock()
+		defer s.Unlock()
+		s.written = req.Timeseries[0].Samples[0].Value
+	})
+	mux.HandleFunc("/"+promQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		s.queries = append(s.queries, r.URL.Query().Get("query"))
+		value := s.written
+		if len(s.queries) <= s.staleReads {
+			value = 0
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"__name__":"writes_total","host":"a"},"value":[1,"%v"]}]}}`, value)
+	})
+
+	return mux
+}
+
+func TestWriteAndReadEventually(t *testing.T) {
+	fake := &fakeReplicaServer{staleReads: 3}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	attempts, err := writeAndReadEventually(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		TimedSample{
+			Sample: Sample{
+				Name:   "writes_total",
+				Labels: map[string]string{"service": "api", "host": "a"},
+				Value:  42,
+			},
+			Timestamp: time.Now(),
+		}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 4, attempts)
+
+	fake.Lock()
+	defer fake.Unlock()
+	assert.Equal(t, 4, len(fake.queries))
+	assert.Equal(t, `writes_total{host="a",service="api"}`, fake.queries[0])
+}
+
+func TestWriteAndReadEventuallyTimeout(t *testing.T) {
+	fake := &fakeReplicaServer{staleReads: 1000}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	attempts, err := writeAndReadEventually(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		TimedSample{
+			Sample:    Sample{Name: "writes_total", Value: 42},
+			Timestamp: time.Now(),
+		}, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.True(t, attempts > 0)
+	assert.Contains(t, err.Error(), "timed out: value 42 not yet read for writes_total{}")
+}
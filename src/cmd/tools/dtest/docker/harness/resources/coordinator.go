@@ -41,6 +41,7 @@ const (
 	defaultCoordinatorSource     = "coordinator"
 	defaultCoordinatorName       = "coord01"
 	defaultCoordinatorDockerfile = "resources/config/m3coordinator.Dockerfile"
+	defaultCoordinatorConfigFile = "resources/config/m3coordinator.yml"
 )
 
 var (
@@ -50,6 +51,8 @@ var (
 		source:        defaultCoordinatorSource,
 		containerName: defaultCoordinatorName,
 		dockerFile:    defaultCoordinatorDockerfile,
+		configFile:    defaultCoordinatorConfigFile,
+		newConfig:     newCoordinatorConfig,
 		portList:      defaultCoordinatorList,
 	}
 )
@@ -68,6 +71,24 @@ type Coordinator interface {
 	WriteCarbon(port int, metric string, v float64, t time.Time) error
 	// RunQuery runs the given query with a given verification function.
 	RunQuery(verifier ResponseVerifier, query string) error
+	// WriteAndQueryRollup writes the given samples and then returns the results
+	// of the given instant query once the aggregated series are available.
+	WriteAndQueryRollup(samples []TimedSample, query string) ([]Sample, error)
+	// WriteAndReadEventually writes the given sample and then reads its series
+	// until the written value is read back, returning the number of reads.
+	WriteAndReadEventually(sample TimedSample) (int, error)
+	// MeasureQueryableLatency measures how long written data takes to become
+	// queryable over the given number of runs.
+	MeasureQueryableLatency(runs int) (LatencyDistribution, error)
+	// VerifyRemoteWriteRoundTrip writes the given samples and then reads each
+	// of their series back, returning an error if any is not read as written.
+	VerifyRemoteWriteRoundTrip(samples []TimedSample) error
+	// RunAcrossConsistencyLevels runs the given scenario once under each of the
+	// given consistency levels, returning the result of each run.
+	RunAcrossConsistencyLevels(
+		levels []ConsistencyLevels,
+		scenario func(ConsistencyLevels) error,
+	) ([]ConsistencyLevelResult, error)
 }
 
 // Admin is a wrapper for admin functions.
@@ -391,6 +412,10 @@ func (c *coordinator) RunQuery(
 	return err
 }
 
+func (c *coordinator) hasLabel(key, value string) bool {
+	return c.resource.hasLabel(key, value)
+}
+
 func (c *coordinator) Close() error {
 	if c.resource.closed {
 		return errClosed
@@ -37,6 +37,7 @@ const (
 	defaultDBNodeSource        = "dbnode"
 	defaultDBNodeContainerName = "dbnode01"
 	defaultDBNodeDockerfile    = "resources/config/m3dbnode.Dockerfile"
+	defaultDBNodeConfigFile    = "resources/config/m3dbnode.yml"
 )
 
 var (
@@ -46,6 +47,8 @@ var (
 		source:        defaultDBNodeSource,
 		containerName: defaultDBNodeContainerName,
 		dockerFile:    getDockerfile(defaultDBNodeDockerfile),
+		configFile:    getDockerfile(defaultDBNodeConfigFile),
+		newConfig:     newDBNodeConfig,
 		portList:      defaultDBNodePortList,
 	}
 )
@@ -186,6 +189,12 @@ func (c *dbNode) WaitForBootstrap() error {
 
 	logger := c.resource.logger.With(zapMethod("waitForBootstrap"))
 	return c.resource.pool.Retry(func() error {
+		// NB: surface the exit reason rather than a connection error if the
+		// container has died.
+		if err := c.resource.checkExited(); err != nil {
+			return err
+		}
+
 		health, err := c.Health()
 		if err != nil {
 			return err
@@ -269,6 +278,10 @@ func (c *dbNode) GoalStateExec(
 	return c.resource.goalStateExec(verifier, commands...)
 }
 
+func (c *dbNode) hasLabel(key, value string) bool {
+	return c.resource.hasLabel(key, value)
+}
+
 func (c *dbNode) Close() error {
 	if c.resource.closed {
 		return errClosed
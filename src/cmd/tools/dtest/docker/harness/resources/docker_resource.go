@@ -22,10 +22,16 @@ package resources
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
 
 	"github.com/ory/dockertest"
 	"github.com/ory/dockertest/docker"
@@ -35,7 +41,42 @@ import (
 )
 
 type dockerResource struct {
-	closed bool
+	closed  bool
+	stopped bool
+
+	// renderedDockerFile is the Dockerfile rendered from a template, if any,
+	// which is removed when the resource is closed.
+	renderedDockerFile string
+
+	// dataDir is the temporary directory bind mounted as the data directory of
+	// the container, if any, which is removed when the resource is closed.
+	dataDir string
+
+	// dataDirTarget is the path the data directory is mounted at in the
+	// container, if any.
+	dataDirTarget string
+
+	// snapshots are tar archives of the data directory by snapshot name, taken
+	// by snapshotVolume.
+	snapshots map[string][]byte
+
+	// labels are the labels the container was created with, which allow
+	// targeting a subset of resources, e.g. for teardown.
+	labels map[string]string
+
+	// networkAliases are the aliases of the container on the test network,
+	// which are restored when it is reconnected.
+	networkAliases []string
+
+	// networkName is the name of the test network the container is attached to.
+	networkName string
+
+	// healthBackoff is the backoff between the health checks of waitForHealthy.
+	healthBackoff healthBackoff
+
+	// logCapture is the capture of the container logs started by captureLogs,
+	// if any, which is stopped when the resource is closed.
+	logCapture *logCapture
 
 	logger *zap.Logger
 
@@ -54,6 +95,8 @@ func newDockerResource(
 		dockerFile    = resourceOpts.dockerFile
 		iOpts         = resourceOpts.iOpts
 		portList      = resourceOpts.portList
+		networkName   = networkNameOrDefault(resourceOpts.networkName)
+		volumeName    = volumeNameOrDefault(resourceOpts.volumeName)
 
 		logger = iOpts.Logger().With(
 			zap.String("source", source),
@@ -61,16 +104,39 @@ func newDockerResource(
 		)
 	)
 
+	// NB: images built from a Dockerfile add the config file, so validate it up
+	// front rather than discovering it is invalid through a crashed container.
+	if image.name == "" && resourceOpts.configFile != "" && resourceOpts.newConfig != nil {
+		if err := validateConfig(resourceOpts.configFile, resourceOpts.newConfig()); err != nil {
+			logger.Error("invalid config", zap.Error(err))
+			return nil, err
+		}
+	}
+
 	if err := pool.RemoveContainerByName(containerName); err != nil {
 		logger.Error("could not remove container from pool", zap.Error(err))
 		return nil, err
 	}
 
-	opts := exposePorts(newOptions(containerName), portList)
+	opts := exposePorts(newOptions(resourceOpts), portList)
+
+	var dataDirMount dc.HostMount
+	if target := resourceOpts.dataDir; target != "" {
+		var err error
+		dataDirMount, err = newDataDirMount(volumeName, containerName,
+			resourceOpts.dataDirSource, target)
+		if err != nil {
+			logger.Error("could not create data dir",
+				zap.String("target", target), zap.Error(err))
+			return nil, err
+		}
+	}
 
 	hostConfigOpts := func(c *dc.HostConfig) {
 		c.NetworkMode = networkName
-		mounts := make([]dc.HostMount, 0, len(resourceOpts.mounts))
+		c.Memory = resourceOpts.memoryBytes
+		c.CPUShares = resourceOpts.cpuShares
+		mounts := make([]dc.HostMount, 0, len(resourceOpts.mounts)+1)
 		for _, m := range resourceOpts.mounts {
 			mounts = append(mounts, dc.HostMount{
 				Target: m,
@@ -78,9 +144,26 @@ func newDockerResource(
 			})
 		}
 
+		if dataDirMount.Source != "" {
+			mounts = append(mounts, dataDirMount)
+		}
+
 		c.Mounts = mounts
 	}
 
+	var renderedDockerFile string
+	if image.name == "" && len(resourceOpts.dockerFileVars) > 0 {
+		rendered, err := renderDockerfile(dockerFile, resourceOpts.dockerFileVars)
+		if err != nil {
+			logger.Error("could not render dockerfile",
+				zap.String("dockerFile", dockerFile), zap.Error(err))
+			removeDataDir(dataDirMount.Source, logger)
+			return nil, err
+		}
+
+		renderedDockerFile, dockerFile = rendered, rendered
+	}
+
 	var resource *dockertest.Resource
 	var err error
 	if image.name == "" {
@@ -97,14 +180,133 @@ func newDockerResource(
 
 	if err != nil {
 		logger.Error("could not run container", zap.Error(err))
+		removeRenderedDockerFile(renderedDockerFile, logger)
+		removeDataDir(dataDirMount.Source, logger)
 		return nil, err
 	}
 
-	return &dockerResource{
-		logger:   logger,
-		resource: resource,
-		pool:     pool,
-	}, nil
+	if aliases := resourceOpts.networkAliases; len(aliases) > 0 {
+		err := connectNetworkWithAliases(pool, networkName, resource.Container.ID, aliases)
+		if err != nil {
+			logger.Error("could not set network aliases",
+				zap.Strings("aliases", aliases), zap.Error(err))
+			pool.Purge(resource)
+			removeRenderedDockerFile(renderedDockerFile, logger)
+			removeDataDir(dataDirMount.Source, logger)
+			return nil, err
+		}
+	}
+
+	c := &dockerResource{
+		renderedDockerFile: renderedDockerFile,
+		dataDir:            dataDirMount.Source,
+		dataDirTarget:      dataDirMount.Target,
+		labels:             resourceOpts.labels,
+		networkAliases:     resourceOpts.networkAliases,
+		networkName:        networkName,
+		healthBackoff:      healthBackoffOrDefault(resourceOpts.healthBackoff),
+		logger:             logger,
+		resource:           resource,
+		pool:               pool,
+	}
+
+	if w := resourceOpts.logOutput; w != nil {
+		// NB: failing to capture logs should not fail the test using the
+		// container, the logs are only an aid for debugging.
+		if err := c.captureLogs(w); err != nil {
+			logger.Error("could not capture container logs", zap.Error(err))
+		}
+	}
+
+	registry.add(c)
+	return c, nil
+}
+
+// hasLabel returns true if the container was created with the given label.
+func (c *dockerResource) hasLabel(key, value string) bool {
+	v, ok := c.labels[key]
+	return ok && v == value
+}
+
+func removeRenderedDockerFile(path string, logger *zap.Logger) {
+	if path == "" {
+		return
+	}
+
+	if err := os.Remove(path); err != nil {
+		logger.Error("could not remove rendered dockerfile",
+			zap.String("dockerFile", path), zap.Error(err))
+	}
+}
+
+func removeDataDir(path string, logger *zap.Logger) {
+	if path == "" {
+		return
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		logger.Error("could not remove data dir",
+			zap.String("dataDir", path), zap.Error(err))
+	}
+}
+
+// newDockerResources brings up the given resources concurrently, running at
+// most maxConcurrency at a time. Results are returned in the same order as the
+// given options. Bring-up is all-or-nothing: if any resource fails, the ones
+// that succeeded are purged and the aggregated errors are returned.
+func newDockerResources(
+	pool *dockertest.Pool,
+	resourceOpts []dockerResourceOptions,
+	maxConcurrency int,
+) ([]*dockerResource, error) {
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+
+	var (
+		resources = make([]*dockerResource, len(resourceOpts))
+		errs      = make([]error, len(resourceOpts))
+		sem       = make(chan struct{}, maxConcurrency)
+		wg        sync.WaitGroup
+	)
+
+	for i, opts := range resourceOpts {
+		i, opts := i, opts
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			resources[i], errs[i] = newDockerResource(pool, opts)
+		}()
+	}
+
+	wg.Wait()
+	multiErr := xerrors.NewMultiError()
+	for _, err := range errs {
+		multiErr = multiErr.Add(err)
+	}
+
+	if multiErr.Empty() {
+		return resources, nil
+	}
+
+	for _, resource := range resources {
+		if resource == nil {
+			continue
+		}
+
+		// NB: the containers are being torn down regardless, so only the
+		// bring-up errors are surfaced.
+		if err := resource.close(); err != nil {
+			resource.logger.Error("could not purge container", zap.Error(err))
+		}
+	}
+
+	return nil, multiErr.FinalError()
 }
 
 func (c *dockerResource) getPort(bindPort int) (int, error) {
@@ -189,6 +391,56 @@ func (c *dockerResource) goalStateExec(
 	})
 }
 
+// containerExitedError is returned when a container has exited unexpectedly.
+type containerExitedError struct {
+	name      string
+	exitCode  int
+	oomKilled bool
+	err       string
+}
+
+func (e containerExitedError) Error() string {
+	msg := fmt.Sprintf("container %s exited unexpectedly with code %d (oom killed: %v)",
+		e.name, e.exitCode, e.oomKilled)
+	if len(e.err) != 0 {
+		msg = fmt.Sprintf("%s: %s", msg, e.err)
+	}
+
+	return msg
+}
+
+func newContainerExitedError(name string, state dc.State) error {
+	if state.Running || state.Restarting {
+		return nil
+	}
+
+	return containerExitedError{
+		name:      strings.TrimLeft(name, "/"),
+		exitCode:  state.ExitCode,
+		oomKilled: state.OOMKilled,
+		err:       state.Error,
+	}
+}
+
+// checkExited inspects the container, returning a containerExitedError
+// describing the exit code and OOM status if the container is no longer
+// running.
+func (c *dockerResource) checkExited() error {
+	logger := c.logger.With(zapMethod("checkExited"))
+	container, err := c.pool.Client.InspectContainer(c.resource.Container.ID)
+	if err != nil {
+		logger.Error("could not inspect container", zap.Error(err))
+		return err
+	}
+
+	if err := newContainerExitedError(container.Name, container.State); err != nil {
+		logger.Error("container exited", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (c *dockerResource) close() error {
 	if c.closed {
 		c.logger.Error("closing closed resource", zap.Error(errClosed))
@@ -197,5 +449,163 @@ func (c *dockerResource) close() error {
 
 	c.closed = true
 	c.logger.Info("closing resource")
-	return c.pool.Purge(c.resource)
+	registry.remove(c)
+	removeRenderedDockerFile(c.renderedDockerFile, c.logger)
+
+	// NB: logs are captured until the container is purged so that the output
+	// of a container that is shutting down is not lost.
+	defer c.stopCapturingLogs()
+
+	// NB: only surface the exit reason here; failing to inspect the container
+	// should not prevent it from being purged.
+	exitErr := c.checkExited()
+	if _, ok := exitErr.(containerExitedError); !ok || c.stopped {
+		exitErr = nil
+	}
+
+	// NB: the data dir is only removed once the container using it is purged.
+	defer removeDataDir(c.dataDir, c.logger)
+	if err := c.pool.Purge(c.resource); err != nil {
+		return err
+	}
+
+	return exitErr
+}
+
+// recovery describes how long a container took to recover after being killed.
+type recovery struct {
+	// restarted is the time taken for the container to be running again.
+	restarted time.Duration
+	// healthy is the time taken for the container to pass its health check.
+	healthy time.Duration
+}
+
+// killAndRecover SIGKILLs the container and waits for it to recover within the
+// given deadline. Containers with a restart policy are left to be restarted by
+// the docker daemon, others are restarted explicitly. Recovery is complete once
+// the container is running and the given health check passes.
+func (c *dockerResource) killAndRecover(
+	deadline time.Duration,
+	healthy func() error,
+) (recovery, error) {
+	if c.closed {
+		return recovery{}, errClosed
+	}
+
+	var (
+		client = c.pool.Client
+		id     = c.resource.Container.ID
+		logger = c.logger.With(zapMethod("killAndRecover"))
+		start  = time.Now()
+		end    = start.Add(deadline)
+	)
+
+	container, err := client.InspectContainer(id)
+	if err != nil {
+		logger.Error("could not inspect container", zap.Error(err))
+		return recovery{}, err
+	}
+
+	logger.Info("killing container")
+	if err := client.KillContainer(docker.KillContainerOptions{
+		ID:     id,
+		Signal: docker.SIGKILL,
+	}); err != nil {
+		logger.Error("could not kill container", zap.Error(err))
+		return recovery{}, err
+	}
+
+	if policy := container.HostConfig.RestartPolicy.Name; policy == "" || policy == "no" {
+		logger.Info("restarting container")
+		if err := client.StartContainer(id, nil); err != nil {
+			logger.Error("could not restart container", zap.Error(err))
+			return recovery{}, err
+		}
+	}
+
+	if err := waitUntil(end, func() error {
+		container, err := client.InspectContainer(id)
+		if err != nil {
+			return err
+		}
+
+		if !container.State.Running {
+			return errors.New("container not running")
+		}
+
+		return nil
+	}); err != nil {
+		logger.Error("container did not restart", zap.Error(err))
+		return recovery{}, err
+	}
+
+	var result recovery
+	result.restarted = time.Since(start)
+	if err := waitUntil(end, healthy); err != nil {
+		logger.Error("container did not become healthy", zap.Error(err))
+		return recovery{}, err
+	}
+
+	result.healthy = time.Since(start)
+	logger.Info("container recovered",
+		zap.Duration("restarted", result.restarted),
+		zap.Duration("healthy", result.healthy))
+	return result, nil
+}
+
+// stopGracefully sends SIGTERM to the container and waits up to the given
+// timeout for it to exit, returning its exit code. If the container does not
+// exit in time it is SIGKILLed and errStopTimeout is returned alongside the
+// resulting exit code.
+func (c *dockerResource) stopGracefully(timeout time.Duration) (int, error) {
+	if c.closed {
+		return 0, errClosed
+	}
+
+	var (
+		client = c.pool.Client
+		id     = c.resource.Container.ID
+		logger = c.logger.With(zapMethod("stopGracefully"))
+	)
+
+	logger.Info("sending SIGTERM to container")
+	if err := client.KillContainer(docker.KillContainerOptions{
+		ID:     id,
+		Signal: docker.SIGTERM,
+	}); err != nil {
+		logger.Error("could not signal container", zap.Error(err))
+		return 0, err
+	}
+
+	c.stopped = true
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	code, err := client.WaitContainerWithContext(id, ctx)
+	if err == nil {
+		logger.Info("container stopped", zap.Int("exitCode", code))
+		return code, nil
+	}
+
+	if ctx.Err() == nil {
+		logger.Error("could not wait for container", zap.Error(err))
+		return 0, err
+	}
+
+	logger.Error("container did not stop in time, killing",
+		zap.Duration("timeout", timeout))
+	if err := client.KillContainer(docker.KillContainerOptions{
+		ID:     id,
+		Signal: docker.SIGKILL,
+	}); err != nil {
+		logger.Error("could not kill container", zap.Error(err))
+		return 0, err
+	}
+
+	code, err = client.WaitContainer(id)
+	if err != nil {
+		logger.Error("could not wait for container", zap.Error(err))
+		return 0, err
+	}
+
+	return code, errStopTimeout
 }
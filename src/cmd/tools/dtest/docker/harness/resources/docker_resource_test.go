@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dc "github.com/ory/dockertest/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseSurfacesOOMKill(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	id := resource.resource.Container.ID
+	docker.handle(http.MethodGet, "/containers/"+id+"/json",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, dc.Container{
+				ID:   id,
+				Name: "/dbnode01",
+				State: dc.State{
+					Running:   false,
+					ExitCode:  137,
+					OOMKilled: true,
+				},
+			})
+		})
+
+	err = resource.close()
+	require.Error(t, err)
+	exitErr, ok := err.(containerExitedError)
+	require.True(t, ok)
+	assert.Equal(t, 137, exitErr.exitCode)
+	assert.True(t, exitErr.oomKilled)
+	assert.Contains(t, err.Error(), "dbnode01")
+
+	// The container should still have been purged.
+	_, found := docker.container(id)
+	assert.False(t, found)
+}
+
+func TestCheckExitedRunning(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	require.NoError(t, resource.checkExited())
+	require.NoError(t, resource.close())
+}
+
+func TestKillAndRecover(t *testing.T) {
+	for _, policy := range []string{"", "always"} {
+		t.Run("policy="+policy, func(t *testing.T) {
+			docker := newFakeDocker()
+			defer docker.close()
+
+			resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+			require.NoError(t, err)
+			c, ok := docker.container("dbnode01")
+			require.True(t, ok)
+			c.hostConfig.RestartPolicy.Name = policy
+
+			checks := 0
+			healthy := func() error {
+				checks++
+				if checks < 3 {
+					return errors.New("not healthy")
+				}
+
+				return nil
+			}
+
+			result, err := resource.killAndRecover(5*time.Second, healthy)
+			require.NoError(t, err)
+			assert.Equal(t, 3, checks)
+			assert.True(t, result.restarted <= result.healthy)
+
+			expected := []string{"start dbnode01", "kill dbnode01"}
+			if policy == "" {
+				expected = append(expected, "start dbnode01")
+			}
+
+			assert.Equal(t, expected, docker.recordedActions())
+			require.NoError(t, resource.close())
+		})
+	}
+}
+
+func TestKillAndRecoverDeadlineExceeded(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	_, err = resource.killAndRecover(300*time.Millisecond, func() error {
+		return errors.New("not healthy")
+	})
+	require.Error(t, err)
+	require.NoError(t, resource.close())
+}
+
+func TestStopGracefully(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	code, err := resource.stopGracefully(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, 0, code)
+	assert.Equal(t, []string{"start dbnode01", "kill dbnode01"},
+		docker.recordedActions())
+
+	// A deliberately stopped container should not be reported as exited.
+	require.NoError(t, resource.close())
+}
+
+func TestStopGracefullyTimeout(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+	c.ignoreTerm = true
+
+	code, err := resource.stopGracefully(100 * time.Millisecond)
+	require.Equal(t, errStopTimeout, err)
+	assert.Equal(t, 137, code)
+	assert.Equal(t, []string{"start dbnode01", "kill dbnode01", "kill dbnode01"},
+		docker.recordedActions())
+	require.NoError(t, resource.close())
+}
+
+func TestNewDockerResourcesBoundedConcurrency(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	var (
+		lock                  sync.Mutex
+		inFlight, maxInFlight int
+	)
+	docker.handle(http.MethodPost, "/containers/create",
+		func(w http.ResponseWriter, r *http.Request) {
+			lock.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			lock.Unlock()
+
+			time.Sleep(50 * time.Millisecond)
+			docker.createContainer(w, r)
+
+			lock.Lock()
+			inFlight--
+			lock.Unlock()
+		})
+
+	var opts []dockerResourceOptions
+	for i := 0; i < 6; i++ {
+		opts = append(opts, testResourceOptions(fmt.Sprintf("dbnode%02d", i)))
+	}
+
+	resources, err := newDockerResources(docker.pool(t), opts, 2)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(resources))
+	assert.Equal(t, 2, maxInFlight)
+	for i, resource := range resources {
+		assert.Equal(t, fmt.Sprintf("dbnode%02d", i),
+			strings.TrimLeft(resource.resource.Container.Name, "/"))
+		require.NoError(t, resource.close())
+	}
+}
+
+func TestNewDockerResourcesPurgesOnFailure(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	docker.handle(http.MethodPost, "/containers/create",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("name") == "dbnode02" {
+				http.Error(w, "create failed", http.StatusInternalServerError)
+				return
+			}
+
+			docker.createContainer(w, r)
+		})
+
+	var opts []dockerResourceOptions
+	for i := 0; i < 4; i++ {
+		opts = append(opts, testResourceOptions(fmt.Sprintf("dbnode%02d", i)))
+	}
+
+	resources, err := newDockerResources(docker.pool(t), opts, 4)
+	require.Error(t, err)
+	assert.Nil(t, resources)
+	assert.Contains(t, err.Error(), "create failed")
+	for i := 0; i < 4; i++ {
+		_, found := docker.container(fmt.Sprintf("dbnode%02d", i))
+		assert.False(t, found)
+	}
+}
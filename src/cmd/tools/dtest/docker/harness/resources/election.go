@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/aggregator/aggregator"
+	aggregatorhttp "github.com/m3db/m3/src/aggregator/server/http"
+)
+
+// multipleLeadersError is returned when more than one instance of a shard set
+// reports being the leader, indicating a split brain.
+type multipleLeadersError struct {
+	leaders []string
+}
+
+func (e multipleLeadersError) Error() string {
+	return fmt.Sprintf("multiple leaders elected: %s", strings.Join(e.leaders, ", "))
+}
+
+// waitForSingleLeader polls the status endpoints of the aggregator instances
+// in a shard set until exactly one of them reports being the leader, returning
+// its status URL. It fails immediately with a multipleLeadersError if more than
+// one instance reports being the leader, and with a timeout error if no single
+// leader is elected before the timeout. Instances whose status cannot be
+// fetched are treated as not leading, as they may still be starting up.
+func waitForSingleLeader(statusURLs []string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		var leaders []string
+		for _, url := range statusURLs {
+			if state, err := fetchElectionState(url); err == nil && state == aggregator.LeaderState {
+				leaders = append(leaders, url)
+			}
+		}
+
+		switch len(leaders) {
+		case 1:
+			return leaders[0], nil
+		case 0:
+		default:
+			sort.Strings(leaders)
+			return "", multipleLeadersError{leaders: leaders}
+		}
+
+		if !time.Now().Add(pollInterval).Before(deadline) {
+			return "", fmt.Errorf("timed out: no leader elected among %d instances",
+				len(statusURLs))
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// fetchElectionState returns the election state reported by the aggregator
+// status endpoint at the given URL.
+func fetchElectionState(url string) (aggregator.ElectionState, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return aggregator.UnknownState, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return aggregator.UnknownState, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	status := aggregatorhttp.NewStatusResponse()
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return aggregator.UnknownState, err
+	}
+
+	return status.Status.FlushStatus.ElectionState, nil
+}
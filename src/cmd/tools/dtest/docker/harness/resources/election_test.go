@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeStatusServer serves the aggregator status endpoint, reporting the
+// election state returned by the given function.
+func newFakeStatusServer(state func() string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"status":{"flushStatus":{"electionState":%q,"canLead":true}}}`, state())
+	}))
+}
+
+func TestWaitForSingleLeader(t *testing.T) {
+	// NB: the second instance becomes leader after a few polls, while the
+	// third is unavailable throughout.
+	var polls int32
+	follower := newFakeStatusServer(func() string { return "follower" })
+	defer follower.Close()
+	converging := newFakeStatusServer(func() string {
+		if atomic.AddInt32(&polls, 1) < 3 {
+			return "pendingFollower"
+		}
+		return "leader"
+	})
+	defer converging.Close()
+	unavailable := httptest.NewServer(http.NotFoundHandler())
+	defer unavailable.Close()
+
+	leader, err := waitForSingleLeader([]string{
+		follower.URL, converging.URL, unavailable.URL,
+	}, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, converging.URL, leader)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+}
+
+func TestWaitForSingleLeaderMultipleLeaders(t *testing.T) {
+	first := newFakeStatusServer(func() string { return "leader" })
+	defer first.Close()
+	second := newFakeStatusServer(func() string { return "leader" })
+	defer second.Close()
+
+	_, err := waitForSingleLeader([]string{first.URL, second.URL}, 5*time.Second)
+	require.Error(t, err)
+	leadersErr, ok := err.(multipleLeadersError)
+	require.True(t, ok)
+	assert.Equal(t, 2, len(leadersErr.leaders))
+}
+
+func TestWaitForSingleLeaderTimeout(t *testing.T) {
+	follower := newFakeStatusServer(func() string { return "follower" })
+	defer follower.Close()
+
+	_, err := waitForSingleLeader([]string{follower.URL}, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// etcdClientPort is the port etcd serves clients on, which is exposed by the DB
+// node container running the embedded etcd.
+const etcdClientPort = 2379
+
+// pause suspends all processes in the container, which keeps its network
+// connections open while making it unresponsive, as a hung process would be.
+func (c *dockerResource) pause() error {
+	if c.closed {
+		return errClosed
+	}
+
+	if err := c.pool.Client.PauseContainer(c.resource.Container.ID); err != nil {
+		c.logger.Error("could not pause container",
+			zapMethod("pause"), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// unpause resumes all processes in the container suspended by pause.
+func (c *dockerResource) unpause() error {
+	if c.closed {
+		return errClosed
+	}
+
+	if err := c.pool.Client.UnpauseContainer(c.resource.Container.ID); err != nil {
+		c.logger.Error("could not unpause container",
+			zapMethod("unpause"), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// interruptEtcd makes the etcd running in the container unavailable for the
+// given duration by pausing the container, then restores it and returns once
+// etcd is reachable again or the timeout fires. This exercises lease loss in
+// elections and placement watches. Note that the DB node running the embedded
+// etcd is paused along with it.
+func (c *dockerResource) interruptEtcd(d, timeout time.Duration) error {
+	return interruptEtcd(c, c.getURL(etcdClientPort, "health"), d, timeout)
+}
+
+func interruptEtcd(c *dockerResource, healthURL string, d, timeout time.Duration) error {
+	logger := c.logger.With(zapMethod("interruptEtcd"), zap.Duration("duration", d))
+	if err := c.pause(); err != nil {
+		return err
+	}
+
+	logger.Info("etcd paused")
+	time.Sleep(d)
+	if err := c.unpause(); err != nil {
+		return err
+	}
+
+	if err := waitForEtcd(healthURL, timeout); err != nil {
+		logger.Error("etcd did not recover", zap.Error(err))
+		return err
+	}
+
+	logger.Info("etcd recovered")
+	return nil
+}
+
+// waitForEtcd polls the etcd health endpoint at the given URL until etcd
+// reports itself healthy or the timeout fires.
+func waitForEtcd(url string, timeout time.Duration) error {
+	return waitUntil(time.Now().Add(timeout), func() error {
+		resp, err := http.Get(url)
+		if err != nil {
+			return err
+		}
+
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+
+		var health struct {
+			Health string `json:"health"`
+		}
+		if err := json.Unmarshal(body, &health); err != nil {
+			return fmt.Errorf("status code %d, could not parse health %q: %v",
+				resp.StatusCode, body, err)
+		}
+
+		if health.Health != "true" {
+			return fmt.Errorf("etcd unhealthy: %s", body)
+		}
+
+		return nil
+	})
+}
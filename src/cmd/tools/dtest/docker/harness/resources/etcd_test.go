@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptEtcd(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	// NB: etcd takes a few polls to report itself healthy once resumed.
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"health":"%t"}`, atomic.AddInt32(&polls, 1) >= 3)
+	}))
+	defer server.Close()
+
+	require.NoError(t, interruptEtcd(resource, server.URL, 50*time.Millisecond, 5*time.Second))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+	assert.Equal(t, []string{"start dbnode01", "pause dbnode01", "unpause dbnode01"},
+		docker.recordedActions())
+	require.NoError(t, resource.close())
+}
+
+func TestWaitForEtcdTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"health":"false"}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := waitForEtcd(server.URL, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `etcd unhealthy: {"health":"false"}`)
+}
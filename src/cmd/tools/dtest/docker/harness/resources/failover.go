@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	aggregatorhttp "github.com/m3db/m3/src/aggregator/server/http"
+)
+
+// failoverFn fails over the aggregator leader with the given status URL, e.g.
+// by making it resign or by killing it.
+type failoverFn func(leaderStatusURL string) error
+
+// failoverCheckOptions configures a check of aggregation across a failover.
+type failoverCheckOptions struct {
+	// writeURL is the remote write URL samples are written to.
+	writeURL string
+	// queryURL is the instant query URL the rollup is queried from.
+	queryURL string
+	// statusURLs are the status URLs of the aggregator instances of the shard
+	// set the written series belongs to.
+	statusURLs []string
+	// sample is the sample written repeatedly, at the time of each write.
+	sample Sample
+	// writes is the number of times the sample is written, half of which are
+	// written before the failover is triggered.
+	writes int
+	// interval is the interval between writes.
+	interval time.Duration
+	// failover fails over the leader once half of the samples are written.
+	failover failoverFn
+	// query is the instant query returning the rollup of the written samples,
+	// which should be their sum.
+	query string
+	// tolerance is the largest difference allowed between the rollup and the
+	// sum of the written samples.
+	tolerance float64
+	// timeout bounds waiting for leaders and for the rollup.
+	timeout time.Duration
+}
+
+// rollupMismatchError is returned when the rollup of the samples written across
+// a failover differs from their sum by more than the tolerance.
+type rollupMismatchError struct {
+	expected  float64
+	actual    float64
+	tolerance float64
+}
+
+func (e rollupMismatchError) Error() string {
+	cause := "samples were dropped"
+	if e.actual > e.expected {
+		cause = "samples were double counted"
+	}
+
+	return fmt.Sprintf("rollup %v differs from expected %v by more than %v: %s",
+		e.actual, e.expected, e.tolerance, cause)
+}
+
+// verifyAggregationAcrossFailover writes a steady stream of samples, fails over
+// the aggregator leader halfway through while writes continue, then waits for
+// the rollup of the samples to equal their sum within the tolerance. This checks
+// that flush times are handed off correctly: the rollup exceeds the sum if the
+// old and new leaders both flush the same data, and falls short of it if
+// neither does. Returns a rollupMismatchError if the rollup never matches.
+func verifyAggregationAcrossFailover(opts failoverCheckOptions) error {
+	if opts.writes < 2 {
+		return fmt.Errorf("at least 2 writes are needed to straddle a failover, got %d",
+			opts.writes)
+	}
+
+	leader, err := waitForSingleLeader(opts.statusURLs, opts.timeout)
+	if err != nil {
+		return err
+	}
+
+	var (
+		failoverCh = make(chan error, 1)
+		expected   float64
+	)
+	for i := 0; i < opts.writes; i++ {
+		if i == opts.writes/2 {
+			go func() {
+				failoverCh <- failoverLeader(opts.statusURLs, leader, opts.failover, opts.timeout)
+			}()
+		}
+
+		err := writePromSamples(opts.writeURL, []TimedSample{{
+			Sample:    opts.sample,
+			Timestamp: time.Now(),
+		}})
+		if err != nil {
+			return err
+		}
+
+		expected += opts.sample.Value
+		time.Sleep(opts.interval)
+	}
+
+	if err := <-failoverCh; err != nil {
+		return err
+	}
+
+	return waitUntil(time.Now().Add(opts.timeout), func() error {
+		results, err := queryPromSamples(opts.queryURL, opts.query)
+		if err != nil {
+			return err
+		}
+
+		if len(results) != 1 {
+			return fmt.Errorf("expected a single rollup, got %v", results)
+		}
+
+		if math.Abs(results[0].Value-expected) > opts.tolerance {
+			return rollupMismatchError{
+				expected:  expected,
+				actual:    results[0].Value,
+				tolerance: opts.tolerance,
+			}
+		}
+
+		return nil
+	})
+}
+
+// failoverLeader fails over the given leader and then waits for another of the
+// instances to become the single leader.
+func failoverLeader(
+	statusURLs []string,
+	leader string,
+	failover failoverFn,
+	timeout time.Duration,
+) error {
+	if err := failover(leader); err != nil {
+		return fmt.Errorf("could not fail over leader %s: %v", leader, err)
+	}
+
+	return waitUntil(time.Now().Add(timeout), func() error {
+		newLeader, err := waitForSingleLeader(statusURLs, 0)
+		if err != nil {
+			return err
+		}
+
+		if newLeader == leader {
+			return fmt.Errorf("leader %s has not failed over", leader)
+		}
+
+		return nil
+	})
+}
+
+// resignFailover is a failoverFn making the leader resign through the resign
+// endpoint served alongside its status endpoint.
+func resignFailover(leaderStatusURL string) error {
+	url := strings.TrimSuffix(leaderStatusURL, aggregatorhttp.StatusPath) +
+		aggregatorhttp.ResignPath
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
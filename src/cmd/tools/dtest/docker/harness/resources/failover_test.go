@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	aggregatorhttp "github.com/m3db/m3/src/aggregator/server/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFailoverCluster is a pair of aggregator instances, one of which leads and
+// resigns to the other, behind a coordinator rolling up written samples into
+// their sum. Samples written after a failover are counted twice if
+// doubleCountAfterFailover is set, as when both leaders flush them.
+type fakeFailoverCluster struct {
+	sync.Mutex
+
+	leader                   int
+	failovers                int
+	sum                      float64
+	doubleCountAfterFailover bool
+
+	instances   []*httptest.Server
+	coordinator *httptest.Server
+}
+
+func newFakeFailoverCluster(t *testing.T, doubleCountAfterFailover bool) *fakeFailoverCluster {
+	c := &fakeFailoverCluster{doubleCountAfterFailover: doubleCountAfterFailover}
+	for i := 0; i < 2; i++ {
+		i := i
+		mux := http.NewServeMux()
+		mux.HandleFunc(aggregatorhttp.StatusPath, func(w http.ResponseWriter, r *http.Request) {
+			c.Lock()
+			defer c.Unlock()
+			state := "follower"
+			if c.leader == i {
+				state = "leader"
+			}
+			fmt.Fprintf(w, `{"status":{"flushStatus":{"electionState":%q,"canLead":true}}}`, state)
+		})
+		mux.HandleFunc(aggregatorhttp.ResignPath, func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, http.MethodPost, r.Method)
+			c.Lock()
+			defer c.Unlock()
+			if c.leader == i {
+				c.leader = 1 - i
+				c.failovers++
+			}
+		})
+		c.instances = append(c.instances, httptest.NewServer(mux))
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+promWritePath, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeWriteRequest(t, r)
+		c.Lock()
+		defer c.Unlock()
+		for _, series := range req.Timeseries {
+			for _, sample := range series.Samples {
+				c.sum += sample.Value
+				if c.doubleCountAfterFailover && c.failovers > 0 {
+					c.sum += sample.Value
+				}
+			}
+		}
+	})
+	mux.HandleFunc("/"+promQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		c.Lock()
+		defer c.Unlock()
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"__name__":"requests_total:rollup"},"value":[1,"%v"]}]}}`, c.sum)
+	})
+	c.coordinator = httptest.NewServer(mux)
+	return c
+}
+
+func (c *fakeFailoverCluster) close() {
+	for _, instance := range c.instances {
+		instance.Close()
+	}
+	c.coordinator.Close()
+}
+
+func (c *fakeFailoverCluster) options() failoverCheckOptions {
+	return failoverCheckOptions{
+		writeURL: c.coordinator.URL + "/" + promWritePath,
+		queryURL: c.coordinator.URL + "/" + promQueryPath,
+		statusURLs: []string{
+			c.instances[0].URL + aggregatorhttp.StatusPath,
+			c.instances[1].URL + aggregatorhttp.StatusPath,
+		},
+		sample:    Sample{Name: "requests_total", Value: 2},
+		writes:    10,
+		interval:  10 * time.Millisecond,
+		failover:  resignFailover,
+		query:     "requests_total:rollup",
+		tolerance: 0.5,
+		timeout:   time.Second,
+	}
+}
+
+func TestVerifyAggregationAcrossFailover(t *testing.T) {
+	cluster := newFakeFailoverCluster(t, false)
+	defer cluster.close()
+
+	require.NoError(t, verifyAggregationAcrossFailover(cluster.options()))
+
+	cluster.Lock()
+	defer cluster.Unlock()
+	assert.Equal(t, 1, cluster.leader)
+	assert.Equal(t, 1, cluster.failovers)
+	assert.Equal(t, float64(20), cluster.sum)
+}
+
+func TestVerifyAggregationAcrossFailoverDoubleCounted(t *testing.T) {
+	cluster := newFakeFailoverCluster(t, true)
+	defer cluster.close()
+
+	opts := cluster.options()
+	opts.timeout = 300 * time.Millisecond
+	err := verifyAggregationAcrossFailover(opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "differs from expected 20 by more than 0.5: "+
+		"samples were double counted")
+}
+
+func TestVerifyAggregationAcrossFailoverNoFailover(t *testing.T) {
+	cluster := newFakeFailoverCluster(t, false)
+	defer cluster.close()
+
+	opts := cluster.options()
+	opts.timeout = 300 * time.Millisecond
+	opts.failover = func(string) error { return nil }
+	err := verifyAggregationAcrossFailover(opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has not failed over")
+}
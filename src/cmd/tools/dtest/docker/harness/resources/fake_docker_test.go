@@ -0,0 +1,578 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/ory/dockertest"
+	dc "github.com/ory/dockertest/docker"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeContainer struct {
+	id             string
+	name           string
+	config         dc.Config
+	hostConfig     dc.HostConfig
+	networkAliases []string
+	running        bool
+	exitCode       int
+	ignoreTerm     bool
+	// files are the contents of the container filesystem by path, served by
+	// archive downloads and written by archive uploads.
+	files map[string]string
+}
+
+// fakeDocker is a minimal in-memory implementation of the docker remote API
+// endpoints used by the harness, allowing resources to be created without
+// a docker daemon.
+type fakeDocker struct {
+	sync.Mutex
+
+	server     *httptest.Server
+	nextID     int
+	builds     []string
+	pulls      []string
+	actions    []string
+	containers map[string]*fakeContainer
+	handlers   map[string]http.HandlerFunc
+	execs      map[string]fakeExec
+
+	// execFn returns the output of the given command run in the container.
+	execFn func(c *fakeContainer, cmd []string) (stdout, stderr string)
+
+	// logsFn returns the output of the container served by log requests.
+	logsFn func(c *fakeContainer) (stdout, stderr string)
+}
+
+type fakeExec struct {
+	container *fakeContainer
+	cmd       []string
+}
+
+func newFakeDocker() *fakeDocker {
+	d := &fakeDocker{
+		containers: make(map[string]*fakeContainer),
+		handlers:   make(map[string]http.HandlerFunc),
+		execs:      make(map[string]fakeExec),
+	}
+
+	d.server = httptest.NewServer(http.HandlerFunc(d.serveHTTP))
+	return d
+}
+
+func (d *fakeDocker) close() {
+	d.server.Close()
+}
+
+func (d *fakeDocker) pool(t *testing.T) *dockertest.Pool {
+	client, err := dc.NewClient(d.server.URL)
+	require.NoError(t, err)
+	return &dockertest.Pool{Client: client, MaxWait: time.Second}
+}
+
+// handle overrides the handler for the given method and path.
+func (d *fakeDocker) handle(method, path string, fn http.HandlerFunc) {
+	d.Lock()
+	d.handlers[method+" "+path] = fn
+	d.Unlock()
+}
+
+func (d *fakeDocker) container(nameOrID string) (*fakeContainer, bool) {
+	d.Lock()
+	defer d.Unlock()
+	return d.containerWithLock(nameOrID)
+}
+
+func (d *fakeDocker) containerWithLock(nameOrID string) (*fakeContainer, bool) {
+	nameOrID = strings.TrimPrefix(nameOrID, "/")
+	if c, ok := d.containers[nameOrID]; ok {
+		return c, true
+	}
+
+	for _, c := range d.containers {
+		if c.name == nameOrID {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+func (d *fakeDocker) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	d.Lock()
+	handler, ok := d.handlers[r.Method+" "+r.URL.Path]
+	d.Unlock()
+	if ok {
+		handler(w, r)
+		return
+	}
+
+	var (
+		path  = strings.Trim(r.URL.Path, "/")
+		parts = strings.Split(path, "/")
+	)
+
+	switch {
+	case r.Method == http.MethodPost && path == "build":
+		d.Lock()
+		d.builds = append(d.builds, r.URL.Query().Get("t"))
+		d.Unlock()
+	case r.Method == http.MethodPost && path == "images/create":
+		d.Lock()
+		d.pulls = append(d.pulls, r.URL.Query().Get("fromImage"))
+		d.Unlock()
+	case r.Method == http.MethodGet && parts[0] == "images":
+		writeJSON(w, dc.Image{ID: path})
+	case r.Method == http.MethodGet && path == "containers/json":
+		d.listContainers(w, r)
+	case r.Method == http.MethodPost && path == "containers/create":
+		d.createContainer(w, r)
+	case parts[0] == "containers" && len(parts) >= 2:
+		d.serveContainer(w, r, parts[1], parts[2:])
+	case r.Method == http.MethodPost && parts[0] == "exec" && len(parts) == 3 && parts[2] == "start":
+		d.startExec(w, r, parts[1])
+	case r.Method == http.MethodPost && parts[0] == "networks" && len(parts) == 3:
+		d.serveNetwork(w, r, parts[2])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (d *fakeDocker) listContainers(w http.ResponseWriter, r *http.Request) {
+	var filters map[string][]string
+	if f := r.URL.Query().Get("filters"); f != "" {
+		if err := json.Unmarshal([]byte(f), &filters); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	result := make([]dc.APIContainers, 0, len(d.containers))
+	for _, c := range d.containers {
+		if names, ok := filters["name"]; ok && !contains(names, c.name) {
+			continue
+		}
+
+		if networks, ok := filters["network"]; ok && !contains(networks, c.hostConfig.NetworkMode) {
+			continue
+		}
+
+		result = append(result, dc.APIContainers{
+			ID:     c.id,
+			Names:  []string{"/" + c.name},
+			Labels: c.config.Labels,
+		})
+	}
+
+	writeJSON(w, result)
+}
+
+func (d *fakeDocker) createContainer(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		dc.Config
+		HostConfig *dc.HostConfig `json:"HostConfig,omitempty"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.Lock()
+	d.nextID++
+	c := &fakeContainer{
+		id:     fmt.Sprintf("container%d", d.nextID),
+		name:   r.URL.Query().Get("name"),
+		config: req.Config,
+	}
+
+	if req.HostConfig != nil {
+		c.hostConfig = *req.HostConfig
+	}
+
+	d.containers[c.id] = c
+	d.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, dc.Container{ID: c.id})
+}
+
+func (d *fakeDocker) serveContainer(
+	w http.ResponseWriter,
+	r *http.Request,
+	nameOrID string,
+	action []string,
+) {
+	c, ok := d.container(nameOrID)
+	if !ok {
+		http.Error(w, "no such container", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && len(action) == 1 && action[0] == "json":
+		config := c.config
+		hostConfig := c.hostConfig
+		d.Lock()
+		state := dc.State{Running: c.running, ExitCode: c.exitCode}
+		d.Unlock()
+		writeJSON(w, dc.Container{
+			ID:         c.id,
+			Name:       "/" + c.name,
+			Config:     &config,
+			HostConfig: &hostConfig,
+			State:      state,
+		})
+	case r.Method == http.MethodGet && len(action) == 1 && action[0] == "logs":
+		d.streamLogs(w, r, c)
+	case r.Method == http.MethodGet && len(action) == 1 && action[0] == "archive":
+		d.downloadArchive(w, r, c)
+	case r.Method == http.MethodPut && len(action) == 1 && action[0] == "archive":
+		d.uploadArchive(w, r, c)
+	case r.Method == http.MethodDelete && len(action) == 0:
+		d.Lock()
+		delete(d.containers, c.id)
+		d.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && len(action) == 1 && action[0] == "exec":
+		d.createExec(w, r, c)
+	case r.Method == http.MethodPost && len(action) == 1 && action[0] == "wait":
+		d.waitContainer(w, r, c)
+	case r.Method == http.MethodPost && len(action) == 1:
+		d.containerAction(c, action[0], r.URL.Query().Get("signal"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// streamLogs writes the multiplexed output of the container and, when following,
+// holds the stream open until the client goes away, as the docker daemon does
+// for a running container.
+func (d *fakeDocker) streamLogs(
+	w http.ResponseWriter,
+	r *http.Request,
+	c *fakeContainer,
+) {
+	d.Lock()
+	logsFn := d.logsFn
+	d.Unlock()
+
+	var stdout, stderr string
+	if logsFn != nil {
+		stdout, stderr = logsFn(c)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	writeStreamFrame(w, 1, stdout)
+	writeStreamFrame(w, 2, stderr)
+	w.(http.Flusher).Flush()
+	if r.URL.Query().Get("follow") == "1" {
+		<-r.Context().Done()
+	}
+}
+
+// downloadArchive writes a tar archive of the container files under the
+// requested path, named relative to its parent as the docker daemon does.
+func (d *fakeDocker) downloadArchive(
+	w http.ResponseWriter,
+	r *http.Request,
+	c *fakeContainer,
+) {
+	root := path.Clean(r.URL.Query().Get("path"))
+	prefix := strings.TrimSuffix(path.Dir(root), "/") + "/"
+	d.Lock()
+	defer d.Unlock()
+	var names []string
+	for name := range c.files {
+		if name == root || strings.HasPrefix(name, root+"/") {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		http.Error(w, "no such file or directory", http.StatusNotFound)
+		return
+	}
+
+	sort.Strings(names)
+	tw := tar.NewWriter(w)
+	tw.WriteHeader(&tar.Header{
+		Name:     strings.TrimPrefix(root, prefix) + "/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+	})
+	for _, name := range names {
+		contents := c.files[name]
+		tw.WriteHeader(&tar.Header{
+			Name: strings.TrimPrefix(name, prefix),
+			Mode: 0644,
+			Size: int64(len(contents)),
+		})
+		io.WriteString(tw, contents)
+	}
+	tw.Close()
+}
+
+// uploadArchive extracts the files of the uploaded tar archive into the
+// requested directory, overwriting existing files.
+func (d *fakeDocker) uploadArchive(
+	w http.ResponseWriter,
+	r *http.Request,
+	c *fakeContainer,
+) {
+	dir := r.URL.Query().Get("path")
+	files := make(map[string]string)
+	reader := tar.NewReader(r.Body)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		contents, err := ioutil.ReadAll(reader)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		files[path.Join(dir, header.Name)] = string(contents)
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	if c.files == nil {
+		c.files = make(map[string]string)
+	}
+
+	for name, contents := range files {
+		c.files[name] = contents
+	}
+}
+
+func (d *fakeDocker) containerAction(c *fakeContainer, action, signal string) {
+	d.Lock()
+	defer d.Unlock()
+	d.actions = append(d.actions, action+" "+c.name)
+	switch action {
+	case "start", "restart":
+		c.running = true
+		c.exitCode = 0
+	case "kill", "stop":
+		if signal == strconv.Itoa(int(dc.SIGTERM)) {
+			if !c.ignoreTerm {
+				// NB: simulate the process draining and exiting cleanly.
+				c.running = false
+				c.exitCode = 0
+			}
+			return
+		}
+
+		c.running = false
+		c.exitCode = 137
+		switch c.hostConfig.RestartPolicy.Name {
+		case "always", "unless-stopped", "on-failure":
+			// NB: simulate the daemon immediately restarting the container.
+			c.running = true
+		}
+	}
+}
+
+func (d *fakeDocker) waitContainer(
+	w http.ResponseWriter,
+	r *http.Request,
+	c *fakeContainer,
+) {
+	for {
+		d.Lock()
+		running, exitCode := c.running, c.exitCode
+		d.Unlock()
+		if !running {
+			writeJSON(w, struct{ StatusCode int }{exitCode})
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (d *fakeDocker) createExec(
+	w http.ResponseWriter,
+	r *http.Request,
+	c *fakeContainer,
+) {
+	var opts dc.CreateExecOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.Lock()
+	d.nextID++
+	id := fmt.Sprintf("exec%d", d.nextID)
+	d.execs[id] = fakeExec{container: c, cmd: opts.Cmd}
+	d.actions = append(d.actions, "exec "+c.name+" "+strings.Join(opts.Cmd, " "))
+	d.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, dc.Exec{ID: id})
+}
+
+// startExec responds to an exec start by hijacking the connection and writing
+// the multiplexed output of the command, as the docker daemon does.
+func (d *fakeDocker) startExec(w http.ResponseWriter, r *http.Request, id string) {
+	d.Lock()
+	exec, ok := d.execs[id]
+	execFn := d.execFn
+	d.Unlock()
+	if !ok {
+		http.Error(w, "no such exec", http.StatusNotFound)
+		return
+	}
+
+	var stdout, stderr string
+	if execFn != nil {
+		stdout, stderr = execFn(exec.container, exec.cmd)
+	}
+
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	defer conn.Close()
+	buf.WriteString("HTTP/1.1 101 UPGRADED\r\n" +
+		"Content-Type: application/vnd.docker.raw-stream\r\n" +
+		"Connection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+	writeStreamFrame(buf, 1, stdout)
+	writeStreamFrame(buf, 2, stderr)
+	buf.Flush()
+}
+
+func writeStreamFrame(w io.Writer, stream byte, payload string) {
+	if len(payload) == 0 {
+		return
+	}
+
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	w.Write(header)
+	io.WriteString(w, payload)
+}
+
+// recordedBuilds returns the tags of the images built, in order.
+func (d *fakeDocker) recordedBuilds() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.builds...)
+}
+
+func (d *fakeDocker) recordedActions() []string {
+	d.Lock()
+	defer d.Unlock()
+	return append([]string(nil), d.actions...)
+}
+
+func (d *fakeDocker) serveNetwork(
+	w http.ResponseWriter,
+	r *http.Request,
+	action string,
+) {
+	var opts dc.NetworkConnectionOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, ok := d.container(opts.Container)
+	if !ok {
+		http.Error(w, "no such container", http.StatusNotFound)
+		return
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	switch action {
+	case "connect":
+		if opts.EndpointConfig != nil {
+			c.networkAliases = opts.EndpointConfig.Aliases
+		}
+	case "disconnect":
+		c.networkAliases = nil
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func testResourceOptions(name string) dockerResourceOptions {
+	return dockerResourceOptions{
+		source:        "test",
+		containerName: name,
+		dockerFile:    "/tmp/test.Dockerfile",
+		iOpts:         instrument.NewOptions(),
+	}
+}
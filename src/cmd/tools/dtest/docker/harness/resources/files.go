@@ -0,0 +1,336 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	dc "github.com/ory/dockertest/docker"
+	"go.uber.org/zap"
+)
+
+// fileExistsScript exits quietly if the file given as its first argument
+// exists in the container, reporting it as missing on stderr otherwise. The
+// path is passed as a positional argument to avoid quoting issues.
+const fileExistsScript = `test -e "$1" || echo "no such file: $1" >&2`
+
+// fillDiskScript writes as many zero bytes as given by its second argument to
+// the file given as its first argument. Running out of space part way through
+// is expected when filling a disk, so this only fails if nothing was written.
+const fillDiskScript = `head -c "$2" /dev/zero > "$1" 2>/dev/null || ` +
+	`test -s "$1" || echo "could not fill disk at $1" >&2`
+
+// orphanedFilePatterns match the base names of files that should not outlive
+// a clean shutdown, namely lock files and partially written files.
+var orphanedFilePatterns = []string{"*.lock", "*.tmp", "*.partial"}
+
+// orphanedFilesError is returned when files matching orphanedFilePatterns
+// remain in a directory after a clean shutdown.
+type orphanedFilesError struct {
+	paths []string
+}
+
+func (e orphanedFilesError) Error() string {
+	return fmt.Sprintf("%d orphaned file(s) left after shutdown: %s",
+		len(e.paths), strings.Join(e.paths, ", "))
+}
+
+// readFile returns the contents of the file at the given path in the
+// container.
+func (c *dockerResource) readFile(path string) ([]byte, error) {
+	if c.closed {
+		return nil, errClosed
+	}
+
+	logger := c.logger.With(zapMethod("readFile"), zap.String("path", path))
+	output, err := c.exec("cat", path)
+	if err != nil {
+		logger.Error("could not read file", zap.Error(err))
+		return nil, err
+	}
+
+	return []byte(output), nil
+}
+
+// assertFileExists returns an error if there is no file at the given path in
+// the container.
+func (c *dockerResource) assertFileExists(path string) error {
+	if c.closed {
+		return errClosed
+	}
+
+	logger := c.logger.With(zapMethod("assertFileExists"),
+		zap.String("path", path))
+	if _, err := c.exec("sh", "-c", fileExistsScript, "sh", path); err != nil {
+		logger.Error("file does not exist", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// fillDisk consumes space on the disk the given path in the container is on by
+// writing a file of the given size there, or as large as fits on the disk. This
+// allows verifying how components degrade once their disk fills up, and should
+// be undone with clearDiskFill.
+func (c *dockerResource) fillDisk(path string, bytes int64) error {
+	if c.closed {
+		return errClosed
+	}
+
+	logger := c.logger.With(zapMethod("fillDisk"), zap.String("path", path),
+		zap.Int64("bytes", bytes))
+	if _, err := c.exec("sh", "-c", fillDiskScript, "sh", path,
+		strconv.FormatInt(bytes, 10)); err != nil {
+		logger.Error("could not fill disk", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// clearDiskFill removes the file written at the given path in the container by
+// fillDisk, releasing the space it consumed.
+func (c *dockerResource) clearDiskFill(path string) error {
+	if c.closed {
+		return errClosed
+	}
+
+	logger := c.logger.With(zapMethod("clearDiskFill"),
+		zap.String("path", path))
+	if _, err := c.exec("rm", "-f", path); err != nil {
+		logger.Error("could not clear disk fill", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// copyOut writes a tar archive of the file or directory at the given path in
+// the container to the given writer. Unlike exec, this works once the
+// container has stopped.
+func (c *dockerResource) copyOut(path string, w io.Writer) error {
+	if c.closed {
+		return errClosed
+	}
+
+	logger := c.logger.With(zapMethod("copyOut"), zap.String("path", path))
+	if err := c.pool.Client.DownloadFromContainer(c.resource.Container.ID,
+		dc.DownloadFromContainerOptions{
+			Path:         path,
+			OutputStream: w,
+		}); err != nil {
+		logger.Error("could not copy out of container", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// writeFile writes the given contents to the file at the given path in the
+// container, replacing the file if it exists. Like copyOut, this works once the
+// container has stopped.
+func (c *dockerResource) writeFile(filePath string, contents []byte) error {
+	if c.closed {
+		return errClosed
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{
+		Name: path.Base(filePath),
+		Mode: 0644,
+		Size: int64(len(contents)),
+	}); err != nil {
+		return err
+	}
+
+	if _, err := tw.Write(contents); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	logger := c.logger.With(zapMethod("writeFile"), zap.String("path", filePath))
+	if err := c.pool.Client.UploadToContainer(c.resource.Container.ID,
+		dc.UploadToContainerOptions{
+			Path:        path.Dir(filePath),
+			InputStream: &buf,
+		}); err != nil {
+		logger.Error("could not write file to container", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// snapshotVolume archives the data directory of the container under the given
+// name, replacing any snapshot of the same name, so that it can be restored by
+// restoreVolume to reset the data between test phases without recreating the
+// container. Snapshots are held in memory, and should be taken while the
+// container is stopped for them to be consistent.
+func (c *dockerResource) snapshotVolume(name string) error {
+	if c.closed {
+		return errClosed
+	}
+
+	if c.dataDirTarget == "" {
+		return errNoDataDir
+	}
+
+	var buf bytes.Buffer
+	if err := c.copyOut(c.dataDirTarget, &buf); err != nil {
+		return err
+	}
+
+	if c.snapshots == nil {
+		c.snapshots = make(map[string][]byte)
+	}
+
+	c.snapshots[name] = buf.Bytes()
+	c.logger.Info("snapshotted data dir", zapMethod("snapshotVolume"),
+		zap.String("name", name), zap.Int("bytes", buf.Len()))
+	return nil
+}
+
+// restoreVolume restores the data directory of the container to the snapshot
+// taken by snapshotVolume under the given name, removing files written since.
+// The container should be stopped while restoring and started afterwards.
+func (c *dockerResource) restoreVolume(name string) error {
+	if c.closed {
+		return errClosed
+	}
+
+	snapshot, ok := c.snapshots[name]
+	if !ok {
+		return fmt.Errorf("no data dir snapshot named %q", name)
+	}
+
+	logger := c.logger.With(zapMethod("restoreVolume"), zap.String("name", name))
+	// NB: uploading an archive only adds and overwrites files, so the data dir is
+	// emptied first through its bind mount.
+	if err := clearDir(c.dataDir); err != nil {
+		logger.Error("could not clear data dir", zap.Error(err))
+		return err
+	}
+
+	// NB: archived entries are relative to the parent of the data dir.
+	if err := c.pool.Client.UploadToContainer(c.resource.Container.ID,
+		dc.UploadToContainerOptions{
+			Path:        path.Dir(path.Clean(c.dataDirTarget)),
+			InputStream: bytes.NewReader(snapshot),
+		}); err != nil {
+		logger.Error("could not restore data dir", zap.Error(err))
+		return err
+	}
+
+	logger.Info("restored data dir")
+	return nil
+}
+
+// clearDir removes the contents of the given directory, leaving it empty.
+func clearDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// assertNoOrphanedFiles copies out the given directory of the container and
+// returns an orphanedFilesError naming any lock or partially written files
+// left in it. This is intended to run once the container has been stopped
+// gracefully, to catch regressions in cleanup on shutdown.
+func (c *dockerResource) assertNoOrphanedFiles(dir string) error {
+	if c.closed {
+		return errClosed
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(c.copyOut(dir, pw))
+	}()
+	defer pr.Close()
+
+	var (
+		// NB: archived entries are relative to the parent of the directory.
+		parent   = path.Dir(path.Clean(dir))
+		orphaned []string
+		reader   = tar.NewReader(pr)
+	)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+
+		if isOrphanedFile(header.Name) {
+			orphaned = append(orphaned, path.Join(parent, header.Name))
+		}
+	}
+
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	sort.Strings(orphaned)
+	c.logger.Error("orphaned files left after shutdown",
+		zapMethod("assertNoOrphanedFiles"), zap.Strings("paths", orphaned))
+	return orphanedFilesError{paths: orphaned}
+}
+
+func isOrphanedFile(name string) bool {
+	base := path.Base(name)
+	for _, pattern := range orphanedFilePatterns {
+		if matched, _ := path.Match(pattern, base); matched {
+			return true
+		}
+	}
+
+	return false
+}
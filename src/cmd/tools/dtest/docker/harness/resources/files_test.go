@@ -0,0 +1,217 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// filesExec simulates a container holding the given files, serving cat and
+// the file existence check.
+func filesExec(files map[string]string) func(c *fakeContainer, cmd []string) (string, string) {
+	return func(c *fakeContainer, cmd []string) (string, string) {
+		switch {
+		case len(cmd) == 2 && cmd[0] == "cat":
+			contents, ok := files[cmd[1]]
+			if !ok {
+				return "", "cat: " + cmd[1] + ": No such file or directory\n"
+			}
+
+			return contents, ""
+		case len(cmd) == 5 && cmd[0] == "sh" && cmd[2] == fileExistsScript:
+			if _, ok := files[cmd[4]]; !ok {
+				return "", "no such file: " + cmd[4] + "\n"
+			}
+
+			return "", ""
+		case len(cmd) == 6 && cmd[0] == "sh" && cmd[2] == fillDiskScript:
+			size, err := strconv.Atoi(cmd[5])
+			if err != nil || size == 0 {
+				return "", "could not fill disk at " + cmd[4] + "\n"
+			}
+
+			files[cmd[4]] = strings.Repeat("\x00", size)
+			return "", ""
+		case len(cmd) == 3 && cmd[0] == "rm" && cmd[1] == "-f":
+			delete(files, cmd[2])
+			return "", ""
+		}
+
+		return "", "unknown command"
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	docker.execFn = filesExec(map[string]string{
+		"/var/lib/m3db/commitlogs/commitlog-0-0.db": "commitlog",
+	})
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	contents, err := resource.readFile("/var/lib/m3db/commitlogs/commitlog-0-0.db")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("commitlog"), contents)
+
+	_, err = resource.readFile("/var/lib/m3db/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No such file")
+
+	require.NoError(t, resource.assertFileExists("/var/lib/m3db/commitlogs/commitlog-0-0.db"))
+	err = resource.assertFileExists("/var/lib/m3db/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file: /var/lib/m3db/missing")
+
+	require.NoError(t, resource.close())
+
+	_, err = resource.readFile("/var/lib/m3db/commitlogs/commitlog-0-0.db")
+	assert.Equal(t, errClosed, err)
+	assert.Equal(t, errClosed, resource.assertFileExists("/var/lib/m3db/missing"))
+}
+
+func TestFillDisk(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	files := make(map[string]string)
+	docker.execFn = filesExec(files)
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	const path = "/var/lib/m3db/fill"
+	require.NoError(t, resource.fillDisk(path, 1024))
+	require.NoError(t, resource.assertFileExists(path))
+	assert.Len(t, files[path], 1024)
+
+	err = resource.fillDisk("/var/lib/m3db/empty", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "could not fill disk at /var/lib/m3db/empty")
+
+	require.NoError(t, resource.clearDiskFill(path))
+	assert.Error(t, resource.assertFileExists(path))
+	require.NoError(t, resource.close())
+
+	assert.Equal(t, errClosed, resource.fillDisk(path, 1024))
+	assert.Equal(t, errClosed, resource.clearDiskFill(path))
+}
+
+func TestAssertNoOrphanedFiles(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+
+	_, err = resource.stopGracefully(time.Second)
+	require.NoError(t, err)
+
+	// A clean shutdown leaves only completed files behind.
+	docker.Lock()
+	c.files = map[string]string{
+		"/var/lib/m3db/commitlogs/commitlog-0-0.db":        "commitlog",
+		"/var/lib/m3db/data/default/0/fileset-0-0-data.db": "data",
+		"/var/lib/m3db-other/leftover.lock":                "",
+	}
+	docker.Unlock()
+	require.NoError(t, resource.assertNoOrphanedFiles("/var/lib/m3db"))
+
+	// Lock files and partial writes left behind are reported.
+	docker.Lock()
+	c.files["/var/lib/m3db/data/default/0/fileset-1-0-data.db.tmp"] = "partial"
+	c.files["/var/lib/m3db/m3db.lock"] = ""
+	docker.Unlock()
+	err = resource.assertNoOrphanedFiles("/var/lib/m3db/")
+	require.Error(t, err)
+	assert.Equal(t, orphanedFilesError{paths: []string{
+		"/var/lib/m3db/data/default/0/fileset-1-0-data.db.tmp",
+		"/var/lib/m3db/m3db.lock",
+	}}, err)
+
+	require.Error(t, resource.assertNoOrphanedFiles("/var/lib/missing"))
+	require.NoError(t, resource.close())
+	assert.Equal(t, errClosed, resource.assertNoOrphanedFiles("/var/lib/m3db"))
+}
+
+func TestSnapshotAndRestoreVolume(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	opts := testResourceOptions("dbnode01")
+	opts.dataDir = "/var/lib/m3db"
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+	defer resource.close()
+	c, ok := docker.container("dbnode01")
+	require.True(t, ok)
+
+	docker.Lock()
+	c.files = map[string]string{
+		"/var/lib/m3db/data/default/0/fileset-0-0-data.db": "known good",
+		"/var/lib/m3db/commitlogs/commitlog-0-0.db":        "commitlog",
+	}
+	docker.Unlock()
+	require.NoError(t, resource.snapshotVolume("phase1"))
+
+	// Mutate a file, and write another one through the bind mount.
+	docker.Lock()
+	c.files["/var/lib/m3db/data/default/0/fileset-0-0-data.db"] = "mutated"
+	docker.Unlock()
+	written := filepath.Join(resource.dataDir, "fileset-1-0-data.db")
+	require.NoError(t, ioutil.WriteFile(written, []byte("written"), 0644))
+
+	require.NoError(t, resource.restoreVolume("phase1"))
+	docker.Lock()
+	assert.Equal(t, map[string]string{
+		"/var/lib/m3db/data/default/0/fileset-0-0-data.db": "known good",
+		"/var/lib/m3db/commitlogs/commitlog-0-0.db":        "commitlog",
+	}, c.files)
+	docker.Unlock()
+	_, err = os.Stat(written)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, resource.restoreVolume("phase2"))
+	require.NoError(t, resource.close())
+	assert.Equal(t, errClosed, resource.snapshotVolume("phase1"))
+	assert.Equal(t, errClosed, resource.restoreVolume("phase1"))
+}
+
+func TestSnapshotVolumeNoDataDir(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	resource, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	defer resource.close()
+	assert.Equal(t, errNoDataDir, resource.snapshotVolume("phase1"))
+}
@@ -50,6 +50,9 @@ const (
 type DockerResources interface {
 	// Cleanup closes and removes all corresponding containers.
 	Cleanup() error
+	// VerifyNoLeaks returns an error naming any harness containers that remain
+	// on the test network, and should be called after Cleanup.
+	VerifyNoLeaks() error
 	// Nodes returns all node resources.
 	Nodes() Nodes
 	// Coordinator returns the coordinator resource.
@@ -60,7 +63,8 @@ type dockerResources struct {
 	coordinator Coordinator
 	nodes       Nodes
 
-	pool *dockertest.Pool
+	networkName string
+	pool        *dockertest.Pool
 }
 
 // SetupSingleM3DBNode creates docker resources representing a setup with a
@@ -77,20 +81,29 @@ func SetupSingleM3DBNode(opts ...SetupOptions) (DockerResources, error) { // nol
 	}
 
 	pool.MaxWait = timeout
-	err = setupNetwork(pool)
+	var (
+		networkName = networkNameOrDefault(options.networkName)
+		volumeName  = volumeNameOrDefault(options.volumeName)
+	)
+	err = setupNetwork(pool, networkName)
 	if err != nil {
 		return nil, err
 	}
 
-	err = setupVolume(pool)
+	err = setupVolume(pool, volumeName)
 	if err != nil {
 		return nil, err
 	}
 
 	iOpts := instrument.NewOptions()
 	dbNode, err := newDockerHTTPNode(pool, dockerResourceOptions{
-		image: options.dbNodeImage,
-		iOpts: iOpts,
+		image:          options.dbNodeImage,
+		dockerFileVars: options.dockerFileVars,
+		labels:         options.dbNodeLabels,
+		networkName:    networkName,
+		volumeName:     volumeName,
+		logOutput:      options.containerLogs,
+		iOpts:          iOpts,
 	})
 
 	success := false
@@ -112,8 +125,13 @@ func SetupSingleM3DBNode(opts ...SetupOptions) (DockerResources, error) { // nol
 	}
 
 	coordinator, err := newDockerHTTPCoordinator(pool, dockerResourceOptions{
-		image: options.coordinatorImage,
-		iOpts: iOpts,
+		image:          options.coordinatorImage,
+		dockerFileVars: options.dockerFileVars,
+		labels:         options.coordinatorLabels,
+		networkName:    networkName,
+		volumeName:     volumeName,
+		logOutput:      options.containerLogs,
+		iOpts:          iOpts,
 	})
 
 	defer func() {
@@ -231,7 +249,8 @@ func SetupSingleM3DBNode(opts ...SetupOptions) (DockerResources, error) { // nol
 		coordinator: coordinator,
 		nodes:       dbNodes,
 
-		pool: pool,
+		networkName: networkName,
+		pool:        pool,
 	}, err
 }
 
@@ -254,5 +273,43 @@ func (r *dockerResources) Cleanup() error {
 	return multiErr.FinalError()
 }
 
+// labeledResource is a resource which may be targeted by label.
+type labeledResource interface {
+	hasLabel(key, value string) bool
+}
+
+// purgeByLabel closes and removes only the resources labeled with the given
+// key and value, leaving the others running. Purged resources are no longer
+// returned by Nodes or Coordinator.
+func (r *dockerResources) purgeByLabel(key, value string) error {
+	matches := func(resource interface{}) bool {
+		labeled, ok := resource.(labeledResource)
+		return ok && labeled.hasLabel(key, value)
+	}
+
+	var multiErr xerrors.MultiError
+	if r.coordinator != nil && matches(r.coordinator) {
+		multiErr = multiErr.Add(r.coordinator.Close())
+		r.coordinator = nil
+	}
+
+	nodes := r.nodes[:0]
+	for _, dbNode := range r.nodes {
+		if dbNode != nil && matches(dbNode) {
+			multiErr = multiErr.Add(dbNode.Close())
+			continue
+		}
+
+		nodes = append(nodes, dbNode)
+	}
+
+	r.nodes = nodes
+	return multiErr.FinalError()
+}
+
+func (r *dockerResources) VerifyNoLeaks() error {
+	return verifyNoLeakedContainers(r.pool, r.networkName)
+}
+
 func (r *dockerResources) Nodes() Nodes             { return r.nodes }
 func (r *dockerResources) Coordinator() Coordinator { return r.coordinator }
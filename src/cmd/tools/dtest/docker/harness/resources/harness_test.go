@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerResourcesPurgeByLabel(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	pool := docker.pool(t)
+
+	newLabeled := func(name, suite string) *dockerResource {
+		opts := testResourceOptions(name)
+		opts.labels = map[string]string{"suite": suite}
+		resource, err := newDockerResource(pool, opts)
+		require.NoError(t, err)
+		return resource
+	}
+
+	var (
+		dbNode01 = &dbNode{resource: newLabeled("dbnode01", "a")}
+		dbNode02 = &dbNode{resource: newLabeled("dbnode02", "b")}
+		coord    = &coordinator{resource: newLabeled("coord01", "a")}
+		r        = &dockerResources{
+			coordinator: coord,
+			nodes:       Nodes{dbNode01, dbNode02},
+			pool:        pool,
+		}
+	)
+
+	// Labels are set on the containers themselves.
+	container, ok := docker.container("dbnode02")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"suite": "b"}, container.config.Labels)
+
+	// Nothing matches an unknown label.
+	require.NoError(t, r.purgeByLabel("suite", "c"))
+	assert.Equal(t, Nodes{dbNode01, dbNode02}, r.Nodes())
+	assert.Equal(t, Coordinator(coord), r.Coordinator())
+
+	require.NoError(t, r.purgeByLabel("suite", "a"))
+	assert.Equal(t, Nodes{dbNode02}, r.Nodes())
+	assert.Nil(t, r.Coordinator())
+	assert.True(t, dbNode01.resource.closed)
+	assert.True(t, coord.resource.closed)
+	assert.False(t, dbNode02.resource.closed)
+	for _, name := range []string{"dbnode01", "coord01"} {
+		_, ok := docker.container(name)
+		assert.False(t, ok, name)
+	}
+
+	// Cleanup only closes the remaining resources.
+	require.NoError(t, r.Cleanup())
+	assert.True(t, dbNode02.resource.closed)
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+
+	"go.uber.org/zap"
+)
+
+// dbNodeHTTPPort is the port of the dbnode HTTP JSON API serving /health.
+const dbNodeHTTPPort = 9002
+
+// healthBackoff is an exponential backoff between health checks, starting at
+// the initial backoff and growing by the factor up to the max backoff.
+type healthBackoff struct {
+	initial time.Duration
+	factor  float64
+	max     time.Duration
+}
+
+var defaultHealthBackoff = healthBackoff{
+	initial: 100 * time.Millisecond,
+	factor:  2,
+	max:     5 * time.Second,
+}
+
+// healthBackoffOrDefault returns the given backoff, or the default backoff if
+// none is given.
+func healthBackoffOrDefault(b healthBackoff) healthBackoff {
+	if b == (healthBackoff{}) {
+		return defaultHealthBackoff
+	}
+
+	return b
+}
+
+// next returns the backoff to wait after the given backoff.
+func (b healthBackoff) next(backoff time.Duration) time.Duration {
+	next := time.Duration(float64(backoff) * b.factor)
+	if next < backoff {
+		// NB: a factor below one would otherwise shrink the backoff.
+		next = backoff
+	}
+
+	if b.max > 0 && next > b.max {
+		next = b.max
+	}
+
+	return next
+}
+
+// healthCheckError is returned when a container does not become healthy
+// before the timeout, describing the last response observed.
+type healthCheckError struct {
+	url            string
+	attempts       int
+	lastStatusCode int
+	lastErr        error
+}
+
+func (e healthCheckError) Error() string {
+	status := "no response"
+	if e.lastStatusCode != 0 {
+		status = fmt.Sprintf("last status code %d", e.lastStatusCode)
+	}
+
+	if e.lastErr != nil {
+		return fmt.Sprintf("%s not healthy after %d attempts, %s, last error: %v",
+			e.url, e.attempts, status, e.lastErr)
+	}
+
+	return fmt.Sprintf("%s not healthy after %d attempts, %s",
+		e.url, e.attempts, status)
+}
+
+// waitForHealthy polls the given path on the given port of the container with
+// an exponential backoff until it responds with a 2xx status code, returning a
+// healthCheckError if it does not before the timeout. This allows blocking
+// until a container is serving rather than sleeping for an arbitrary duration.
+func (c *dockerResource) waitForHealthy(port int, path string, timeout time.Duration) error {
+	if c.closed {
+		return errClosed
+	}
+
+	return waitForHealthy(c.getURL(port, path), timeout, c.healthBackoff,
+		c.logger.With(zapMethod("waitForHealthy")))
+}
+
+// waitForHealthy polls the given URL with the given backoff until it responds
+// with a 2xx status code or the timeout fires, logging each attempt.
+func waitForHealthy(
+	url string,
+	timeout time.Duration,
+	backoff healthBackoff,
+	logger *zap.Logger,
+) error {
+	var (
+		deadline = time.Now().Add(timeout)
+		wait     = backoff.initial
+		checkErr = healthCheckError{url: url}
+	)
+	logger = logger.With(zap.String("url", url))
+	for {
+		checkErr.attempts++
+		statusCode, err := checkHealth(url)
+		if statusCode != 0 {
+			checkErr.lastStatusCode = statusCode
+		}
+
+		checkErr.lastErr = err
+		if err == nil && statusCode/100 == 2 {
+			logger.Info("healthy", zap.Int("attempt", checkErr.attempts))
+			return nil
+		}
+
+		logger.Info("not yet healthy", zap.Int("attempt", checkErr.attempts),
+			zap.Int("statusCode", statusCode), zap.Error(err))
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			logger.Error("not healthy before timeout", zap.Error(checkErr))
+			return checkErr
+		}
+
+		if wait <= 0 {
+			wait = pollInterval
+		}
+
+		if wait > remaining {
+			wait = remaining
+		}
+
+		time.Sleep(wait)
+		wait = backoff.next(wait)
+	}
+}
+
+// checkHealth returns the status code the given URL responds with.
+func checkHealth(url string) (int, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	// NB: drain the body so the connection can be reused.
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// waitForHTTPBootstrapped waits until the dbnode reports being bootstrapped on
+// its HTTP health endpoint, or until the timeout.
+func (c *dbNode) waitForHTTPBootstrapped(timeout time.Duration) error {
+	if c.resource.closed {
+		return errClosed
+	}
+
+	logger := c.resource.logger.With(zapMethod("waitForHTTPBootstrapped"))
+	url := c.resource.getURL(dbNodeHTTPPort, "health")
+	if err := waitForBootstrapped(url, timeout); err != nil {
+		logger.Error("node did not bootstrap", zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// waitForBootstrapped polls the dbnode health endpoint at the given URL until
+// its bootstrapped field is true. Unlike waiting for any 2xx response, this
+// waits for bootstrapping to complete rather than for the node to be serving.
+// If the node does not bootstrap before the timeout, the returned error holds
+// the last observed health body.
+func waitForBootstrapped(url string, timeout time.Duration) error {
+	return waitUntil(time.Now().Add(timeout), func() error {
+		health, body, err := fetchHealth(url)
+		if err != nil {
+			return err
+		}
+
+		if !health.Bootstrapped {
+			return fmt.Errorf("not bootstrapped, last health: %s", body)
+		}
+
+		return nil
+	})
+}
+
+// fetchHealth returns the health reported by the dbnode health endpoint at the
+// given URL, along with the raw body.
+func fetchHealth(url string) (rpc.NodeHealthResult_, string, error) {
+	var health rpc.NodeHealthResult_
+	resp, err := http.Get(url)
+	if err != nil {
+		return health, "", err
+	}
+
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return health, "", err
+	}
+
+	body := strings.TrimSpace(string(b))
+	if resp.StatusCode/100 != 2 {
+		return health, body, fmt.Errorf("status code %d, last health: %s",
+			resp.StatusCode, body)
+	}
+
+	if err := json.Unmarshal(b, &health); err != nil {
+		return health, body, err
+	}
+
+	return health, body, nil
+}
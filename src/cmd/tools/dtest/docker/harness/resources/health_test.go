@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newFakeHealthServer serves the dbnode health endpoint, reporting the
+// bootstrapped state returned by the given function.
+func newFakeHealthServer(bootstrapped func() bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"ok":true,"status":"up","bootstrapped":%t}`, bootstrapped())
+	}))
+}
+
+func TestWaitForBootstrapped(t *testing.T) {
+	var polls int32
+	server := newFakeHealthServer(func() bool {
+		return atomic.AddInt32(&polls, 1) >= 3
+	})
+	defer server.Close()
+
+	require.NoError(t, waitForBootstrapped(server.URL, 5*time.Second))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&polls))
+}
+
+func TestWaitForBootstrappedTimeout(t *testing.T) {
+	server := newFakeHealthServer(func() bool { return false })
+	defer server.Close()
+
+	err := waitForBootstrapped(server.URL, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Contains(t, err.Error(), `{"ok":true,"status":"up","bootstrapped":false}`)
+}
+
+func TestWaitForBootstrappedNotOK(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := waitForBootstrapped(server.URL, 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code 503, last health: starting")
+}
+
+func TestWaitForHealthy(t *testing.T) {
+	var (
+		polls    int32
+		lastPoll time.Time
+		gaps     []time.Duration
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		if !lastPoll.IsZero() {
+			gaps = append(gaps, now.Sub(lastPoll))
+		}
+
+		lastPoll = now
+		if atomic.AddInt32(&polls, 1) < 4 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	backoff := healthBackoff{initial: 10 * time.Millisecond, factor: 2, max: time.Second}
+	require.NoError(t, waitForHealthy(server.URL, 5*time.Second, backoff, zap.NewNop()))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&polls))
+
+	// Each check backs off for longer than the previous one.
+	require.Equal(t, 3, len(gaps))
+	assert.True(t, gaps[0] >= 10*time.Millisecond, "gap %v", gaps[0])
+	assert.True(t, gaps[1] >= 20*time.Millisecond, "gap %v", gaps[1])
+	assert.True(t, gaps[2] >= 40*time.Millisecond, "gap %v", gaps[2])
+}
+
+func TestWaitForHealthyTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	backoff := healthBackoff{initial: 10 * time.Millisecond, factor: 2, max: 50 * time.Millisecond}
+	err := waitForHealthy(server.URL, 200*time.Millisecond, backoff, zap.NewNop())
+	require.Error(t, err)
+	checkErr, ok := err.(healthCheckError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusServiceUnavailable, checkErr.lastStatusCode)
+	assert.True(t, checkErr.attempts > 1)
+	assert.Contains(t, err.Error(), server.URL)
+	assert.Contains(t, err.Error(), "last status code 503")
+}
+
+func TestWaitForHealthyNoResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	err := waitForHealthy(url, 50*time.Millisecond, defaultHealthBackoff, zap.NewNop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no response")
+	assert.Contains(t, err.Error(), "last error")
+}
+
+func TestHealthBackoffNext(t *testing.T) {
+	backoff := healthBackoff{initial: 100 * time.Millisecond, factor: 2, max: 300 * time.Millisecond}
+	assert.Equal(t, 200*time.Millisecond, backoff.next(100*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, backoff.next(200*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, backoff.next(300*time.Millisecond))
+	assert.Equal(t, defaultHealthBackoff, healthBackoffOrDefault(healthBackoff{}))
+	assert.Equal(t, backoff, healthBackoffOrDefault(backoff))
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// ContainerInfo describes a container created by the harness.
+type ContainerInfo struct {
+	// Name is the name of the container.
+	Name string
+	// ID is the docker ID of the container.
+	ID string
+}
+
+// containerRegistry records the containers created by the harness which have
+// not yet been closed.
+type containerRegistry struct {
+	sync.RWMutex
+
+	containers map[string]registeredContainer
+}
+
+type registeredContainer struct {
+	info     ContainerInfo
+	resource *dockerResource
+}
+
+var registry = newContainerRegistry()
+
+func newContainerRegistry() *containerRegistry {
+	return &containerRegistry{containers: make(map[string]registeredContainer)}
+}
+
+func (r *containerRegistry) add(c *dockerResource) {
+	info := ContainerInfo{
+		// NB: this is prefixed with a `/` that should be trimmed off.
+		Name: strings.TrimLeft(c.resource.Container.Name, "/"),
+		ID:   c.resource.Container.ID,
+	}
+
+	r.Lock()
+	r.containers[info.ID] = registeredContainer{info: info, resource: c}
+	r.Unlock()
+}
+
+func (r *containerRegistry) remove(c *dockerResource) {
+	r.Lock()
+	delete(r.containers, c.resource.Container.ID)
+	r.Unlock()
+}
+
+func (r *containerRegistry) inventory() []ContainerInfo {
+	r.RLock()
+	inventory := make([]ContainerInfo, 0, len(r.containers))
+	for _, c := range r.containers {
+		inventory = append(inventory, c.info)
+	}
+	r.RUnlock()
+
+	sort.Slice(inventory, func(i, j int) bool {
+		if inventory[i].Name != inventory[j].Name {
+			return inventory[i].Name < inventory[j].Name
+		}
+
+		return inventory[i].ID < inventory[j].ID
+	})
+
+	return inventory
+}
+
+// purgeAll forcibly removes all containers which have not yet been closed,
+// returning the containers that were removed ordered by name.
+func (r *containerRegistry) purgeAll() ([]ContainerInfo, error) {
+	r.Lock()
+	containers := r.containers
+	r.containers = make(map[string]registeredContainer)
+	r.Unlock()
+
+	var (
+		purged   = make([]ContainerInfo, 0, len(containers))
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, c := range containers {
+		if err := c.resource.pool.Purge(c.resource.resource); err != nil {
+			multiErr = multiErr.Add(fmt.Errorf("could not purge %s: %v", c.info.Name, err))
+			continue
+		}
+
+		purged = append(purged, c.info)
+	}
+
+	sort.Slice(purged, func(i, j int) bool {
+		return purged[i].Name < purged[j].Name
+	})
+
+	return purged, multiErr.FinalError()
+}
+
+// Inventory returns the containers created by the harness which have not yet
+// been closed, ordered by name. This is useful to report on the containers of
+// a failed run.
+func Inventory() []ContainerInfo {
+	return registry.inventory()
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory(t *testing.T) {
+	defer func(r *containerRegistry) { registry = r }(registry)
+	registry = newContainerRegistry()
+
+	docker := newFakeDocker()
+	defer docker.close()
+
+	pool := docker.pool(t)
+	coordinator, err := newDockerResource(pool, testResourceOptions("coord01"))
+	require.NoError(t, err)
+	dbNode, err := newDockerResource(pool, testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []ContainerInfo{
+		{Name: "coord01", ID: coordinator.resource.Container.ID},
+		{Name: "dbnode01", ID: dbNode.resource.Container.ID},
+	}, Inventory())
+
+	require.NoError(t, coordinator.close())
+	assert.Equal(t, []ContainerInfo{
+		{Name: "dbnode01", ID: dbNode.resource.Container.ID},
+	}, Inventory())
+
+	require.NoError(t, dbNode.close())
+	assert.Empty(t, Inventory())
+}
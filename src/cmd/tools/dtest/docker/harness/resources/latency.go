@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	queryableLatencyTimeout      = time.Minute
+	queryableLatencyPollInterval = 10 * time.Millisecond
+	queryableLatencyMetric       = "dtest_queryable_latency_probe"
+	queryableLatencyProbeLabel   = "probe"
+)
+
+// LatencyDistribution is a distribution of measured latencies.
+type LatencyDistribution struct {
+	// Samples are the measured latencies in ascending order.
+	Samples []time.Duration
+}
+
+func newLatencyDistribution(samples []time.Duration) LatencyDistribution {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return LatencyDistribution{Samples: sorted}
+}
+
+// Percentile returns the latency at the given percentile between 0 and 100
+// using the nearest rank, or zero if there are no samples.
+func (d LatencyDistribution) Percentile(p float64) time.Duration {
+	if len(d.Samples) == 0 {
+		return 0
+	}
+
+	rank := int(p/100*float64(len(d.Samples))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	if rank >= len(d.Samples) {
+		rank = len(d.Samples) - 1
+	}
+
+	return d.Samples[rank]
+}
+
+func (d LatencyDistribution) String() string {
+	if len(d.Samples) == 0 {
+		return "no samples"
+	}
+
+	return fmt.Sprintf("n=%d min=%v p50=%v p90=%v p99=%v max=%v",
+		len(d.Samples), d.Samples[0], d.Percentile(50), d.Percentile(90),
+		d.Percentile(99), d.Samples[len(d.Samples)-1])
+}
+
+// MeasureQueryableLatency measures how long data written through the
+// coordinator takes to become queryable, returning the distribution of the
+// latencies measured over the given number of runs.
+func (c *coordinator) MeasureQueryableLatency(runs int) (LatencyDistribution, error) {
+	if c.resource.closed {
+		return LatencyDistribution{}, errClosed
+	}
+
+	return measureQueryableLatency(
+		c.resource.getURL(7201, promWritePath),
+		c.resource.getURL(7201, promQueryPath),
+		runs, queryableLatencyTimeout)
+}
+
+// measureQueryableLatency writes a uniquely labelled sample to the remote write
+// URL on each run, then polls the instant query URL for it, measuring the time
+// from the write being issued until the sample is returned by a query.
+func measureQueryableLatency(
+	writeURL string,
+	queryURL string,
+	runs int,
+	timeout time.Duration,
+) (LatencyDistribution, error) {
+	samples := make([]time.Duration, 0, runs)
+	for i := 0; i < runs; i++ {
+		probe := strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.Itoa(i)
+		latency, err := measureProbeLatency(writeURL, queryURL, probe, timeout)
+		if err != nil {
+			return LatencyDistribution{}, fmt.Errorf("run %d of %d failed: %v", i+1, runs, err)
+		}
+
+		samples = append(samples, latency)
+	}
+
+	return newLatencyDistribution(samples), nil
+}
+
+func measureProbeLatency(
+	writeURL string,
+	queryURL string,
+	probe string,
+	timeout time.Duration,
+) (time.Duration, error) {
+	labels := map[string]string{queryableLatencyProbeLabel: probe}
+	start := time.Now()
+	if err := writePromSamples(writeURL, []TimedSample{{
+		Sample:    Sample{Name: queryableLatencyMetric, Labels: labels, Value: 1},
+		Timestamp: start,
+	}}); err != nil {
+		return 0, err
+	}
+
+	query := promSelector(queryableLatencyMetric, labels)
+	deadline := start.Add(timeout)
+	for {
+		results, err := queryPromSamples(queryURL, query)
+		if err == nil && len(results) > 0 {
+			return time.Since(start), nil
+		}
+
+		if time.Now().After(deadline) {
+			if err == nil {
+				err = fmt.Errorf("%s not queryable", query)
+			}
+
+			return 0, fmt.Errorf("timed out: %v", err)
+		}
+
+		time.Sleep(queryableLatencyPollInterval)
+	}
+}
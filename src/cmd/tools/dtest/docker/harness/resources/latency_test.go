@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIngestServer makes written series queryable once the given delay has
+// elapsed since they were written.
+type fakeIngestServer struct {
+	sync.Mutex
+
+	delay   time.Duration
+	written map[string]time.Time
+}
+
+func (s *fakeIngestServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+promWritePath, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeWriteRequest(t, r)
+		s.Lock()
+		defer s.Unlock()
+		for _, series := range req.Timeseries {
+			labels := make(map[string]string, len(series.Labels))
+			for _, l := range series.Labels {
+				labels[string(l.Name)] = string(l.Value)
+			}
+
+			name := labels[promMetricNameLabel]
+			delete(labels, promMetricNameLabel)
+			s.written[promSelector(name, labels)] = time.Now()
+		}
+	})
+	mux.HandleFunc("/"+promQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		writtenAt, ok := s.written[r.URL.Query().Get("query")]
+		if !ok || time.Since(writtenAt) < s.delay {
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"__name__":"%s"},"value":[1,"1"]}]}}`, queryableLatencyMetric)
+	})
+
+	return mux
+}
+
+func TestMeasureQueryableLatency(t *testing.T) {
+	fake := &fakeIngestServer{delay: 50 * time.Millisecond, written: make(map[string]time.Time)}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	distribution, err := measureQueryableLatency(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		5, 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(distribution.Samples))
+	for _, latency := range distribution.Samples {
+		assert.True(t, latency >= fake.delay, "latency %v below delay", latency)
+		assert.True(t, latency < fake.delay+time.Second, "latency %v too high", latency)
+	}
+	assert.Equal(t, distribution.Samples[0], distribution.Percentile(0))
+	assert.Equal(t, distribution.Samples[4], distribution.Percentile(100))
+
+	// Each run should write a distinct series.
+	fake.Lock()
+	defer fake.Unlock()
+	assert.Equal(t, 5, len(fake.written))
+}
+
+func TestMeasureQueryableLatencyTimeout(t *testing.T) {
+	fake := &fakeIngestServer{delay: time.Hour, written: make(map[string]time.Time)}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	_, err := measureQueryableLatency(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		3, 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "run 1 of 3 failed: timed out")
+}
+
+func TestLatencyDistribution(t *testing.T) {
+	var samples []time.Duration
+	for i := 10; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+
+	distribution := newLatencyDistribution(samples)
+	assert.Equal(t, time.Millisecond, distribution.Samples[0])
+	assert.Equal(t, 5*time.Millisecond, distribution.Percentile(50))
+	assert.Equal(t, 9*time.Millisecond, distribution.Percentile(90))
+	assert.Equal(t, 10*time.Millisecond, distribution.Percentile(99))
+	assert.Equal(t, "n=10 min=1ms p50=5ms p90=9ms p99=10ms max=10ms", distribution.String())
+	assert.Equal(t, time.Duration(0), LatencyDistribution{}.Percentile(50))
+}
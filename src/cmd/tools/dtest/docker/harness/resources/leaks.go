@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	dockertest "github.com/ory/dockertest"
+	dc "github.com/ory/dockertest/docker"
+)
+
+// harnessContainerPrefixes are the name prefixes of containers created by the
+// harness, matching the default container names of each component.
+var harnessContainerPrefixes = []string{"dbnode", "coord"}
+
+// leakedContainersError is returned when harness containers remain on the
+// test network after teardown.
+type leakedContainersError struct {
+	names []string
+}
+
+func (e leakedContainersError) Error() string {
+	return fmt.Sprintf("%d container(s) leaked after teardown: %s",
+		len(e.names), strings.Join(e.names, ", "))
+}
+
+// verifyNoLeakedContainers lists the containers, including stopped ones, on
+// the test network with the given name and returns a leakedContainersError
+// naming any created by the harness, which indicates a missed cleanup.
+func verifyNoLeakedContainers(pool *dockertest.Pool, networkName string) error {
+	containers, err := pool.Client.ListContainers(dc.ListContainersOptions{
+		All:     true,
+		Filters: map[string][]string{"network": {networkName}},
+	})
+	if err != nil {
+		return err
+	}
+
+	var leaked []string
+	for _, c := range containers {
+		for _, name := range c.Names {
+			// NB: this is prefixed with a `/` that should be trimmed off.
+			name = strings.TrimLeft(name, "/")
+			if hasHarnessPrefix(name) {
+				leaked = append(leaked, name)
+				break
+			}
+		}
+	}
+
+	if len(leaked) == 0 {
+		return nil
+	}
+
+	sort.Strings(leaked)
+	return leakedContainersError{names: leaked}
+}
+
+func hasHarnessPrefix(name string) bool {
+	for _, prefix := range harnessContainerPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
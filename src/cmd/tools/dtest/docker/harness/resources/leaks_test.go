@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"testing"
+
+	dc "github.com/ory/dockertest/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyNoLeakedContainers(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	pool := docker.pool(t)
+
+	dbNode, err := newDockerResource(pool, testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	coord, err := newDockerResource(pool, testResourceOptions("coord01"))
+	require.NoError(t, err)
+
+	// NB: containers outside of the harness or the test network are ignored.
+	docker.Lock()
+	docker.containers["other"] = &fakeContainer{
+		id:         "other",
+		name:       "other01",
+		hostConfig: dc.HostConfig{NetworkMode: defaultNetworkName},
+	}
+	docker.containers["elsewhere"] = &fakeContainer{
+		id:         "elsewhere",
+		name:       "dbnode02",
+		hostConfig: dc.HostConfig{NetworkMode: "bridge"},
+	}
+	docker.Unlock()
+
+	// Skip the coordinator cleanup, leaving it behind.
+	require.NoError(t, dbNode.close())
+	err = verifyNoLeakedContainers(pool, defaultNetworkName)
+	require.Error(t, err)
+	assert.Equal(t, leakedContainersError{names: []string{"coord01"}}, err)
+	assert.Contains(t, err.Error(), "coord01")
+
+	require.NoError(t, coord.close())
+	require.NoError(t, verifyNoLeakedContainers(pool, defaultNetworkName))
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	dc "github.com/ory/dockertest/docker"
+	"go.uber.org/zap"
+)
+
+var errLogsAlreadyCaptured = errors.New("container logs are already being captured")
+
+// logCapture is a running capture of the container logs.
+type logCapture struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	writer *prefixWriter
+}
+
+// captureLogs attaches to the container and streams its stdout and stderr to
+// the given writer, prefixing each line with the container name, until the
+// resource is closed.
+func (c *dockerResource) captureLogs(w io.Writer) error {
+	if c.closed {
+		return errClosed
+	}
+
+	if c.logCapture != nil {
+		return errLogsAlreadyCaptured
+	}
+
+	var (
+		// NB: this is prefixed with a `/` that should be trimmed off.
+		name   = strings.TrimLeft(c.resource.Container.Name, "/")
+		logger = c.logger.With(zapMethod("captureLogs"))
+		writer = newPrefixWriter(w, fmt.Sprintf("[%s] ", name))
+
+		ctx, cancel = context.WithCancel(context.Background())
+		capture     = &logCapture{
+			cancel: cancel,
+			done:   make(chan struct{}),
+			writer: writer,
+		}
+	)
+
+	go func() {
+		defer close(capture.done)
+		err := c.pool.Client.Logs(dc.LogsOptions{
+			Context:      ctx,
+			Container:    c.resource.Container.ID,
+			OutputStream: writer,
+			ErrorStream:  writer,
+			Follow:       true,
+			Stdout:       true,
+			Stderr:       true,
+		})
+
+		// NB: the stream is expected to end with an error once cancelled.
+		if err != nil && ctx.Err() == nil {
+			logger.Error("could not capture container logs", zap.Error(err))
+		}
+	}()
+
+	c.logCapture = capture
+	logger.Info("capturing container logs")
+	return nil
+}
+
+// stopCapturingLogs stops capturing the container logs, if they are being
+// captured, waiting for the capture to finish.
+func (c *dockerResource) stopCapturingLogs() {
+	capture := c.logCapture
+	if capture == nil {
+		return
+	}
+
+	c.logCapture = nil
+	capture.cancel()
+	<-capture.done
+	if err := capture.writer.flush(); err != nil {
+		c.logger.Error("could not flush container logs", zap.Error(err))
+	}
+}
+
+// prefixWriter writes complete lines to the underlying writer with a prefix,
+// buffering partial lines until they are completed or flushed.
+type prefixWriter struct {
+	sync.Mutex
+
+	w      io.Writer
+	prefix []byte
+	buf    bytes.Buffer
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{w: w, prefix: []byte(prefix)}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.Lock()
+	defer w.Unlock()
+	w.buf.Write(p)
+	for {
+		idx := bytes.IndexByte(w.buf.Bytes(), '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+
+		if err := w.writeLine(w.buf.Next(idx + 1)); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// flush writes any buffered partial line, terminating it with a newline.
+func (w *prefixWriter) flush() error {
+	w.Lock()
+	defer w.Unlock()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	line := append([]byte(nil), w.buf.Next(w.buf.Len())...)
+	return w.writeLine(append(line, '\n'))
+}
+
+// NB: the prefix and line are written at once so that lines of containers
+// sharing a writer are not interleaved.
+func (w *prefixWriter) writeLine(line []byte) error {
+	out := make([]byte, 0, len(w.prefix)+len(line))
+	out = append(out, w.prefix...)
+	out = append(out, line...)
+	_, err := w.w.Write(out)
+	return err
+}
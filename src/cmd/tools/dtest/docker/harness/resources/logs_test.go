@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.Lock()
+	defer b.Unlock()
+	return b.buf.String()
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newPrefixWriter(&buf, "[dbnode01] ")
+
+	_, err := w.Write([]byte("first\nsec"))
+	require.NoError(t, err)
+	assert.Equal(t, "[dbnode01] first\n", buf.String())
+
+	_, err = w.Write([]byte("ond\nthird"))
+	require.NoError(t, err)
+	assert.Equal(t, "[dbnode01] first\n[dbnode01] second\n", buf.String())
+
+	require.NoError(t, w.flush())
+	assert.Equal(t, "[dbnode01] first\n[dbnode01] second\n[dbnode01] third\n",
+		buf.String())
+	require.NoError(t, w.flush())
+}
+
+func TestCaptureLogs(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+	docker.logsFn = func(c *fakeContainer) (string, string) {
+		return "starting " + c.name + "\nbootstrapped\n", "warning\n"
+	}
+
+	var out syncBuffer
+	opts := testResourceOptions("dbnode01")
+	opts.logOutput = &out
+	resource, err := newDockerResource(docker.pool(t), opts)
+	require.NoError(t, err)
+	require.NotNil(t, resource.logCapture)
+	assert.Equal(t, errLogsAlreadyCaptured, resource.captureLogs(&out))
+
+	expected := []string{
+		"[dbnode01] starting dbnode01",
+		"[dbnode01] bootstrapped",
+		"[dbnode01] warning",
+	}
+	require.NoError(t, waitUntil(time.Now().Add(time.Second), func() error {
+		if n := strings.Count(out.String(), "\n"); n != len(expected) {
+			return fmt.Errorf("expected %d lines, got %d", len(expected), n)
+		}
+		return nil
+	}))
+
+	done := resource.logCapture.done
+	require.NoError(t, resource.close())
+	assert.Nil(t, resource.logCapture)
+	select {
+	case <-done:
+	default:
+		require.FailNow(t, "log capture not stopped on close")
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, expected, lines)
+	assert.Equal(t, errClosed, resource.captureLogs(&out))
+}
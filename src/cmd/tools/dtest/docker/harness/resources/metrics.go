@@ -0,0 +1,384 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+)
+
+// Sample is a single sample of a metric, identified by its name and labels.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// scrapeMetrics scrapes the prometheus metrics exposed on the given port.
+func (c *dockerResource) scrapeMetrics(port int) ([]Sample, error) {
+	if c.closed {
+		return nil, errClosed
+	}
+
+	url := c.getURL(port, "metrics")
+	logger := c.logger.With(zapMethod("scrapeMetrics"), zap.String("url", url))
+	return fetchSamples(url, logger)
+}
+
+// fetchSamples fetches and parses the prometheus metrics served at the given
+// URL.
+func fetchSamples(url string, logger *zap.Logger) ([]Sample, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		logger.Error("failed get", zap.Error(err))
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		logger.Error("status code not 2xx",
+			zap.Int("status code", resp.StatusCode),
+			zap.String("status", resp.Status))
+		return nil, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	samples, err := parseSamples(resp.Body)
+	if err != nil {
+		logger.Error("could not parse metrics", zap.Error(err))
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+// parseSamples parses a prometheus text exposition payload into samples,
+// sorted by name. Summaries and histograms are expanded into their _sum,
+// _count and quantile or bucket samples, as they appear in the payload.
+func parseSamples(r io.Reader) ([]Sample, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	var samples []Sample
+	for _, name := range names {
+		family := families[name]
+		for _, m := range family.GetMetric() {
+			samples = append(samples, newSamples(name, family.GetType(), m)...)
+		}
+	}
+
+	return samples, nil
+}
+
+func newSamples(name string, metricType dto.MetricType, m *dto.Metric) []Sample {
+	labels := make(map[string]string, len(m.GetLabel()))
+	for _, l := range m.GetLabel() {
+		labels[l.GetName()] = l.GetValue()
+	}
+
+	sample := func(suffix string, value float64, extraLabels ...string) Sample {
+		sampleLabels := make(map[string]string, len(labels)+len(extraLabels)/2)
+		for k, v := range labels {
+			sampleLabels[k] = v
+		}
+
+		for i := 0; i+1 < len(extraLabels); i += 2 {
+			sampleLabels[extraLabels[i]] = extraLabels[i+1]
+		}
+
+		return Sample{Name: name + suffix, Labels: sampleLabels, Value: value}
+	}
+
+	switch metricType {
+	case dto.MetricType_COUNTER:
+		return []Sample{sample("", m.GetCounter().GetValue())}
+	case dto.MetricType_GAUGE:
+		return []Sample{sample("", m.GetGauge().GetValue())}
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		samples := make([]Sample, 0, len(s.GetQuantile())+2)
+		for _, q := range s.GetQuantile() {
+			samples = append(samples, sample("", q.GetValue(),
+				"quantile", formatFloat(q.GetQuantile())))
+		}
+
+		return append(samples,
+			sample("_sum", s.GetSampleSum()),
+			sample("_count", float64(s.GetSampleCount())))
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		samples := make([]Sample, 0, len(h.GetBucket())+2)
+		for _, b := range h.GetBucket() {
+			samples = append(samples, sample("_bucket", float64(b.GetCumulativeCount()),
+				"le", formatFloat(b.GetUpperBound())))
+		}
+
+		return append(samples,
+			sample("_sum", h.GetSampleSum()),
+			sample("_count", float64(h.GetSampleCount())))
+	default:
+		return []Sample{sample("", m.GetUntyped().GetValue())}
+	}
+}
+
+// counterResetError is returned when a metric decreases or disappears while
+// measuring its delta, which usually means the container restarted.
+type counterResetError struct {
+	name   string
+	before float64
+	after  float64
+}
+
+func (e counterResetError) Error() string {
+	return fmt.Sprintf("metric %s reset from %v to %v: did the container restart?",
+		e.name, e.before, e.after)
+}
+
+// metricDelta returns how much the metric with the given name and labels
+// increased on the given port while running the action.
+func (c *dockerResource) metricDelta(
+	port int,
+	name string,
+	labels map[string]string,
+	action func() error,
+) (float64, error) {
+	if c.closed {
+		return 0, errClosed
+	}
+
+	scrape := func() ([]Sample, error) { return c.scrapeMetrics(port) }
+	return metricDelta(scrape, name, labels, action)
+}
+
+// metricDelta scrapes the metric with the given name and labels, summing all
+// matching samples, before and after running the action and returns the
+// difference. A metric missing before the action is treated as zero, since
+// counters are often only exported once first incremented. A counterResetError
+// is returned if the metric decreased or disappeared.
+func metricDelta(
+	scrape func() ([]Sample, error),
+	name string,
+	labels map[string]string,
+	action func() error,
+) (float64, error) {
+	before, err := scrapeSum(scrape, name, labels)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := action(); err != nil {
+		return 0, err
+	}
+
+	after, err := scrapeSum(scrape, name, labels)
+	if err != nil {
+		return 0, err
+	}
+
+	if after < before {
+		return 0, counterResetError{name: name, before: before, after: after}
+	}
+
+	return after - before, nil
+}
+
+// scrapeSum returns the sum of the samples with the given name and labels,
+// which is zero if there are none.
+func scrapeSum(
+	scrape func() ([]Sample, error),
+	name string,
+	labels map[string]string,
+) (float64, error) {
+	samples, err := scrape()
+	if err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for _, s := range findSamples(samples, name, labels) {
+		sum += s.Value
+	}
+
+	return sum, nil
+}
+
+// maxCardinalityExamples is the number of series reported when a metric exceeds
+// its cardinality limit, which is usually enough to spot the offending label.
+const maxCardinalityExamples = 5
+
+// cardinalityError is returned when a metric has more distinct series than
+// allowed, which usually means it gained a per-series label.
+type cardinalityError struct {
+	name     string
+	series   int
+	limit    int
+	examples []map[string]string
+}
+
+func (e cardinalityError) Error() string {
+	return fmt.Sprintf("metric %s has %d distinct series, exceeding the limit of %d, e.g. %v",
+		e.name, e.series, e.limit, e.examples)
+}
+
+// verifyCardinality verifies that the metric with the given name exposed on the
+// given port has at most limit distinct series.
+func (c *dockerResource) verifyCardinality(port int, name string, limit int) error {
+	if c.closed {
+		return errClosed
+	}
+
+	scrape := func() ([]Sample, error) { return c.scrapeMetrics(port) }
+	return verifyCardinality(scrape, name, limit)
+}
+
+// verifyCardinality scrapes the metric with the given name and returns a
+// cardinalityError naming some of its series if it has more than limit
+// distinct series. Each sample of a metric is a distinct series, so samples of
+// summaries and histograms are counted under their suffixed names.
+func verifyCardinality(
+	scrape func() ([]Sample, error),
+	name string,
+	limit int,
+) error {
+	samples, err := scrape()
+	if err != nil {
+		return err
+	}
+
+	matches := findSamples(samples, name, nil)
+	if len(matches) <= limit {
+		return nil
+	}
+
+	examples := make([]map[string]string, 0, maxCardinalityExamples)
+	for _, s := range matches {
+		if len(examples) == maxCardinalityExamples {
+			break
+		}
+
+		examples = append(examples, s.Labels)
+	}
+
+	return cardinalityError{
+		name:     name,
+		series:   len(matches),
+		limit:    limit,
+		examples: examples,
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// findSamples returns the samples with the given name whose labels include
+// all of the given labels.
+func findSamples(samples []Sample, name string, labels map[string]string) []Sample {
+	var matches []Sample
+	for _, s := range samples {
+		if s.Name != name || !hasLabels(s, labels) {
+			continue
+		}
+
+		matches = append(matches, s)
+	}
+
+	return matches
+}
+
+func hasLabels(s Sample, labels map[string]string) bool {
+	for k, v := range labels {
+		if value, ok := s.Labels[k]; !ok || value != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// staleMetricError is returned when a counter does not advance over a reporting
+// interval, which usually means metrics are no longer being reported.
+type staleMetricError struct {
+	name     string
+	interval time.Duration
+}
+
+func (e staleMetricError) Error() string {
+	return fmt.Sprintf("metric %s did not advance within the reporting interval of %v: "+
+		"are metrics being reported?", e.name, e.interval)
+}
+
+// verifyMetricReported verifies that the counter with the given name and labels
+// exposed on the given port advances within the given reporting interval.
+func (c *dockerResource) verifyMetricReported(
+	port int,
+	name string,
+	labels map[string]string,
+	interval time.Duration,
+) error {
+	if c.closed {
+		return errClosed
+	}
+
+	scrape := func() ([]Sample, error) { return c.scrapeMetrics(port) }
+	return verifyMetricReported(scrape, name, labels, interval)
+}
+
+// verifyMetricReported scrapes the counter with the given name and labels twice,
+// a reporting interval apart, and returns a staleMetricError if it did not
+// advance in between. The counter must be one that advances continuously, e.g.
+// one counting reports or ticks, for the check to be meaningful.
+func verifyMetricReported(
+	scrape func() ([]Sample, error),
+	name string,
+	labels map[string]string,
+	interval time.Duration,
+) error {
+	delta, err := metricDelta(scrape, name, labels, func() error {
+		time.Sleep(interval)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if delta == 0 {
+		return staleMetricError{name: name, interval: interval}
+	}
+
+	return nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testExposition = `# HELP writes_total Total writes.
+# TYPE writes_total counter
+writes_total{shard="0",status="success"} 10
+writes_total{shard="1",status="success"} 20
+writes_total{shard="1",status="error"} 2
+# HELP uptime Uptime in seconds.
+# TYPE uptime gauge
+uptime 42.5
+# HELP latency Write latency.
+# TYPE latency histogram
+latency_bucket{shard="0",le="0.1"} 3
+latency_bucket{shard="0",le="+Inf"} 5
+latency_sum{shard="0"} 1.5
+latency_count{shard="0"} 5
+`
+
+func TestParseSamples(t *testing.T) {
+	samples, err := parseSamples(strings.NewReader(testExposition))
+	require.NoError(t, err)
+	require.Equal(t, 8, len(samples))
+
+	assert.Equal(t, []Sample{
+		{Name: "uptime", Labels: map[string]string{}, Value: 42.5},
+	}, findSamples(samples, "uptime", nil))
+	assert.Equal(t, []Sample{
+		{Name: "latency_bucket", Labels: map[string]string{"shard": "0", "le": "0.1"}, Value: 3},
+	}, findSamples(samples, "latency_bucket", map[string]string{"le": "0.1"}))
+	assert.Equal(t, []Sample{
+		{Name: "latency_count", Labels: map[string]string{"shard": "0"}, Value: 5},
+	}, findSamples(samples, "latency_count", map[string]string{"shard": "0"}))
+}
+
+func TestFindSamplesByLabels(t *testing.T) {
+	samples, err := parseSamples(strings.NewReader(testExposition))
+	require.NoError(t, err)
+
+	shard1 := findSamples(samples, "writes_total", map[string]string{"shard": "1"})
+	require.Equal(t, 2, len(shard1))
+	var total float64
+	for _, s := range shard1 {
+		assert.Equal(t, "1", s.Labels["shard"])
+		total += s.Value
+	}
+	assert.Equal(t, float64(22), total)
+
+	assert.Equal(t, []Sample{
+		{
+			Name:   "writes_total",
+			Labels: map[string]string{"shard": "1", "status": "error"},
+			Value:  2,
+		},
+	}, findSamples(samples, "writes_total", map[string]string{"shard": "1", "status": "error"}))
+	assert.Equal(t, 3, len(findSamples(samples, "writes_total", nil)))
+	assert.Empty(t, findSamples(samples, "writes_total", map[string]string{"shard": "2"}))
+	assert.Empty(t, findSamples(samples, "reads_total", nil))
+}
+
+func TestParseSamplesInvalid(t *testing.T) {
+	_, err := parseSamples(strings.NewReader("writes_total{shard=0} 10\n"))
+	require.Error(t, err)
+}
+
+// newFakeMetricsServer serves a writes_total counter with the value returned by
+// the given function.
+func newFakeMetricsServer(writes func() float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# TYPE writes_total counter\nwrites_total{shard=\"0\"} %v\n", writes())
+	}))
+}
+
+func TestMetricDelta(t *testing.T) {
+	var writes int64 = 10
+	server := newFakeMetricsServer(func() float64 {
+		return float64(atomic.LoadInt64(&writes))
+	})
+	defer server.Close()
+
+	scrape := func() ([]Sample, error) {
+		return fetchSamples(server.URL, zap.NewNop())
+	}
+	delta, err := metricDelta(scrape, "writes_total", map[string]string{"shard": "0"},
+		func() error {
+			atomic.AddInt64(&writes, 3)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, float64(3), delta)
+
+	// Metrics not yet exported count as zero.
+	delta, err = metricDelta(scrape, "writes_total", map[string]string{"shard": "1"},
+		func() error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), delta)
+
+	errAction := errors.New("action failed")
+	_, err = metricDelta(scrape, "writes_total", nil, func() error { return errAction })
+	assert.Equal(t, errAction, err)
+}
+
+func TestMetricDeltaCounterReset(t *testing.T) {
+	var writes int64 = 10
+	server := newFakeMetricsServer(func() float64 {
+		return float64(atomic.LoadInt64(&writes))
+	})
+	defer server.Close()
+
+	scrape := func() ([]Sample, error) {
+		return fetchSamples(server.URL, zap.NewNop())
+	}
+	_, err := metricDelta(scrape, "writes_total", nil, func() error {
+		// NB: simulate the container restarting and counting from scratch.
+		atomic.StoreInt64(&writes, 1)
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, counterResetError{name: "writes_total", before: 10, after: 1}, err)
+}
+
+func TestVerifyCardinality(t *testing.T) {
+	// NB: simulate a metric which accidentally gained a per-series label.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "# TYPE writes_total counter")
+		for i := 0; i < 10; i++ {
+			fmt.Fprintf(w, "writes_total{series=\"series%d\"} 1\n", i)
+		}
+
+		fmt.Fprintln(w, "# TYPE errors_total counter")
+		fmt.Fprintln(w, "errors_total{type=\"timeout\"} 1")
+	}))
+	defer server.Close()
+
+	scrape := func() ([]Sample, error) {
+		return fetchSamples(server.URL, zap.NewNop())
+	}
+	require.NoError(t, verifyCardinality(scrape, "errors_total", 5))
+	require.NoError(t, verifyCardinality(scrape, "writes_total", 10))
+	require.NoError(t, verifyCardinality(scrape, "missing_total", 0))
+
+	err := verifyCardinality(scrape, "writes_total", 5)
+	require.Error(t, err)
+	cardinalityErr, ok := err.(cardinalityError)
+	require.True(t, ok)
+	assert.Equal(t, 10, cardinalityErr.series)
+	assert.Len(t, cardinalityErr.examples, maxCardinalityExamples)
+	assert.Contains(t, err.Error(),
+		"metric writes_total has 10 distinct series, exceeding the limit of 5")
+}
+
+func TestVerifyMetricReported(t *testing.T) {
+	// NB: simulate a binary reporting its metrics every 50ms.
+	start := time.Now()
+	server := newFakeMetricsServer(func() float64 {
+		return float64(time.Since(start) / (50 * time.Millisecond))
+	})
+	defer server.Close()
+
+	scrape := func() ([]Sample, error) {
+		return fetchSamples(server.URL, zap.NewNop())
+	}
+	labels := map[string]string{"shard": "0"}
+	require.NoError(t, verifyMetricReported(scrape, "writes_total", labels, 100*time.Millisecond))
+
+	// Metrics which are never reported are stale too.
+	err := verifyMetricReported(scrape, "missing_total", nil, 10*time.Millisecond)
+	assert.Equal(t, staleMetricError{name: "missing_total", interval: 10 * time.Millisecond}, err)
+}
+
+func TestVerifyMetricReportedStuck(t *testing.T) {
+	server := newFakeMetricsServer(func() float64 { return 10 })
+	defer server.Close()
+
+	scrape := func() ([]Sample, error) {
+		return fetchSamples(server.URL, zap.NewNop())
+	}
+	err := verifyMetricReported(scrape, "writes_total", nil, 100*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(),
+		"metric writes_total did not advance within the reporting interval of 100ms")
+}
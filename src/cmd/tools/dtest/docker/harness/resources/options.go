@@ -20,14 +20,22 @@
 
 package resources
 
+import "io"
+
 type dockerImage struct {
 	name string
 	tag  string
 }
 
 type setupOptions struct {
-	dbNodeImage      dockerImage
-	coordinatorImage dockerImage
+	dbNodeImage       dockerImage
+	coordinatorImage  dockerImage
+	dbNodeLabels      map[string]string
+	coordinatorLabels map[string]string
+	dockerFileVars    map[string]string
+	networkName       string
+	volumeName        string
+	containerLogs     io.Writer
 }
 
 // SetupOptions is a setup option.
@@ -46,3 +54,55 @@ func WithCoordinatorImage(name, tag string) SetupOptions {
 		o.coordinatorImage = dockerImage{name: name, tag: tag}
 	}
 }
+
+// WithDBNodeLabels sets an option to label the DB node container, which allows
+// purging it along with other resources sharing a label.
+func WithDBNodeLabels(labels map[string]string) SetupOptions {
+	return func(o *setupOptions) {
+		o.dbNodeLabels = labels
+	}
+}
+
+// WithCoordinatorLabels sets an option to label the coordinator container, which
+// allows purging it along with other resources sharing a label.
+func WithCoordinatorLabels(labels map[string]string) SetupOptions {
+	return func(o *setupOptions) {
+		o.coordinatorLabels = labels
+	}
+}
+
+// WithDockerFileVars sets an option to render the DB node and coordinator
+// Dockerfile templates with the given variables, e.g. the base image, when
+// images are built rather than pulled.
+func WithDockerFileVars(vars map[string]string) SetupOptions {
+	return func(o *setupOptions) {
+		o.dockerFileVars = vars
+	}
+}
+
+// WithNetworkName sets an option to attach the containers to the docker network
+// with the given name rather than the default one, which allows running
+// isolated setups side by side on one host.
+func WithNetworkName(name string) SetupOptions {
+	return func(o *setupOptions) {
+		o.networkName = name
+	}
+}
+
+// WithVolumeName sets an option to use the docker volume with the given name
+// rather than the default one, which allows running isolated setups side by
+// side on one host.
+func WithVolumeName(name string) SetupOptions {
+	return func(o *setupOptions) {
+		o.volumeName = name
+	}
+}
+
+// WithContainerLogs sets an option to stream the stdout and stderr of the
+// containers to the given writer, prefixed with the container name, which
+// helps debugging failing tests.
+func WithContainerLogs(w io.Writer) SetupOptions {
+	return func(o *setupOptions) {
+		o.containerLogs = w
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// StatusError is returned when a request is answered with a non 2xx status
+// code, allowing callers to tell rejected requests apart from failed ones.
+type StatusError struct {
+	// StatusCode is the status code of the response.
+	StatusCode int
+	// Body is the body of the response.
+	Body string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("status code %d: %s", e.StatusCode, e.Body)
+}
+
+// isOverloadRejection returns true if the error is a response with a status
+// code a server sheds load with.
+func isOverloadRejection(err error) bool {
+	statusErr, ok := err.(StatusError)
+	if !ok {
+		return false
+	}
+
+	return statusErr.StatusCode == http.StatusTooManyRequests ||
+		statusErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// verifyGracefulRejection fires the given number of concurrent writes at the
+// coordinator and verifies that it sheds load, i.e. that the writes it does not
+// accept are rejected as rate limited or unavailable rather than failing, e.g.
+// on a connection reset.
+func (c *coordinator) verifyGracefulRejection(
+	samples []TimedSample,
+	concurrency int,
+) error {
+	if c.resource.closed {
+		return errClosed
+	}
+
+	writeURL := c.resource.getURL(7201, promWritePath)
+	return verifyGracefulRejection(concurrency, func() error {
+		return writePromSamples(writeURL, samples)
+	})
+}
+
+// verifyGracefulRejection runs the given number of concurrent writes, returning
+// an error if any write fails other than by being rejected under overload, or
+// if no write is rejected at all since the server was then not overloaded.
+func verifyGracefulRejection(concurrency int, writeFn func() error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		rejected int
+		multiErr = xerrors.NewMultiError()
+	)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := writeFn()
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+			case isOverloadRejection(err):
+				rejected++
+			default:
+				multiErr = multiErr.Add(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	if err := multiErr.FinalError(); err != nil {
+		return fmt.Errorf("writes failed rather than being rejected: %v", err)
+	}
+
+	if rejected == 0 {
+		return fmt.Errorf("none of %d concurrent writes were rejected", concurrency)
+	}
+
+	return nil
+}
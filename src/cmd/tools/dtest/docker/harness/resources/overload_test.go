@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOverloadServer returns a server handling up to the given number of
+// concurrent requests, responding to any further ones with overloadFn.
+func newOverloadServer(
+	limit int32,
+	overloadFn func(w http.ResponseWriter),
+) *httptest.Server {
+	var inflight int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer atomic.AddInt32(&inflight, -1)
+		if atomic.AddInt32(&inflight, 1) > limit {
+			overloadFn(w)
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestVerifyGracefulRejection(t *testing.T) {
+	samples := []TimedSample{{
+		Sample:    Sample{Name: "foo", Value: 1},
+		Timestamp: time.Unix(1000, 0),
+	}}
+	writeTo := func(server *httptest.Server) func() error {
+		return func() error {
+			return writePromSamples(server.URL+"/"+promWritePath, samples)
+		}
+	}
+
+	rateLimited := newOverloadServer(2, func(w http.ResponseWriter) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	})
+	defer rateLimited.Close()
+	require.NoError(t, verifyGracefulRejection(10, writeTo(rateLimited)))
+
+	// Dropping connections under overload is not graceful.
+	dropping := newOverloadServer(2, func(w http.ResponseWriter) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+	defer dropping.Close()
+	err := verifyGracefulRejection(10, writeTo(dropping))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "writes failed rather than being rejected")
+
+	// A server which is not overloaded rejects nothing.
+	err = verifyGracefulRejection(2, writeTo(rateLimited))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "none of 2 concurrent writes were rejected")
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// remoteWriteMismatch is a written sample which was not read back as written,
+// along with the samples read for its series.
+type remoteWriteMismatch struct {
+	expected Sample
+	read     []Sample
+}
+
+// remoteWriteMismatchError is returned when samples written through remote
+// write are not all read back as written.
+type remoteWriteMismatchError struct {
+	mismatches []remoteWriteMismatch
+}
+
+func (e remoteWriteMismatchError) Error() string {
+	details := make([]string, 0, len(e.mismatches))
+	for _, m := range e.mismatches {
+		details = append(details, fmt.Sprintf("expected %s = %v, read %v",
+			promSelector(m.expected.Name, m.expected.Labels), m.expected.Value, m.read))
+	}
+
+	return fmt.Sprintf("%d sample(s) not read back as written: %s",
+		len(e.mismatches), strings.Join(details, "; "))
+}
+
+// VerifyRemoteWriteRoundTrip writes the given samples through the coordinator's
+// Prometheus remote write endpoint and then queries each of their series until
+// it is read back with the written name, labels and value. This validates the
+// ingest path end to end without dtests having to encode remote write requests
+// by hand. The samples should be timestamped within the query lookback of now.
+func (c *coordinator) VerifyRemoteWriteRoundTrip(samples []TimedSample) error {
+	if c.resource.closed {
+		return errClosed
+	}
+
+	return verifyRemoteWriteRoundTrip(
+		c.resource.getURL(7201, promWritePath),
+		c.resource.getURL(7201, promQueryPath),
+		samples, readAfterWriteTimeout)
+}
+
+// verifyRemoteWriteRoundTrip writes the given samples to the remote write URL,
+// then polls the instant query URL for each of their series until every sample
+// is read back as written or the timeout fires, in which case the samples not
+// yet read back are returned in a remoteWriteMismatchError.
+func verifyRemoteWriteRoundTrip(
+	writeURL string,
+	queryURL string,
+	samples []TimedSample,
+	timeout time.Duration,
+) error {
+	if err := writePromSamples(writeURL, samples); err != nil {
+		return err
+	}
+
+	pending := make([]Sample, 0, len(samples))
+	for _, s := range samples {
+		pending = append(pending, s.Sample)
+	}
+
+	return waitUntil(time.Now().Add(timeout), func() error {
+		var mismatches []remoteWriteMismatch
+		for _, expected := range pending {
+			results, err := queryPromSamples(queryURL,
+				promSelector(expected.Name, expected.Labels))
+			if err != nil {
+				return err
+			}
+
+			if !containsSample(results, expected) {
+				mismatches = append(mismatches, remoteWriteMismatch{
+					expected: expected,
+					read:     results,
+				})
+			}
+		}
+
+		if len(mismatches) == 0 {
+			return nil
+		}
+
+		pending = pending[:0]
+		for _, m := range mismatches {
+			pending = append(pending, m.expected)
+		}
+
+		return remoteWriteMismatchError{mismatches: mismatches}
+	})
+}
+
+// containsSample returns true if one of the given samples has exactly the name,
+// labels and value of the expected sample.
+func containsSample(samples []Sample, expected Sample) bool {
+	for _, s := range samples {
+		if s.Name == expected.Name && s.Value == expected.Value &&
+			sameLabels(s.Labels, expected.Labels) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+
+	return true
+}
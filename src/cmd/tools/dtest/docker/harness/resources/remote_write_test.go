@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRemoteWriteServer accepts remote writes and serves the written series
+// with the queried name, passing each through mutate if set to simulate
+// samples corrupted on ingest.
+type fakeRemoteWriteServer struct {
+	sync.Mutex
+
+	written []prompb.TimeSeries
+	queries []string
+	mutate  func(labels map[string]string, value float64) float64
+}
+
+func (s *fakeRemoteWriteServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+promWritePath, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeWriteRequest(t, r)
+		s.Lock()
+		defer s.Unlock()
+		s.written = append(s.written, req.Timeseries...)
+	})
+	mux.HandleFunc("/"+promQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		query := r.URL.Query().Get("query")
+		s.queries = append(s.queries, query)
+		name := query[:strings.Index(query, "{")]
+
+		result := []map[string]interface{}{}
+		for _, series := range s.written {
+			metric := make(map[string]string, len(series.Labels))
+			for _, l := range series.Labels {
+				metric[string(l.Name)] = string(l.Value)
+			}
+
+			if metric[promMetricNameLabel] != name {
+				continue
+			}
+
+			value := series.Samples[0].Value
+			if s.mutate != nil {
+				value = s.mutate(metric, value)
+			}
+
+			result = append(result, map[string]interface{}{
+				"metric": metric,
+				"value":  []interface{}{1, strconv.FormatFloat(value, 'f', -1, 64)},
+			})
+		}
+
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data": map[string]interface{}{
+				"resultType": "vector",
+				"result":     result,
+			},
+		}))
+	})
+
+	return mux
+}
+
+func testRemoteWriteSamples() []TimedSample {
+	now := time.Now()
+	return []TimedSample{
+		{
+			Sample: Sample{
+				Name:   "requests_total",
+				Labels: map[string]string{"service": "api", "host": "a"},
+				Value:  3,
+			},
+			Timestamp: now,
+		},
+		{
+			Sample: Sample{
+				Name:   "requests_total",
+				Labels: map[string]string{"service": "api", "host": "b"},
+				Value:  4.5,
+			},
+			Timestamp: now,
+		},
+		{
+			Sample:    Sample{Name: "up", Value: 1},
+			Timestamp: now,
+		},
+	}
+}
+
+func TestVerifyRemoteWriteRoundTrip(t *testing.T) {
+	fake := &fakeRemoteWriteServer{}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	samples := testRemoteWriteSamples()
+	require.NoError(t, verifyRemoteWriteRoundTrip(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		samples, 5*time.Second))
+
+	fake.Lock()
+	defer fake.Unlock()
+	require.Equal(t, 3, len(fake.written))
+	assert.Equal(t, []prompb.Label{
+		{Name: []byte(promMetricNameLabel), Value: []byte("requests_total")},
+		{Name: []byte("host"), Value: []byte("a")},
+		{Name: []byte("service"), Value: []byte("api")},
+	}, fake.written[0].Labels)
+	assert.Equal(t, samples[0].Timestamp.UnixNano()/int64(time.Millisecond),
+		fake.written[0].Samples[0].Timestamp)
+	assert.Equal(t, []string{
+		`requests_total{host="a",service="api"}`,
+		`requests_total{host="b",service="api"}`,
+		`up{}`,
+	}, fake.queries)
+}
+
+func TestVerifyRemoteWriteRoundTripMismatch(t *testing.T) {
+	fake := &fakeRemoteWriteServer{
+		mutate: func(labels map[string]string, value float64) float64 {
+			if labels["host"] == "b" {
+				return value * 2
+			}
+			return value
+		},
+	}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	err := verifyRemoteWriteRoundTrip(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		testRemoteWriteSamples(), 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 sample(s) not read back as written: "+
+		`expected requests_total{host="b",service="api"} = 4.5, read `)
+	assert.NotContains(t, err.Error(), `host="a"`)
+
+	// Only the mismatched series should be re-queried after the first poll.
+	fake.Lock()
+	defer fake.Unlock()
+	require.True(t, len(fake.queries) > 3)
+	for _, q := range fake.queries[3:] {
+		assert.Equal(t, `requests_total{host="b",service="api"}`, q)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// scenarioReporter is the subset of testing.T used to report on scenario
+// attempts.
+type scenarioReporter interface {
+	Helper()
+	Logf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// RunWithRetry runs the given scenario up to n times until it succeeds, only
+// failing the test if every attempt fails. This is opt-in for scenarios which
+// are inherently flaky due to timing. The scenario is responsible for setting
+// up and tearing down its resources, e.g. with SetupSingleM3DBNode and
+// Cleanup, so that each attempt runs against freshly built resources, and
+// should report failures by returning an error rather than failing the test.
+func RunWithRetry(t *testing.T, n int, scenario func(t *testing.T) error) {
+	t.Helper()
+	runWithRetry(t, n, func() error { return scenario(t) })
+}
+
+func runWithRetry(t scenarioReporter, n int, scenario func() error) {
+	t.Helper()
+	if n < 1 {
+		n = 1
+	}
+
+	var multiErr xerrors.MultiError
+	for attempt := 1; attempt <= n; attempt++ {
+		err := scenario()
+		if err == nil {
+			if attempt > 1 {
+				t.Logf("scenario succeeded on attempt %d of %d", attempt, n)
+			}
+
+			return
+		}
+
+		t.Logf("scenario attempt %d of %d failed: %v", attempt, n, err)
+		multiErr = multiErr.Add(fmt.Errorf("attempt %d: %v", attempt, err))
+	}
+
+	t.Fatalf("scenario failed after %d attempts: %v", n, multiErr.FinalError())
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeReporter struct {
+	logs  []string
+	fatal string
+}
+
+func (r *fakeReporter) Helper() {}
+
+func (r *fakeReporter) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeReporter) Fatalf(format string, args ...interface{}) {
+	r.fatal = fmt.Sprintf(format, args...)
+}
+
+func TestRunWithRetryFailsOnceThenSucceeds(t *testing.T) {
+	var (
+		attempts  int
+		teardowns int
+	)
+	RunWithRetry(t, 3, func(t *testing.T) error {
+		// NB: resources are rebuilt and torn down on every attempt.
+		attempts++
+		defer func() { teardowns++ }()
+		if attempts == 1 {
+			return errors.New("flaky")
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 2, teardowns)
+}
+
+func TestRunWithRetryAllAttemptsFail(t *testing.T) {
+	var (
+		reporter fakeReporter
+		attempts int
+	)
+	runWithRetry(&reporter, 3, func() error {
+		attempts++
+		return fmt.Errorf("failure %d", attempts)
+	})
+
+	assert.Equal(t, 3, attempts)
+	require.Equal(t, 3, len(reporter.logs))
+	assert.Contains(t, reporter.fatal, "failed after 3 attempts")
+	assert.Contains(t, reporter.fatal, "failure 1")
+	assert.Contains(t, reporter.fatal, "failure 3")
+}
+
+func TestRunWithRetryRunsAtLeastOnce(t *testing.T) {
+	var (
+		reporter fakeReporter
+		attempts int
+	)
+	runWithRetry(&reporter, 0, func() error {
+		attempts++
+		return nil
+	})
+
+	assert.Equal(t, 1, attempts)
+	assert.Empty(t, reporter.fatal)
+	assert.Empty(t, reporter.logs)
+}
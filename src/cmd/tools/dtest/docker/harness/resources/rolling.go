@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// rollingRestartOptions configure a rolling restart.
+type rollingRestartOptions struct {
+	// timeout bounds the time taken by each instance to restart and rejoin.
+	timeout time.Duration
+	// healthy returns nil if the given instance is serving, and is used both to
+	// wait for restarted instances to rejoin and to measure availability.
+	healthy func(c *dockerResource) error
+	// settle, if set, blocks until cluster leadership has settled after each
+	// instance rejoins, e.g. by waiting for a single leader to be elected with
+	// waitForSingleLeader.
+	settle func() error
+	// minAvailability is the minimum fraction of instances which must be
+	// healthy throughout the roll.
+	minAvailability float64
+}
+
+// availabilityError is returned when too few instances are healthy during a
+// rolling restart.
+type availabilityError struct {
+	instance     string
+	availability float64
+	min          float64
+}
+
+func (e availabilityError) Error() string {
+	return fmt.Sprintf("availability dropped to %.2f while restarting %s, below the minimum of %.2f",
+		e.availability, e.instance, e.min)
+}
+
+// restart restarts the container, waiting up to the given timeout for it to
+// stop before killing it.
+func (c *dockerResource) restart(timeout time.Duration) error {
+	if c.closed {
+		return errClosed
+	}
+
+	if err := c.pool.Client.RestartContainer(c.resource.Container.ID,
+		uint(timeout/time.Second)); err != nil {
+		c.logger.Error("could not restart container",
+			zapMethod("restart"), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// rollingRestart restarts the given instances one at a time, as a rolling
+// upgrade would, waiting for each to rejoin and for leadership to settle before
+// moving onto the next. Availability is measured as the fraction of healthy
+// instances on every check while waiting for an instance to rejoin, failing
+// the roll once an instance has rejoined if it dropped below the minimum.
+func rollingRestart(instances []*dockerResource, opts rollingRestartOptions) error {
+	for _, c := range instances {
+		name := strings.TrimPrefix(c.resource.Container.Name, "/")
+		logger := c.logger.With(zapMethod("rollingRestart"))
+		logger.Info("restarting instance")
+		if err := c.restart(opts.timeout); err != nil {
+			return err
+		}
+
+		minAvailability := 1.0
+		if err := waitUntil(time.Now().Add(opts.timeout), func() error {
+			var (
+				healthy int
+				err     error
+			)
+			for _, instance := range instances {
+				instanceErr := opts.healthy(instance)
+				if instanceErr == nil {
+					healthy++
+				} else if instance == c {
+					err = instanceErr
+				}
+			}
+
+			availability := float64(healthy) / float64(len(instances))
+			if availability < minAvailability {
+				minAvailability = availability
+			}
+
+			return err
+		}); err != nil {
+			logger.Error("instance did not rejoin", zap.Error(err))
+			return fmt.Errorf("instance %s did not rejoin: %v", name, err)
+		}
+
+		if minAvailability < opts.minAvailability {
+			return availabilityError{
+				instance:     name,
+				availability: minAvailability,
+				min:          opts.minAvailability,
+			}
+		}
+
+		if opts.settle != nil {
+			if err := opts.settle(); err != nil {
+				logger.Error("leadership did not settle", zap.Error(err))
+				return fmt.Errorf("leadership did not settle after restarting %s: %v",
+					name, err)
+			}
+		}
+
+		logger.Info("instance rejoined", zap.Float64("minAvailability", minAvailability))
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRollingCluster simulates instances which are unhealthy for a number of
+// health checks after each restart recorded by the fake docker daemon.
+type fakeRollingCluster struct {
+	sync.Mutex
+
+	docker        *fakeDocker
+	downChecks    int
+	restartsSeen  map[string]int
+	checksPending map[string]int
+	events        []string
+}
+
+func newFakeRollingCluster(docker *fakeDocker, downChecks int) *fakeRollingCluster {
+	return &fakeRollingCluster{
+		docker:        docker,
+		downChecks:    downChecks,
+		restartsSeen:  make(map[string]int),
+		checksPending: make(map[string]int),
+	}
+}
+
+func (f *fakeRollingCluster) healthy(c *dockerResource) error {
+	name := strings.TrimPrefix(c.resource.Container.Name, "/")
+	restarts := 0
+	for _, action := range f.docker.recordedActions() {
+		if action == "restart "+name {
+			restarts++
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+	if restarts > f.restartsSeen[name] {
+		f.restartsSeen[name] = restarts
+		f.checksPending[name] = f.downChecks
+	}
+
+	if f.checksPending[name] > 0 {
+		f.checksPending[name]--
+		if f.checksPending[name] == 0 {
+			f.events = append(f.events, "rejoined "+name)
+		}
+
+		return errors.New("not healthy")
+	}
+
+	return nil
+}
+
+func (f *fakeRollingCluster) settle() error {
+	f.Lock()
+	defer f.Unlock()
+	f.events = append(f.events, "settled")
+	return nil
+}
+
+func newRollingInstances(t *testing.T, docker *fakeDocker, names ...string) []*dockerResource {
+	instances := make([]*dockerResource, 0, len(names))
+	for _, name := range names {
+		resource, err := newDockerResource(docker.pool(t), testResourceOptions(name))
+		require.NoError(t, err)
+		instances = append(instances, resource)
+	}
+
+	return instances
+}
+
+func TestRollingRestart(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	instances := newRollingInstances(t, docker, "dbnode01", "dbnode02", "dbnode03")
+	cluster := newFakeRollingCluster(docker, 2)
+	require.NoError(t, rollingRestart(instances, rollingRestartOptions{
+		timeout:         5 * time.Second,
+		healthy:         cluster.healthy,
+		settle:          cluster.settle,
+		minAvailability: 0.6,
+	}))
+
+	var restarts []string
+	for _, action := range docker.recordedActions() {
+		if strings.HasPrefix(action, "restart ") {
+			restarts = append(restarts, action)
+		}
+	}
+	assert.Equal(t, []string{
+		"restart dbnode01", "restart dbnode02", "restart dbnode03",
+	}, restarts)
+	assert.Equal(t, []string{
+		"rejoined dbnode01", "settled",
+		"rejoined dbnode02", "settled",
+		"rejoined dbnode03", "settled",
+	}, cluster.events)
+
+	for _, instance := range instances {
+		require.NoError(t, instance.close())
+	}
+}
+
+func TestRollingRestartAvailabilityDropped(t *testing.T) {
+	docker := newFakeDocker()
+	defer docker.close()
+
+	instances := newRollingInstances(t, docker, "dbnode01", "dbnode02")
+	cluster := newFakeRollingCluster(docker, 2)
+	err := rollingRestart(instances, rollingRestartOptions{
+		timeout:         5 * time.Second,
+		healthy:         cluster.healthy,
+		settle:          cluster.settle,
+		minAvailability: 0.6,
+	})
+	require.Error(t, err)
+	assert.Equal(t, availabilityError{instance: "dbnode01", availability: 0.5, min: 0.6}, err)
+	assert.Equal(t, []string{"rejoined dbnode01"}, cluster.events)
+
+	for _, instance := range instances {
+		require.NoError(t, instance.close())
+	}
+}
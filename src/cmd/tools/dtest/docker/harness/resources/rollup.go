@@ -0,0 +1,236 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+const (
+	promWritePath        = "api/v1/prom/remote/write"
+	promQueryPath        = "api/v1/query"
+	promMetricNameLabel  = "__name__"
+	rollupResultsTimeout = time.Minute
+)
+
+var errNoRollupResults = errors.New("no rollup results")
+
+// TimedSample is a sample at a given time, as written to the coordinator.
+type TimedSample struct {
+	Sample
+
+	Timestamp time.Time
+}
+
+// WriteAndQueryRollup writes the given samples through the coordinator's
+// Prometheus remote write endpoint and then runs the given instant query until
+// it returns results, returning them as samples. This encodes the end-to-end
+// aggregator flow: raw samples go in, and the rolled up series come out once
+// the aggregator has flushed them.
+func (c *coordinator) WriteAndQueryRollup(
+	samples []TimedSample,
+	query string,
+) ([]Sample, error) {
+	if c.resource.closed {
+		return nil, errClosed
+	}
+
+	return writeAndQueryRollup(
+		c.resource.getURL(7201, promWritePath),
+		c.resource.getURL(7201, promQueryPath),
+		samples, query, rollupResultsTimeout)
+}
+
+// writeAndQueryRollup writes the given samples to the remote write URL, then
+// polls the instant query URL with the given query until it returns results or
+// the timeout fires.
+func writeAndQueryRollup(
+	writeURL string,
+	queryURL string,
+	samples []TimedSample,
+	query string,
+	timeout time.Duration,
+) ([]Sample, error) {
+	if err := writePromSamples(writeURL, samples); err != nil {
+		return nil, err
+	}
+
+	var results []Sample
+	err := waitUntil(time.Now().Add(timeout), func() error {
+		var err error
+		results, err = queryPromSamples(queryURL, query)
+		if err == nil && len(results) == 0 {
+			err = errNoRollupResults
+		}
+
+		return err
+	})
+
+	return results, err
+}
+
+// writePromSamples writes the given samples as a snappy compressed Prometheus
+// remote write request.
+func writePromSamples(writeURL string, samples []TimedSample) error {
+	body, err := encodePromWriteRequest(samples)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, writeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	httpReq.Header.Set("Content-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return StatusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// encodePromWriteRequest encodes the given samples as the body of a Prometheus
+// remote write request, a snappy compressed prompb.WriteRequest.
+func encodePromWriteRequest(samples []TimedSample) ([]byte, error) {
+	req := &prompb.WriteRequest{
+		Timeseries: make([]prompb.TimeSeries, 0, len(samples)),
+	}
+	for _, s := range samples {
+		req.Timeseries = append(req.Timeseries, prompb.TimeSeries{
+			Labels: promLabels(s.Name, s.Labels),
+			Samples: []prompb.Sample{{
+				Value:     s.Value,
+				Timestamp: s.Timestamp.UnixNano() / int64(time.Millisecond),
+			}},
+		})
+	}
+
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return snappy.Encode(nil, data), nil
+}
+
+// promLabels returns the labels of a series, including its name, sorted by
+// label name as expected by Prometheus.
+func promLabels(name string, labels map[string]string) []prompb.Label {
+	result := make([]prompb.Label, 0, len(labels)+1)
+	result = append(result, prompb.Label{
+		Name:  []byte(promMetricNameLabel),
+		Value: []byte(name),
+	})
+	for k, v := range labels {
+		result = append(result, prompb.Label{Name: []byte(k), Value: []byte(v)})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Name, result[j].Name) < 0
+	})
+
+	return result
+}
+
+// queryPromSamples runs the given instant query, returning the resulting
+// vector as samples.
+func queryPromSamples(queryURL string, query string) ([]Sample, error) {
+	resp, err := http.Get(queryURL + "?" + url.Values{"query": []string{query}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("query failed with status code %d: %s",
+			resp.StatusCode, b)
+	}
+
+	var parsed prometheus.Response
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		return nil, err
+	}
+
+	vector, ok := parsed.Data.Result.(*prometheus.VectorResult)
+	if !ok {
+		return nil, fmt.Errorf("expected vector result, got %s", parsed.Data.ResultType)
+	}
+
+	samples := make([]Sample, 0, len(vector.Result))
+	for _, item := range vector.Result {
+		if len(item.Value) != 2 {
+			return nil, fmt.Errorf("invalid value: %v", item.Value)
+		}
+
+		str, ok := item.Value[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid value: %v", item.Value)
+		}
+
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		sample := Sample{Labels: make(map[string]string, len(item.Metric)), Value: value}
+		for k, v := range item.Metric {
+			if k == promMetricNameLabel {
+				sample.Name = v
+				continue
+			}
+
+			sample.Labels[k] = v
+		}
+
+		samples = append(samples, sample)
+	}
+
+	return samples, nil
+}
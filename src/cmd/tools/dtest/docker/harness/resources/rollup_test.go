@@ -0,0 +1,176 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRollupServer accepts remote writes and serves the sum of the written
+// values as a rollup, after answering the first emptyQueries queries with no
+// results to simulate the aggregator not having flushed yet.
+type fakeRollupServer struct {
+	sync.Mutex
+
+	written       []prompb.TimeSeries
+	queries       []string
+	emptyQueries  int
+	writeStatusOK bool
+}
+
+// decodeWriteRequest decodes the snappy compressed remote write request.
+func decodeWriteRequest(t *testing.T, r *http.Request) prompb.WriteRequest {
+	assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+	compressed, err := ioutil.ReadAll(r.Body)
+	require.NoError(t, err)
+	data, err := snappy.Decode(nil, compressed)
+	require.NoError(t, err)
+	var req prompb.WriteRequest
+	require.NoError(t, proto.Unmarshal(data, &req))
+	return req
+}
+
+func (s *fakeRollupServer) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+promWritePath, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeWriteRequest(t, r)
+		s.Lock()
+		defer s.Unlock()
+		if !s.writeStatusOK {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		s.written = append(s.written, req.Timeseries...)
+	})
+	mux.HandleFunc("/"+promQueryPath, func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		s.queries = append(s.queries, r.URL.Query().Get("query"))
+		if len(s.queries) <= s.emptyQueries {
+			fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+			return
+		}
+
+		var sum float64
+		for _, series := range s.written {
+			for _, sample := range series.Samples {
+				sum += sample.Value
+			}
+		}
+
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[`+
+			`{"metric":{"__name__":"requests_total:rollup","service":"api"},"value":[1,"%v"]}]}}`, sum)
+	})
+
+	return mux
+}
+
+func TestWriteAndQueryRollup(t *testing.T) {
+	fake := &fakeRollupServer{emptyQueries: 2, writeStatusOK: true}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	now := time.Unix(1600000000, 0)
+	results, err := writeAndQueryRollup(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		[]TimedSample{
+			{
+				Sample: Sample{
+					Name:   "requests_total",
+					Labels: map[string]string{"service": "api", "host": "a"},
+					Value:  3,
+				},
+				Timestamp: now,
+			},
+			{
+				Sample: Sample{
+					Name:   "requests_total",
+					Labels: map[string]string{"service": "api", "host": "b"},
+					Value:  4,
+				},
+				Timestamp: now,
+			},
+		},
+		`requests_total:rollup{service="api"}`, 5*time.Second)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Sample{{
+		Name:   "requests_total:rollup",
+		Labels: map[string]string{"service": "api"},
+		Value:  7,
+	}}, results)
+
+	fake.Lock()
+	defer fake.Unlock()
+	assert.Equal(t, 3, len(fake.queries))
+	assert.Equal(t, `requests_total:rollup{service="api"}`, fake.queries[0])
+	require.Equal(t, 2, len(fake.written))
+	assert.Equal(t, []prompb.Label{
+		{Name: []byte("__name__"), Value: []byte("requests_total")},
+		{Name: []byte("host"), Value: []byte("a")},
+		{Name: []byte("service"), Value: []byte("api")},
+	}, fake.written[0].Labels)
+	assert.Equal(t, []prompb.Sample{{Value: 3, Timestamp: 1600000000000}},
+		fake.written[0].Samples)
+}
+
+func TestWriteAndQueryRollupWriteError(t *testing.T) {
+	fake := &fakeRollupServer{}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	_, err := writeAndQueryRollup(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		[]TimedSample{{Sample: Sample{Name: "requests_total", Value: 1}}},
+		"requests_total:rollup", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status code 503")
+}
+
+func TestWriteAndQueryRollupTimeout(t *testing.T) {
+	fake := &fakeRollupServer{emptyQueries: 1000, writeStatusOK: true}
+	server := httptest.NewServer(fake.handler(t))
+	defer server.Close()
+
+	_, err := writeAndQueryRollup(
+		server.URL+"/"+promWritePath,
+		server.URL+"/"+promQueryPath,
+		[]TimedSample{{Sample: Sample{Name: "requests_total", Value: 1}}},
+		"requests_total:rollup", 300*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errNoRollupResults.Error())
+}
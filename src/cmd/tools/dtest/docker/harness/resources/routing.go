@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+// dbNodeTChannelPort is the port the DB node serves node TChannel requests on,
+// and the port instances are registered with in the placement.
+const dbNodeTChannelPort = 9000
+
+// VerifySeriesRouting verifies that the given series is routed to the DB nodes
+// owning its shard in the placement of the coordinator, i.e. that datapoints
+// written between start and end are fetched from each of its shard owners.
+func VerifySeriesRouting(
+	coordinator Coordinator,
+	nodes Nodes,
+	namespace, seriesID string,
+	start, end time.Time,
+) error {
+	resp, err := coordinator.GetPlacement()
+	if err != nil {
+		return err
+	}
+
+	owners, err := seriesOwners(resp.Placement, seriesID)
+	if err != nil {
+		return err
+	}
+
+	nodesByID := make(map[string]Node, len(nodes))
+	for _, node := range nodes {
+		host, err := node.HostDetails(dbNodeTChannelPort)
+		if err != nil {
+			return err
+		}
+
+		nodesByID[host.GetId()] = node
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, owner := range owners {
+		node, ok := nodesByID[owner]
+		if !ok {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"no node for instance %s owning series %s", owner, seriesID))
+			continue
+		}
+
+		result, err := node.Fetch(&rpc.FetchRequest{
+			NameSpace:      namespace,
+			ID:             seriesID,
+			RangeStart:     start.Unix(),
+			RangeEnd:       end.Unix(),
+			RangeType:      rpc.TimeType_UNIX_SECONDS,
+			ResultTimeType: rpc.TimeType_UNIX_SECONDS,
+		})
+		if err != nil {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"could not fetch series %s from instance %s: %v", seriesID, owner, err))
+			continue
+		}
+
+		if len(result.GetDatapoints()) == 0 {
+			multiErr = multiErr.Add(fmt.Errorf(
+				"series %s not found on instance %s", seriesID, owner))
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
+// seriesOwners returns the IDs of the instances owning the shard of the given
+// series in the placement in ascending order, hashing the series ID the same way
+// DB nodes do. Instances the shard is leaving are not owners.
+func seriesOwners(p *placementpb.Placement, seriesID string) ([]string, error) {
+	if p == nil || p.NumShards == 0 {
+		return nil, fmt.Errorf("no shards in placement")
+	}
+
+	shardID := sharding.DefaultHashFn(int(p.NumShards))(ident.StringID(seriesID))
+	var owners []string
+	for id, instance := range p.Instances {
+		for _, s := range instance.Shards {
+			if s.Id == shardID && s.State != placementpb.ShardState_LEAVING {
+				owners = append(owners, id)
+				break
+			}
+		}
+	}
+
+	if len(owners) == 0 {
+		return nil, fmt.Errorf("no instance owns shard %d of series %s",
+			shardID, seriesID)
+	}
+
+	sort.Strings(owners)
+	return owners, nil
+}
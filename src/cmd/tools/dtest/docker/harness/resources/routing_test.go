@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRoutingCoordinator struct {
+	Coordinator
+
+	placement *placementpb.Placement
+}
+
+func (c fakeRoutingCoordinator) GetPlacement() (admin.PlacementGetResponse, error) {
+	return admin.PlacementGetResponse{Placement: c.placement}, nil
+}
+
+type fakeRoutingNode struct {
+	Node
+
+	id     string
+	series map[string]struct{}
+}
+
+func (n fakeRoutingNode) HostDetails(port int) (*admin.Host, error) {
+	return &admin.Host{Id: n.id, Port: uint32(port)}, nil
+}
+
+func (n fakeRoutingNode) Fetch(req *rpc.FetchRequest) (*rpc.FetchResult_, error) {
+	result := &rpc.FetchResult_{}
+	if _, ok := n.series[req.ID]; ok {
+		result.Datapoints = []*rpc.Datapoint{{Timestamp: req.RangeStart, Value: 42}}
+	}
+
+	return result, nil
+}
+
+func TestVerifySeriesRouting(t *testing.T) {
+	const (
+		numShards = 4
+		seriesID  = "foo"
+	)
+
+	// NB: node01 owns the shard of the series and node02 every other shard, while
+	// the shard of the series is leaving node03.
+	owned := sharding.DefaultHashFn(numShards)(ident.StringID(seriesID))
+	p := &placementpb.Placement{
+		NumShards: numShards,
+		Instances: map[string]*placementpb.Instance{
+			"node01": {Id: "node01"},
+			"node02": {Id: "node02"},
+			"node03": {
+				Id: "node03",
+				Shards: []*placementpb.Shard{
+					{Id: owned, State: placementpb.ShardState_LEAVING},
+				},
+			},
+		},
+	}
+	for shardID := uint32(0); shardID < numShards; shardID++ {
+		owner := p.Instances["node02"]
+		if shardID == owned {
+			owner = p.Instances["node01"]
+		}
+
+		owner.Shards = append(owner.Shards, &placementpb.Shard{
+			Id:    shardID,
+			State: placementpb.ShardState_AVAILABLE,
+		})
+	}
+
+	owners, err := seriesOwners(p, seriesID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node01"}, owners)
+
+	var (
+		coordinator = fakeRoutingCoordinator{placement: p}
+		written     = map[string]struct{}{seriesID: {}}
+		start       = time.Unix(1000, 0)
+		end         = time.Unix(2000, 0)
+	)
+	require.NoError(t, VerifySeriesRouting(coordinator, Nodes{
+		fakeRoutingNode{id: "node01", series: written},
+		fakeRoutingNode{id: "node02"},
+		fakeRoutingNode{id: "node03"},
+	}, UnaggName, seriesID, start, end))
+
+	// Series routed to a node other than the shard owner are not found.
+	assert.EqualError(t, VerifySeriesRouting(coordinator, Nodes{
+		fakeRoutingNode{id: "node01"},
+		fakeRoutingNode{id: "node02", series: written},
+	}, UnaggName, seriesID, start, end), "series foo not found on instance node01")
+
+	assert.EqualError(t, VerifySeriesRouting(coordinator, Nodes{
+		fakeRoutingNode{id: "node02", series: written},
+	}, UnaggName, seriesID, start, end), "no node for instance node01 owning series foo")
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/ory/dockertest"
+	"go.uber.org/zap"
+)
+
+const singleNodeStackShards = 4
+
+// SingleNodeStack is a minimal M3 stack of a single DB node, which runs the
+// embedded etcd, and a coordinator, seeded with a placement for the DB node
+// and the unaggregated namespace.
+type SingleNodeStack interface {
+	// Node returns the DB node.
+	Node() Node
+	// Coordinator returns the coordinator.
+	Coordinator() Coordinator
+	// Teardown closes and removes all containers of the stack.
+	Teardown() error
+}
+
+type singleNodeStack struct {
+	node        Node
+	coordinator Coordinator
+	tornDown    bool
+}
+
+// NewSingleNodeStack brings up a DB node and a coordinator wired together, then
+// seeds a placement for the DB node and the unaggregated namespace and waits
+// for the DB node to bootstrap. This covers most tests needing a running stack;
+// tests needing more control should use SetupSingleM3DBNode or the lower level
+// resources instead. The caller is responsible for calling Teardown once done.
+func NewSingleNodeStack(opts ...SetupOptions) (SingleNodeStack, error) {
+	options := setupOptions{}
+	for _, f := range opts {
+		f(&options)
+	}
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		return nil, err
+	}
+
+	pool.MaxWait = timeout
+	var (
+		networkName = networkNameOrDefault(options.networkName)
+		volumeName  = volumeNameOrDefault(options.volumeName)
+	)
+	if err := setupNetwork(pool, networkName); err != nil {
+		return nil, err
+	}
+
+	if err := setupVolume(pool, volumeName); err != nil {
+		return nil, err
+	}
+
+	iOpts := instrument.NewOptions()
+	node, err := newDockerHTTPNode(pool, dockerResourceOptions{
+		image:          options.dbNodeImage,
+		dockerFileVars: options.dockerFileVars,
+		labels:         options.dbNodeLabels,
+		networkName:    networkName,
+		volumeName:     volumeName,
+		logOutput:      options.containerLogs,
+		iOpts:          iOpts,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	coordinator, err := newDockerHTTPCoordinator(pool, dockerResourceOptions{
+		image:          options.coordinatorImage,
+		dockerFileVars: options.dockerFileVars,
+		labels:         options.coordinatorLabels,
+		networkName:    networkName,
+		volumeName:     volumeName,
+		logOutput:      options.containerLogs,
+		iOpts:          iOpts,
+	})
+	if err != nil {
+		node.Close()
+		return nil, err
+	}
+
+	etcdHealthURL := node.(*dbNode).resource.getURL(etcdClientPort, "health")
+	logger := iOpts.Logger().With(zap.String("source", "harness"))
+	return newSingleNodeStack(node, coordinator, etcdHealthURL, logger)
+}
+
+// newSingleNodeStack waits for etcd at the given health URL and the coordinator
+// to come up, then seeds the stack and waits for the node to bootstrap. The
+// stack is torn down if it does not become ready.
+func newSingleNodeStack(
+	node Node,
+	coordinator Coordinator,
+	etcdHealthURL string,
+	logger *zap.Logger,
+) (SingleNodeStack, error) {
+	stack := &singleNodeStack{node: node, coordinator: coordinator}
+	if err := stack.waitUntilReady(etcdHealthURL, logger); err != nil {
+		logger.Error("single node stack did not become ready", zap.Error(err))
+		if teardownErr := stack.Teardown(); teardownErr != nil {
+			logger.Error("could not tear down single node stack", zap.Error(teardownErr))
+		}
+
+		return nil, err
+	}
+
+	logger.Info("single node stack ready")
+	return stack, nil
+}
+
+func (s *singleNodeStack) waitUntilReady(etcdHealthURL string, logger *zap.Logger) error {
+	logger.Info("waiting for etcd")
+	if err := waitForEtcd(etcdHealthURL, timeout); err != nil {
+		return err
+	}
+
+	logger.Info("waiting for coordinator")
+	if err := s.coordinator.WaitForNamespace(""); err != nil {
+		return err
+	}
+
+	host, err := s.node.HostDetails(9000)
+	if err != nil {
+		return err
+	}
+
+	req := admin.DatabaseCreateRequest{
+		Type:              "cluster",
+		NamespaceName:     UnaggName,
+		RetentionTime:     retention,
+		NumShards:         singleNodeStackShards,
+		ReplicationFactor: 1,
+		Hosts:             []*admin.Host{host},
+	}
+	logger.Info("creating database", zap.Any("request", req))
+	if _, err := s.coordinator.CreateDatabase(req); err != nil {
+		return err
+	}
+
+	logger.Info("waiting for placement", zap.String("id", host.GetId()))
+	if err := s.coordinator.WaitForInstances([]string{host.GetId()}); err != nil {
+		return err
+	}
+
+	logger.Info("waiting for namespace", zap.String("name", UnaggName))
+	if err := s.coordinator.WaitForNamespace(UnaggName); err != nil {
+		return err
+	}
+
+	logger.Info("waiting for bootstrap")
+	return s.node.WaitForBootstrap()
+}
+
+func (s *singleNodeStack) Node() Node               { return s.node }
+func (s *singleNodeStack) Coordinator() Coordinator { return s.coordinator }
+
+func (s *singleNodeStack) Teardown() error {
+	if s.tornDown {
+		return nil
+	}
+
+	s.tornDown = true
+	var multiErr xerrors.MultiError
+	multiErr = multiErr.Add(s.coordinator.Close())
+	multiErr = multiErr.Add(s.node.Close())
+	return multiErr.FinalError()
+}
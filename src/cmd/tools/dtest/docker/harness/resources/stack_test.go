@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type stackCalls struct {
+	calls []string
+}
+
+func (c *stackCalls) record(format string, args ...interface{}) {
+	c.calls = append(c.calls, fmt.Sprintf(format, args...))
+}
+
+type fakeStackCoordinator struct {
+	Coordinator
+
+	calls     *stackCalls
+	createErr error
+}
+
+func (c fakeStackCoordinator) WaitForNamespace(name string) error {
+	c.calls.record("coordinator.WaitForNamespace(%q)", name)
+	return nil
+}
+
+func (c fakeStackCoordinator) CreateDatabase(
+	req admin.DatabaseCreateRequest,
+) (admin.DatabaseCreateResponse, error) {
+	c.calls.record("coordinator.CreateDatabase(%s, %d hosts)", req.NamespaceName, len(req.Hosts))
+	return admin.DatabaseCreateResponse{}, c.createErr
+}
+
+func (c fakeStackCoordinator) WaitForInstances(ids []string) error {
+	c.calls.record("coordinator.WaitForInstances(%v)", ids)
+	return nil
+}
+
+func (c fakeStackCoordinator) Close() error {
+	c.calls.record("coordinator.Close()")
+	return nil
+}
+
+type fakeStackNode struct {
+	Node
+
+	calls *stackCalls
+}
+
+func (n fakeStackNode) HostDetails(port int) (*admin.Host, error) {
+	return &admin.Host{Id: "m3db_local", Port: uint32(port)}, nil
+}
+
+func (n fakeStackNode) WaitForBootstrap() error {
+	n.calls.record("node.WaitForBootstrap()")
+	return nil
+}
+
+func (n fakeStackNode) Close() error {
+	n.calls.record("node.Close()")
+	return nil
+}
+
+func newFakeEtcdServer(unhealthyChecks int32) *httptest.Server {
+	var checks int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&checks, 1) <= unhealthyChecks {
+			fmt.Fprint(w, `{"health":"false"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"health":"true"}`)
+	}))
+}
+
+func TestNewSingleNodeStack(t *testing.T) {
+	etcd := newFakeEtcdServer(2)
+	defer etcd.Close()
+
+	var (
+		calls       = &stackCalls{}
+		node        = fakeStackNode{calls: calls}
+		coordinator = fakeStackCoordinator{calls: calls}
+	)
+	stack, err := newSingleNodeStack(node, coordinator, etcd.URL+"/health", zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, Node(node), stack.Node())
+	assert.Equal(t, Coordinator(coordinator), stack.Coordinator())
+	assert.Equal(t, []string{
+		`coordinator.WaitForNamespace("")`,
+		`coordinator.CreateDatabase(default, 1 hosts)`,
+		`coordinator.WaitForInstances([m3db_local])`,
+		`coordinator.WaitForNamespace("default")`,
+		`node.WaitForBootstrap()`,
+	}, calls.calls)
+
+	calls.calls = nil
+	require.NoError(t, stack.Teardown())
+	require.NoError(t, stack.Teardown())
+	assert.Equal(t, []string{`coordinator.Close()`, `node.Close()`}, calls.calls)
+}
+
+func TestNewSingleNodeStackTearsDownIfNotReady(t *testing.T) {
+	etcd := newFakeEtcdServer(0)
+	defer etcd.Close()
+
+	var (
+		calls       = &stackCalls{}
+		errCreate   = errors.New("create error")
+		node        = fakeStackNode{calls: calls}
+		coordinator = fakeStackCoordinator{calls: calls, createErr: errCreate}
+	)
+	_, err := newSingleNodeStack(node, coordinator, etcd.URL+"/health", zap.NewNop())
+	require.Equal(t, errCreate, err)
+	assert.Equal(t, []string{
+		`coordinator.WaitForNamespace("")`,
+		`coordinator.CreateDatabase(default, 1 hosts)`,
+		`coordinator.Close()`,
+		`node.Close()`,
+	}, calls.calls)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImageVersion is a version of the DB node and coordinator images to run a
+// scenario against, e.g. for compatibility and rolling upgrade tests.
+type ImageVersion struct {
+	// Name identifies the version in results, e.g. a release tag.
+	Name string
+	// DBNodeImage is the name of the DB node image, which is built from the
+	// Dockerfile if empty.
+	DBNodeImage string
+	// CoordinatorImage is the name of the coordinator image, which is built from
+	// the Dockerfile if empty.
+	CoordinatorImage string
+	// Tag is the tag of the images.
+	Tag string
+	// DockerFileVars are the variables the Dockerfile templates are rendered
+	// with when images are built, e.g. the base image.
+	DockerFileVars map[string]string
+}
+
+// SetupOptions returns the setup options selecting the images of the version.
+func (v ImageVersion) SetupOptions() []SetupOptions {
+	var opts []SetupOptions
+	if v.DBNodeImage != "" {
+		opts = append(opts, WithDBNodeImage(v.DBNodeImage, v.Tag))
+	}
+
+	if v.CoordinatorImage != "" {
+		opts = append(opts, WithCoordinatorImage(v.CoordinatorImage, v.Tag))
+	}
+
+	if len(v.DockerFileVars) > 0 {
+		opts = append(opts, WithDockerFileVars(v.DockerFileVars))
+	}
+
+	return opts
+}
+
+// VersionResult is the result of running a scenario against an image version.
+type VersionResult struct {
+	// Version is the name of the image version.
+	Version string
+	// Err is the error returned by the scenario, if any.
+	Err error
+}
+
+// VersionResults are the results of running a scenario against several image
+// versions, in the order the versions were run.
+type VersionResults []VersionResult
+
+// Err returns an error naming each version the scenario failed against, or nil
+// if it succeeded against all versions.
+func (r VersionResults) Err() error {
+	var failures []string
+	for _, result := range r {
+		if result.Err != nil {
+			failures = append(failures,
+				fmt.Sprintf("%s: %v", result.Version, result.Err))
+		}
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("scenario failed against %d of %d version(s): %s",
+		len(failures), len(r), strings.Join(failures, "; "))
+}
+
+// RunAcrossVersions runs the scenario against each of the given image versions
+// in order, regardless of failures against earlier versions. The scenario is
+// expected to set up its resources with the setup options of the version and
+// to clean them up before returning.
+func RunAcrossVersions(
+	versions []ImageVersion,
+	scenario func(version ImageVersion) error,
+) VersionResults {
+	results := make(VersionResults, 0, len(versions))
+	for _, version := range versions {
+		results = append(results, VersionResult{
+			Version: version.Name,
+			Err:     scenario(version),
+		})
+	}
+
+	return results
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAcrossVersions(t *testing.T) {
+	versions := []ImageVersion{
+		{
+			Name:             "v1.0.0",
+			DBNodeImage:      "quay.io/m3db/m3dbnode",
+			CoordinatorImage: "quay.io/m3db/m3coordinator",
+			Tag:              "v1.0.0",
+		},
+		{
+			Name:           "head",
+			DockerFileVars: map[string]string{"BaseImage": "alpine:3.11"},
+		},
+	}
+
+	var ran []setupOptions
+	results := RunAcrossVersions(versions, func(version ImageVersion) error {
+		var options setupOptions
+		for _, opt := range version.SetupOptions() {
+			opt(&options)
+		}
+
+		ran = append(ran, options)
+		if version.Name == "head" {
+			return errors.New("write failed")
+		}
+
+		return nil
+	})
+
+	assert.Equal(t, []setupOptions{
+		{
+			dbNodeImage: dockerImage{
+				name: "quay.io/m3db/m3dbnode",
+				tag:  "v1.0.0",
+			},
+			coordinatorImage: dockerImage{
+				name: "quay.io/m3db/m3coordinator",
+				tag:  "v1.0.0",
+			},
+		},
+		{
+			dockerFileVars: map[string]string{"BaseImage": "alpine:3.11"},
+		},
+	}, ran)
+	assert.Equal(t, VersionResults{
+		{Version: "v1.0.0"},
+		{Version: "head", Err: errors.New("write failed")},
+	}, results)
+	assert.EqualError(t, results.Err(),
+		"scenario failed against 1 of 2 version(s): head: write failed")
+	assert.NoError(t, results[:1].Err())
+}
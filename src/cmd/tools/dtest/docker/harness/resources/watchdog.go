@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestingT is the subset of testing.TB used by the watchdog to fail a test.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Watchdog guards a test suite against hanging past its deadline, e.g. on a
+// container that never becomes healthy.
+type Watchdog struct {
+	stopOnce sync.Once
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+}
+
+// StartWatchdog starts a watchdog which, unless stopped before the deadline
+// elapses, forcibly purges all containers created by the harness that have not
+// yet been closed and fails the test, naming the containers that were still
+// running.
+func StartWatchdog(t TestingT, deadline time.Duration) *Watchdog {
+	w := &Watchdog{
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+
+	go w.run(t, deadline)
+	return w
+}
+
+// Stop stops the watchdog, waiting for an in progress purge to complete.
+func (w *Watchdog) Stop() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+	<-w.doneCh
+}
+
+func (w *Watchdog) run(t TestingT, deadline time.Duration) {
+	defer close(w.doneCh)
+
+	timer := time.NewTimer(deadline)
+	defer timer.Stop()
+
+	select {
+	case <-w.stopCh:
+		return
+	case <-timer.C:
+	}
+
+	purged, err := registry.purgeAll()
+	names := make([]string, 0, len(purged))
+	for _, info := range purged {
+		names = append(names, info.Name)
+	}
+
+	t.Errorf("suite exceeded deadline of %v, purged %d running container(s): [%s]",
+		deadline, len(names), strings.Join(names, ", "))
+	if err != nil {
+		t.Errorf("could not purge all containers: %v", err)
+	}
+}
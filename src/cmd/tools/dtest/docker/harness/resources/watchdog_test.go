@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package resources
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingT struct {
+	sync.Mutex
+
+	errors []string
+}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.Lock()
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+	t.Unlock()
+}
+
+func TestWatchdogDeadlineExceeded(t *testing.T) {
+	defer func(r *containerRegistry) { registry = r }(registry)
+	registry = newContainerRegistry()
+
+	docker := newFakeDocker()
+	defer docker.close()
+
+	pool := docker.pool(t)
+	_, err := newDockerResource(pool, testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+	_, err = newDockerResource(pool, testResourceOptions("coord01"))
+	require.NoError(t, err)
+
+	var rt recordingT
+	w := StartWatchdog(&rt, 10*time.Millisecond)
+	// NB: simulate a hanging suite by waiting past the deadline.
+	time.Sleep(50 * time.Millisecond)
+	w.Stop()
+
+	assert.Equal(t, []string{
+		"suite exceeded deadline of 10ms, purged 2 running container(s): [coord01, dbnode01]",
+	}, rt.errors)
+	assert.Empty(t, Inventory())
+
+	_, ok := docker.container("dbnode01")
+	assert.False(t, ok)
+	_, ok = docker.container("coord01")
+	assert.False(t, ok)
+}
+
+func TestWatchdogStoppedBeforeDeadline(t *testing.T) {
+	defer func(r *containerRegistry) { registry = r }(registry)
+	registry = newContainerRegistry()
+
+	docker := newFakeDocker()
+	defer docker.close()
+
+	dbNode, err := newDockerResource(docker.pool(t), testResourceOptions("dbnode01"))
+	require.NoError(t, err)
+
+	var rt recordingT
+	w := StartWatchdog(&rt, time.Minute)
+	w.Stop()
+	w.Stop()
+
+	assert.Empty(t, rt.errors)
+	_, ok := docker.container("dbnode01")
+	assert.True(t, ok)
+	require.NoError(t, dbNode.close())
+}
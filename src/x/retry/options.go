@@ -46,6 +46,7 @@ type options struct {
 	forever        bool
 	jitter         bool
 	rngFn          RngFn
+	sleepFn        SleepFn
 }
 
 // NewOptions creates new retry options.
@@ -59,6 +60,7 @@ func NewOptions() Options {
 		forever:        defaultForever,
 		jitter:         defaultJitter,
 		rngFn:          rand.Int63n,
+		sleepFn:        time.Sleep,
 	}
 }
 
@@ -141,3 +143,13 @@ func (o *options) SetRngFn(value RngFn) Options {
 func (o *options) RngFn() RngFn {
 	return o.rngFn
 }
+
+func (o *options) SetSleepFn(value SleepFn) Options {
+	opts := *o
+	opts.sleepFn = value
+	return &opts
+}
+
+func (o *options) SleepFn() SleepFn {
+	return o.sleepFn
+}
@@ -45,7 +45,7 @@ type retrier struct {
 	forever        bool
 	jitter         bool
 	rngFn          RngFn
-	sleepFn        func(t time.Duration)
+	sleepFn        SleepFn
 	metrics        retrierMetrics
 }
 
@@ -85,7 +85,7 @@ func NewRetrier(opts Options) Retrier {
 		forever:        opts.Forever(),
 		jitter:         opts.Jitter(),
 		rngFn:          opts.RngFn(),
-		sleepFn:        time.Sleep,
+		sleepFn:        opts.SleepFn(),
 		metrics: retrierMetrics{
 			calls:              scope.Counter("calls"),
 			attempts:           scope.Counter("attempts"),
@@ -200,6 +200,19 @@ func TestRetryForever(t *testing.T) {
 	assert.Equal(t, time.Duration(1023*time.Second), totalSlept)
 }
 
+func TestRetrierSleepFn(t *testing.T) {
+	var (
+		succeedAfter = 2
+		slept        []time.Duration
+	)
+	r := NewRetrier(testOptions().SetSleepFn(func(t time.Duration) {
+		slept = append(slept, t)
+	}))
+	err := r.Attempt(newTestFn(testFnOpts{succeedAfter: &succeedAfter}))
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, slept)
+}
+
 func TestBackoffValidResult(t *testing.T) {
 	seed := time.Now().UnixNano()
 	parameters := gopter.DefaultTestParameters()
@@ -42,6 +42,9 @@ func NonRetryableError(err error) error {
 // RngFn returns a non-negative pseudo-random number in [0,n).
 type RngFn func(n int64) int64
 
+// SleepFn blocks for the given duration between attempts.
+type SleepFn func(t time.Duration)
+
 // Fn is a function that can be retried.
 type Fn func() error
 
@@ -114,4 +117,10 @@ type Options interface {
 
 	// RngFn returns the RngFn.
 	RngFn() RngFn
+
+	// SetSleepFn sets the function used to back off between attempts.
+	SetSleepFn(value SleepFn) Options
+
+	// SleepFn returns the function used to back off between attempts.
+	SleepFn() SleepFn
 }